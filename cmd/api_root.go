@@ -2,30 +2,128 @@ package cmd
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/config"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"github.com/ybalcin/wallet-service/internal/reconciliation"
 	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/apikey"
+	"github.com/ybalcin/wallet-service/pkg/health"
+	"github.com/ybalcin/wallet-service/pkg/logger"
+	"github.com/ybalcin/wallet-service/pkg/metrics"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/ratelimit"
+	"github.com/ybalcin/wallet-service/pkg/tracing"
+	"log/slog"
+)
+
+const (
+	apiTitle   = "Wallet Service"
+	apiVersion = "1.0.0"
 )
 
 type ApiRoot struct {
-	app *fiber.App
+	app      *fiber.App
+	log      *slog.Logger
+	registry *prometheus.Registry
+	health   *health.Registry
+	limiter  *ratelimit.Limiter
+	keys     *apikey.Keys
 
-	walletApi *wallet.Api
+	walletApi         *wallet.Api
+	walletAdminApi    *wallet.AdminApi
+	batchApi          *wallet.BatchApi
+	graphqlApi        *wallet.GraphqlApi
+	auditApi          *audit.Api
+	ledgerApi         *ledger.Api
+	reconciliationApi *reconciliation.Api
+	configApi         *config.Api
 }
 
-func NewApiRoot(walletApi *wallet.Api) *ApiRoot {
-	app := fiber.New()
+func NewApiRoot(settings config.ServerSettings, log *slog.Logger, registry *prometheus.Registry, healthRegistry *health.Registry,
+	limiter *ratelimit.Limiter, keys *apikey.Keys, walletApi *wallet.Api, walletAdminApi *wallet.AdminApi, batchApi *wallet.BatchApi, graphqlApi *wallet.GraphqlApi,
+	auditApi *audit.Api, ledgerApi *ledger.Api, reconciliationApi *reconciliation.Api, configApi *config.Api) *ApiRoot {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ReadTimeout:           settings.ReadTimeout,
+		WriteTimeout:          settings.WriteTimeout,
+		IdleTimeout:           settings.IdleTimeout,
+		BodyLimit:             settings.BodyLimit,
+	})
 
 	root := &ApiRoot{
-		app:       app,
-		walletApi: walletApi,
+		app:               app,
+		log:               log,
+		registry:          registry,
+		health:            healthRegistry,
+		limiter:           limiter,
+		keys:              keys,
+		walletApi:         walletApi,
+		walletAdminApi:    walletAdminApi,
+		batchApi:          batchApi,
+		graphqlApi:        graphqlApi,
+		auditApi:          auditApi,
+		ledgerApi:         ledgerApi,
+		reconciliationApi: reconciliationApi,
+		configApi:         configApi,
 	}
-	root.RegisterRoutes(walletApi)
+	root.RegisterRoutes(walletApi, walletAdminApi, batchApi, graphqlApi, auditApi, ledgerApi, reconciliationApi, configApi)
 
 	return root
 }
 
-func (r *ApiRoot) RegisterRoutes(walletApi *wallet.Api) {
-	group := r.app.Group("api")
+func (r *ApiRoot) RegisterRoutes(walletApi *wallet.Api, walletAdminApi *wallet.AdminApi, batchApi *wallet.BatchApi, graphqlApi *wallet.GraphqlApi,
+	auditApi *audit.Api, ledgerApi *ledger.Api, reconciliationApi *reconciliation.Api, configApi *config.Api) {
+	// probes are registered before middlewares so that they are not traced, logged or measured
+	r.app.Get("/healthz", health.LivenessHandler())
+	r.app.Get("/readyz", health.ReadinessHandler(r.health))
+
+	r.app.Use(tracing.Middleware())
+	r.app.Use(logger.Middleware(r.log))
+	r.app.Use(apikey.Middleware(r.keys))
+	r.app.Use(audit.Middleware())
+	r.app.Use(metrics.Middleware(r.registry))
+	r.app.Get("/metrics", metrics.Handler(r.registry))
+
+	group := r.app.Group("api", ratelimit.Middleware(r.limiter, rateLimitKey))
+	// admin endpoints and batches, which move money of many wallets at once, require an admin key
+	group.Use("/admin", apikey.RequireAdmin())
+	group.Use("/batches", apikey.RequireAdmin())
 	walletApi.AddRoutesTo(group)
+	walletAdminApi.AddRoutesTo(group)
+	batchApi.AddRoutesTo(group)
+	auditApi.AddRoutesTo(group)
+	ledgerApi.AddRoutesTo(group)
+	reconciliationApi.AddRoutesTo(group)
+	configApi.AddRoutesTo(group)
+
+	docs := openapi.New(apiTitle, apiVersion).
+		AddRoutes("/api", walletApi.Routes()...).
+		AddRoutes("/api", walletAdminApi.Routes()...).
+		AddRoutes("/api", batchApi.Routes()...).
+		AddRoutes("/api", auditApi.Routes()...).
+		AddRoutes("/api", ledgerApi.Routes()...).
+		AddRoutes("/api", reconciliationApi.Routes()...).
+		AddRoutes("/api", configApi.Routes()...)
+
+	// graphql api is nil when it is disabled by config
+	if graphqlApi != nil {
+		graphqlApi.AddRoutesTo(group)
+		docs.AddRoutes("/api", graphqlApi.Routes()...)
+	}
+	group.Get("/openapi.json", docs.Handler())
+	group.Get("/docs", docs.UIHandler("/api/openapi.json", "/api/docs"))
+	group.Get("/docs/:asset", openapi.UIAssetHandler())
+}
+
+// rateLimitKey limits requests per authenticated api key or per ip of requests without a key
+func rateLimitKey(c *fiber.Ctx) string {
+	if key, ok := apikey.FromContext(c.UserContext()); ok {
+		return "api_key:" + key.Name
+	}
+
+	return c.IP()
 }
 
 func (r *ApiRoot) Listen(port string) error {
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/config"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"github.com/ybalcin/wallet-service/internal/reconciliation"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/apikey"
+	"github.com/ybalcin/wallet-service/pkg/health"
+	"github.com/ybalcin/wallet-service/pkg/logger"
+	"github.com/ybalcin/wallet-service/pkg/metrics"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/ratelimit"
+	"io"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// undocumentedRoutes are registered routes that are not part of the api documentation
+var undocumentedRoutes = map[string]bool{
+	"GET /api/openapi.json": true,
+	"GET /api/docs":         true,
+	"GET /api/docs/{asset}": true,
+	"GET /metrics":          true,
+	"GET /healthz":          true,
+	"GET /readyz":           true,
+}
+
+func TestApiRoot_OpenAPI(t *testing.T) {
+	root := newTestApiRoot(t)
+
+	res, err := root.app.Test(httptest.NewRequest(fiber.MethodGet, "/api/openapi.json", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	body, _ := io.ReadAll(res.Body)
+	doc := new(openapi.Document)
+	assert.Nil(t, json.Unmarshal(body, doc))
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	var registered []string
+	for _, r := range root.app.GetRoutes(true) {
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		route := r.Method + " " + openapi.Path(r.Path)
+		if !undocumentedRoutes[route] {
+			registered = append(registered, route)
+		}
+	}
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range *item {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(registered)
+	sort.Strings(documented)
+	assert.Equal(t, registered, documented, "registered routes and openapi document diverged")
+
+	t.Run("every referenced schema exists", func(t *testing.T) {
+		for _, ref := range refsOf(string(body)) {
+			_, ok := doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+			assert.True(t, ok, "missing schema %s", ref)
+		}
+	})
+}
+
+func TestApiRoot_Docs(t *testing.T) {
+	root := newTestApiRoot(t)
+
+	res, err := root.app.Test(httptest.NewRequest(fiber.MethodGet, "/api/docs", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	body, _ := io.ReadAll(res.Body)
+	assert.Contains(t, string(body), "/api/openapi.json")
+	assert.Contains(t, string(body), `src="/api/docs/swagger-ui-bundle.js"`)
+
+	res, err = root.app.Test(httptest.NewRequest(fiber.MethodGet, "/api/docs/swagger-ui-bundle.js", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode, "assets must be served by the service itself")
+	assert.Contains(t, res.Header.Get(fiber.HeaderContentType), "javascript")
+
+	res, err = root.app.Test(httptest.NewRequest(fiber.MethodGet, "/api/docs/index.html", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+}
+
+func TestApiRoot_RateLimit(t *testing.T) {
+	root := newLimitedTestApiRoot(t, ratelimit.New(1, 1))
+
+	assert.Equal(t, fiber.StatusOK, send(t, root, "/api/openapi.json", ""))
+	assert.Equal(t, fiber.StatusTooManyRequests, send(t, root, "/api/openapi.json", ""))
+	assert.Equal(t, fiber.StatusOK, send(t, root, "/api/openapi.json", testKeys[0].Value), "authenticated keys must have their own buckets")
+	assert.Equal(t, fiber.StatusUnauthorized, send(t, root, "/api/openapi.json", "unknown"), "an unknown key must not get a new bucket")
+}
+
+func TestApiRoot_Auth(t *testing.T) {
+	root := newTestApiRoot(t)
+
+	for _, path := range []string{"/api/admin/audit", "/api/admin/config/version", "/api/admin/wallets", "/api/admin/transactions/feed",
+		"/api/admin/ledger/trial-balance", "/api/admin/reconciliations", "/api/batches/1"} {
+		assert.Equal(t, fiber.StatusUnauthorized, send(t, root, path, ""), path)
+		assert.Equal(t, fiber.StatusForbidden, send(t, root, path, testKeys[0].Value), path)
+	}
+	assert.Equal(t, fiber.StatusNotFound, send(t, root, "/api/admin/unknown", testKeys[1].Value), "admin keys must pass")
+	assert.Equal(t, fiber.StatusUnauthorized, send(t, root, "/api/docs", "unknown"))
+}
+
+// testKeys are api keys of test roots, the second one is admin
+var testKeys = []apikey.Key{{Name: "payroll", Value: "payroll-key"}, {Name: "ops", Value: "ops-key", Admin: true}}
+
+func send(t *testing.T, root *ApiRoot, path, key string) int {
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	if key != "" {
+		req.Header.Set(apikey.Header, key)
+	}
+	res, err := root.app.Test(req)
+	assert.Nil(t, err)
+
+	return res.StatusCode
+}
+
+func newTestApiRoot(t *testing.T) *ApiRoot {
+	return newLimitedTestApiRoot(t, ratelimit.New(0, 0))
+}
+
+func newLimitedTestApiRoot(t *testing.T, limiter *ratelimit.Limiter) *ApiRoot {
+	graphqlApi, err := wallet.NewGraphqlApi(nil, nil, wallet.DefaultGraphqlLimits)
+	assert.Nil(t, err)
+
+	return NewApiRoot(config.Default().ServerSettings, logger.New(io.Discard, logger.Config{}), metrics.NewRegistry(), health.NewRegistry(0),
+		limiter, apikey.New(testKeys...), wallet.NewApi(nil), wallet.NewAdminApi(nil, nil), wallet.NewBatchApi(nil), graphqlApi, audit.NewApi(nil), ledger.NewApi(nil), reconciliation.NewApi(nil), config.NewApi(nil))
+}
+
+func refsOf(body string) []string {
+	var refs []string
+	for _, part := range strings.Split(body, `"$ref":"`)[1:] {
+		refs = append(refs, part[:strings.Index(part, `"`)])
+	}
+
+	return refs
+}
+
+func TestApiRoot_Metrics(t *testing.T) {
+	root := newTestApiRoot(t)
+
+	_, err := root.app.Test(httptest.NewRequest(fiber.MethodGet, "/api/docs", nil))
+	assert.Nil(t, err)
+
+	res, err := root.app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	body, _ := io.ReadAll(res.Body)
+	assert.Contains(t, string(body), `http_requests_total{method="GET",route="/api/docs",status="200"} 1`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
+
+func TestApiRoot_Health(t *testing.T) {
+	root := newTestApiRoot(t)
+
+	ready := func() int {
+		res, err := root.app.Test(httptest.NewRequest(fiber.MethodGet, "/readyz", nil))
+		assert.Nil(t, err)
+		return res.StatusCode
+	}
+
+	res, err := root.app.Test(httptest.NewRequest(fiber.MethodGet, "/healthz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	assert.Equal(t, fiber.StatusOK, ready())
+
+	root.health.Shutdown()
+	assert.Equal(t, fiber.StatusServiceUnavailable, ready())
+
+	res, err = root.app.Test(httptest.NewRequest(fiber.MethodGet, "/healthz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode, "liveness must not depend on readiness")
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/config"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"github.com/ybalcin/wallet-service/internal/reconciliation"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"github.com/ybalcin/wallet-service/pkg/logger"
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"github.com/ybalcin/wallet-service/pkg/projection"
+	"go.mongodb.org/mongo-driver/mongo"
+	"io"
+	"log/slog"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// app is the config, logger and database that every command shares
+type app struct {
+	cfg    *config.Config
+	levels *logger.Levels
+	log    *slog.Logger
+	client *mongo.Client
+	db     *mongo.Database
+}
+
+// newFlagSet creates flag set of command with config flags, synopsis describes args of command in usage
+func newFlagSet(name, synopsis string) (*flag.FlagSet, *config.Flags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		usage := strings.TrimSpace(fmt.Sprintf("%s %s [flags] %s", serviceName, name, synopsis))
+		fmt.Fprintf(fs.Output(), "Usage: %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+
+	return fs, config.AddFlags(fs)
+}
+
+// newApp loads config by flags, creates logger writing to w and connects to mongo. Nil is returned if
+// --print-config flag is set, config is printed instead
+func newApp(ctx context.Context, flags *config.Flags, w io.Writer) (*app, error) {
+	cfg, err := loadConfig(flags)
+	if cfg == nil || err != nil {
+		return nil, err
+	}
+
+	levels := logger.NewLevels(cfg.LoggingSettings.Level, cfg.LoggingSettings.SampleRatio)
+	logConfig := loggerConfig(cfg)
+	logConfig.Levels = levels
+	log := logger.New(w, logConfig)
+	slog.SetDefault(log)
+
+	client, err := connectMongo(ctx, cfg.MongoSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &app{
+		cfg:    cfg,
+		levels: levels,
+		log:    log,
+		client: client,
+		db:     client.Database(cfg.MongoSettings.Database),
+	}, nil
+}
+
+// close disconnects from mongo
+func (a *app) close() {
+	if err := a.client.Disconnect(context.Background()); err != nil {
+		a.log.Error("mongo disconnect failed", "error", err)
+	}
+}
+
+// walletRepository creates repository of wallets
+func (a *app) walletRepository() wallet.Repository {
+	return wallet.NewMongoRepository(a.db, walletMongoOptions(a.cfg.MongoSettings))
+}
+
+// eventStore creates store of event streams
+func (a *app) eventStore() eventstore.Store {
+	return eventstore.NewMongoStore(a.db)
+}
+
+// snapshotRepository creates repository of snapshots of wallet states
+func (a *app) snapshotRepository() wallet.SnapshotRepository {
+	return wallet.NewMongoSnapshotRepository(a.db)
+}
+
+// sequencer creates sequencer of positions of appended events
+func (a *app) sequencer() *eventstore.Sequencer {
+	s := a.cfg.SequencerSettings
+	return eventstore.NewSequencer(a.db, s.Lease, s.BatchSize, a.log)
+}
+
+// auditLog creates audit log
+func (a *app) auditLog() *audit.Log {
+	return audit.NewLog(audit.NewMongoRepository(a.db))
+}
+
+// ledger creates the general ledger
+func (a *app) ledger() *ledger.Ledger {
+	return ledger.New(ledger.NewMongoRepository(a.db))
+}
+
+// reconciler creates reconciler of settlement files, runs are saved in units of work of repository
+func (a *app) reconciler(repository wallet.Repository) *reconciliation.Reconciler {
+	return reconciliation.NewReconciler(reconciliation.NewMongoRepository(a.db), repository)
+}
+
+// migrator creates migrator of schema migrations
+func (a *app) migrator() (*migration.Migrator, error) {
+	return migration.New(migration.NewMongoStore(a.db), migrations(a.db)...)
+}
+
+// walletViewRunner creates runner of the projection of wallet views, metrics are optional
+func (a *app) walletViewRunner(metrics *projection.Metrics) *projection.Runner {
+	s := a.cfg.ProjectionSettings
+	return projection.NewRunner(
+		wallet.NewViewProjection(a.eventStore(), wallet.NewMongoViewRepository(a.db)),
+		projection.NewMongoCheckpointStore(a.db),
+		s.BatchSize,
+		metrics,
+		a.log,
+	)
+}
+
+// requireTransactions refuses to write wallets without transactions, events of both wallets of a transfer and
+// their read models are separate writes that only a transaction commits together
+func (a *app) requireTransactions() error {
+	if !a.cfg.MongoSettings.Transactions {
+		return errors.New("wallets can't be written with MONGO_TRANSACTIONS=false, writes of a use case are atomic only in a transaction")
+	}
+
+	return nil
+}
+
+// walletService creates service of wallets for operator commands, operations are journaled, logged and audited
+func (a *app) walletService() wallet.Service {
+	repository := a.walletRepository()
+	return wallet.NewAuditingService(
+		wallet.NewLoggingService(wallet.NewLedgerService(wallet.NewService(repository, a.eventStore(), a.snapshotRepository()), repository, a.ledger()), a.log),
+		a.auditLog(),
+		a.log,
+	)
+}
+
+// operatorContext returns copy of ctx that records os user running the command as actor of audit entries
+func operatorContext(ctx context.Context, command string) context.Context {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+
+	return audit.WithMetadata(ctx, audit.Metadata{
+		Actor:   "operator:" + name,
+		Request: audit.Request{Protocol: "cli", Method: command},
+	})
+}
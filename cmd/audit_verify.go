@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// runAuditVerify walks the audit log chain and fails if an entry was tampered with
+func runAuditVerify(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("audit-verify", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	v, err := a.auditLog().Verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !v.Valid {
+		return fmt.Errorf("audit log is broken at sequence %d after %d valid entries: %s",
+			v.BrokenAt, v.Entries, v.Problem)
+	}
+	fmt.Printf("audit log is valid, %d entries, last hash %s\n", v.Entries, v.LastHash)
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// runBalances writes balances of every wallet as of a time to file or stdout for accounting close, json lines
+// or csv. Transactions created before the time are included
+func runBalances(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("balances", "")
+	asOf := fs.String("as-of", "", "date or RFC3339 time, e.g. 2024-04-01 for balances at the end of March")
+	format := fs.String("format", "json", "json or csv")
+	path := fs.String("output", "", "file to write, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *asOf == "" {
+		return errors.New("provide time of balances via --as-of flag")
+	}
+	t, err := wallet.ParseDateOrTime(*asOf)
+	if err != nil {
+		return fmt.Errorf(wallet.ErrInvalidTime, "--as-of")
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("format %q is unknown, use json or csv", *format)
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	var out io.Writer = os.Stdout
+	if *path != "" {
+		f, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	write, flush := balanceLineWriter(w, *format)
+	total, err := wallet.BalanceReport(ctx, a.walletRepository(), a.eventStore(), a.snapshotRepository(), t, write)
+	if err != nil {
+		return err
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+	a.log.Info("balances are reported", "as_of", total.AsOf.Format(time.RFC3339), "wallets", total.Wallets,
+		"total", strconv.FormatFloat(float64(total.Total)/100, 'f', 2, 64))
+
+	return nil
+}
+
+// balanceLineWriter returns funcs writing lines of balance report to w in format and flushing them
+func balanceLineWriter(w *bufio.Writer, format string) (func(l wallet.BalanceReportLine) error, func() error) {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		return func(l wallet.BalanceReportLine) error { return encoder.Encode(l) }, w.Flush
+	}
+
+	writer := csv.NewWriter(w)
+	// errors of writes are returned by the next ones or by flush
+	_ = writer.Write([]string{"wallet_id", "username", "balance", "transactions"})
+	write := func(l wallet.BalanceReportLine) error {
+		return writer.Write([]string{
+			l.WalletID,
+			l.Username,
+			strconv.FormatFloat(float64(l.Balance.Amount), 'f', 2, 32),
+			strconv.FormatInt(l.Transactions, 10),
+		})
+	}
+	flush := func() error {
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	return write, flush
+}
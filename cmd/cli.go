@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const serviceName = "wallet-service"
+
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve http and grpc apis, it is the default command", runServe},
+	{"migrate", "create indexes and run schema migrations", runMigrate},
+	{"wallet", "get, create or freeze wallets", runWallet},
+	{"replay", "recompute balances of wallets from their transactions", runReplay},
+	{"export", "export wallets with their transactions as json lines", runExport},
+	{"import", "import wallets exported by export command", runImport},
+	{"statements", "write statements of wallets for a period to files", runStatements},
+	{"balances", "report balances of every wallet as of a time", runBalances},
+	{"projection", "rebuild wallet views or show their checkpoint", runProjection},
+	{"reconcile", "reconcile settlement file against transactions", runReconcile},
+	{"audit-verify", "verify hash chain of the audit log", runAuditVerify},
+}
+
+// Execute runs the command named by the first arg with the rest of args, server is started if args don't
+// start with a command so that flags can be given without serve command
+func Execute(ctx context.Context, args []string) error {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && !isHelp(args[0])) {
+		return runServe(ctx, args)
+	}
+
+	return dispatch(ctx, serviceName, commands, args)
+}
+
+// dispatch runs the command of cmds named by the first arg
+func dispatch(ctx context.Context, prefix string, cmds []command, args []string) error {
+	if len(args) == 0 || isHelp(args[0]) {
+		printUsage(os.Stderr, prefix, cmds)
+		if len(args) == 0 {
+			return fmt.Errorf("%s requires a command", prefix)
+		}
+		return nil
+	}
+
+	for _, c := range cmds {
+		if c.name == args[0] {
+			return c.run(ctx, args[1:])
+		}
+	}
+	printUsage(os.Stderr, prefix, cmds)
+
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func isHelp(arg string) bool {
+	switch arg {
+	case "help", "-h", "-help", "--help":
+		return true
+	}
+
+	return false
+}
+
+func printUsage(w io.Writer, prefix string, cmds []command) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [args]\n\nCommands:\n", prefix)
+	for _, c := range cmds {
+		fmt.Fprintf(w, "  %-14s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> --help' for flags of a command.\n", prefix)
+}
+
+// printJSON writes v to stdout as indented json
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
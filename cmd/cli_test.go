@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should print usage for help", func(t *testing.T) {
+		assert.Nil(t, Execute(ctx, []string{"help"}))
+		assert.Nil(t, Execute(ctx, []string{"wallet", "--help"}))
+	})
+
+	t.Run("should return error for unknown commands", func(t *testing.T) {
+		assert.EqualError(t, Execute(ctx, []string{"unknown"}), `unknown command "unknown"`)
+		assert.EqualError(t, Execute(ctx, []string{"wallet", "delete"}), `unknown command "delete"`)
+		assert.EqualError(t, Execute(ctx, []string{"wallet"}), "wallet-service wallet requires a command")
+	})
+
+	t.Run("should return error if argument is missing", func(t *testing.T) {
+		assert.EqualError(t, Execute(ctx, []string{"wallet", "freeze"}), "wallet freeze requires <wallet-id>")
+		assert.EqualError(t, Execute(ctx, []string{"migrate", "down"}), "migrate down requires --to version")
+	})
+
+	t.Run("should return flag.ErrHelp for help of commands", func(t *testing.T) {
+		for _, c := range commands {
+			if c.name == "wallet" || c.name == "migrate" || c.name == "projection" {
+				continue
+			}
+			assert.ErrorIs(t, c.run(ctx, []string{"--help"}), flag.ErrHelp, c.name)
+		}
+		subcommands := append(append(append([]command{}, walletCommands...), migrateCommands...), projectionCommands...)
+		for _, c := range subcommands {
+			assert.ErrorIs(t, c.run(ctx, []string{"--help"}), flag.ErrHelp, c.name)
+		}
+	})
+}
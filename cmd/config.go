@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/config"
+	"github.com/ybalcin/wallet-service/pkg/logger"
+	"os"
+	"strings"
+)
+
+// loadConfig loads config by parsed command line flags, config is printed and nil is returned if
+// --print-config flag is set
+func loadConfig(flags *config.Flags) (*config.Config, error) {
+	cfg, err := config.Load(flags)
+	if err == nil {
+		err = checkSecrets(cfg)
+	}
+	if flags.PrintConfig && cfg != nil {
+		if e := cfg.Print(os.Stdout); e != nil {
+			return nil, e
+		}
+		return nil, err
+	}
+
+	return cfg, err
+}
+
+// loggerConfig returns logger config of cfg
+func loggerConfig(cfg *config.Config) logger.Config {
+	return logger.Config{
+		Level:       cfg.LoggingSettings.Level,
+		SampleRatio: cfg.LoggingSettings.SampleRatio,
+		Redact:      cfg.LoggingSettings.Redact,
+		RedactKeys:  cfg.LoggingSettings.RedactFields,
+		Secrets:     cfg.Secrets(),
+	}
+}
+
+// checkSecrets refuses to start if secrets of cfg would be echoed by the printed config or logs
+func checkSecrets(cfg *config.Config) error {
+	printed := new(bytes.Buffer)
+	if err := cfg.Print(printed); err != nil {
+		return err
+	}
+
+	logged := new(bytes.Buffer)
+	log := logger.New(logged, loggerConfig(cfg))
+	log.Error("secret check", "config", cfg, "error", fmt.Errorf("connection to %s failed", cfg.MongoSettings.URI.Value()))
+
+	for _, secret := range cfg.Secrets() {
+		if strings.Contains(printed.String(), secret) || strings.Contains(logged.String(), secret) {
+			return errors.New("secrets are not masked, refusing to start")
+		}
+	}
+
+	return nil
+}
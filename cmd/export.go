@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"io"
+	"os"
+)
+
+// runExport writes every wallet with its transactions to file or stdout as json lines
+func runExport(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("export", "")
+	path := fs.String("output", "", "file to write, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	var out io.Writer = os.Stdout
+	if *path != "" {
+		f, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	exported, err := wallet.Export(ctx, a.walletRepository(), w)
+	if err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	a.log.Info("wallets are exported", "wallets", exported)
+
+	return nil
+}
+
+// runImport imports wallets written by export command from file or stdin, existing wallets are skipped
+func runImport(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("import", "")
+	path := fs.String("input", "", "file to read, stdin if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+	if err = a.requireTransactions(); err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if *path != "" {
+		f, err := os.Open(*path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	res, err := wallet.Import(ctx, a.walletRepository(), a.eventStore(), a.ledger(), bufio.NewReader(in))
+	a.log.Info("wallets are imported", "imported", res.Imported, "skipped", res.Skipped)
+
+	return err
+}
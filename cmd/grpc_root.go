@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/apikey"
+	"github.com/ybalcin/wallet-service/pkg/logger"
+	"github.com/ybalcin/wallet-service/pkg/metrics"
+	walletv1 "github.com/ybalcin/wallet-service/pkg/pb/wallet/v1"
+	"github.com/ybalcin/wallet-service/pkg/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"log/slog"
+	"net"
+)
+
+type GrpcRoot struct {
+	server *grpc.Server
+}
+
+func NewGrpcRoot(log *slog.Logger, reg prometheus.Registerer, keys *apikey.Keys, walletServer *wallet.GrpcServer) *GrpcRoot {
+	grpcMetrics := metrics.NewGrpcMetrics(reg)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor(), grpcMetrics.UnaryServerInterceptor(),
+			logger.UnaryServerInterceptor(log), apikey.UnaryServerInterceptor(keys), audit.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(tracing.StreamServerInterceptor(), grpcMetrics.StreamServerInterceptor(),
+			logger.StreamServerInterceptor(log), apikey.StreamServerInterceptor(keys)),
+	)
+
+	root := &GrpcRoot{server: server}
+	root.RegisterServices(walletServer)
+
+	return root
+}
+
+func (r *GrpcRoot) RegisterServices(walletServer *wallet.GrpcServer) {
+	walletv1.RegisterWalletServiceServer(r.server, walletServer)
+	reflection.Register(r.server)
+}
+
+func (r *GrpcRoot) Listen(port string) error {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+
+	return r.server.Serve(lis)
+}
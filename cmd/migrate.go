@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/config"
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"os"
+	"strings"
+)
+
+var migrateCommands = []command{
+	{"up", "apply pending migrations, it is the default command", runMigrateUp},
+	{"down", "revert applied migrations above a version", runMigrateDown},
+	{"status", "list migrations and whether they are applied", runMigrateStatus},
+}
+
+// runMigrate manages schema migrations, pending migrations are applied if args don't start with a command
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && !isHelp(args[0])) {
+		return runMigrateUp(ctx, args)
+	}
+
+	return dispatch(ctx, serviceName+" migrate", migrateCommands, args)
+}
+
+func runMigrateUp(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("migrate up", "")
+	to := fs.Int("to", 0, "version to migrate up to, 0 is the latest")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return withMigrator(ctx, flags, func(a *app, m *migration.Migrator) error {
+		done, err := m.Up(ctx, *to)
+		for _, d := range done {
+			a.log.Info("migration is applied", "version", d.Version, "description", d.Description)
+		}
+		if err == nil && len(done) == 0 {
+			a.log.Info("schema is current")
+		}
+		return err
+	})
+}
+
+func runMigrateDown(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("migrate down", "")
+	to := fs.Int("to", -1, "version to migrate down to, 0 reverts every migration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *to < 0 {
+		return errors.New("migrate down requires --to version")
+	}
+
+	return withMigrator(ctx, flags, func(a *app, m *migration.Migrator) error {
+		done, err := m.Down(ctx, *to)
+		for _, d := range done {
+			a.log.Info("migration is reverted", "version", d.Version, "description", d.Description)
+		}
+		return err
+	})
+}
+
+func runMigrateStatus(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("migrate status", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return withMigrator(ctx, flags, func(a *app, m *migration.Migrator) error {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(statuses)
+	})
+}
+
+// withMigrator runs fn with migrator of the database of config loaded by flags
+func withMigrator(ctx context.Context, flags *config.Flags, fn func(a *app, m *migration.Migrator) error) error {
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+
+	return fn(a, m)
+}
+
+// checkSchema applies pending migrations, verifies there is none or skips the check at startup of serve by
+// MongoSettings.Migrations
+func checkSchema(ctx context.Context, a *app) error {
+	mode := a.cfg.MongoSettings.Migrations
+	if mode == "skip" {
+		return nil
+	}
+
+	m, err := a.migrator()
+	if err != nil {
+		return err
+	}
+
+	if mode == "apply" {
+		done, err := m.Up(ctx, 0)
+		for _, d := range done {
+			a.log.Info("migration is applied", "version", d.Version, "description", d.Description)
+		}
+		return err
+	}
+
+	if err = m.Verify(ctx); err != nil {
+		return fmt.Errorf("%w, run migrate command or set MONGO_MIGRATIONS=apply", err)
+	}
+
+	return nil
+}
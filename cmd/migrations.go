@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"github.com/ybalcin/wallet-service/internal/reconciliation"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrations are the schema migrations of db, new migrations are appended with the next version and applied
+// migrations are never changed
+func migrations(db *mongo.Database) []migration.Migration {
+	walletIndexes := append(append([]migration.Index{}, wallet.WalletIndexes...), wallet.TransactionIndexes...)
+	reconciliationIndexes := append(append([]migration.Index{}, reconciliation.Indexes...), wallet.TransactionCreationIndexes...)
+
+	return []migration.Migration{
+		{
+			Version:     1,
+			Description: "create audit log indexes",
+			Up:          migration.CreateIndexes(db, audit.Indexes...),
+			Down:        migration.DropIndexes(db, audit.Indexes...),
+		},
+		{
+			Version:     2,
+			Description: "create wallet and transaction indexes",
+			Up:          migration.CreateIndexes(db, walletIndexes...),
+			Down:        migration.DropIndexes(db, walletIndexes...),
+		},
+		{
+			Version:     3,
+			Description: "create idempotency key indexes",
+			Up:          migration.CreateIndexes(db, wallet.IdempotencyKeyIndexes...),
+			Down:        migration.DropIndexes(db, wallet.IdempotencyKeyIndexes...),
+		},
+		{
+			Version:     4,
+			Description: "backfill wallet versions",
+			Up:          wallet.BackfillVersions(db),
+			Down:        wallet.DropVersions(db),
+		},
+		{
+			Version:     5,
+			Description: "create journal entry indexes",
+			Up:          migration.CreateIndexes(db, ledger.Indexes...),
+			Down:        migration.DropIndexes(db, ledger.Indexes...),
+		},
+		{
+			Version:     6,
+			Description: "backfill journal entries of existing transactions",
+			Up:          wallet.BackfillJournal(db, ledger.New(ledger.NewMongoRepository(db))),
+		},
+		{
+			Version:     7,
+			Description: "create reconciliation and transaction creation indexes",
+			Up:          migration.CreateIndexes(db, reconciliationIndexes...),
+			Down:        migration.DropIndexes(db, reconciliationIndexes...),
+		},
+		{
+			Version:     8,
+			Description: "create transaction reference indexes",
+			Up:          migration.CreateIndexes(db, wallet.TransactionReferenceIndexes...),
+			Down:        migration.DropIndexes(db, wallet.TransactionReferenceIndexes...),
+		},
+		{
+			Version:     9,
+			Description: "create wallet view indexes",
+			Up:          migration.CreateIndexes(db, wallet.WalletViewIndexes...),
+			Down:        migration.DropIndexes(db, wallet.WalletViewIndexes...),
+		},
+		{
+			Version:     10,
+			Description: "create event indexes",
+			Up:          migration.CreateIndexes(db, eventstore.CounterIndexes...),
+			Down:        migration.DropIndexes(db, eventstore.CounterIndexes...),
+		},
+		{
+			Version:     11,
+			Description: "backfill event streams of existing wallets",
+			Up:          wallet.BackfillEvents(db, eventstore.NewMongoStore(db)),
+		},
+		{
+			Version:     12,
+			Description: "create batch indexes",
+			Up:          migration.CreateIndexes(db, wallet.BatchIndexes...),
+			Down:        migration.DropIndexes(db, wallet.BatchIndexes...),
+		},
+		{
+			Version:     13,
+			Description: "replace event position index so that positions are stamped after appends commit",
+			// the stream index is kept, the position index is replaced by a sparse one and pending events are indexed
+			Up: migration.ReplaceIndexes(db, eventstore.CounterIndexes[1:], eventstore.Indexes[1:]),
+		},
+		{
+			Version:     14,
+			Description: "reset wallet views so that they are projected from the event store",
+			Up:          wallet.ResetViews(db),
+		},
+		{
+			Version:     15,
+			Description: "create wallet snapshot indexes",
+			Up:          migration.CreateIndexes(db, wallet.SnapshotIndexes...),
+			Down:        migration.DropIndexes(db, wallet.SnapshotIndexes...),
+		},
+	}
+}
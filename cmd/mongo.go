@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"github.com/ybalcin/wallet-service/internal/config"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"strconv"
+)
+
+func connectMongo(ctx context.Context, settings config.MongoSettings) (*mongo.Client, error) {
+	opts := options.Client().
+		ApplyURI(settings.URI.Value()).
+		SetMinPoolSize(settings.MinPoolSize).
+		SetMaxPoolSize(settings.MaxPoolSize).
+		SetConnectTimeout(settings.ConnectTimeout).
+		SetServerSelectionTimeout(settings.ServerSelectionTimeout).
+		SetTimeout(settings.Timeout).
+		SetReadPreference(readPreference(settings.ReadPreference)).
+		SetReadConcern(readConcern(settings.ReadConcern)).
+		SetWriteConcern(writeConcern(settings.WriteConcern))
+
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// walletMongoOptions returns options of wallet repository by settings
+func walletMongoOptions(settings config.MongoSettings) wallet.MongoOptions {
+	operations := make(map[string]wallet.OperationOptions, len(settings.Operations))
+	for name, o := range settings.Operations {
+		operations[name] = wallet.OperationOptions{
+			ReadPreference: readPreference(o.ReadPreference),
+			ReadConcern:    readConcern(o.ReadConcern),
+			WriteConcern:   writeConcern(o.WriteConcern),
+		}
+	}
+
+	return wallet.MongoOptions{
+		Transactions:       settings.Transactions,
+		TransactionRetries: settings.TransactionRetries,
+		Operations:         operations,
+	}
+}
+
+// readPreference returns read preference of mode validated by config, nil is returned for empty mode
+func readPreference(mode string) *readpref.ReadPref {
+	m, err := readpref.ModeFromString(mode)
+	if mode == "" || err != nil {
+		return nil
+	}
+	rp, err := readpref.New(m)
+	if err != nil {
+		return nil
+	}
+
+	return rp
+}
+
+// readConcern returns read concern of level validated by config, nil is returned for empty level
+func readConcern(level string) *readconcern.ReadConcern {
+	if level == "" {
+		return nil
+	}
+
+	return readconcern.New(readconcern.Level(level))
+}
+
+// writeConcern returns write concern of w validated by config, majority or number of nodes, nil is
+// returned for empty w
+func writeConcern(w string) *writeconcern.WriteConcern {
+	if w == "" {
+		return nil
+	}
+	if n, err := strconv.Atoi(w); err == nil {
+		return &writeconcern.WriteConcern{W: n}
+	}
+
+	return writeconcern.Majority()
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/config"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"testing"
+)
+
+func TestWalletMongoOptions(t *testing.T) {
+	settings := config.Default().MongoSettings
+	settings.Operations = map[string]config.OperationSettings{
+		"FindTransactionsByWalletID": {ReadPreference: "secondaryPreferred", ReadConcern: "majority"},
+		"InsertTransactions":         {WriteConcern: "2"},
+	}
+
+	opts := walletMongoOptions(settings)
+	assert.True(t, opts.Transactions)
+	assert.Equal(t, 3, opts.TransactionRetries)
+
+	find := opts.Operations["FindTransactionsByWalletID"]
+	assert.Equal(t, readpref.SecondaryPreferredMode, find.ReadPreference.Mode())
+	assert.Equal(t, readconcern.Majority(), find.ReadConcern)
+	assert.Nil(t, find.WriteConcern)
+
+	insert := opts.Operations["InsertTransactions"]
+	assert.Nil(t, insert.ReadPreference)
+	assert.Equal(t, &writeconcern.WriteConcern{W: 2}, insert.WriteConcern)
+
+	assert.Equal(t, writeconcern.Majority(), writeConcern(settings.WriteConcern))
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/projection"
+	"os"
+	"time"
+)
+
+var projectionCommands = []command{
+	{"rebuild", "delete wallet views and project them from scratch", runProjectionRebuild},
+	{"status", "print checkpoint of wallet views", runProjectionStatus},
+}
+
+// runProjection manages the projection of wallet views that serve runs in the background
+func runProjection(ctx context.Context, args []string) error {
+	return dispatch(ctx, serviceName+" projection", projectionCommands, args)
+}
+
+func runProjectionRebuild(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("projection rebuild", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	start := time.Now()
+	projected, err := a.walletViewRunner(nil).Rebuild(ctx)
+	if err != nil {
+		return err
+	}
+	a.log.Info("wallet views are rebuilt", "events", projected, "duration", time.Since(start).String())
+
+	return nil
+}
+
+func runProjectionStatus(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("projection status", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	checkpoint, err := projection.NewMongoCheckpointStore(a.db).Load(ctx, wallet.WalletViewProjection)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(checkpoint)
+}
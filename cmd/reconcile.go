@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/reconciliation"
+	"os"
+	"path/filepath"
+)
+
+// runReconcile reconciles a settlement file against transactions and prints the run. It fails if an item needs
+// review, items are reviewed and resolved through the admin api
+func runReconcile(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("reconcile", "")
+	path := fs.String("input", "", "settlement file to read")
+	format := fs.String("format", "", "csv or json, by extension of input if empty")
+	tolerance := fs.Duration("tolerance", reconciliation.DefaultTolerance, "max difference of settlement and transaction dates")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("provide settlement file via --input flag")
+	}
+	if *format == "" {
+		*format = reconciliation.FormatOf(*path)
+	}
+
+	f, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	settlements, err := reconciliation.ParseSettlements(bufio.NewReader(f), *format)
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	run, ex := a.reconciler(a.walletRepository()).Reconcile(operatorContext(ctx, "reconcile"), filepath.Base(*path), settlements, *tolerance)
+	if ex != nil {
+		return ex
+	}
+	if err = printJSON(run); err != nil {
+		return err
+	}
+
+	if run.Open {
+		return fmt.Errorf("%d mismatched and %d unmatched items of run %s need review",
+			run.Summary.Mismatched, run.Summary.Unmatched, run.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"os"
+)
+
+// runReplay recomputes balances of wallets given as args, or of every wallet, and prints one json line per
+// wallet. It fails if transactions of any wallet are inconsistent
+func runReplay(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("replay", "[wallet-id...]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	encoder := json.NewEncoder(os.Stdout)
+	replayed, inconsistent := 0, 0
+	err = wallet.Replay(ctx, a.walletRepository(), fs.Args(), func(r wallet.ReplayResult) error {
+		replayed++
+		if r.Problem != "" {
+			inconsistent++
+		}
+		return encoder.Encode(r)
+	})
+	if err != nil {
+		return err
+	}
+
+	if inconsistent > 0 {
+		return fmt.Errorf("%d of %d wallets are inconsistent", inconsistent, replayed)
+	}
+	a.log.Info("wallets are replayed", "wallets", replayed)
+
+	return nil
+}
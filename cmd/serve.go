@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/config"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"github.com/ybalcin/wallet-service/internal/reconciliation"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/apikey"
+	"github.com/ybalcin/wallet-service/pkg/cache"
+	"github.com/ybalcin/wallet-service/pkg/health"
+	"github.com/ybalcin/wallet-service/pkg/metrics"
+	"github.com/ybalcin/wallet-service/pkg/projection"
+	"github.com/ybalcin/wallet-service/pkg/ratelimit"
+	"github.com/ybalcin/wallet-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"os"
+)
+
+// runServe serves http and grpc apis until ctx is done
+func runServe(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("serve", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stdout)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+	cfg, log := a.cfg, a.log
+
+	reloader := config.NewReloader(flags, cfg, log)
+	reloader.Subscribe("logging", func(c *config.Config) error {
+		a.levels.Set(c.LoggingSettings.Level, c.LoggingSettings.SampleRatio)
+		return nil
+	})
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		ServiceName:  serviceName,
+		Exporter:     cfg.TracingSettings.Exporter,
+		OTLPEndpoint: cfg.TracingSettings.OTLPEndpoint,
+		OTLPInsecure: cfg.TracingSettings.OTLPInsecure,
+		File:         cfg.TracingSettings.File,
+		SampleRatio:  cfg.TracingSettings.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	if err = a.requireTransactions(); err != nil {
+		return err
+	}
+	if err = checkSchema(ctx, a); err != nil {
+		return err
+	}
+	auditLog := a.auditLog()
+
+	healthRegistry := health.NewRegistry(cfg.ServerSettings.HealthCheckTimeout)
+
+	registry := metrics.NewRegistry()
+	walletMetrics := wallet.NewMetrics(registry)
+
+	tracerProvider := otel.GetTracerProvider()
+
+	walletRepo := wallet.NewTracingRepository(
+		wallet.NewInstrumentedRepository(a.walletRepository(), walletMetrics),
+		tracerProvider,
+	)
+	healthRegistry.Register("mongo", walletRepo.Ping)
+	events := wallet.NewTracingEventStore(a.eventStore(), tracerProvider)
+
+	core := wallet.NewService(walletRepo, events, a.snapshotRepository())
+	core.SetFees(feeSchedules(cfg.FeeSettings))
+	reloader.Subscribe("fees", func(c *config.Config) error {
+		core.SetFees(feeSchedules(c.FeeSettings))
+		return nil
+	})
+	var service wallet.Service = core
+	if size := cfg.CacheSettings.Size; size > 0 {
+		service = wallet.NewCachingService(service, walletRepo, cache.NewLRU(size, cfg.CacheSettings.TTL), walletMetrics)
+	}
+	generalLedger := a.ledger()
+	service = wallet.NewLedgerService(service, walletRepo, generalLedger)
+
+	// traced wraps service so that its use cases are traced and logged, observed measures them too
+	traced := func(service wallet.Service) wallet.Service {
+		return wallet.NewLoggingService(wallet.NewTracingService(service, tracerProvider), log)
+	}
+	observed := func(service wallet.Service) wallet.Service {
+		return traced(wallet.NewInstrumentedService(service, walletMetrics))
+	}
+
+	walletService := wallet.NewAuditingService(observed(service), auditLog, log)
+	walletApi := wallet.NewApi(walletService)
+
+	// items of batches are applied without the cache since their states may be rolled back, the processor audits
+	// and measures them once their units of work are over
+	batchRepo := wallet.NewMongoBatchRepository(a.db)
+	batchProcessor := wallet.NewBatchProcessor(batchRepo, walletRepo,
+		traced(wallet.NewLedgerService(core, walletRepo, generalLedger)), auditLog, walletMetrics, cfg.BatchSettings.Lease, log)
+	batchLimits := wallet.BatchLimits{MaxItems: cfg.BatchSettings.MaxItems, MaxAtomicItems: cfg.BatchSettings.MaxAtomicItems}
+	var graphqlApi *wallet.GraphqlApi
+	if cfg.FeatureSettings.Graphql {
+		graphqlApi, err = wallet.NewGraphqlApi(walletService, walletRepo, wallet.GraphqlLimits{
+			MaxDepth:      cfg.LimitSettings.GraphqlMaxDepth,
+			MaxComplexity: cfg.LimitSettings.GraphqlMaxComplexity,
+		})
+		if err != nil {
+			return err
+		}
+		reloader.Subscribe("graphql limits", func(c *config.Config) error {
+			graphqlApi.SetLimits(wallet.GraphqlLimits{
+				MaxDepth:      c.LimitSettings.GraphqlMaxDepth,
+				MaxComplexity: c.LimitSettings.GraphqlMaxComplexity,
+			})
+			return nil
+		})
+	}
+
+	limiter := ratelimit.New(cfg.RateLimitSettings.RequestsPerSecond, cfg.RateLimitSettings.Burst)
+	reloader.Subscribe("rate limits", func(c *config.Config) error {
+		limiter.SetLimit(c.RateLimitSettings.RequestsPerSecond, c.RateLimitSettings.Burst)
+		return nil
+	})
+	go reloader.Watch(ctx, config.DefaultWatchInterval)
+
+	keys := apiKeys(cfg.AuthSettings)
+	if !hasAdminKey(cfg.AuthSettings) {
+		log.Warn("admin endpoints are unreachable without an admin api key, set API_KEYS to reach them")
+	}
+
+	// positions of appended events are stamped, balance updates of every instance are published to watchers and
+	// wallet views of the admin listing are projected from wallets and transactions in the background
+	broadcaster := wallet.NewBroadcaster()
+	go a.sequencer().Run(ctx, cfg.SequencerSettings.Interval)
+	go wallet.NewBalancePublisher(events, broadcaster, log).Run(ctx, cfg.FeedSettings.PollInterval)
+	go a.walletViewRunner(projection.NewMetrics(registry)).Run(ctx, cfg.ProjectionSettings.Interval)
+	go batchProcessor.Run(ctx, cfg.BatchSettings.Interval)
+
+	root := NewApiRoot(cfg.ServerSettings, log, registry, healthRegistry, limiter, keys, walletApi,
+		wallet.NewAdminApi(
+			wallet.NewSearch(wallet.NewMongoViewRepository(a.db)),
+			wallet.NewFeed(events, cfg.FeedSettings.MaxWait, cfg.FeedSettings.PollInterval),
+		), wallet.NewBatchApi(wallet.NewBatchService(batchRepo, batchLimits)), graphqlApi,
+		audit.NewApi(auditLog), ledger.NewApi(generalLedger), reconciliation.NewApi(a.reconciler(walletRepo)), config.NewApi(reloader))
+
+	go func() {
+		log.Info("server is listening", "port", cfg.Port)
+		if err := root.Listen(cfg.Port); err != nil {
+			log.Error("server listen error", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	var grpcRoot *GrpcRoot
+	if cfg.FeatureSettings.Grpc {
+		grpcRoot = NewGrpcRoot(log, registry, keys, wallet.NewGrpcServer(walletService, broadcaster))
+		go func() {
+			log.Info("grpc server is listening", "port", cfg.GrpcPort)
+			if err := grpcRoot.Listen(cfg.GrpcPort); err != nil {
+				log.Error("grpc server listen error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// graceful
+	<-ctx.Done()
+	healthRegistry.Shutdown()
+	broadcaster.Close()
+	if grpcRoot != nil {
+		grpcRoot.server.GracefulStop()
+	}
+	if err = root.app.ShutdownWithTimeout(cfg.ServerSettings.ShutdownTimeout); err != nil {
+		return fmt.Errorf("server shutdown failed: %w", err)
+	}
+	log.Info("server exited properly")
+
+	return nil
+}
+
+// apiKeys returns api keys of settings that authenticate clients
+func apiKeys(settings config.AuthSettings) *apikey.Keys {
+	var keys []apikey.Key
+	for _, k := range settings.APIKeys {
+		keys = append(keys, apikey.Key{Name: k.Name, Value: k.Key.Value(), Admin: k.Admin})
+	}
+
+	return apikey.New(keys...)
+}
+
+// feeSchedules returns fee schedules of settings
+func feeSchedules(settings config.FeeSettings) wallet.FeeSchedules {
+	scheduleOf := func(s config.FeeSchedule) wallet.FeeSchedule {
+		return wallet.FeeSchedule{Fixed: float32(s.Fixed), Rate: s.Rate, Max: float32(s.Max)}
+	}
+
+	return wallet.FeeSchedules{Withdraw: scheduleOf(settings.Withdraw), Transfer: scheduleOf(settings.Transfer)}
+}
+
+func hasAdminKey(settings config.AuthSettings) bool {
+	for _, k := range settings.APIKeys {
+		if k.Admin {
+			return true
+		}
+	}
+
+	return false
+}
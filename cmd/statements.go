@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"os"
+	"path/filepath"
+)
+
+// runStatements writes one statement file per wallet given as args, or per every wallet, to output directory
+func runStatements(ctx context.Context, args []string) error {
+	fs, flags := newFlagSet("statements", "[wallet-id...]")
+	from := fs.String("from", "", "inclusive start of period as date or RFC3339 time, start of the current month if empty")
+	to := fs.String("to", "", "exclusive end of period as date or RFC3339 time, now if empty")
+	format := fs.String("format", wallet.CSVStatementFormat, "csv, json or text")
+	dir := fs.String("output-dir", ".", "directory to write statements to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if wallet.StatementContentType(*format) == "" {
+		return fmt.Errorf(wallet.ErrUnknownStatementFormat, *format)
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+
+	service := a.walletService()
+	req := &wallet.StatementRequest{From: *from, To: *to}
+	written := 0
+	write := func(walletID string) error {
+		path := filepath.Join(*dir, wallet.StatementFileName(walletID, *format))
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w, err := wallet.NewStatementWriter(f, *format)
+		if err != nil {
+			return err
+		}
+		if ex := service.WriteStatement(ctx, walletID, req, w); ex != nil {
+			return fmt.Errorf("statement of wallet %s can't be written: %w", walletID, ex)
+		}
+		written++
+
+		return f.Close()
+	}
+
+	if ids := fs.Args(); len(ids) > 0 {
+		for _, id := range ids {
+			if err = write(id); err != nil {
+				return err
+			}
+		}
+	} else if err = a.walletRepository().IterateWallets(ctx, func(w *wallet.Wallet) error {
+		return write(w.ID)
+	}); err != nil {
+		return err
+	}
+	a.log.Info("statements are written", "statements", written, "directory", *dir)
+
+	return nil
+}
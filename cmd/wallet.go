@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"os"
+)
+
+var walletCommands = []command{
+	{"get", "get wallet with its current balance", runWalletGet},
+	{"create", "create wallet of a user", runWalletCreate},
+	{"freeze", "freeze wallet so that money can't be deposited to or withdrawn from it", runWalletFreeze},
+}
+
+// runWallet runs wallet operations for operators, mutating ones are audited with the os user as actor
+func runWallet(ctx context.Context, args []string) error {
+	return dispatch(ctx, serviceName+" wallet", walletCommands, args)
+}
+
+func runWalletGet(ctx context.Context, args []string) error {
+	return runWalletOperation(ctx, "wallet get", "<wallet-id>", args, func(ctx context.Context, s wallet.Service, arg string) (any, error) {
+		w, err := s.GetWallet(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	})
+}
+
+func runWalletCreate(ctx context.Context, args []string) error {
+	return runWalletOperation(ctx, "wallet create", "<username>", args, func(ctx context.Context, s wallet.Service, arg string) (any, error) {
+		id, err := s.CreateWallet(ctx, &wallet.CreateWalletRequest{Username: arg})
+		if err != nil {
+			return nil, err
+		}
+		return id, nil
+	})
+}
+
+func runWalletFreeze(ctx context.Context, args []string) error {
+	return runWalletOperation(ctx, "wallet freeze", "<wallet-id>", args, func(ctx context.Context, s wallet.Service, arg string) (any, error) {
+		w, err := s.FreezeWallet(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	})
+}
+
+// runWalletOperation parses args of command that takes a single argument, runs operation with it and prints
+// the result
+func runWalletOperation(ctx context.Context, name, synopsis string, args []string,
+	operation func(ctx context.Context, s wallet.Service, arg string) (any, error)) error {
+	fs, flags := newFlagSet(name, synopsis)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%s requires %s", name, synopsis)
+	}
+
+	a, err := newApp(ctx, flags, os.Stderr)
+	if a == nil || err != nil {
+		return err
+	}
+	defer a.close()
+	if err = a.requireTransactions(); err != nil {
+		return err
+	}
+
+	res, err := operation(operatorContext(ctx, name), a.walletService(), fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return printJSON(res)
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/response"
+	"strconv"
+)
+
+type Api struct {
+	log *Log
+}
+
+func NewApi(log *Log) *Api {
+	return &Api{log: log}
+}
+
+func (a *Api) AddRoutesTo(r fiber.Router) {
+	admin := r.Group("admin")
+
+	admin.Get("/audit", a.FindEntries)
+}
+
+// Routes describes the routes added by AddRoutesTo for the api documentation
+func (a *Api) Routes() []openapi.Route {
+	integer := &openapi.Schema{Type: "integer"}
+
+	return []openapi.Route{
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/admin/audit",
+			Summary: "Find audit log entries, newest first",
+			Tags:    []string{"admin"},
+			Query: []openapi.Parameter{
+				{Name: "wallet_id", Description: "entries targeting the wallet"},
+				{Name: "actor", Description: "entries of the actor"},
+				{Name: "action", Description: "entries of the action, e.g. wallet.withdraw"},
+				{Name: "before_sequence", Description: "entries older than the sequence, for paging", Schema: integer},
+				{Name: "limit", Description: "max number of entries, 100 by default", Schema: integer},
+			},
+			Response: []Entry{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+		},
+	}
+}
+
+func (a *Api) FindEntries(c *fiber.Ctx) error {
+	filter := Filter{
+		WalletID: c.Query("wallet_id"),
+		Actor:    c.Query("actor"),
+		Action:   c.Query("action"),
+	}
+
+	var err error
+	if filter.BeforeSequence, err = queryInt(c, "before_sequence"); err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+	if filter.Limit, err = queryInt(c, "limit"); err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	entries, e := a.log.Find(c.UserContext(), filter)
+	if e != nil {
+		return response.New(c).Error(e).JSON()
+	}
+
+	return response.New(c).Data(entries).JSON()
+}
+
+func queryInt(c *fiber.Ctx, key string) (int64, error) {
+	if c.Query(key) == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseInt(c.Query(key), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(ErrInvalidQueryParam, key)
+	}
+
+	return v, nil
+}
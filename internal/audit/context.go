@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/apikey"
+	"github.com/ybalcin/wallet-service/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const AnonymousActor = "anonymous"
+
+type (
+	// Metadata is who made the request and how, it is attached to entries recorded with the context
+	Metadata struct {
+		Actor   string
+		Request Request
+	}
+
+	metadataKey struct{}
+)
+
+// WithMetadata returns copy of ctx that carries m
+func WithMetadata(ctx context.Context, m Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, m)
+}
+
+// MetadataFrom returns metadata carried by ctx, actor is anonymous if ctx has no metadata
+func MetadataFrom(ctx context.Context) Metadata {
+	m, ok := ctx.Value(metadataKey{}).(Metadata)
+	if !ok || m.Actor == "" {
+		m.Actor = AnonymousActor
+	}
+
+	return m
+}
+
+// ActorOf identifies actor by name of the api key authenticated for ctx, it is anonymous without one
+func ActorOf(ctx context.Context) string {
+	key, ok := apikey.FromContext(ctx)
+	if !ok {
+		return AnonymousActor
+	}
+
+	return "api_key:" + key.Name
+}
+
+// Middleware returns fiber.Handler that attaches actor and request metadata to context of handlers,
+// it must be used after logger.Middleware and apikey.Middleware to include request id and actor
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(WithMetadata(c.UserContext(), Metadata{
+			Actor: ActorOf(c.UserContext()),
+			Request: Request{
+				ID:        logger.RequestID(c.UserContext()),
+				Protocol:  "http",
+				Method:    c.Method(),
+				Path:      c.Path(),
+				RemoteIP:  c.IP(),
+				UserAgent: c.Get(fiber.HeaderUserAgent),
+			},
+		}))
+
+		return c.Next()
+	}
+}
+
+// UnaryServerInterceptor returns grpc.UnaryServerInterceptor that attaches actor and request metadata to
+// context of handlers, it must be chained after logger.UnaryServerInterceptor and apikey.UnaryServerInterceptor to
+// include request id and actor
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		m := Metadata{Actor: ActorOf(ctx), Request: Request{
+			ID:       logger.RequestID(ctx),
+			Protocol: "grpc",
+			Method:   info.FullMethod,
+		}}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			m.Request.UserAgent = first(md.Get("user-agent"))
+		}
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			m.Request.RemoteIP = p.Addr.String()
+		}
+
+		return handler(WithMetadata(ctx, m), req)
+	}
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
@@ -0,0 +1,9 @@
+package audit
+
+const (
+	ErrInvalidQueryParam = "provide valid %s"
+	ErrInvalidLimit      = "provide valid limit between 1 and %d"
+	ErrBrokenSequence    = "entry %s has sequence %d, expected %d"
+	ErrBrokenPrevHash    = "entry %s does not refer to hash of previous entry"
+	ErrTamperedContent   = "hash of entry %s does not match its content"
+)
@@ -0,0 +1,13 @@
+package audit
+
+import (
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Indexes are indexes of the audit log, unique sequence keeps the chain linear and the others serve filters
+var Indexes = []migration.Index{
+	{Collection: auditCollection, Keys: bson.D{{Key: "sequence", Value: 1}}, Unique: true},
+	{Collection: auditCollection, Keys: bson.D{{Key: "wallet_ids", Value: 1}, {Key: "sequence", Value: -1}}},
+	{Collection: auditCollection, Keys: bson.D{{Key: "actor", Value: 1}, {Key: "sequence", Value: -1}}},
+}
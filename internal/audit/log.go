@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+
+	maxAppendAttempts = 5
+)
+
+var errChainBroken = errors.New("audit chain is broken")
+
+type (
+	// Recorder records audited calls
+	Recorder interface {
+		// Record appends entry of r to the audit log with metadata carried by ctx
+		Record(ctx context.Context, r Record) error
+	}
+
+	// Log is the hash-chained audit log, every entry stores hash of the previous entry so that updating
+	// or removing an entry breaks the chain
+	Log struct {
+		repository Repository
+		// mu serializes appends of this instance, unique sequence index serializes appends of instances
+		mu sync.Mutex
+	}
+
+	// Verification is the result of walking the chain
+	Verification struct {
+		Entries  int64  `json:"entries"`
+		Valid    bool   `json:"valid"`
+		LastHash string `json:"last_hash,omitempty"`
+		// BrokenAt is sequence of the first entry that breaks the chain
+		BrokenAt int64  `json:"broken_at,omitempty"`
+		Problem  string `json:"problem,omitempty"`
+	}
+)
+
+// NewLog creates new instance of Log
+func NewLog(repository Repository) *Log {
+	return &Log{repository: repository}
+}
+
+// Record appends entry of r to the audit log with metadata carried by ctx
+func (l *Log) Record(ctx context.Context, r Record) error {
+	m := MetadataFrom(ctx)
+	entry := &Entry{
+		ID:        uuid.NewString(),
+		Actor:     m.Actor,
+		Action:    r.Action,
+		WalletIDs: r.WalletIDs,
+		Request:   m.Request,
+		Outcome:   SucceededOutcome,
+		// mongo stores milliseconds, hash must be computed from what is stored
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if r.Err != nil {
+		entry.Outcome = FailedOutcome
+		entry.Error = r.Err.Error()
+	}
+
+	var err error
+	if entry.Before, err = marshalState(r.Before); err != nil {
+		return err
+	}
+	if entry.After, err = marshalState(r.After); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		last, err := l.repository.FindLastEntry(ctx)
+		if err != nil {
+			return err
+		}
+
+		entry.Sequence, entry.PrevHash = 1, ""
+		if last != nil {
+			entry.Sequence, entry.PrevHash = last.Sequence+1, last.Hash
+		}
+		entry.Hash = entry.ComputeHash()
+
+		if err = l.repository.InsertEntry(ctx, entry); !errors.Is(err, ErrDuplicateSequence) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("audit entry could not be appended after %d attempts: %w", maxAppendAttempts, ErrDuplicateSequence)
+}
+
+// Find finds entries by filter, newest first
+func (l *Log) Find(ctx context.Context, filter Filter) ([]Entry, *errr.Error) {
+	if filter.Limit == 0 {
+		filter.Limit = DefaultLimit
+	}
+	if filter.Limit < 0 || filter.Limit > MaxLimit {
+		return nil, errr.ThrowBadRequestError(fmt.Errorf(ErrInvalidLimit, MaxLimit))
+	}
+
+	entries, err := l.repository.FindEntries(ctx, filter)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+
+	return entries, nil
+}
+
+// Verify walks the chain from the first entry and reports the first entry whose sequence, link to the
+// previous entry or hash does not match
+func (l *Log) Verify(ctx context.Context) (*Verification, error) {
+	v := &Verification{Valid: true}
+
+	err := l.repository.IterateEntries(ctx, func(e *Entry) error {
+		expected := v.Entries + 1
+
+		switch {
+		case e.Sequence != expected:
+			v.Problem = fmt.Sprintf(ErrBrokenSequence, e.ID, e.Sequence, expected)
+		case e.PrevHash != v.LastHash:
+			v.Problem = fmt.Sprintf(ErrBrokenPrevHash, e.ID)
+		case e.ComputeHash() != e.Hash:
+			v.Problem = fmt.Sprintf(ErrTamperedContent, e.ID)
+		default:
+			v.Entries, v.LastHash = expected, e.Hash
+			return nil
+		}
+
+		v.Valid, v.BrokenAt = false, e.Sequence
+		return errChainBroken
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func marshalState(state interface{}) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if string(b) == "null" {
+		return nil, nil
+	}
+
+	return b, nil
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	SucceededOutcome = "succeeded"
+	FailedOutcome    = "failed"
+)
+
+type (
+	// Entry is an append-only audit log entry, every entry is chained to the previous one by PrevHash
+	Entry struct {
+		ID        string          `bson:"_id" json:"id"`
+		Sequence  int64           `bson:"sequence" json:"sequence"`
+		Actor     string          `bson:"actor" json:"actor"`
+		Action    string          `bson:"action" json:"action"`
+		WalletIDs []string        `bson:"wallet_ids" json:"wallet_ids"`
+		Request   Request         `bson:"request" json:"request"`
+		Outcome   string          `bson:"outcome" json:"outcome"`
+		Error     string          `bson:"error,omitempty" json:"error,omitempty"`
+		Before    json.RawMessage `bson:"before,omitempty" json:"before,omitempty"`
+		After     json.RawMessage `bson:"after,omitempty" json:"after,omitempty"`
+		CreatedAt time.Time       `bson:"created_at" json:"created_at"`
+		PrevHash  string          `bson:"prev_hash" json:"prev_hash"`
+		Hash      string          `bson:"hash" json:"hash"`
+	}
+
+	// Request is metadata of the request that made the audited call
+	Request struct {
+		ID        string `bson:"id,omitempty" json:"id,omitempty"`
+		Protocol  string `bson:"protocol,omitempty" json:"protocol,omitempty"`
+		Method    string `bson:"method,omitempty" json:"method,omitempty"`
+		Path      string `bson:"path,omitempty" json:"path,omitempty"`
+		RemoteIP  string `bson:"remote_ip,omitempty" json:"remote_ip,omitempty"`
+		UserAgent string `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	}
+
+	// Record is an audited call, Before and After are states of targets that are serialized as json
+	Record struct {
+		Action    string
+		WalletIDs []string
+		Before    interface{}
+		After     interface{}
+		Err       error
+	}
+)
+
+// ComputeHash computes hash of the entry from its content and PrevHash, Hash itself is excluded
+func (e *Entry) ComputeHash() string {
+	content, _ := json.Marshal(struct {
+		ID        string          `json:"id"`
+		Sequence  int64           `json:"sequence"`
+		Actor     string          `json:"actor"`
+		Action    string          `json:"action"`
+		WalletIDs []string        `json:"wallet_ids"`
+		Request   Request         `json:"request"`
+		Outcome   string          `json:"outcome"`
+		Error     string          `json:"error"`
+		Before    json.RawMessage `json:"before"`
+		After     json.RawMessage `json:"after"`
+		CreatedAt int64           `json:"created_at"`
+		PrevHash  string          `json:"prev_hash"`
+	}{
+		ID:        e.ID,
+		Sequence:  e.Sequence,
+		Actor:     e.Actor,
+		Action:    e.Action,
+		WalletIDs: e.WalletIDs,
+		Request:   e.Request,
+		Outcome:   e.Outcome,
+		Error:     e.Error,
+		Before:    e.Before,
+		After:     e.After,
+		CreatedAt: e.CreatedAt.UnixMilli(),
+		PrevHash:  e.PrevHash,
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen -source=repository.go -destination=./test/repository_mock.go -package=audit
+
+const auditCollection = "audit_log"
+
+// ErrDuplicateSequence is returned by InsertEntry when an entry with the same sequence exists
+var ErrDuplicateSequence = errors.New("duplicate audit entry sequence")
+
+type (
+	// Repository is an interface that do db operations of audit log, entries are never updated or deleted
+	Repository interface {
+		// InsertEntry inserts entry to collection, ErrDuplicateSequence is returned if sequence is taken
+		InsertEntry(ctx context.Context, e *Entry) error
+		// FindLastEntry finds entry with the highest sequence, nil is returned if log is empty
+		FindLastEntry(ctx context.Context) (*Entry, error)
+		// FindEntries finds entries matching filter in descending sequence order
+		FindEntries(ctx context.Context, filter Filter) ([]Entry, error)
+		// IterateEntries calls fn for every entry in ascending sequence order until fn returns error
+		IterateEntries(ctx context.Context, fn func(e *Entry) error) error
+	}
+
+	// Filter filters audit entries, empty fields match every entry
+	Filter struct {
+		WalletID string
+		Actor    string
+		Action   string
+		// BeforeSequence matches entries with lower sequence when it is greater than zero
+		BeforeSequence int64
+		Limit          int64
+	}
+
+	// MongoRepository is a concrete implementation of Repository interface
+	MongoRepository struct {
+		entries *mongo.Collection
+	}
+)
+
+// NewMongoRepository creates instance of MongoRepository
+func NewMongoRepository(db *mongo.Database) *MongoRepository {
+	return &MongoRepository{entries: db.Collection(auditCollection)}
+}
+
+// InsertEntry inserts entry to collection, ErrDuplicateSequence is returned if sequence is taken
+func (r *MongoRepository) InsertEntry(ctx context.Context, e *Entry) error {
+	_, err := r.entries.InsertOne(ctx, e)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateSequence
+	}
+
+	return err
+}
+
+// FindLastEntry finds entry with the highest sequence, nil is returned if log is empty
+func (r *MongoRepository) FindLastEntry(ctx context.Context) (*Entry, error) {
+	opts := options.FindOne().SetSort(bson.M{"sequence": -1})
+
+	res := r.entries.FindOne(ctx, bson.M{}, opts)
+	if res.Err() != nil {
+		if res.Err() == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, res.Err()
+	}
+
+	entry := new(Entry)
+	if err := res.Decode(entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// FindEntries finds entries matching filter in descending sequence order
+func (r *MongoRepository) FindEntries(ctx context.Context, filter Filter) ([]Entry, error) {
+	query := bson.M{}
+	if filter.WalletID != "" {
+		query["wallet_ids"] = filter.WalletID
+	}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.BeforeSequence > 0 {
+		query["sequence"] = bson.M{"$lt": filter.BeforeSequence}
+	}
+
+	opts := options.Find().SetSort(bson.M{"sequence": -1}).SetLimit(filter.Limit)
+
+	cursor, err := r.entries.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// IterateEntries calls fn for every entry in ascending sequence order until fn returns error
+func (r *MongoRepository) IterateEntries(ctx context.Context, fn func(e *Entry) error) error {
+	opts := options.Find().SetSort(bson.M{"sequence": 1})
+
+	cursor, err := r.entries.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		entry := new(Entry)
+		if err = cursor.Decode(entry); err != nil {
+			return err
+		}
+		if err = fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
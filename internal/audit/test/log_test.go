@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/pkg/apikey"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func setupMockRepo(t *testing.T) *MockRepository {
+	return NewMockRepository(gomock.NewController(t))
+}
+
+// appendTo makes mockRepo store inserted entries to entries
+func appendTo(mockRepo *MockRepository, entries *[]audit.Entry) {
+	mockRepo.EXPECT().FindLastEntry(gomock.Any()).DoAndReturn(func(context.Context) (*audit.Entry, error) {
+		if len(*entries) == 0 {
+			return nil, nil
+		}
+		last := (*entries)[len(*entries)-1]
+		return &last, nil
+	}).AnyTimes()
+	mockRepo.EXPECT().InsertEntry(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *audit.Entry) error {
+		*entries = append(*entries, *e)
+		return nil
+	}).AnyTimes()
+}
+
+func iterate(mockRepo *MockRepository, entries []audit.Entry) {
+	mockRepo.EXPECT().IterateEntries(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, fn func(e *audit.Entry) error) error {
+			for i := range entries {
+				e := entries[i]
+				if err := fn(&e); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func TestLog_Record(t *testing.T) {
+	mockRepo := setupMockRepo(t)
+	log := audit.NewLog(mockRepo)
+
+	var entries []audit.Entry
+	appendTo(mockRepo, &entries)
+
+	ctx := audit.WithMetadata(context.Background(), audit.Metadata{
+		Actor:   audit.ActorOf(apikey.WithKey(context.Background(), apikey.Key{Name: "payroll"})),
+		Request: audit.Request{ID: "request-1", Protocol: "http"},
+	})
+	assert.Nil(t, log.Record(ctx, audit.Record{
+		Action:    "wallet.deposit",
+		WalletIDs: []string{"1"},
+		Before:    map[string]int{"balance": 0},
+		After:     map[string]int{"balance": 10},
+	}))
+	assert.Nil(t, log.Record(context.Background(), audit.Record{
+		Action: "wallet.withdraw",
+		After:  (*struct{})(nil),
+		Err:    errors.New("insufficient"),
+	}))
+
+	assert.Len(t, entries, 2)
+
+	first := entries[0]
+	assert.Equal(t, int64(1), first.Sequence)
+	assert.Empty(t, first.PrevHash)
+	assert.Equal(t, first.ComputeHash(), first.Hash)
+	assert.Equal(t, "api_key:payroll", first.Actor)
+	assert.Equal(t, "request-1", first.Request.ID)
+	assert.Equal(t, audit.SucceededOutcome, first.Outcome)
+	assert.JSONEq(t, `{"balance":0}`, string(first.Before))
+	assert.JSONEq(t, `{"balance":10}`, string(first.After))
+
+	second := entries[1]
+	assert.Equal(t, int64(2), second.Sequence)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.Equal(t, audit.AnonymousActor, second.Actor)
+	assert.Equal(t, audit.FailedOutcome, second.Outcome)
+	assert.Equal(t, "insufficient", second.Error)
+	assert.Nil(t, second.After)
+
+	t.Run("should retry when sequence is taken by another instance", func(t *testing.T) {
+		mockRepo := setupMockRepo(t)
+		log := audit.NewLog(mockRepo)
+
+		gomock.InOrder(
+			mockRepo.EXPECT().FindLastEntry(gomock.Any()).Return(&first, nil),
+			mockRepo.EXPECT().InsertEntry(gomock.Any(), gomock.Any()).Return(audit.ErrDuplicateSequence),
+			mockRepo.EXPECT().FindLastEntry(gomock.Any()).Return(&second, nil),
+			mockRepo.EXPECT().InsertEntry(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, e *audit.Entry) error {
+				assert.Equal(t, int64(3), e.Sequence)
+				assert.Equal(t, second.Hash, e.PrevHash)
+				return nil
+			}),
+		)
+
+		assert.Nil(t, log.Record(context.Background(), audit.Record{Action: "wallet.create"}))
+	})
+}
+
+func TestLog_Verify(t *testing.T) {
+	ctx := context.Background()
+	record := func() []audit.Entry {
+		mockRepo := setupMockRepo(t)
+		var entries []audit.Entry
+		appendTo(mockRepo, &entries)
+
+		log := audit.NewLog(mockRepo)
+		for _, action := range []string{"wallet.create", "wallet.deposit", "wallet.withdraw"} {
+			assert.Nil(t, log.Record(ctx, audit.Record{Action: action, After: map[string]string{"action": action}}))
+		}
+		return entries
+	}
+
+	cases := []struct {
+		name     string
+		tamper   func(entries []audit.Entry) []audit.Entry
+		valid    bool
+		brokenAt int64
+	}{
+		{"intact chain", func(entries []audit.Entry) []audit.Entry { return entries }, true, 0},
+		{"updated content", func(entries []audit.Entry) []audit.Entry {
+			entries[1].After = []byte(`{"action":"wallet.withdraw"}`)
+			return entries
+		}, false, 2},
+		{"updated content with recomputed hash", func(entries []audit.Entry) []audit.Entry {
+			entries[1].Actor = "someone"
+			entries[1].Hash = entries[1].ComputeHash()
+			return entries
+		}, false, 3},
+		{"removed entry", func(entries []audit.Entry) []audit.Entry {
+			return append(entries[:1], entries[2:]...)
+		}, false, 3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mockRepo := setupMockRepo(t)
+			iterate(mockRepo, c.tamper(record()))
+
+			v, err := audit.NewLog(mockRepo).Verify(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, c.valid, v.Valid)
+			assert.Equal(t, c.brokenAt, v.BrokenAt)
+			if c.valid {
+				assert.Equal(t, int64(3), v.Entries)
+			} else {
+				assert.NotEmpty(t, v.Problem)
+			}
+		})
+	}
+}
+
+func TestLog_Find(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+	log := audit.NewLog(mockRepo)
+
+	mockRepo.EXPECT().FindEntries(ctx, audit.Filter{WalletID: "1", Limit: audit.DefaultLimit}).Return(nil, nil)
+
+	entries, err := log.Find(ctx, audit.Filter{WalletID: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []audit.Entry{}, entries)
+
+	_, err = log.Find(ctx, audit.Filter{Limit: audit.MaxLimit + 1})
+	assert.NotNil(t, err)
+	assert.Equal(t, 400, err.Code)
+}
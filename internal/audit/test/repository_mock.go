@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+
+// Package audit is a generated GoMock package.
+package audit
+
+import (
+	context "context"
+	reflect "reflect"
+
+	audit "github.com/ybalcin/wallet-service/internal/audit"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindEntries mocks base method.
+func (m *MockRepository) FindEntries(ctx context.Context, filter audit.Filter) ([]audit.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEntries", ctx, filter)
+	ret0, _ := ret[0].([]audit.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEntries indicates an expected call of FindEntries.
+func (mr *MockRepositoryMockRecorder) FindEntries(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEntries", reflect.TypeOf((*MockRepository)(nil).FindEntries), ctx, filter)
+}
+
+// FindLastEntry mocks base method.
+func (m *MockRepository) FindLastEntry(ctx context.Context) (*audit.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindLastEntry", ctx)
+	ret0, _ := ret[0].(*audit.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindLastEntry indicates an expected call of FindLastEntry.
+func (mr *MockRepositoryMockRecorder) FindLastEntry(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLastEntry", reflect.TypeOf((*MockRepository)(nil).FindLastEntry), ctx)
+}
+
+// InsertEntry mocks base method.
+func (m *MockRepository) InsertEntry(ctx context.Context, e *audit.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertEntry", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertEntry indicates an expected call of InsertEntry.
+func (mr *MockRepositoryMockRecorder) InsertEntry(ctx, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertEntry", reflect.TypeOf((*MockRepository)(nil).InsertEntry), ctx, e)
+}
+
+// IterateEntries mocks base method.
+func (m *MockRepository) IterateEntries(ctx context.Context, fn func(*audit.Entry) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IterateEntries", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IterateEntries indicates an expected call of IterateEntries.
+func (mr *MockRepositoryMockRecorder) IterateEntries(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterateEntries", reflect.TypeOf((*MockRepository)(nil).IterateEntries), ctx, fn)
+}
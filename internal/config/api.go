@@ -0,0 +1,38 @@
+package config
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/response"
+)
+
+type Api struct {
+	reloader *Reloader
+}
+
+func NewApi(reloader *Reloader) *Api {
+	return &Api{reloader: reloader}
+}
+
+func (a *Api) AddRoutesTo(r fiber.Router) {
+	admin := r.Group("admin")
+
+	admin.Get("/config/version", a.GetVersion)
+}
+
+// Routes describes the routes added by AddRoutesTo for the api documentation
+func (a *Api) Routes() []openapi.Route {
+	return []openapi.Route{
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/admin/config/version",
+			Summary:  "Get version of the config in use and result of the last reload",
+			Tags:     []string{"admin"},
+			Response: Version{},
+		},
+	}
+}
+
+func (a *Api) GetVersion(c *fiber.Ctx) error {
+	return response.New(c).Data(a.reloader.Version()).JSON()
+}
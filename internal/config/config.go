@@ -2,39 +2,307 @@ package config
 
 import (
 	"errors"
-	"github.com/ybalcin/wallet-service/pkg/utility"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
 	"os"
+	"time"
 )
 
+const ConfigFileEnv = "CONFIG_FILE"
+
 type (
 	Config struct {
-		MongoSettings MongoSettings `yaml:"MongoSettings"`
-		Port          string
+		MongoSettings      MongoSettings      `yaml:"MongoSettings"`
+		ServerSettings     ServerSettings     `yaml:"ServerSettings"`
+		AuthSettings       AuthSettings       `yaml:"AuthSettings"`
+		TracingSettings    TracingSettings    `yaml:"TracingSettings"`
+		LoggingSettings    LoggingSettings    `yaml:"LoggingSettings"`
+		LimitSettings      LimitSettings      `yaml:"LimitSettings"`
+		RateLimitSettings  RateLimitSettings  `yaml:"RateLimitSettings"`
+		FeeSettings        FeeSettings        `yaml:"FeeSettings"`
+		CacheSettings      CacheSettings      `yaml:"CacheSettings"`
+		ProjectionSettings ProjectionSettings `yaml:"ProjectionSettings"`
+		FeedSettings       FeedSettings       `yaml:"FeedSettings"`
+		SequencerSettings  SequencerSettings  `yaml:"SequencerSettings"`
+		BatchSettings      BatchSettings      `yaml:"BatchSettings"`
+		FeatureSettings    FeatureSettings    `yaml:"FeatureSettings"`
+		Port               string             `yaml:"Port"`
+		GrpcPort           string             `yaml:"GrpcPort"`
 	}
 
 	MongoSettings struct {
-		URI      string `yaml:"URI"`
-		Database string `yaml:"Database"`
+		URI                    Secret        `yaml:"URI"`
+		Database               string        `yaml:"Database"`
+		MinPoolSize            uint64        `yaml:"MinPoolSize"`
+		MaxPoolSize            uint64        `yaml:"MaxPoolSize"`
+		ConnectTimeout         time.Duration `yaml:"ConnectTimeout"`
+		ServerSelectionTimeout time.Duration `yaml:"ServerSelectionTimeout"`
+		// Timeout is the timeout of every database operation
+		Timeout time.Duration `yaml:"Timeout"`
+		// Migrations is what serve does with pending schema migrations at startup, verify refuses to start,
+		// apply applies them and skip ignores them
+		Migrations string `yaml:"Migrations"`
+		// ReadPreference, ReadConcern and WriteConcern are defaults of every operation, WriteConcern is majority
+		// or number of nodes
+		ReadPreference string `yaml:"ReadPreference"`
+		ReadConcern    string `yaml:"ReadConcern"`
+		WriteConcern   string `yaml:"WriteConcern"`
+		// Transactions runs multiple writes of a use case in a transaction, it requires a replica set. Wallets are
+		// written only with transactions, commands that only read wallets run without them
+		Transactions bool `yaml:"Transactions"`
+		// TransactionRetries is how many times a transaction is retried on transient errors
+		TransactionRetries int `yaml:"TransactionRetries"`
+		// Operations override the defaults per repository operation, e.g. FindTransactionsByWalletID
+		Operations map[string]OperationSettings `yaml:"Operations"`
+	}
+
+	// OperationSettings are read preference and concerns of a repository operation, empty ones are the defaults
+	OperationSettings struct {
+		ReadPreference string `yaml:"ReadPreference"`
+		ReadConcern    string `yaml:"ReadConcern"`
+		WriteConcern   string `yaml:"WriteConcern"`
+	}
+
+	ServerSettings struct {
+		ReadTimeout        time.Duration `yaml:"ReadTimeout"`
+		WriteTimeout       time.Duration `yaml:"WriteTimeout"`
+		IdleTimeout        time.Duration `yaml:"IdleTimeout"`
+		ShutdownTimeout    time.Duration `yaml:"ShutdownTimeout"`
+		HealthCheckTimeout time.Duration `yaml:"HealthCheckTimeout"`
+		// BodyLimit is the max size of request bodies in bytes
+		BodyLimit int `yaml:"BodyLimit"`
+	}
+
+	// AuthSettings are api keys that authenticate clients by X-API-Key header, admin endpoints require an Admin key
+	AuthSettings struct {
+		APIKeys []APIKey `yaml:"APIKeys"`
+	}
+
+	// APIKey is an api key of a client, Name identifies the client in audit entries in place of Key
+	APIKey struct {
+		Name  string `yaml:"Name"`
+		Key   Secret `yaml:"Key"`
+		Admin bool   `yaml:"Admin"`
+	}
+
+	TracingSettings struct {
+		Exporter     string  `yaml:"Exporter"`
+		OTLPEndpoint string  `yaml:"OTLPEndpoint"`
+		OTLPInsecure bool    `yaml:"OTLPInsecure"`
+		File         string  `yaml:"File"`
+		SampleRatio  float64 `yaml:"SampleRatio"`
+	}
+
+	LoggingSettings struct {
+		Level        string   `yaml:"Level"`
+		SampleRatio  float64  `yaml:"SampleRatio"`
+		Redact       bool     `yaml:"Redact"`
+		RedactFields []string `yaml:"RedactFields"`
+	}
+
+	LimitSettings struct {
+		GraphqlMaxDepth      int `yaml:"GraphqlMaxDepth"`
+		GraphqlMaxComplexity int `yaml:"GraphqlMaxComplexity"`
+	}
+
+	// RateLimitSettings limits http requests per api key or ip, zero RequestsPerSecond is unlimited
+	RateLimitSettings struct {
+		RequestsPerSecond float64 `yaml:"RequestsPerSecond"`
+		Burst             int     `yaml:"Burst"`
+	}
+
+	// FeeSettings are fee schedules of withdrawals and transfers, fees are withdrawn from the wallet with the money
+	// and credited to the fees account of the ledger
+	FeeSettings struct {
+		Withdraw FeeSchedule `yaml:"Withdraw"`
+		Transfer FeeSchedule `yaml:"Transfer"`
+	}
+
+	// FeeSchedule charges Rate of the amount plus Fixed, at most Max unless it is 0. Zero schedule charges no fee
+	FeeSchedule struct {
+		Fixed float64 `yaml:"Fixed"`
+		Rate  float64 `yaml:"Rate"`
+		Max   float64 `yaml:"Max"`
+	}
+
+	// CacheSettings are settings of the in-process cache of wallet states, zero Size disables it
+	CacheSettings struct {
+		Size int           `yaml:"Size"`
+		TTL  time.Duration `yaml:"TTL"`
+	}
+
+	// ProjectionSettings are settings of projections of read models, such as wallet views of the listing
+	ProjectionSettings struct {
+		Interval  time.Duration `yaml:"Interval"`
+		BatchSize int           `yaml:"BatchSize"`
+	}
+
+	// FeedSettings are settings of the transaction feed, long polls wait at most MaxWait for new transactions and
+	// look for them every PollInterval. Balance updates of WatchBalance are looked for every PollInterval too
+	FeedSettings struct {
+		MaxWait      time.Duration `yaml:"MaxWait"`
+		PollInterval time.Duration `yaml:"PollInterval"`
+	}
+
+	// SequencerSettings are settings of the sequencer that stamps positions of appended events, it looks for pending
+	// events every Interval and stamps at most BatchSize per step. One instance stamps at a time for Lease
+	SequencerSettings struct {
+		Interval  time.Duration `yaml:"Interval"`
+		Lease     time.Duration `yaml:"Lease"`
+		BatchSize int           `yaml:"BatchSize"`
+	}
+
+	// BatchSettings are settings of batch operations, pending batches are looked for every Interval and a batch is
+	// processed by one instance at a time for Lease, which is renewed as its items are processed. Atomic batches
+	// are limited to MaxAtomicItems since all of their items are in one transaction, which is aborted after half of
+	// Lease, 0 disables them
+	BatchSettings struct {
+		Interval       time.Duration `yaml:"Interval"`
+		Lease          time.Duration `yaml:"Lease"`
+		MaxItems       int           `yaml:"MaxItems"`
+		MaxAtomicItems int           `yaml:"MaxAtomicItems"`
+	}
+
+	FeatureSettings struct {
+		Graphql bool `yaml:"Graphql"`
+		Grpc    bool `yaml:"Grpc"`
 	}
 )
 
-func Read() (*Config, error) {
-	mongoURI := os.Getenv("MONGO_URI")
-	databaseName := os.Getenv("DATABASE_NAME")
-	port := os.Getenv("PORT")
+// Default returns config with default values, it is the base that file, env and flags override
+func Default() *Config {
+	return &Config{
+		MongoSettings: MongoSettings{
+			MaxPoolSize:            100,
+			ConnectTimeout:         10 * time.Second,
+			ServerSelectionTimeout: 5 * time.Second,
+			Timeout:                5 * time.Second,
+			Migrations:             "verify",
+			ReadPreference:         "primary",
+			ReadConcern:            "local",
+			WriteConcern:           "majority",
+			Transactions:           true,
+			TransactionRetries:     3,
+		},
+		ServerSettings: ServerSettings{
+			ReadTimeout:        10 * time.Second,
+			WriteTimeout:       10 * time.Second,
+			IdleTimeout:        60 * time.Second,
+			ShutdownTimeout:    15 * time.Second,
+			HealthCheckTimeout: 2 * time.Second,
+			BodyLimit:          1 << 20,
+		},
+		TracingSettings: TracingSettings{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		LoggingSettings: LoggingSettings{
+			Level:        "info",
+			SampleRatio:  1,
+			RedactFields: []string{"username", "amount"},
+		},
+		LimitSettings: LimitSettings{
+			GraphqlMaxDepth:      8,
+			GraphqlMaxComplexity: 2000,
+		},
+		RateLimitSettings: RateLimitSettings{
+			RequestsPerSecond: 50,
+			Burst:             100,
+		},
+		CacheSettings: CacheSettings{
+			Size: 10000,
+			TTL:  5 * time.Minute,
+		},
+		ProjectionSettings: ProjectionSettings{
+			Interval:  time.Second,
+			BatchSize: 500,
+		},
+		FeedSettings: FeedSettings{
+			MaxWait:      5 * time.Second,
+			PollInterval: 250 * time.Millisecond,
+		},
+		SequencerSettings: SequencerSettings{
+			Interval:  100 * time.Millisecond,
+			Lease:     10 * time.Second,
+			BatchSize: 500,
+		},
+		BatchSettings: BatchSettings{
+			Interval:       time.Second,
+			Lease:          time.Minute,
+			MaxItems:       5000,
+			MaxAtomicItems: 500,
+		},
+		FeatureSettings: FeatureSettings{
+			Graphql: true,
+			Grpc:    true,
+		},
+		Port:     "8080",
+		GrpcPort: "9090",
+	}
+}
+
+// Load loads config from defaults, yaml file of flags or CONFIG_FILE env, env vars or their files and flags, each one
+// overriding the previous. Every invalid value is reported at once, config is returned with the error unless
+// the file can't be read so that it can still be printed
+func Load(flags *Flags) (*Config, error) {
+	if flags == nil {
+		flags = &Flags{}
+	}
+	cfg := Default()
 
-	if utility.IsStrEmpty(port) {
-		return nil, errors.New("provide valid PORT via env")
+	if file := flags.configFile(); file != "" {
+		if err := readFile(file, cfg); err != nil {
+			return nil, err
+		}
 	}
-	if utility.IsStrEmpty(mongoURI) {
-		return nil, errors.New("provide valid MONGO_URI via env")
+
+	var errs []error
+	for _, o := range append(secretOverrides, overrides...) {
+		v, ok, err := o.lookupEnv()
+		if ok {
+			err = o.apply(cfg, v)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("provide valid %s via env: %w", o.env, err))
+		}
 	}
-	if utility.IsStrEmpty(databaseName) {
-		return nil, errors.New("provide valid DATABASE_NAME via env")
+	for _, o := range overrides {
+		if v, ok := flags.lookup(o.flag()); ok {
+			if err := o.apply(cfg, v); err != nil {
+				errs = append(errs, fmt.Errorf("provide valid --%s flag: %w", o.flag(), err))
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// configFile returns path of config file given by flag or env
+func (f *Flags) configFile() string {
+	if f.File != "" {
+		return f.File
+	}
+
+	return os.Getenv(ConfigFileEnv)
+}
+
+func readFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file can't be read: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err = decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s is invalid: %w", path, err)
 	}
 
-	return &Config{MongoSettings: MongoSettings{
-		URI:      mongoURI,
-		Database: databaseName,
-	}, Port: port}, nil
+	return nil
 }
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const fileEnvSuffix = "_FILE"
+
+type (
+	// Flags are command line flags, every env override except secrets has a flag named after it,
+	// e.g. --database-name for DATABASE_NAME
+	Flags struct {
+		File        string
+		PrintConfig bool
+
+		// set is the flag set flags are defined on, nil if flags aren't parsed from command line
+		set *flag.FlagSet
+	}
+
+	// override overrides a config value by env var, file of the value at path of env var suffixed with _FILE and flag
+	override struct {
+		env   string
+		usage string
+		apply func(cfg *Config, v string) error
+	}
+)
+
+// secretOverrides are overridden by env vars only, flags would expose them in process list and shell history
+var secretOverrides = []override{
+	{"MONGO_URI", "mongo connection uri", setSecret(func(c *Config) *Secret { return &c.MongoSettings.URI })},
+	{"API_KEYS", "comma separated api keys as name:key or name:key:admin", setAPIKeys(func(c *Config) *[]APIKey { return &c.AuthSettings.APIKeys })},
+}
+
+var overrides = []override{
+	{"PORT", "http port", setString(func(c *Config) *string { return &c.Port })},
+	{"GRPC_PORT", "grpc port", setString(func(c *Config) *string { return &c.GrpcPort })},
+
+	{"DATABASE_NAME", "mongo database name", setString(func(c *Config) *string { return &c.MongoSettings.Database })},
+	{"MONGO_MIN_POOL_SIZE", "min number of mongo connections", setUint(func(c *Config) *uint64 { return &c.MongoSettings.MinPoolSize })},
+	{"MONGO_MAX_POOL_SIZE", "max number of mongo connections", setUint(func(c *Config) *uint64 { return &c.MongoSettings.MaxPoolSize })},
+	{"MONGO_CONNECT_TIMEOUT", "mongo connect timeout", setDuration(func(c *Config) *time.Duration { return &c.MongoSettings.ConnectTimeout })},
+	{"MONGO_SERVER_SELECTION_TIMEOUT", "mongo server selection timeout", setDuration(func(c *Config) *time.Duration { return &c.MongoSettings.ServerSelectionTimeout })},
+	{"MONGO_TIMEOUT", "mongo operation timeout", setDuration(func(c *Config) *time.Duration { return &c.MongoSettings.Timeout })},
+	{"MONGO_READ_PREFERENCE", "primary, primaryPreferred, secondary, secondaryPreferred or nearest", setString(func(c *Config) *string { return &c.MongoSettings.ReadPreference })},
+	{"MONGO_READ_CONCERN", "local, available, majority or linearizable", setString(func(c *Config) *string { return &c.MongoSettings.ReadConcern })},
+	{"MONGO_WRITE_CONCERN", "majority or number of nodes", setString(func(c *Config) *string { return &c.MongoSettings.WriteConcern })},
+	{"MONGO_TRANSACTIONS", "run writes of a use case in a transaction, requires a replica set", setBool(func(c *Config) *bool { return &c.MongoSettings.Transactions })},
+	{"MONGO_TRANSACTION_RETRIES", "retries of transactions on transient errors", setInt(func(c *Config) *int { return &c.MongoSettings.TransactionRetries })},
+	{"MONGO_MIGRATIONS", "verify, apply or skip pending migrations at startup", setString(func(c *Config) *string { return &c.MongoSettings.Migrations })},
+
+	{"SERVER_READ_TIMEOUT", "http read timeout", setDuration(func(c *Config) *time.Duration { return &c.ServerSettings.ReadTimeout })},
+	{"SERVER_WRITE_TIMEOUT", "http write timeout", setDuration(func(c *Config) *time.Duration { return &c.ServerSettings.WriteTimeout })},
+	{"SERVER_IDLE_TIMEOUT", "http keep-alive idle timeout", setDuration(func(c *Config) *time.Duration { return &c.ServerSettings.IdleTimeout })},
+	{"SERVER_SHUTDOWN_TIMEOUT", "graceful shutdown timeout", setDuration(func(c *Config) *time.Duration { return &c.ServerSettings.ShutdownTimeout })},
+	{"SERVER_BODY_LIMIT", "max request body size in bytes", setInt(func(c *Config) *int { return &c.ServerSettings.BodyLimit })},
+	{"HEALTH_CHECK_TIMEOUT", "timeout of every readiness check", setDuration(func(c *Config) *time.Duration { return &c.ServerSettings.HealthCheckTimeout })},
+
+	{"TRACING_EXPORTER", "none, otlp or stdout", setString(func(c *Config) *string { return &c.TracingSettings.Exporter })},
+	{"TRACING_OTLP_ENDPOINT", "otlp grpc collector host:port", setString(func(c *Config) *string { return &c.TracingSettings.OTLPEndpoint })},
+	{"TRACING_OTLP_INSECURE", "disable tls of otlp exporter", setBool(func(c *Config) *bool { return &c.TracingSettings.OTLPInsecure })},
+	{"TRACING_FILE", "file of stdout exporter", setString(func(c *Config) *string { return &c.TracingSettings.File })},
+	{"TRACING_SAMPLE_RATIO", "ratio of sampled traces", setFloat(func(c *Config) *float64 { return &c.TracingSettings.SampleRatio })},
+
+	{"LOG_LEVEL", "debug, info, warn or error", setString(func(c *Config) *string { return &c.LoggingSettings.Level })},
+	{"LOG_SAMPLE_RATIO", "ratio of logged records below warn", setFloat(func(c *Config) *float64 { return &c.LoggingSettings.SampleRatio })},
+	{"LOG_REDACT", "redact values of LOG_REDACT_FIELDS", setBool(func(c *Config) *bool { return &c.LoggingSettings.Redact })},
+	{"LOG_REDACT_FIELDS", "comma separated log keys to redact", setList(func(c *Config) *[]string { return &c.LoggingSettings.RedactFields })},
+
+	{"GRAPHQL_MAX_DEPTH", "max depth of graphql queries", setInt(func(c *Config) *int { return &c.LimitSettings.GraphqlMaxDepth })},
+	{"GRAPHQL_MAX_COMPLEXITY", "max complexity of graphql queries", setInt(func(c *Config) *int { return &c.LimitSettings.GraphqlMaxComplexity })},
+
+	{"RATE_LIMIT_RPS", "requests per second per api key or ip, 0 is unlimited", setFloat(func(c *Config) *float64 { return &c.RateLimitSettings.RequestsPerSecond })},
+	{"RATE_LIMIT_BURST", "burst of requests per api key or ip", setInt(func(c *Config) *int { return &c.RateLimitSettings.Burst })},
+
+	{"WITHDRAW_FEE_FIXED", "fixed fee of withdrawals", setFloat(func(c *Config) *float64 { return &c.FeeSettings.Withdraw.Fixed })},
+	{"WITHDRAW_FEE_RATE", "fee of withdrawals as ratio of the amount", setFloat(func(c *Config) *float64 { return &c.FeeSettings.Withdraw.Rate })},
+	{"WITHDRAW_FEE_MAX", "max fee of withdrawals, 0 is no max", setFloat(func(c *Config) *float64 { return &c.FeeSettings.Withdraw.Max })},
+	{"TRANSFER_FEE_FIXED", "fixed fee of transfers", setFloat(func(c *Config) *float64 { return &c.FeeSettings.Transfer.Fixed })},
+	{"TRANSFER_FEE_RATE", "fee of transfers as ratio of the amount", setFloat(func(c *Config) *float64 { return &c.FeeSettings.Transfer.Rate })},
+	{"TRANSFER_FEE_MAX", "max fee of transfers, 0 is no max", setFloat(func(c *Config) *float64 { return &c.FeeSettings.Transfer.Max })},
+
+	{"CACHE_SIZE", "max number of cached wallet states, 0 disables the cache", setInt(func(c *Config) *int { return &c.CacheSettings.Size })},
+	{"CACHE_TTL", "how long wallet states are cached, 0 is until evicted", setDuration(func(c *Config) *time.Duration { return &c.CacheSettings.TTL })},
+
+	{"PROJECTION_INTERVAL", "how long projections wait after catching up", setDuration(func(c *Config) *time.Duration { return &c.ProjectionSettings.Interval })},
+	{"PROJECTION_BATCH_SIZE", "max number of events projected per step", setInt(func(c *Config) *int { return &c.ProjectionSettings.BatchSize })},
+
+	{"FEED_MAX_WAIT", "max time long polls of the transaction feed wait", setDuration(func(c *Config) *time.Duration { return &c.FeedSettings.MaxWait })},
+	{"FEED_POLL_INTERVAL", "how often long polls of the transaction feed look for transactions", setDuration(func(c *Config) *time.Duration { return &c.FeedSettings.PollInterval })},
+
+	{"SEQUENCER_INTERVAL", "how often pending events are looked for to stamp their positions", setDuration(func(c *Config) *time.Duration { return &c.SequencerSettings.Interval })},
+	{"SEQUENCER_LEASE", "how long one instance stamps positions before another may take over", setDuration(func(c *Config) *time.Duration { return &c.SequencerSettings.Lease })},
+	{"SEQUENCER_BATCH_SIZE", "max number of events stamped per step", setInt(func(c *Config) *int { return &c.SequencerSettings.BatchSize })},
+
+	{"BATCH_INTERVAL", "how often pending batches are looked for", setDuration(func(c *Config) *time.Duration { return &c.BatchSettings.Interval })},
+	{"BATCH_LEASE", "how long a batch is processed by one instance before another may take it over", setDuration(func(c *Config) *time.Duration { return &c.BatchSettings.Lease })},
+	{"BATCH_MAX_ITEMS", "max number of items of a batch", setInt(func(c *Config) *int { return &c.BatchSettings.MaxItems })},
+	{"BATCH_MAX_ATOMIC_ITEMS", "max number of items of an atomic batch, 0 disables atomic batches", setInt(func(c *Config) *int { return &c.BatchSettings.MaxAtomicItems })},
+
+	{"FEATURE_GRAPHQL", "serve graphql endpoint", setBool(func(c *Config) *bool { return &c.FeatureSettings.Graphql })},
+	{"FEATURE_GRPC", "serve grpc api", setBool(func(c *Config) *bool { return &c.FeatureSettings.Grpc })},
+}
+
+// ParseFlags parses command line flags from args
+func ParseFlags(name string, args []string, output io.Writer) (*Flags, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	flags := AddFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return flags, nil
+}
+
+// AddFlags defines command line flags on fs so that commands can add their own flags, returned flags are
+// read after fs is parsed
+func AddFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{set: fs}
+	fs.StringVar(&flags.File, "config", "", "yaml config file, "+ConfigFileEnv+" env too")
+	fs.BoolVar(&flags.PrintConfig, "print-config", false, "print effective config with secrets masked and exit")
+	for _, o := range overrides {
+		fs.String(o.flag(), "", o.usage+", "+o.env+" env too")
+	}
+
+	return flags
+}
+
+// lookup returns value of override flag if it is set explicitly
+func (f *Flags) lookup(name string) (string, bool) {
+	var (
+		v  string
+		ok bool
+	)
+	if f.set != nil {
+		f.set.Visit(func(fl *flag.Flag) {
+			if fl.Name == name {
+				v, ok = fl.Value.String(), true
+			}
+		})
+	}
+
+	return v, ok
+}
+
+// lookupEnv looks up value of env var or the file at path of env var suffixed with _FILE, e.g. mounted
+// docker or kubernetes secrets
+func (o override) lookupEnv() (string, bool, error) {
+	v := os.Getenv(o.env)
+	path := os.Getenv(o.env + fileEnvSuffix)
+	if path == "" {
+		return v, v != "", nil
+	}
+	if v != "" {
+		return "", false, fmt.Errorf("set only one of %s and %s%s", o.env, o.env, fileEnvSuffix)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), true, nil
+}
+
+func (o override) flag() string {
+	return strings.ReplaceAll(strings.ToLower(o.env), "_", "-")
+}
+
+func setString(field func(c *Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setSecret(field func(c *Config) *Secret) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = Secret(v)
+		return nil
+	}
+}
+
+// setAPIKeys sets api keys of v, comma separated keys as name:key or name:key:admin
+func setAPIKeys(field func(c *Config) *[]APIKey) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		var keys []APIKey
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			parts := strings.Split(s, ":")
+			if len(parts) < 2 || len(parts) > 3 || (len(parts) == 3 && parts[2] != "admin") {
+				return errors.New("api keys must be name:key or name:key:admin")
+			}
+			keys = append(keys, APIKey{Name: parts[0], Key: Secret(parts[1]), Admin: len(parts) == 3})
+		}
+		*field(c) = keys
+		return nil
+	}
+}
+
+func setList(field func(c *Config) *[]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		var values []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+		*field(c) = values
+		return nil
+	}
+}
+
+func setBool(field func(c *Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err == nil {
+			*field(c) = b
+		}
+		return err
+	}
+}
+
+func setInt(field func(c *Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		i, err := strconv.Atoi(v)
+		if err == nil {
+			*field(c) = i
+		}
+		return err
+	}
+}
+
+func setUint(field func(c *Config) *uint64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		i, err := strconv.ParseUint(v, 10, 64)
+		if err == nil {
+			*field(c) = i
+		}
+		return err
+	}
+}
+
+func setFloat(field func(c *Config) *float64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err == nil {
+			*field(c) = f
+		}
+		return err
+	}
+}
+
+func setDuration(field func(c *Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			*field(c) = d
+		}
+		return err
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"io"
+)
+
+// Print writes config to w as yaml, secrets are masked
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+
+	return encoder.Encode(c)
+}
+
+// Secrets returns unmasked values of secrets that must never be logged
+func (c *Config) Secrets() []string {
+	secrets := c.MongoSettings.URI.secrets()
+	for _, k := range c.AuthSettings.APIKeys {
+		secrets = append(secrets, k.Key.secrets()...)
+	}
+
+	return secrets
+}
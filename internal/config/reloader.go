@@ -0,0 +1,204 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultWatchInterval is the default interval of checking config file for changes
+const DefaultWatchInterval = 5 * time.Second
+
+type (
+	// Reloader reloads config on SIGHUP or change of config file and applies the settings that can be changed at
+	// runtime to subscribers, other settings require restart
+	Reloader struct {
+		flags *Flags
+		log   *slog.Logger
+
+		// mu serializes reloads
+		mu          sync.Mutex
+		subscribers []subscriber
+		current     atomic.Pointer[Config]
+		version     atomic.Pointer[Version]
+	}
+
+	// Version describes the config in use and the last reload attempt
+	Version struct {
+		Version         int64     `json:"version"`
+		Checksum        string    `json:"checksum"`
+		LoadedAt        time.Time `json:"loaded_at"`
+		LastReloadAt    time.Time `json:"last_reload_at,omitempty"`
+		LastReloadError string    `json:"last_reload_error,omitempty"`
+	}
+
+	subscriber struct {
+		name  string
+		apply func(cfg *Config) error
+	}
+)
+
+// NewReloader creates new instance of Reloader whose current config is cfg loaded by flags
+func NewReloader(flags *Flags, cfg *Config, log *slog.Logger) *Reloader {
+	if flags == nil {
+		flags = &Flags{}
+	}
+
+	r := &Reloader{flags: flags, log: log}
+	r.current.Store(cfg)
+	r.version.Store(&Version{Version: 1, Checksum: checksum(cfg), LoadedAt: time.Now()})
+
+	return r
+}
+
+// Current returns the config in use
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Version returns version of the config in use
+func (r *Reloader) Version() Version {
+	return *r.version.Load()
+}
+
+// Subscribe adds apply that applies reloaded config to a component, it must be safe to call while the component
+// is used and it is called with the previous config to roll back when a later subscriber fails
+func (r *Reloader) Subscribe(name string, apply func(cfg *Config) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, subscriber{name: name, apply: apply})
+}
+
+// Reload loads config and applies its runtime settings to subscribers if it is valid and changed, the config in use
+// is kept if loading or applying fails
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.reload()
+
+	version := r.Version()
+	version.LastReloadAt = time.Now()
+	version.LastReloadError = ""
+	if err != nil {
+		version.LastReloadError = err.Error()
+	}
+	r.version.Store(&version)
+
+	return err
+}
+
+func (r *Reloader) reload() error {
+	loaded, err := Load(r.flags)
+	if err != nil {
+		return fmt.Errorf("invalid config is not applied: %w", err)
+	}
+
+	current := r.Current()
+	next := current.withRuntimeSettings(loaded)
+	if !reflect.DeepEqual(next, loaded) {
+		r.log.Warn("config changes that require restart are ignored")
+	}
+
+	sum := checksum(next)
+	if sum == r.Version().Checksum {
+		return nil
+	}
+
+	for i, s := range r.subscribers {
+		if err = s.apply(next); err != nil {
+			r.rollback(current, i)
+			return fmt.Errorf("config is rolled back, %s could not apply it: %w", s.name, err)
+		}
+	}
+
+	r.current.Store(next)
+	r.version.Store(&Version{Version: r.Version().Version + 1, Checksum: sum, LoadedAt: time.Now()})
+
+	return nil
+}
+
+// rollback applies cfg to the first n subscribers
+func (r *Reloader) rollback(cfg *Config, n int) {
+	for _, s := range r.subscribers[:n] {
+		if err := s.apply(cfg); err != nil {
+			r.log.Error("config could not be rolled back", "component", s.name, "error", err)
+		}
+	}
+}
+
+// Watch reloads config on SIGHUP and when modification time of config file changes until ctx is done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	file := r.flags.configFile()
+	modTime := modTimeOf(file)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		case <-ticker.C:
+			t := modTimeOf(file)
+			if t.Equal(modTime) {
+				continue
+			}
+			modTime = t
+		}
+
+		if err := r.Reload(); err != nil {
+			r.log.Error("config reload failed", "error", err)
+			continue
+		}
+		r.log.Info("config reloaded", "version", r.Version().Version)
+	}
+}
+
+// withRuntimeSettings returns copy of c with settings of next that can be changed at runtime
+func (c *Config) withRuntimeSettings(next *Config) *Config {
+	cfg := *c
+	cfg.LoggingSettings.Level = next.LoggingSettings.Level
+	cfg.LoggingSettings.SampleRatio = next.LoggingSettings.SampleRatio
+	cfg.LimitSettings = next.LimitSettings
+	cfg.RateLimitSettings = next.RateLimitSettings
+	cfg.FeeSettings = next.FeeSettings
+
+	return &cfg
+}
+
+func checksum(cfg *Config) string {
+	buf := new(bytes.Buffer)
+	_ = cfg.Print(buf)
+	sum := sha256.Sum256(buf.Bytes())
+
+	return hex.EncodeToString(sum[:])
+}
+
+func modTimeOf(file string) time.Time {
+	if file == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
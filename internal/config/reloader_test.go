@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	base := "MongoSettings:\n  URI: mongodb://localhost:27017\n  Database: db\n"
+	file := writeFile(t, base)
+	flags := &Flags{File: file}
+
+	cfg, err := Load(flags)
+	assert.Nil(t, err)
+
+	r := NewReloader(flags, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.Equal(t, int64(1), r.Version().Version)
+
+	var levels []string
+	r.Subscribe("logging", func(c *Config) error {
+		levels = append(levels, c.LoggingSettings.Level)
+		return nil
+	})
+
+	t.Run("should not apply unchanged config", func(t *testing.T) {
+		assert.Nil(t, r.Reload())
+		assert.Equal(t, int64(1), r.Version().Version)
+		assert.Empty(t, levels)
+	})
+
+	t.Run("should apply runtime settings and ignore the others", func(t *testing.T) {
+		assert.Nil(t, os.WriteFile(file, []byte(base+"LoggingSettings:\n  Level: debug\nLimitSettings:\n  GraphqlMaxDepth: 4\nFeeSettings:\n  Withdraw:\n    Fixed: 0.5\nPort: \"9000\"\n"), 0o600))
+
+		assert.Nil(t, r.Reload())
+		assert.Equal(t, int64(2), r.Version().Version)
+		assert.Equal(t, []string{"debug"}, levels)
+		assert.Equal(t, 4, r.Current().LimitSettings.GraphqlMaxDepth)
+		assert.Equal(t, 0.5, r.Current().FeeSettings.Withdraw.Fixed)
+		assert.Equal(t, "8080", r.Current().Port, "port requires restart")
+		assert.Equal(t, "info", cfg.LoggingSettings.Level, "config in use must not be mutated")
+	})
+
+	t.Run("should keep config in use when reloaded config is invalid", func(t *testing.T) {
+		assert.Nil(t, os.WriteFile(file, []byte(base+"LoggingSettings:\n  Level: verbose\n"), 0o600))
+
+		assert.NotNil(t, r.Reload())
+		assert.Equal(t, int64(2), r.Version().Version)
+		assert.Contains(t, r.Version().LastReloadError, "LOG_LEVEL")
+		assert.Equal(t, "debug", r.Current().LoggingSettings.Level)
+	})
+
+	t.Run("should roll back applied subscribers when a subscriber fails", func(t *testing.T) {
+		r.Subscribe("failing", func(*Config) error { return errors.New("failed") })
+		assert.Nil(t, os.WriteFile(file, []byte(base+"LoggingSettings:\n  Level: warn\n"), 0o600))
+
+		assert.NotNil(t, r.Reload())
+		assert.Equal(t, []string{"debug", "warn", "debug"}, levels)
+		assert.Equal(t, int64(2), r.Version().Version)
+		assert.Equal(t, "debug", r.Current().LoggingSettings.Level)
+	})
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/url"
+)
+
+// mask is the password mask of url.URL.Redacted
+const mask = "xxxxx"
+
+// Secret is a config value that is masked whenever it is printed, logged or encoded, Value returns the value itself
+type Secret string
+
+// Value returns the unmasked value
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns masked value, only the password of urls is masked to keep them recognizable
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	u, err := url.Parse(string(s))
+	if err != nil || u.Scheme == "" {
+		return mask
+	}
+	if _, ok := u.User.Password(); !ok {
+		// the secret part of url is unknown
+		return u.Scheme + "://" + mask
+	}
+
+	return u.Redacted()
+}
+
+// GoString returns masked value for %#v
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// LogValue returns masked value for slog
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalYAML returns masked value
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// MarshalJSON returns masked value
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// secrets returns unmasked secret values and their parts that can appear alone, e.g. password of urls
+func (s Secret) secrets() []string {
+	if s == "" {
+		return nil
+	}
+
+	secrets := []string{string(s)}
+	if u, err := url.Parse(string(s)); err == nil {
+		if password, ok := u.User.Password(); ok && password != "" {
+			secrets = append(secrets, password)
+		}
+	}
+
+	return secrets
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// minAPIKeyLength is the min length of api keys so that they can't be guessed
+const minAPIKeyLength = 16
+
+// Validate validates every setting and reports every problem at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.Port), "provide valid Port (PORT) between 1 and 65535")
+	check(validPort(c.GrpcPort), "provide valid GrpcPort (GRPC_PORT) between 1 and 65535")
+	check(c.Port != c.GrpcPort, "Port and GrpcPort must be different")
+
+	m := c.MongoSettings
+	check(strings.HasPrefix(m.URI.Value(), "mongodb://") || strings.HasPrefix(m.URI.Value(), "mongodb+srv://"),
+		"provide valid MongoSettings.URI (MONGO_URI) with mongodb:// or mongodb+srv:// scheme")
+	check(strings.TrimSpace(m.Database) != "", "provide valid MongoSettings.Database (DATABASE_NAME)")
+	check(m.MaxPoolSize > 0, "provide valid MongoSettings.MaxPoolSize (MONGO_MAX_POOL_SIZE) greater than 0")
+	check(m.MinPoolSize <= m.MaxPoolSize, "MongoSettings.MinPoolSize (MONGO_MIN_POOL_SIZE) can't be greater than MaxPoolSize")
+	check(m.ConnectTimeout > 0, "provide valid MongoSettings.ConnectTimeout (MONGO_CONNECT_TIMEOUT) greater than 0")
+	check(m.ServerSelectionTimeout > 0, "provide valid MongoSettings.ServerSelectionTimeout (MONGO_SERVER_SELECTION_TIMEOUT) greater than 0")
+	check(m.Timeout > 0, "provide valid MongoSettings.Timeout (MONGO_TIMEOUT) greater than 0")
+	check(validReadPreference(m.ReadPreference),
+		"provide valid MongoSettings.ReadPreference (MONGO_READ_PREFERENCE), one of primary, primaryPreferred, secondary, secondaryPreferred or nearest")
+	check(validReadConcern(m.ReadConcern),
+		"provide valid MongoSettings.ReadConcern (MONGO_READ_CONCERN), one of local, available, majority or linearizable")
+	check(validWriteConcern(m.WriteConcern), "provide valid MongoSettings.WriteConcern (MONGO_WRITE_CONCERN), majority or number of nodes")
+	check(m.TransactionRetries >= 0, "provide valid MongoSettings.TransactionRetries (MONGO_TRANSACTION_RETRIES), 0 is no retry")
+	for name, o := range m.Operations {
+		check(o.ReadPreference == "" || validReadPreference(o.ReadPreference), "provide valid MongoSettings.Operations.%s.ReadPreference", name)
+		check(o.ReadConcern == "" || validReadConcern(o.ReadConcern), "provide valid MongoSettings.Operations.%s.ReadConcern", name)
+		check(o.WriteConcern == "" || validWriteConcern(o.WriteConcern), "provide valid MongoSettings.Operations.%s.WriteConcern", name)
+	}
+	check(m.Migrations == "verify" || m.Migrations == "apply" || m.Migrations == "skip",
+		"provide valid MongoSettings.Migrations (MONGO_MIGRATIONS), one of verify, apply or skip")
+
+	s := c.ServerSettings
+	check(s.ReadTimeout >= 0, "provide valid ServerSettings.ReadTimeout (SERVER_READ_TIMEOUT), 0 is no timeout")
+	check(s.WriteTimeout >= 0, "provide valid ServerSettings.WriteTimeout (SERVER_WRITE_TIMEOUT), 0 is no timeout")
+	check(s.IdleTimeout >= 0, "provide valid ServerSettings.IdleTimeout (SERVER_IDLE_TIMEOUT), 0 is no timeout")
+	check(s.ShutdownTimeout > 0, "provide valid ServerSettings.ShutdownTimeout (SERVER_SHUTDOWN_TIMEOUT) greater than 0")
+	check(s.HealthCheckTimeout > 0, "provide valid ServerSettings.HealthCheckTimeout (HEALTH_CHECK_TIMEOUT) greater than 0")
+	check(s.BodyLimit > 0, "provide valid ServerSettings.BodyLimit (SERVER_BODY_LIMIT) greater than 0")
+
+	names, keys := map[string]bool{}, map[Secret]bool{}
+	for i, k := range c.AuthSettings.APIKeys {
+		check(strings.TrimSpace(k.Name) != "" && !names[k.Name], "provide unique AuthSettings.APIKeys[%d].Name (API_KEYS)", i)
+		check(len(k.Key) >= minAPIKeyLength && !keys[k.Key],
+			"provide unique AuthSettings.APIKeys[%d].Key (API_KEYS) of at least %d characters", i, minAPIKeyLength)
+		names[k.Name], keys[k.Key] = true, true
+	}
+
+	t := c.TracingSettings
+	check(t.Exporter == "none" || t.Exporter == "otlp" || t.Exporter == "stdout",
+		"provide valid TracingSettings.Exporter (TRACING_EXPORTER), one of none, otlp or stdout")
+	check(t.SampleRatio >= 0 && t.SampleRatio <= 1, "provide valid TracingSettings.SampleRatio (TRACING_SAMPLE_RATIO) between 0 and 1")
+
+	l := c.LoggingSettings
+	var level slog.Level
+	check(level.UnmarshalText([]byte(l.Level)) == nil, "provide valid LoggingSettings.Level (LOG_LEVEL), one of debug, info, warn or error")
+	check(l.SampleRatio >= 0 && l.SampleRatio <= 1, "provide valid LoggingSettings.SampleRatio (LOG_SAMPLE_RATIO) between 0 and 1")
+
+	check(c.LimitSettings.GraphqlMaxDepth > 0, "provide valid LimitSettings.GraphqlMaxDepth (GRAPHQL_MAX_DEPTH) greater than 0")
+	check(c.LimitSettings.GraphqlMaxComplexity > 0, "provide valid LimitSettings.GraphqlMaxComplexity (GRAPHQL_MAX_COMPLEXITY) greater than 0")
+
+	r := c.RateLimitSettings
+	check(r.RequestsPerSecond >= 0, "provide valid RateLimitSettings.RequestsPerSecond (RATE_LIMIT_RPS), 0 is unlimited")
+	check(r.RequestsPerSecond == 0 || r.Burst > 0, "provide valid RateLimitSettings.Burst (RATE_LIMIT_BURST) greater than 0")
+
+	for _, f := range []struct {
+		name, env string
+		schedule  FeeSchedule
+	}{{"Withdraw", "WITHDRAW", c.FeeSettings.Withdraw}, {"Transfer", "TRANSFER", c.FeeSettings.Transfer}} {
+		check(f.schedule.Fixed >= 0, "provide valid FeeSettings.%s.Fixed (%s_FEE_FIXED), 0 is no fixed fee", f.name, f.env)
+		check(f.schedule.Rate >= 0 && f.schedule.Rate < 1, "provide valid FeeSettings.%s.Rate (%s_FEE_RATE) between 0 and 1", f.name, f.env)
+		check(f.schedule.Max == 0 || f.schedule.Max >= f.schedule.Fixed,
+			"provide valid FeeSettings.%s.Max (%s_FEE_MAX) not less than Fixed, 0 is no max", f.name, f.env)
+	}
+
+	check(c.CacheSettings.Size >= 0, "provide valid CacheSettings.Size (CACHE_SIZE), 0 disables the cache")
+	check(c.CacheSettings.TTL >= 0, "provide valid CacheSettings.TTL (CACHE_TTL), 0 is until evicted")
+
+	p := c.ProjectionSettings
+	check(p.Interval > 0, "provide valid ProjectionSettings.Interval (PROJECTION_INTERVAL) greater than 0")
+	check(p.BatchSize > 0, "provide valid ProjectionSettings.BatchSize (PROJECTION_BATCH_SIZE) greater than 0")
+
+	f := c.FeedSettings
+	check(f.MaxWait >= 0, "provide valid FeedSettings.MaxWait (FEED_MAX_WAIT), 0 disables long polls")
+	check(s.WriteTimeout == 0 || f.MaxWait < s.WriteTimeout,
+		"FeedSettings.MaxWait (FEED_MAX_WAIT) must be less than ServerSettings.WriteTimeout (SERVER_WRITE_TIMEOUT)")
+	check(f.PollInterval > 0, "provide valid FeedSettings.PollInterval (FEED_POLL_INTERVAL) greater than 0")
+
+	q := c.SequencerSettings
+	check(q.Interval > 0, "provide valid SequencerSettings.Interval (SEQUENCER_INTERVAL) greater than 0")
+	check(q.Lease > 0, "provide valid SequencerSettings.Lease (SEQUENCER_LEASE) greater than 0")
+	check(q.BatchSize > 0, "provide valid SequencerSettings.BatchSize (SEQUENCER_BATCH_SIZE) greater than 0")
+
+	b := c.BatchSettings
+	check(b.Interval > 0, "provide valid BatchSettings.Interval (BATCH_INTERVAL) greater than 0")
+	check(b.Lease > 0, "provide valid BatchSettings.Lease (BATCH_LEASE) greater than 0")
+	check(b.MaxItems > 0, "provide valid BatchSettings.MaxItems (BATCH_MAX_ITEMS) greater than 0")
+	check(b.MaxAtomicItems >= 0 && b.MaxAtomicItems <= b.MaxItems,
+		"provide valid BatchSettings.MaxAtomicItems (BATCH_MAX_ATOMIC_ITEMS) between 0 and BatchSettings.MaxItems, 0 disables atomic batches")
+
+	return errors.Join(errs...)
+}
+
+func validReadPreference(mode string) bool {
+	switch mode {
+	case "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest":
+		return true
+	}
+
+	return false
+}
+
+func validReadConcern(level string) bool {
+	switch level {
+	case "local", "available", "majority", "linearizable":
+		return true
+	}
+
+	return false
+}
+
+func validWriteConcern(w string) bool {
+	n, err := strconv.Atoi(w)
+	return w == "majority" || (err == nil && n >= 0)
+}
+
+func validPort(port string) bool {
+	p, err := strconv.Atoi(port)
+	return err == nil && p > 0 && p <= 65535
+}
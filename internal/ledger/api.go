@@ -0,0 +1,44 @@
+package ledger
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/response"
+)
+
+type Api struct {
+	ledger *Ledger
+}
+
+func NewApi(ledger *Ledger) *Api {
+	return &Api{ledger: ledger}
+}
+
+func (a *Api) AddRoutesTo(r fiber.Router) {
+	admin := r.Group("admin")
+
+	admin.Get("/ledger/trial-balance", a.GetTrialBalance)
+}
+
+// Routes describes the routes added by AddRoutesTo for the api documentation
+func (a *Api) Routes() []openapi.Route {
+	return []openapi.Route{
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/admin/ledger/trial-balance",
+			Summary:  "Get trial balance of the ledger, amounts are in minor units",
+			Tags:     []string{"admin"},
+			Response: TrialBalance{},
+			Errors:   []int{fiber.StatusInternalServerError},
+		},
+	}
+}
+
+func (a *Api) GetTrialBalance(c *fiber.Ctx) error {
+	tb, err := a.ledger.TrialBalance(c.UserContext())
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(tb).JSON()
+}
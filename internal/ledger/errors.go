@@ -0,0 +1,7 @@
+package ledger
+
+const (
+	ErrTooFewPostings  = "journal entry must have at least two postings"
+	ErrInvalidPosting  = "posting %d must have an account, a currency and a non-zero amount"
+	ErrUnbalancedEntry = "postings of journal entry don't sum to zero in %s"
+)
@@ -0,0 +1,12 @@
+package ledger
+
+import (
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Indexes are indexes of the journal, unique transaction ids keep a wallet transaction from being recorded twice
+var Indexes = []migration.Index{
+	{Collection: journalCollection, Keys: bson.D{{Key: "transaction_ids", Value: 1}}, Unique: true, Sparse: true},
+	{Collection: journalCollection, Keys: bson.D{{Key: "postings.account", Value: 1}, {Key: "created_at", Value: 1}}},
+}
@@ -0,0 +1,37 @@
+package ledger
+
+import (
+	"context"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+)
+
+// Ledger is the double-entry general ledger, money only moves between its accounts by balanced journal entries
+type Ledger struct {
+	repository Repository
+}
+
+// New creates new instance of Ledger
+func New(repository Repository) *Ledger {
+	return &Ledger{repository: repository}
+}
+
+// Post validates and records entries at once, nothing is recorded if an entry is invalid or already recorded
+func (l *Ledger) Post(ctx context.Context, entries ...*JournalEntry) error {
+	for _, e := range entries {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return l.repository.InsertEntries(ctx, entries...)
+}
+
+// TrialBalance sums every account and reports whether debits equal credits in every currency
+func (l *Ledger) TrialBalance(ctx context.Context) (*TrialBalance, *errr.Error) {
+	balances, err := l.repository.SumPostings(ctx)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+
+	return NewTrialBalance(balances), nil
+}
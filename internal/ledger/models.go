@@ -0,0 +1,166 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Accounts of the business, user wallets are accounts named by WalletAccount
+const (
+	// CashInClearingAccount is debited by money received from outside for deposits
+	CashInClearingAccount = "cash_in_clearing"
+	// CashOutClearingAccount is credited by money paid outside for withdrawals
+	CashOutClearingAccount = "cash_out_clearing"
+	// FeesAccount is credited by fees charged to wallets
+	FeesAccount = "fees"
+	// SuspenseAccount holds money that can't be attributed to an account until it is investigated
+	SuspenseAccount = "suspense"
+
+	walletAccountPrefix = "wallet:"
+)
+
+type (
+	// JournalEntry records a money movement as postings that sum to zero per currency, entries are never
+	// updated or deleted, mistakes are corrected by reversing entries
+	JournalEntry struct {
+		ID          string `bson:"_id" json:"id"`
+		Description string `bson:"description" json:"description"`
+		// TransactionIDs are wallet transactions the entry records, a transaction is recorded by one entry only
+		TransactionIDs []string  `bson:"transaction_ids,omitempty" json:"transaction_ids,omitempty"`
+		Postings       []Posting `bson:"postings" json:"postings"`
+		CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+	}
+
+	// Posting debits or credits an account, Amount is in minor units of currency, e.g. kuruş, and positive for
+	// debits and negative for credits
+	Posting struct {
+		Account  string `bson:"account" json:"account"`
+		Currency string `bson:"currency" json:"currency"`
+		Amount   int64  `bson:"amount" json:"amount"`
+	}
+
+	// AccountBalance is the sum of debits and credits of an account in a currency
+	AccountBalance struct {
+		Account  string `bson:"account" json:"account"`
+		Currency string `bson:"currency" json:"currency"`
+		Debit    int64  `bson:"debit" json:"debit"`
+		Credit   int64  `bson:"credit" json:"credit"`
+		// Balance is Debit minus Credit, it is negative for accounts the business owes, e.g. wallets
+		Balance int64 `bson:"-" json:"balance"`
+	}
+
+	// TrialBalance lists balances of every account, the books balance if debits equal credits in every currency
+	TrialBalance struct {
+		Accounts []AccountBalance `json:"accounts"`
+		Totals   []AccountBalance `json:"totals"`
+		Balanced bool             `json:"balanced"`
+	}
+)
+
+// WalletAccount returns account of wallet
+func WalletAccount(walletID string) string {
+	return walletAccountPrefix + walletID
+}
+
+// WalletIDOf returns id of wallet of account, ok is false if account isn't a wallet account
+func WalletIDOf(account string) (string, bool) {
+	if !strings.HasPrefix(account, walletAccountPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(account, walletAccountPrefix), true
+}
+
+// NewJournalEntry creates new instance of JournalEntry, error is returned if postings don't balance
+func NewJournalEntry(description string, transactionIDs []string, postings ...Posting) (*JournalEntry, error) {
+	e := &JournalEntry{
+		ID:             uuid.NewString(),
+		Description:    description,
+		TransactionIDs: transactionIDs,
+		Postings:       postings,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Debit returns posting that debits account by amount
+func Debit(account, currency string, amount int64) Posting {
+	return Posting{Account: account, Currency: currency, Amount: amount}
+}
+
+// Credit returns posting that credits account by amount
+func Credit(account, currency string, amount int64) Posting {
+	return Posting{Account: account, Currency: currency, Amount: -amount}
+}
+
+// Validate checks entry has at least two valid postings that sum to zero in every currency
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return errors.New(ErrTooFewPostings)
+	}
+
+	sums := map[string]int64{}
+	for i, p := range e.Postings {
+		if p.Account == "" || p.Currency == "" || p.Amount == 0 {
+			return fmt.Errorf(ErrInvalidPosting, i)
+		}
+		sums[p.Currency] += p.Amount
+	}
+	for _, currency := range sortedKeys(sums) {
+		if sums[currency] != 0 {
+			return fmt.Errorf(ErrUnbalancedEntry, currency)
+		}
+	}
+
+	return nil
+}
+
+// NewTrialBalance creates trial balance of account balances, totals are summed per currency
+func NewTrialBalance(accounts []AccountBalance) *TrialBalance {
+	totals := map[string]*AccountBalance{}
+	for i := range accounts {
+		a := &accounts[i]
+		a.Balance = a.Debit - a.Credit
+
+		t, ok := totals[a.Currency]
+		if !ok {
+			t = &AccountBalance{Currency: a.Currency}
+			totals[a.Currency] = t
+		}
+		t.Debit += a.Debit
+		t.Credit += a.Credit
+	}
+
+	tb := &TrialBalance{Accounts: accounts, Totals: []AccountBalance{}, Balanced: true}
+	if tb.Accounts == nil {
+		tb.Accounts = []AccountBalance{}
+	}
+	for _, currency := range sortedKeys(totals) {
+		t := totals[currency]
+		t.Balance = t.Debit - t.Credit
+		tb.Totals = append(tb.Totals, *t)
+		if t.Balance != 0 {
+			tb.Balanced = false
+		}
+	}
+
+	return tb
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=repository.go -destination=./test/repository_mock.go -package=ledger
+
+const journalCollection = "journal_entries"
+
+// ErrDuplicateEntry is returned by InsertEntries when an entry or one of its transactions is already recorded
+var ErrDuplicateEntry = errors.New("journal entry or its transaction is already recorded")
+
+type (
+	// Repository is an interface that do db operations of the journal, entries are never updated or deleted
+	Repository interface {
+		// InsertEntries inserts entries to collection, ErrDuplicateEntry is returned if an entry or one of its
+		// transactions is recorded
+		InsertEntries(ctx context.Context, entries ...*JournalEntry) error
+		// SumPostings sums debits and credits of every account per currency in ascending account order
+		SumPostings(ctx context.Context) ([]AccountBalance, error)
+	}
+
+	// MongoRepository is a concrete implementation of Repository interface, it joins mongo transactions
+	// carried by context
+	MongoRepository struct {
+		entries *mongo.Collection
+	}
+)
+
+// NewMongoRepository creates instance of MongoRepository
+func NewMongoRepository(db *mongo.Database) *MongoRepository {
+	return &MongoRepository{entries: db.Collection(journalCollection)}
+}
+
+// InsertEntries inserts entries to collection, ErrDuplicateEntry is returned if an entry or one of its
+// transactions is recorded
+func (r *MongoRepository) InsertEntries(ctx context.Context, entries ...*JournalEntry) error {
+	documents := make([]interface{}, len(entries))
+	for i, e := range entries {
+		documents[i] = e
+	}
+
+	_, err := r.entries.InsertMany(ctx, documents)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateEntry
+	}
+
+	return err
+}
+
+// SumPostings sums debits and credits of every account per currency in ascending account order
+func (r *MongoRepository) SumPostings(ctx context.Context) ([]AccountBalance, error) {
+	amount := "$postings.amount"
+	cursor, err := r.entries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$unwind", Value: "$postings"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"account": "$postings.account", "currency": "$postings.currency"},
+			"debit":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{amount, 0}}, amount, 0}}},
+			"credit": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{amount, 0}}, bson.M{"$multiply": bson.A{amount, -1}}, 0}}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":      0,
+			"account":  "$_id.account",
+			"currency": "$_id.currency",
+			"debit":    1,
+			"credit":   1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "account", Value: 1}, {Key: "currency", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var balances []AccountBalance
+	if err = cursor.All(ctx, &balances); err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
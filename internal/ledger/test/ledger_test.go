@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestNewJournalEntry(t *testing.T) {
+	wallet := ledger.WalletAccount("w")
+
+	t.Run("should create entry whose postings sum to zero", func(t *testing.T) {
+		e, err := ledger.NewJournalEntry("deposit", []string{"t"},
+			ledger.Debit(ledger.CashInClearingAccount, "TRY", 100),
+			ledger.Credit(wallet, "TRY", 100))
+		assert.Nil(t, err)
+		assert.NotEmpty(t, e.ID)
+		assert.Equal(t, int64(-100), e.Postings[1].Amount)
+	})
+
+	tests := []struct {
+		name     string
+		postings []ledger.Posting
+		err      string
+	}{
+		{"should reject single posting", []ledger.Posting{ledger.Debit(wallet, "TRY", 100)}, "at least two postings"},
+		{"should reject unbalanced postings", []ledger.Posting{
+			ledger.Debit(ledger.CashInClearingAccount, "TRY", 100),
+			ledger.Credit(wallet, "TRY", 90),
+		}, "don't sum to zero in TRY"},
+		{"should balance every currency on its own", []ledger.Posting{
+			ledger.Debit(ledger.CashInClearingAccount, "TRY", 100),
+			ledger.Credit(wallet, "USD", 100),
+		}, "don't sum to zero in TRY"},
+		{"should reject zero amount", []ledger.Posting{
+			ledger.Debit(ledger.CashInClearingAccount, "TRY", 0),
+			ledger.Credit(wallet, "TRY", 0),
+		}, "posting 0"},
+		{"should reject posting without account", []ledger.Posting{
+			ledger.Debit("", "TRY", 100),
+			ledger.Credit(wallet, "TRY", 100),
+		}, "posting 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := ledger.NewJournalEntry("entry", nil, tt.postings...)
+			assert.Nil(t, e)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestWalletIDOf(t *testing.T) {
+	id, ok := ledger.WalletIDOf(ledger.WalletAccount("w"))
+	assert.True(t, ok)
+	assert.Equal(t, "w", id)
+
+	_, ok = ledger.WalletIDOf(ledger.FeesAccount)
+	assert.False(t, ok)
+}
+
+func TestLedger(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := NewMockRepository(gomock.NewController(t))
+	l := ledger.New(mockRepo)
+
+	t.Run("Post", func(t *testing.T) {
+		t.Run("should insert valid entries at once", func(t *testing.T) {
+			e, _ := ledger.NewJournalEntry("deposit", nil,
+				ledger.Debit(ledger.CashInClearingAccount, "TRY", 100),
+				ledger.Credit(ledger.WalletAccount("w"), "TRY", 100))
+			mockRepo.EXPECT().InsertEntries(ctx, e, e).Return(nil)
+
+			assert.Nil(t, l.Post(ctx, e, e))
+		})
+
+		t.Run("should not insert anything if an entry is unbalanced", func(t *testing.T) {
+			e := &ledger.JournalEntry{Postings: []ledger.Posting{
+				ledger.Debit(ledger.CashInClearingAccount, "TRY", 100),
+				ledger.Credit(ledger.WalletAccount("w"), "TRY", 1),
+			}}
+
+			assert.ErrorContains(t, l.Post(ctx, e), "don't sum to zero")
+		})
+	})
+
+	t.Run("TrialBalance", func(t *testing.T) {
+		t.Run("should total accounts per currency", func(t *testing.T) {
+			mockRepo.EXPECT().SumPostings(ctx).Return([]ledger.AccountBalance{
+				{Account: ledger.CashInClearingAccount, Currency: "TRY", Debit: 150},
+				{Account: ledger.CashOutClearingAccount, Currency: "TRY", Credit: 30},
+				{Account: ledger.WalletAccount("a"), Currency: "TRY", Debit: 30, Credit: 100},
+				{Account: ledger.WalletAccount("b"), Currency: "TRY", Credit: 50},
+			}, nil)
+
+			tb, err := l.TrialBalance(ctx)
+			assert.Nil(t, err)
+			assert.True(t, tb.Balanced)
+			assert.Equal(t, []ledger.AccountBalance{{Currency: "TRY", Debit: 180, Credit: 180}}, tb.Totals)
+			assert.Equal(t, int64(-70), tb.Accounts[2].Balance)
+		})
+
+		t.Run("should report books that don't balance", func(t *testing.T) {
+			mockRepo.EXPECT().SumPostings(ctx).Return([]ledger.AccountBalance{
+				{Account: ledger.CashInClearingAccount, Currency: "TRY", Debit: 100},
+				{Account: ledger.WalletAccount("a"), Currency: "TRY", Credit: 90},
+			}, nil)
+
+			tb, err := l.TrialBalance(ctx)
+			assert.Nil(t, err)
+			assert.False(t, tb.Balanced)
+		})
+
+		t.Run("should return empty trial balance of empty ledger", func(t *testing.T) {
+			mockRepo.EXPECT().SumPostings(ctx).Return(nil, nil)
+
+			tb, err := l.TrialBalance(ctx)
+			assert.Nil(t, err)
+			assert.True(t, tb.Balanced)
+			assert.Empty(t, tb.Accounts)
+			assert.NotNil(t, tb.Accounts)
+		})
+
+		t.Run("should return error if repository fails", func(t *testing.T) {
+			mockRepo.EXPECT().SumPostings(ctx).Return(nil, errors.New("down"))
+
+			tb, err := l.TrialBalance(ctx)
+			assert.Nil(t, tb)
+			assert.Equal(t, 500, err.Code)
+		})
+	})
+}
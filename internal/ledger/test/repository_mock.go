@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+
+// Package ledger is a generated GoMock package.
+package ledger
+
+import (
+	context "context"
+	reflect "reflect"
+
+	ledger "github.com/ybalcin/wallet-service/internal/ledger"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// InsertEntries mocks base method.
+func (m *MockRepository) InsertEntries(ctx context.Context, entries ...*ledger.JournalEntry) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range entries {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "InsertEntries", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertEntries indicates an expected call of InsertEntries.
+func (mr *MockRepositoryMockRecorder) InsertEntries(ctx interface{}, entries ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, entries...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertEntries", reflect.TypeOf((*MockRepository)(nil).InsertEntries), varargs...)
+}
+
+// SumPostings mocks base method.
+func (m *MockRepository) SumPostings(ctx context.Context) ([]ledger.AccountBalance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumPostings", ctx)
+	ret0, _ := ret[0].([]ledger.AccountBalance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumPostings indicates an expected call of SumPostings.
+func (mr *MockRepositoryMockRecorder) SumPostings(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumPostings", reflect.TypeOf((*MockRepository)(nil).SumPostings), ctx)
+}
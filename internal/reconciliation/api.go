@@ -0,0 +1,177 @@
+package reconciliation
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/response"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Api struct {
+	reconciler *Reconciler
+}
+
+func NewApi(reconciler *Reconciler) *Api {
+	return &Api{reconciler: reconciler}
+}
+
+func (a *Api) AddRoutesTo(r fiber.Router) {
+	admin := r.Group("admin")
+
+	admin.Post("/reconciliations", a.Reconcile)
+	admin.Get("/reconciliations", a.FindRuns)
+	admin.Get("/reconciliations/:id", a.FindRun)
+	admin.Get("/reconciliations/:id/items", a.FindItems)
+	admin.Post("/reconciliations/:id/items/:item/resolve", a.Resolve)
+}
+
+// Routes describes the routes added by AddRoutesTo for the api documentation
+func (a *Api) Routes() []openapi.Route {
+	tags := []string{"admin"}
+
+	return []openapi.Route{
+		{
+			Method:  fiber.MethodPost,
+			Path:    "/admin/reconciliations",
+			Summary: "Reconcile settlement file in request body against transactions",
+			Tags:    tags,
+			Query: []openapi.Parameter{
+				{Name: "format", Description: "csv or json, by content type if it is empty"},
+				{Name: "source", Description: "name of the settlement file"},
+				{Name: "tolerance", Description: "max difference of settlement and transaction dates, 24h by default"},
+			},
+			Response: Run{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+		},
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/admin/reconciliations",
+			Summary: "Find reconciliation runs, newest first",
+			Tags:    tags,
+			Query: []openapi.Parameter{
+				{Name: "limit", Description: "max number of runs, 20 by default", Schema: &openapi.Schema{Type: "integer"}},
+			},
+			Response: []Run{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/admin/reconciliations/:id",
+			Summary:  "Get reconciliation run",
+			Tags:     tags,
+			Response: Run{},
+			Errors:   []int{fiber.StatusNotFound, fiber.StatusInternalServerError},
+		},
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/admin/reconciliations/:id/items",
+			Summary: "Find items of reconciliation run",
+			Tags:    tags,
+			Query: []openapi.Parameter{
+				{Name: "status", Description: "items of the status, matched, mismatched or unmatched"},
+			},
+			Response: []Item{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError},
+		},
+		{
+			Method:   fiber.MethodPost,
+			Path:     "/admin/reconciliations/:id/items/:item/resolve",
+			Summary:  "Resolve item of reconciliation run that isn't matched",
+			Tags:     tags,
+			Request:  ResolveRequest{},
+			Response: Run{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError},
+		},
+	}
+}
+
+func (a *Api) Reconcile(c *fiber.Ctx) error {
+	format := c.Query("format")
+	if format == "" {
+		format = formatOfContentType(c.Get(fiber.HeaderContentType))
+	}
+	tolerance := DefaultTolerance
+	if v := c.Query("tolerance"); v != "" {
+		var err error
+		if tolerance, err = time.ParseDuration(v); err != nil {
+			return response.New(c).Error(errr.ThrowBadRequestError(fmt.Errorf(ErrInvalidQueryParam, "tolerance"))).JSON()
+		}
+	}
+
+	settlements, err := ParseSettlements(bytes.NewReader(c.Body()), format)
+	if err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	run, ex := a.reconciler.Reconcile(c.UserContext(), c.Query("source"), settlements, tolerance)
+	if ex != nil {
+		return response.New(c).Error(ex).JSON()
+	}
+
+	return response.New(c).Data(run).JSON()
+}
+
+func (a *Api) FindRuns(c *fiber.Ctx) error {
+	var limit int64
+	if v := c.Query("limit"); v != "" {
+		var err error
+		if limit, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return response.New(c).Error(errr.ThrowBadRequestError(fmt.Errorf(ErrInvalidQueryParam, "limit"))).JSON()
+		}
+	}
+
+	runs, err := a.reconciler.FindRuns(c.UserContext(), limit)
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(runs).JSON()
+}
+
+func (a *Api) FindRun(c *fiber.Ctx) error {
+	run, err := a.reconciler.FindRun(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(run).JSON()
+}
+
+func (a *Api) FindItems(c *fiber.Ctx) error {
+	items, err := a.reconciler.FindItems(c.UserContext(), c.Params("id"), ItemStatus(c.Query("status")))
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(items).JSON()
+}
+
+func (a *Api) Resolve(c *fiber.Ctx) error {
+	req := new(ResolveRequest)
+	if err := c.BodyParser(req); err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	run, err := a.reconciler.Resolve(c.UserContext(), c.Params("id"), c.Params("item"), req)
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(run).JSON()
+}
+
+func formatOfContentType(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return CSVFormat
+	case strings.HasPrefix(contentType, fiber.MIMEApplicationJSON):
+		return JSONFormat
+	}
+
+	return ""
+}
@@ -0,0 +1,15 @@
+package reconciliation
+
+const (
+	ErrUnknownFormat     = "unknown settlement file format %q, use csv or json"
+	ErrMissingColumn     = "settlement file has no %s column"
+	ErrInvalidSettlement = "settlement %d is invalid: %w"
+	ErrEmptySettlement   = "settlement file has no items"
+	ErrInvalidTolerance  = "provide valid tolerance of zero or more"
+	ErrRunNotFound       = "reconciliation run with id %s not found"
+	ErrItemNotFound      = "item %s of reconciliation run %s not found or doesn't need review"
+	ErrEmptyNote         = "provide note of the review"
+	ErrInvalidLimit      = "provide valid limit between 1 and %d"
+	ErrInvalidStatus     = "provide valid status, matched, mismatched or unmatched"
+	ErrInvalidQueryParam = "provide valid %s"
+)
@@ -0,0 +1,12 @@
+package reconciliation
+
+import (
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Indexes are indexes of reconciliation runs and their items
+var Indexes = []migration.Index{
+	{Collection: runsCollection, Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+	{Collection: itemsCollection, Keys: bson.D{{Key: "run_id", Value: 1}, {Key: "status", Value: 1}, {Key: "_id", Value: 1}}},
+}
@@ -0,0 +1,79 @@
+package reconciliation
+
+import (
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"time"
+)
+
+const (
+	// MatchedStatus is an item whose settlement and transaction agree
+	MatchedStatus ItemStatus = "matched"
+	// MismatchedStatus is an item whose settlement and transaction are found by reference but disagree
+	MismatchedStatus ItemStatus = "mismatched"
+	// UnmatchedStatus is a settlement without transaction or a transaction without settlement
+	UnmatchedStatus ItemStatus = "unmatched"
+)
+
+type (
+	ItemStatus string
+
+	// Settlement is an item of a settlement file of the payment provider, Amount is in minor units
+	Settlement struct {
+		Reference string                 `bson:"reference" json:"reference"`
+		Amount    int64                  `bson:"amount" json:"amount"`
+		Currency  string                 `bson:"currency" json:"currency"`
+		Type      wallet.TransactionType `bson:"type" json:"type"`
+		SettledAt time.Time              `bson:"settled_at" json:"settled_at"`
+	}
+
+	// Run is a reconciliation of a settlement file against transactions, its items are stored apart
+	Run struct {
+		ID     string `bson:"_id" json:"id"`
+		Source string `bson:"source" json:"source"`
+		// Tolerance is the max difference of settlement and creation dates of matched items
+		Tolerance string    `bson:"tolerance" json:"tolerance"`
+		Summary   Summary   `bson:"summary" json:"summary"`
+		CreatedAt time.Time `bson:"created_at" json:"created_at"`
+		CreatedBy string    `bson:"created_by" json:"created_by"`
+		// Open is true while an item needs review, it is computed from summary
+		Open bool `bson:"-" json:"open"`
+	}
+
+	// Summary counts items of a run by status, a run is open until every item that isn't matched is resolved
+	Summary struct {
+		Settlements int `bson:"settlements" json:"settlements"`
+		Matched     int `bson:"matched" json:"matched"`
+		Mismatched  int `bson:"mismatched" json:"mismatched"`
+		Unmatched   int `bson:"unmatched" json:"unmatched"`
+		Resolved    int `bson:"resolved" json:"resolved"`
+	}
+
+	// Item is a settlement and its transaction, one of them is nil for unmatched items
+	Item struct {
+		ID          string              `bson:"_id" json:"id"`
+		RunID       string              `bson:"run_id" json:"run_id"`
+		Status      ItemStatus          `bson:"status" json:"status"`
+		Settlement  *Settlement         `bson:"settlement,omitempty" json:"settlement,omitempty"`
+		Transaction *wallet.Transaction `bson:"transaction,omitempty" json:"transaction,omitempty"`
+		// Problems describe why the item isn't matched
+		Problems   []string    `bson:"problems,omitempty" json:"problems,omitempty"`
+		Resolution *Resolution `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	}
+
+	// Resolution is the review of an item that isn't matched
+	Resolution struct {
+		Note       string    `bson:"note" json:"note"`
+		ResolvedBy string    `bson:"resolved_by" json:"resolved_by"`
+		ResolvedAt time.Time `bson:"resolved_at" json:"resolved_at"`
+	}
+
+	// ResolveRequest resolves an item with a note of the review
+	ResolveRequest struct {
+		Note string `json:"note"`
+	}
+)
+
+// Open reports whether an item of run still needs review
+func (s Summary) Open() bool {
+	return s.Mismatched+s.Unmatched > s.Resolved
+}
@@ -0,0 +1,194 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CSVFormat  = "csv"
+	JSONFormat = "json"
+)
+
+// settlementRecord is a settlement as it is written in files, amount is in major units, e.g. 12.50
+type settlementRecord struct {
+	Reference string      `json:"reference"`
+	Amount    json.Number `json:"amount"`
+	Currency  string      `json:"currency"`
+	Type      string      `json:"type"`
+	SettledAt string      `json:"settled_at"`
+}
+
+// FormatOf returns format of settlement file by its extension, empty if it is unknown
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return CSVFormat
+	case ".json", ".jsonl":
+		return JSONFormat
+	}
+
+	return ""
+}
+
+// ParseSettlements parses settlement file of format. CSV files have a header with reference, amount and
+// settled_at columns and optional currency and type columns, JSON files are an array or lines of objects with
+// the same fields. Currency is DefaultCurrency and type is deposit if they are empty
+func ParseSettlements(r io.Reader, format string) ([]Settlement, error) {
+	var (
+		records []settlementRecord
+		err     error
+	)
+	switch format {
+	case CSVFormat:
+		records, err = readCSV(r)
+	case JSONFormat:
+		records, err = readJSON(r)
+	default:
+		return nil, fmt.Errorf(ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New(ErrEmptySettlement)
+	}
+
+	settlements := make([]Settlement, len(records))
+	for i, record := range records {
+		if settlements[i], err = record.settlement(); err != nil {
+			return nil, fmt.Errorf(ErrInvalidSettlement, i+1, err)
+		}
+	}
+
+	return settlements, nil
+}
+
+func readCSV(r io.Reader) ([]settlementRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"reference", "amount", "settled_at"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf(ErrMissingColumn, name)
+		}
+	}
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var records []settlementRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, settlementRecord{
+			Reference: value(row, "reference"),
+			Amount:    json.Number(value(row, "amount")),
+			Currency:  value(row, "currency"),
+			Type:      value(row, "type"),
+			SettledAt: value(row, "settled_at"),
+		})
+	}
+}
+
+func readJSON(r io.Reader) ([]settlementRecord, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var records []settlementRecord
+	for {
+		var v json.RawMessage
+		if err := decoder.Decode(&v); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return nil, err
+		}
+
+		// a file is either an array of settlements or a settlement per line
+		if strings.HasPrefix(strings.TrimSpace(string(v)), "[") {
+			var batch []settlementRecord
+			if err := json.Unmarshal(v, &batch); err != nil {
+				return nil, err
+			}
+			records = append(records, batch...)
+			continue
+		}
+
+		var record settlementRecord
+		if err := json.Unmarshal(v, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+func (r settlementRecord) settlement() (Settlement, error) {
+	s := Settlement{
+		Reference: strings.TrimSpace(r.Reference),
+		Currency:  strings.ToUpper(strings.TrimSpace(r.Currency)),
+		Type:      wallet.TransactionType(strings.ToLower(strings.TrimSpace(r.Type))),
+	}
+	if s.Reference == "" {
+		return s, errors.New("reference is empty")
+	}
+	if s.Currency == "" {
+		s.Currency = wallet.DefaultCurrency
+	}
+	switch s.Type {
+	case "":
+		s.Type = wallet.DepositTransactionType
+	case wallet.DepositTransactionType, wallet.WithdrawTransactionType:
+	default:
+		return s, fmt.Errorf("type %q is unknown", r.Type)
+	}
+
+	amount, err := strconv.ParseFloat(r.Amount.String(), 64)
+	if err != nil || amount <= 0 {
+		return s, fmt.Errorf("amount %q is invalid", r.Amount)
+	}
+	s.Amount = int64(math.Round(amount * 100))
+
+	if s.SettledAt, err = parseTime(strings.TrimSpace(r.SettledAt)); err != nil {
+		return s, fmt.Errorf("settled_at %q is invalid, use RFC 3339 or YYYY-MM-DD", r.SettledAt)
+	}
+
+	return s, nil
+}
+
+// parseTime parses RFC 3339 time or date, dates are midnight in UTC
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), nil
+	}
+
+	return time.Parse(time.DateOnly, v)
+}
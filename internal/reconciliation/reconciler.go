@@ -0,0 +1,251 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/utility"
+	"time"
+)
+
+const (
+	// DefaultTolerance is the default max difference of settlement and creation dates of matched items
+	DefaultTolerance = 24 * time.Hour
+
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Reconciler matches settlements of payment providers with transactions of wallets and keeps runs for review
+type Reconciler struct {
+	repository   Repository
+	transactions wallet.Repository
+}
+
+// NewReconciler creates new instance of Reconciler, runs are saved in units of work of transactions
+func NewReconciler(repository Repository, transactions wallet.Repository) *Reconciler {
+	return &Reconciler{repository: repository, transactions: transactions}
+}
+
+// Reconcile matches settlements with transactions and saves the run. The reference of a settlement is the reference
+// of its transaction, or its id for transactions without reference. A settlement is matched if its
+// transaction has the same amount, currency and type and was created within tolerance of settlement date, it is
+// mismatched if they disagree and unmatched if there is no transaction. Transactions of settled types created
+// within tolerance of the settlement dates that no settlement refers to are unmatched too, except transfers between
+// wallets
+func (r *Reconciler) Reconcile(ctx context.Context, source string, settlements []Settlement, tolerance time.Duration) (*Run, *errr.Error) {
+	if len(settlements) == 0 {
+		return nil, errr.ThrowBadRequestError(errors.New(ErrEmptySettlement))
+	}
+	if tolerance < 0 {
+		return nil, errr.ThrowBadRequestError(errors.New(ErrInvalidTolerance))
+	}
+
+	references := make([]string, len(settlements))
+	for i, s := range settlements {
+		references[i] = s.Reference
+	}
+	byReference, err := r.transactions.FindTransactionsByReferences(ctx, references)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	byID, err := r.transactions.FindTransactionsByIDs(ctx, references)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	transactions := make(map[string][]*wallet.Transaction, len(references))
+	for i := range byReference {
+		transactions[byReference[i].Reference] = append(transactions[byReference[i].Reference], &byReference[i])
+	}
+	for i := range byID {
+		if _, ok := transactions[byID[i].ID]; !ok {
+			transactions[byID[i].ID] = []*wallet.Transaction{&byID[i]}
+		}
+	}
+
+	run := &Run{
+		ID:        uuid.NewString(),
+		Source:    source,
+		Tolerance: tolerance.String(),
+		Summary:   Summary{Settlements: len(settlements)},
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: audit.MetadataFrom(ctx).Actor,
+	}
+	var items []Item
+	add := func(settlement *Settlement, t *wallet.Transaction, problems ...string) {
+		item := Item{ID: uuid.NewString(), RunID: run.ID, Settlement: settlement, Transaction: t, Problems: problems}
+		switch {
+		case settlement == nil || t == nil:
+			item.Status = UnmatchedStatus
+			run.Summary.Unmatched++
+		case len(problems) > 0:
+			item.Status = MismatchedStatus
+			run.Summary.Mismatched++
+		default:
+			item.Status = MatchedStatus
+			run.Summary.Matched++
+		}
+		items = append(items, item)
+	}
+
+	// settled are references of settlements, referenced are ids of their transactions
+	settled, referenced := map[string]bool{}, map[string]bool{}
+	types := map[wallet.TransactionType]bool{}
+	from, to := settlements[0].SettledAt, settlements[0].SettledAt
+	for i := range settlements {
+		s := &settlements[i]
+		types[s.Type] = true
+		if s.SettledAt.Before(from) {
+			from = s.SettledAt
+		}
+		if s.SettledAt.After(to) {
+			to = s.SettledAt
+		}
+
+		candidates := transactions[s.Reference]
+		switch {
+		case settled[s.Reference]:
+			add(s, nil, fmt.Sprintf("reference %s is settled more than once", s.Reference))
+		case len(candidates) == 0:
+			add(s, nil, fmt.Sprintf("no transaction with reference %s", s.Reference))
+		case len(candidates) > 1:
+			add(s, nil, fmt.Sprintf("reference %s matches %d transactions", s.Reference, len(candidates)))
+		default:
+			t := candidates[0]
+			add(s, t, compare(s, t, tolerance)...)
+			referenced[t.ID] = true
+		}
+		settled[s.Reference] = true
+	}
+
+	// transactions that should have been settled in the period of the file, transfers between wallets and fees
+	// aren't settled by payment providers
+	window, err := r.transactions.FindTransactionsBetween(ctx, from.Add(-tolerance), to.Add(tolerance+time.Nanosecond))
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	for i := range window {
+		if t := &window[i]; types[t.Type] && !referenced[t.ID] && t.CounterpartyWalletID == "" && t.FeeOf == "" {
+			add(nil, t, "transaction isn't settled")
+		}
+	}
+
+	err = r.transactions.WithinTransaction(ctx, func(ctx context.Context) error {
+		return r.repository.InsertRun(ctx, run, items)
+	})
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	run.Open = run.Summary.Open()
+
+	return run, nil
+}
+
+// FindRuns finds runs, newest first
+func (r *Reconciler) FindRuns(ctx context.Context, limit int64) ([]Run, *errr.Error) {
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit < 0 || limit > MaxLimit {
+		return nil, errr.ThrowBadRequestError(fmt.Errorf(ErrInvalidLimit, MaxLimit))
+	}
+
+	runs, err := r.repository.FindRuns(ctx, limit)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if runs == nil {
+		runs = []Run{}
+	}
+	for i := range runs {
+		runs[i].Open = runs[i].Summary.Open()
+	}
+
+	return runs, nil
+}
+
+// FindRun finds run by id
+func (r *Reconciler) FindRun(ctx context.Context, id string) (*Run, *errr.Error) {
+	run, err := r.repository.FindRunByID(ctx, id)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if run == nil {
+		return nil, errr.ThrowNotFoundError(fmt.Errorf(ErrRunNotFound, id))
+	}
+	run.Open = run.Summary.Open()
+
+	return run, nil
+}
+
+// FindItems finds items of run with status, empty status finds every item
+func (r *Reconciler) FindItems(ctx context.Context, runID string, status ItemStatus) ([]Item, *errr.Error) {
+	switch status {
+	case "", MatchedStatus, MismatchedStatus, UnmatchedStatus:
+	default:
+		return nil, errr.ThrowBadRequestError(errors.New(ErrInvalidStatus))
+	}
+	if _, err := r.FindRun(ctx, runID); err != nil {
+		return nil, err
+	}
+
+	items, err := r.repository.FindItems(ctx, runID, status)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if items == nil {
+		items = []Item{}
+	}
+
+	return items, nil
+}
+
+// Resolve records review of an item that isn't matched by the actor of context and returns its run, the run is
+// closed once every such item is resolved
+func (r *Reconciler) Resolve(ctx context.Context, runID, itemID string, req *ResolveRequest) (*Run, *errr.Error) {
+	if req == nil || utility.IsStrEmpty(req.Note) {
+		return nil, errr.ThrowBadRequestError(errors.New(ErrEmptyNote))
+	}
+	if _, err := r.FindRun(ctx, runID); err != nil {
+		return nil, err
+	}
+
+	resolution := Resolution{Note: req.Note, ResolvedBy: audit.MetadataFrom(ctx).Actor, ResolvedAt: time.Now().UTC()}
+
+	var resolved bool
+	err := r.transactions.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+		resolved, err = r.repository.ResolveItem(ctx, runID, itemID, resolution)
+		return err
+	})
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if !resolved {
+		return nil, errr.ThrowNotFoundError(fmt.Errorf(ErrItemNotFound, itemID, runID))
+	}
+
+	return r.FindRun(ctx, runID)
+}
+
+// compare returns differences of settlement and its transaction
+func compare(s *Settlement, t *wallet.Transaction, tolerance time.Duration) []string {
+	var problems []string
+	if amount := t.Money.MinorUnits(); s.Amount != amount {
+		problems = append(problems, fmt.Sprintf("settled amount %d differs from transaction amount %d", s.Amount, amount))
+	}
+	if s.Currency != wallet.DefaultCurrency {
+		problems = append(problems, fmt.Sprintf("settled currency %s differs from wallet currency %s", s.Currency, wallet.DefaultCurrency))
+	}
+	if s.Type != t.Type {
+		problems = append(problems, fmt.Sprintf("settled type %s differs from transaction type %s", s.Type, t.Type))
+	}
+	if d := s.SettledAt.Sub(t.CreatedAt); d > tolerance || -d > tolerance {
+		problems = append(problems, fmt.Sprintf("settled %s apart from transaction, beyond tolerance of %s", d.Abs(), tolerance))
+	}
+
+	return problems
+}
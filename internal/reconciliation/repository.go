@@ -0,0 +1,134 @@
+package reconciliation
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//go:generate mockgen -source=repository.go -destination=./test/repository_mock.go -package=reconciliation
+
+const (
+	runsCollection  = "reconciliation_runs"
+	itemsCollection = "reconciliation_items"
+)
+
+type (
+	// Repository is an interface that do db operations of reconciliation runs and their items
+	Repository interface {
+		// InsertRun inserts run and its items
+		InsertRun(ctx context.Context, run *Run, items []Item) error
+		// FindRuns finds runs in descending creation order
+		FindRuns(ctx context.Context, limit int64) ([]Run, error)
+		// FindRunByID finds run by id, nil is returned if it doesn't exist
+		FindRunByID(ctx context.Context, id string) (*Run, error)
+		// FindItems finds items of run with status, empty status matches every item
+		FindItems(ctx context.Context, runID string, status ItemStatus) ([]Item, error)
+		// ResolveItem sets resolution of item that isn't matched or resolved and counts it in summary of its
+		// run, false is returned if there is no such item
+		ResolveItem(ctx context.Context, runID, itemID string, resolution Resolution) (bool, error)
+	}
+
+	// MongoRepository is a concrete implementation of Repository interface
+	MongoRepository struct {
+		runs  *mongo.Collection
+		items *mongo.Collection
+	}
+)
+
+// NewMongoRepository creates instance of MongoRepository
+func NewMongoRepository(db *mongo.Database) *MongoRepository {
+	return &MongoRepository{runs: db.Collection(runsCollection), items: db.Collection(itemsCollection)}
+}
+
+// InsertRun inserts run and its items, items are inserted first so that a run is never listed without them
+func (r *MongoRepository) InsertRun(ctx context.Context, run *Run, items []Item) error {
+	if len(items) > 0 {
+		documents := make([]interface{}, len(items))
+		for i, item := range items {
+			documents[i] = item
+		}
+		if _, err := r.items.InsertMany(ctx, documents); err != nil {
+			return err
+		}
+	}
+
+	_, err := r.runs.InsertOne(ctx, run)
+	return err
+}
+
+// FindRuns finds runs in descending creation order
+func (r *MongoRepository) FindRuns(ctx context.Context, limit int64) ([]Run, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit)
+
+	cursor, err := r.runs.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Run
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// FindRunByID finds run by id, nil is returned if it doesn't exist
+func (r *MongoRepository) FindRunByID(ctx context.Context, id string) (*Run, error) {
+	res := r.runs.FindOne(ctx, bson.M{"_id": id})
+	if res.Err() != nil {
+		if res.Err() == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, res.Err()
+	}
+
+	run := new(Run)
+	if err := res.Decode(run); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// FindItems finds items of run with status, empty status matches every item
+func (r *MongoRepository) FindItems(ctx context.Context, runID string, status ItemStatus) ([]Item, error) {
+	query := bson.M{"run_id": runID}
+	if status != "" {
+		query["status"] = status
+	}
+
+	cursor, err := r.items.Find(ctx, query, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	if err = cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// ResolveItem sets resolution of item that isn't matched or resolved and counts it in summary of its run,
+// false is returned if there is no such item
+func (r *MongoRepository) ResolveItem(ctx context.Context, runID, itemID string, resolution Resolution) (bool, error) {
+	res, err := r.items.UpdateOne(ctx, bson.M{
+		"_id":        itemID,
+		"run_id":     runID,
+		"status":     bson.M{"$ne": MatchedStatus},
+		"resolution": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"resolution": resolution}})
+	if err != nil {
+		return false, err
+	}
+	if res.ModifiedCount == 0 {
+		return false, nil
+	}
+
+	_, err = r.runs.UpdateOne(ctx, bson.M{"_id": runID}, bson.M{"$inc": bson.M{"summary.resolved": 1}})
+	return true, err
+}
@@ -0,0 +1,68 @@
+package reconciliation
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/reconciliation"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSettlements(t *testing.T) {
+	expected := []reconciliation.Settlement{
+		{Reference: "t1", Amount: 1250, Currency: "TRY", Type: wallet.DepositTransactionType, SettledAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Reference: "t2", Amount: 300, Currency: "USD", Type: wallet.WithdrawTransactionType, SettledAt: time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)},
+	}
+
+	t.Run("should parse csv by column names", func(t *testing.T) {
+		in := "Settled_At,Reference,Amount,Type,Currency\n" +
+			"2024-03-01,t1,12.50,,\n" +
+			"2024-03-01T13:30:00+03:00,t2,3,WITHDRAW,usd\n"
+
+		settlements, err := reconciliation.ParseSettlements(strings.NewReader(in), reconciliation.CSVFormat)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, settlements)
+	})
+
+	t.Run("should parse json array and lines", func(t *testing.T) {
+		array := `[{"reference":"t1","amount":12.5,"settled_at":"2024-03-01"},
+			{"reference":"t2","amount":"3.00","currency":"USD","type":"withdraw","settled_at":"2024-03-01T10:30:00Z"}]`
+		lines := `{"reference":"t1","amount":12.5,"settled_at":"2024-03-01"}
+{"reference":"t2","amount":3,"currency":"USD","type":"withdraw","settled_at":"2024-03-01T10:30:00Z"}`
+
+		for _, in := range []string{array, lines} {
+			settlements, err := reconciliation.ParseSettlements(strings.NewReader(in), reconciliation.JSONFormat)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, settlements)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		format string
+		in     string
+		err    string
+	}{
+		{"should reject unknown format", "xml", "", "unknown settlement file format"},
+		{"should reject csv without required column", reconciliation.CSVFormat, "reference,amount\nt1,1\n", "no settled_at column"},
+		{"should reject empty file", reconciliation.CSVFormat, "reference,amount,settled_at\n", "has no items"},
+		{"should reject invalid amount", reconciliation.CSVFormat, "reference,amount,settled_at\nt1,-1,2024-03-01\n", "settlement 1 is invalid: amount"},
+		{"should reject invalid date", reconciliation.JSONFormat, `[{"reference":"t1","amount":1,"settled_at":"yesterday"}]`, "settled_at"},
+		{"should reject unknown type", reconciliation.JSONFormat, `[{"reference":"t1","amount":1,"type":"refund","settled_at":"2024-03-01"}]`, "type"},
+		{"should reject empty reference", reconciliation.JSONFormat, `[{"amount":1,"settled_at":"2024-03-01"}]`, "reference is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settlements, err := reconciliation.ParseSettlements(strings.NewReader(tt.in), tt.format)
+			assert.Nil(t, settlements)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, reconciliation.CSVFormat, reconciliation.FormatOf("settlements/2024-03-01.CSV"))
+	assert.Equal(t, reconciliation.JSONFormat, reconciliation.FormatOf("2024-03-01.json"))
+	assert.Equal(t, "", reconciliation.FormatOf("2024-03-01.txt"))
+}
@@ -0,0 +1,224 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/reconciliation"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	walletmock "github.com/ybalcin/wallet-service/internal/wallet/test"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestReconciler(t *testing.T) {
+	ctx := audit.WithMetadata(context.Background(), audit.Metadata{Actor: "operator"})
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	setup := func(t *testing.T) (*MockRepository, *walletmock.MockRepository, *reconciliation.Reconciler) {
+		ctrl := gomock.NewController(t)
+		repo, transactions := NewMockRepository(ctrl), walletmock.NewMockRepository(ctrl)
+		transactions.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }).
+			AnyTimes()
+		return repo, transactions, reconciliation.NewReconciler(repo, transactions)
+	}
+	transaction := func(id string, typ wallet.TransactionType, amount float32, createdAt time.Time) wallet.Transaction {
+		return wallet.Transaction{ID: id, WalletID: "w", Type: typ, Money: wallet.Money{Amount: amount}, CreatedAt: createdAt}
+	}
+	settlement := func(reference string, amount int64, settledAt time.Time) reconciliation.Settlement {
+		return reconciliation.Settlement{Reference: reference, Amount: amount, Currency: wallet.DefaultCurrency,
+			Type: wallet.DepositTransactionType, SettledAt: settledAt}
+	}
+
+	t.Run("Reconcile", func(t *testing.T) {
+		repo, transactions, reconciler := setup(t)
+		matched := transaction("matched", wallet.DepositTransactionType, 12.5, day.Add(time.Hour))
+		late := transaction("late", wallet.DepositTransactionType, 10, day.Add(-48*time.Hour))
+		withdrawal := transaction("withdrawal", wallet.WithdrawTransactionType, 5, day.Add(time.Hour))
+		unsettled := transaction("unsettled", wallet.DepositTransactionType, 7, day.Add(2*time.Hour))
+		byReference := transaction("by-reference", wallet.DepositTransactionType, 5, day.Add(3*time.Hour))
+		byReference.Reference = "psp-1"
+		settlements := []reconciliation.Settlement{
+			settlement("matched", 1250, day),
+			settlement("late", 1000, day),
+			settlement("withdrawal", 600, day),
+			settlement("missing", 100, day),
+			settlement("matched", 1250, day),
+			settlement("psp-1", 500, day),
+		}
+
+		references := []string{"matched", "late", "withdrawal", "missing", "matched", "psp-1"}
+		transactions.EXPECT().FindTransactionsByReferences(ctx, references).Return([]wallet.Transaction{byReference}, nil)
+		transactions.EXPECT().FindTransactionsByIDs(ctx, references).Return([]wallet.Transaction{matched, late, withdrawal}, nil)
+		transactions.EXPECT().FindTransactionsBetween(ctx, day.Add(-24*time.Hour), day.Add(24*time.Hour+time.Nanosecond)).
+			Return([]wallet.Transaction{matched, withdrawal, unsettled, byReference}, nil)
+		var items []reconciliation.Item
+		repo.EXPECT().InsertRun(ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, run *reconciliation.Run, i []reconciliation.Item) error {
+				items = i
+				return nil
+			})
+
+		run, err := reconciler.Reconcile(ctx, "settlements.csv", settlements, 24*time.Hour)
+		assert.Nil(t, err)
+		assert.Equal(t, reconciliation.Summary{Settlements: 6, Matched: 2, Mismatched: 2, Unmatched: 3}, run.Summary)
+		assert.True(t, run.Open)
+		assert.Equal(t, "operator", run.CreatedBy)
+		assert.Equal(t, "24h0m0s", run.Tolerance)
+
+		assert.Len(t, items, 7)
+		assert.Equal(t, reconciliation.MatchedStatus, items[0].Status)
+		assert.Equal(t, reconciliation.MismatchedStatus, items[1].Status)
+		assert.Contains(t, items[1].Problems[0], "beyond tolerance")
+		assert.Equal(t, reconciliation.MismatchedStatus, items[2].Status)
+		assert.Len(t, items[2].Problems, 2, "amount and type differ")
+		assert.Equal(t, reconciliation.UnmatchedStatus, items[3].Status)
+		assert.Nil(t, items[3].Transaction)
+		assert.Contains(t, items[4].Problems[0], "more than once")
+		assert.Equal(t, reconciliation.MatchedStatus, items[5].Status)
+		assert.Equal(t, "by-reference", items[5].Transaction.ID)
+		assert.Equal(t, reconciliation.UnmatchedStatus, items[6].Status)
+		assert.Nil(t, items[6].Settlement)
+		assert.Equal(t, "unsettled", items[6].Transaction.ID)
+		for _, item := range items {
+			assert.Equal(t, run.ID, item.RunID)
+		}
+	})
+
+	t.Run("Reconcile should close run if every item matches", func(t *testing.T) {
+		repo, transactions, reconciler := setup(t)
+		matched := transaction("matched", wallet.DepositTransactionType, 12.5, day)
+		transactions.EXPECT().FindTransactionsByReferences(ctx, gomock.Any()).Return(nil, nil)
+		transactions.EXPECT().FindTransactionsByIDs(ctx, gomock.Any()).Return([]wallet.Transaction{matched}, nil)
+		transactions.EXPECT().FindTransactionsBetween(ctx, gomock.Any(), gomock.Any()).Return([]wallet.Transaction{matched}, nil)
+		repo.EXPECT().InsertRun(ctx, gomock.Any(), gomock.Any()).Return(nil)
+
+		run, err := reconciler.Reconcile(ctx, "settlements.csv", []reconciliation.Settlement{settlement("matched", 1250, day)}, 0)
+		assert.Nil(t, err)
+		assert.False(t, run.Open)
+	})
+
+	t.Run("Reconcile should not report transfers between wallets as unsettled", func(t *testing.T) {
+		repo, transactions, reconciler := setup(t)
+		matched := transaction("matched", wallet.DepositTransactionType, 12.5, day)
+		transferred := transaction("transferred", wallet.DepositTransactionType, 3, day)
+		transferred.CounterpartyWalletID = "other"
+		transactions.EXPECT().FindTransactionsByReferences(ctx, gomock.Any()).Return(nil, nil)
+		transactions.EXPECT().FindTransactionsByIDs(ctx, gomock.Any()).Return([]wallet.Transaction{matched}, nil)
+		transactions.EXPECT().FindTransactionsBetween(ctx, gomock.Any(), gomock.Any()).
+			Return([]wallet.Transaction{matched, transferred}, nil)
+		repo.EXPECT().InsertRun(ctx, gomock.Any(), gomock.Any()).Return(nil)
+
+		run, err := reconciler.Reconcile(ctx, "settlements.csv", []reconciliation.Settlement{settlement("matched", 1250, day)}, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, reconciliation.Summary{Settlements: 1, Matched: 1}, run.Summary)
+		assert.False(t, run.Open)
+	})
+
+	t.Run("Reconcile should not report fees as unsettled", func(t *testing.T) {
+		repo, transactions, reconciler := setup(t)
+		withdrawal := transaction("withdrawal", wallet.WithdrawTransactionType, 10, day)
+		fee := transaction("fee", wallet.WithdrawTransactionType, 0.5, day)
+		fee.FeeOf = withdrawal.ID
+		withdrawn := settlement("withdrawal", 1000, day)
+		withdrawn.Type = wallet.WithdrawTransactionType
+		transactions.EXPECT().FindTransactionsByReferences(ctx, gomock.Any()).Return(nil, nil)
+		transactions.EXPECT().FindTransactionsByIDs(ctx, gomock.Any()).Return([]wallet.Transaction{withdrawal}, nil)
+		transactions.EXPECT().FindTransactionsBetween(ctx, gomock.Any(), gomock.Any()).
+			Return([]wallet.Transaction{withdrawal, fee}, nil)
+		repo.EXPECT().InsertRun(ctx, gomock.Any(), gomock.Any()).Return(nil)
+
+		run, err := reconciler.Reconcile(ctx, "settlements.csv", []reconciliation.Settlement{withdrawn}, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, reconciliation.Summary{Settlements: 1, Matched: 1}, run.Summary)
+	})
+
+	t.Run("Reconcile should return errors", func(t *testing.T) {
+		repo, transactions, reconciler := setup(t)
+
+		_, err := reconciler.Reconcile(ctx, "empty.csv", nil, time.Hour)
+		assert.Equal(t, 400, err.Code)
+		_, err = reconciler.Reconcile(ctx, "file.csv", []reconciliation.Settlement{settlement("t", 1, day)}, -time.Hour)
+		assert.Equal(t, 400, err.Code)
+
+		transactions.EXPECT().FindTransactionsByReferences(ctx, gomock.Any()).Return(nil, nil)
+		transactions.EXPECT().FindTransactionsByIDs(ctx, gomock.Any()).Return(nil, nil)
+		transactions.EXPECT().FindTransactionsBetween(ctx, gomock.Any(), gomock.Any()).Return(nil, nil)
+		repo.EXPECT().InsertRun(ctx, gomock.Any(), gomock.Any()).Return(errors.New("db is down"))
+		_, err = reconciler.Reconcile(ctx, "file.csv", []reconciliation.Settlement{settlement("t", 1, day)}, time.Hour)
+		assert.Equal(t, 500, err.Code)
+	})
+
+	t.Run("Reconcile should not match reference of transactions of different wallets", func(t *testing.T) {
+		repo, transactions, reconciler := setup(t)
+		first := transaction("first", wallet.DepositTransactionType, 5, day)
+		first.Reference = "psp-1"
+		second := first
+		second.ID, second.WalletID = "second", "other"
+		transactions.EXPECT().FindTransactionsByReferences(ctx, gomock.Any()).Return([]wallet.Transaction{first, second}, nil)
+		transactions.EXPECT().FindTransactionsByIDs(ctx, gomock.Any()).Return(nil, nil)
+		transactions.EXPECT().FindTransactionsBetween(ctx, gomock.Any(), gomock.Any()).Return(nil, nil)
+		var items []reconciliation.Item
+		repo.EXPECT().InsertRun(ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ *reconciliation.Run, i []reconciliation.Item) error {
+				items = i
+				return nil
+			})
+
+		_, err := reconciler.Reconcile(ctx, "settlements.csv", []reconciliation.Settlement{settlement("psp-1", 500, day)}, time.Hour)
+		assert.Nil(t, err)
+		assert.Equal(t, reconciliation.UnmatchedStatus, items[0].Status)
+		assert.Contains(t, items[0].Problems[0], "matches 2 transactions")
+	})
+
+	t.Run("FindItems", func(t *testing.T) {
+		repo, _, reconciler := setup(t)
+
+		_, err := reconciler.FindItems(ctx, "run", "unknown")
+		assert.Equal(t, 400, err.Code)
+
+		repo.EXPECT().FindRunByID(ctx, "missing").Return(nil, nil)
+		_, err = reconciler.FindItems(ctx, "missing", "")
+		assert.Equal(t, 404, err.Code)
+
+		repo.EXPECT().FindRunByID(ctx, "run").Return(&reconciliation.Run{ID: "run"}, nil)
+		repo.EXPECT().FindItems(ctx, "run", reconciliation.UnmatchedStatus).Return(nil, nil)
+		items, err := reconciler.FindItems(ctx, "run", reconciliation.UnmatchedStatus)
+		assert.Nil(t, err)
+		assert.Equal(t, []reconciliation.Item{}, items)
+	})
+
+	t.Run("Resolve", func(t *testing.T) {
+		repo, _, reconciler := setup(t)
+		open := &reconciliation.Run{ID: "run", Summary: reconciliation.Summary{Settlements: 1, Unmatched: 1}}
+		closed := &reconciliation.Run{ID: "run", Summary: reconciliation.Summary{Settlements: 1, Unmatched: 1, Resolved: 1}}
+		gomock.InOrder(
+			repo.EXPECT().FindRunByID(ctx, "run").Return(open, nil),
+			repo.EXPECT().ResolveItem(ctx, "run", "item", gomock.Any()).
+				DoAndReturn(func(_ context.Context, _, _ string, r reconciliation.Resolution) (bool, error) {
+					assert.Equal(t, "operator", r.ResolvedBy)
+					assert.Equal(t, "refunded by provider", r.Note)
+					return true, nil
+				}),
+			repo.EXPECT().FindRunByID(ctx, "run").Return(closed, nil),
+		)
+
+		run, err := reconciler.Resolve(ctx, "run", "item", &reconciliation.ResolveRequest{Note: "refunded by provider"})
+		assert.Nil(t, err)
+		assert.False(t, run.Open)
+	})
+
+	t.Run("Resolve should return errors", func(t *testing.T) {
+		repo, _, reconciler := setup(t)
+
+		_, err := reconciler.Resolve(ctx, "run", "item", &reconciliation.ResolveRequest{Note: " "})
+		assert.Equal(t, 400, err.Code)
+
+		repo.EXPECT().FindRunByID(ctx, "run").Return(&reconciliation.Run{ID: "run"}, nil)
+		repo.EXPECT().ResolveItem(ctx, "run", "matched", gomock.Any()).Return(false, nil)
+		_, err = reconciler.Resolve(ctx, "run", "matched", &reconciliation.ResolveRequest{Note: "ok"})
+		assert.Equal(t, 404, err.Code)
+	})
+}
@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+
+// Package reconciliation is a generated GoMock package.
+package reconciliation
+
+import (
+	context "context"
+	reflect "reflect"
+
+	reconciliation "github.com/ybalcin/wallet-service/internal/reconciliation"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindItems mocks base method.
+func (m *MockRepository) FindItems(ctx context.Context, runID string, status reconciliation.ItemStatus) ([]reconciliation.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindItems", ctx, runID, status)
+	ret0, _ := ret[0].([]reconciliation.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindItems indicates an expected call of FindItems.
+func (mr *MockRepositoryMockRecorder) FindItems(ctx, runID, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindItems", reflect.TypeOf((*MockRepository)(nil).FindItems), ctx, runID, status)
+}
+
+// FindRunByID mocks base method.
+func (m *MockRepository) FindRunByID(ctx context.Context, id string) (*reconciliation.Run, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRunByID", ctx, id)
+	ret0, _ := ret[0].(*reconciliation.Run)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRunByID indicates an expected call of FindRunByID.
+func (mr *MockRepositoryMockRecorder) FindRunByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRunByID", reflect.TypeOf((*MockRepository)(nil).FindRunByID), ctx, id)
+}
+
+// FindRuns mocks base method.
+func (m *MockRepository) FindRuns(ctx context.Context, limit int64) ([]reconciliation.Run, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRuns", ctx, limit)
+	ret0, _ := ret[0].([]reconciliation.Run)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRuns indicates an expected call of FindRuns.
+func (mr *MockRepositoryMockRecorder) FindRuns(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRuns", reflect.TypeOf((*MockRepository)(nil).FindRuns), ctx, limit)
+}
+
+// InsertRun mocks base method.
+func (m *MockRepository) InsertRun(ctx context.Context, run *reconciliation.Run, items []reconciliation.Item) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertRun", ctx, run, items)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertRun indicates an expected call of InsertRun.
+func (mr *MockRepositoryMockRecorder) InsertRun(ctx, run, items interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertRun", reflect.TypeOf((*MockRepository)(nil).InsertRun), ctx, run, items)
+}
+
+// ResolveItem mocks base method.
+func (m *MockRepository) ResolveItem(ctx context.Context, runID, itemID string, resolution reconciliation.Resolution) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveItem", ctx, runID, itemID, resolution)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveItem indicates an expected call of ResolveItem.
+func (mr *MockRepositoryMockRecorder) ResolveItem(ctx, runID, itemID, resolution interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveItem", reflect.TypeOf((*MockRepository)(nil).ResolveItem), ctx, runID, itemID, resolution)
+}
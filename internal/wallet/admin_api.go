@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/response"
+)
+
+// AdminApi is the back-office api of wallets
+type AdminApi struct {
+	search *Search
+	feed   *Feed
+}
+
+func NewAdminApi(search *Search, feed *Feed) *AdminApi {
+	return &AdminApi{search: search, feed: feed}
+}
+
+func (a *AdminApi) AddRoutesTo(r fiber.Router) {
+	admin := r.Group("admin")
+
+	admin.Get("/wallets", a.FindWallets)
+	admin.Get("/transactions/feed", a.GetTransactionFeed)
+}
+
+// Routes describes the routes added by AddRoutesTo for the api documentation
+func (a *AdminApi) Routes() []openapi.Route {
+	return []openapi.Route{
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/admin/wallets",
+			Summary: "List wallets by filters with total count, pages continue from next_cursor of the previous one",
+			Tags:    []string{"admin"},
+			Query: []openapi.Parameter{
+				{Name: "username", Description: "prefix of username"},
+				{Name: "status", Description: "active or frozen"},
+				{Name: "currency", Description: "currency of wallets"},
+				{Name: "created_from", Description: "inclusive start of creation time as date or RFC3339 time"},
+				{Name: "created_to", Description: "exclusive end of creation time as date or RFC3339 time"},
+				{Name: "min_balance", Description: "inclusive min balance as amount, e.g. 10.50"},
+				{Name: "max_balance", Description: "inclusive max balance as amount, e.g. 10.50"},
+				{Name: "sort", Description: "created_at, username or balance with - prefix for descending order, created_at by default"},
+				{Name: "cursor", Description: "next_cursor of the previous page"},
+				{Name: "limit", Description: "max number of wallets, 20 by default and at most 100"},
+			},
+			Response: WalletPage{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+		},
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/admin/transactions/feed",
+			Summary: "Read transactions of every wallet in position order, pages continue after next of the previous one",
+			Tags:    []string{"admin"},
+			Query: []openapi.Parameter{
+				{Name: "after", Description: "position to read after, 0 by default which starts from the first transaction"},
+				{Name: "limit", Description: "max number of transactions, 100 by default and at most 1000"},
+				{Name: "wait", Description: "how long to wait for transactions if there are none yet, e.g. 10s, shortened to the max wait"},
+			},
+			Response: FeedPage{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+		},
+	}
+}
+
+func (a *AdminApi) FindWallets(c *fiber.Ctx) error {
+	req := new(FindWalletsRequest)
+	if err := c.QueryParser(req); err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	page, err := a.search.FindWallets(c.UserContext(), req)
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(page).JSON()
+}
+
+func (a *AdminApi) GetTransactionFeed(c *fiber.Ctx) error {
+	req := new(FeedRequest)
+	if err := c.QueryParser(req); err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	page, err := a.feed.Read(c.UserContext(), req)
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(page).JSON()
+}
@@ -1,9 +1,12 @@
 package wallet
 
 import (
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
 	"github.com/ybalcin/wallet-service/pkg/response"
+	"io"
 )
 
 type Api struct {
@@ -20,7 +23,100 @@ func (a *Api) AddRoutesTo(r fiber.Router) {
 	wallets.Post("/", a.CreateWallet)
 	wallets.Put("/:id/deposit", a.DepositMoney)
 	wallets.Put("/:id/withdraw", a.WithdrawMoney)
+	wallets.Put("/:id/transfer", a.TransferMoney)
 	wallets.Get("/:id", a.GetWallet)
+	wallets.Get("/:id/transactions", a.GetTransactions)
+	wallets.Get("/:id/statements", a.GetStatement)
+
+	transactions := r.Group("transactions")
+	transactions.Get("/", a.FindTransactions)
+}
+
+// Routes describes the routes added by AddRoutesTo for the api documentation
+func (a *Api) Routes() []openapi.Route {
+	tags := []string{"wallets"}
+
+	return []openapi.Route{
+		{
+			Method:   fiber.MethodPost,
+			Path:     "/wallets/",
+			Summary:  "Create wallet",
+			Tags:     tags,
+			Request:  CreateWalletRequest{},
+			Response: ID{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+		},
+		{
+			Method:   fiber.MethodPut,
+			Path:     "/wallets/:id/deposit",
+			Summary:  "Deposit money to wallet",
+			Tags:     tags,
+			Request:  MoneyTransactionRequest{},
+			Response: Wallet{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict, fiber.StatusInternalServerError},
+		},
+		{
+			Method:   fiber.MethodPut,
+			Path:     "/wallets/:id/withdraw",
+			Summary:  "Withdraw money from wallet",
+			Tags:     tags,
+			Request:  MoneyTransactionRequest{},
+			Response: Wallet{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict, fiber.StatusInternalServerError},
+		},
+		{
+			Method:   fiber.MethodPut,
+			Path:     "/wallets/:id/transfer",
+			Summary:  "Transfer money from wallet to another wallet",
+			Tags:     tags,
+			Request:  TransferMoneyRequest{},
+			Response: TransferMoneyResponse{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusConflict, fiber.StatusInternalServerError},
+		},
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/wallets/:id",
+			Summary: "Get wallet with current balance, or with balance as of a time in the past",
+			Tags:    tags,
+			Query: []openapi.Parameter{
+				{Name: "as_of", Description: "date or RFC3339 time, transactions created before it are included"},
+			},
+			Response: Wallet{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError},
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/wallets/:id/transactions",
+			Summary:  "Get transaction history of wallet",
+			Tags:     tags,
+			Response: []Transaction{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError},
+		},
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/wallets/:id/statements",
+			Summary: "Get statement of wallet with opening balance, transactions with running balances and closing balance",
+			Tags:    tags,
+			Query: []openapi.Parameter{
+				{Name: "from", Description: "inclusive start of period as date or RFC3339 time, start of the current month by default"},
+				{Name: "to", Description: "exclusive end of period as date or RFC3339 time, now by default"},
+				{Name: "format", Description: "csv, json or text, csv by default"},
+			},
+			Response: StatementDocument{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusInternalServerError},
+		},
+		{
+			Method:  fiber.MethodGet,
+			Path:    "/transactions/",
+			Summary: "Find transactions of every wallet by reference",
+			Tags:    tags,
+			Query: []openapi.Parameter{
+				{Name: "reference", Description: "reference given on deposit or withdrawal, required"},
+			},
+			Response: []Transaction{},
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+		},
+	}
 }
 
 func (a *Api) CreateWallet(c *fiber.Ctx) error {
@@ -67,8 +163,27 @@ func (a *Api) WithdrawMoney(c *fiber.Ctx) error {
 	return response.New(c).Data(wallet).JSON()
 }
 
+func (a *Api) TransferMoney(c *fiber.Ctx) error {
+	id := c.Params("id")
+	transfer := new(TransferMoneyRequest)
+	if err := c.BodyParser(transfer); err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	res, err := a.service.TransferMoney(c.UserContext(), id, transfer)
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(res).JSON()
+}
+
 func (a *Api) GetWallet(c *fiber.Ctx) error {
 	id := c.Params("id")
+	if c.Query("as_of") != "" {
+		return a.getWalletAsOf(c, id)
+	}
+
 	wallet, err := a.service.GetWallet(c.UserContext(), id)
 	if err != nil {
 		return response.New(c).Error(err).JSON()
@@ -76,3 +191,87 @@ func (a *Api) GetWallet(c *fiber.Ctx) error {
 
 	return response.New(c).Data(wallet).JSON()
 }
+
+func (a *Api) getWalletAsOf(c *fiber.Ctx, id string) error {
+	asOf, err := ParseDateOrTime(c.Query("as_of"))
+	if err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(fmt.Errorf(ErrInvalidTime, "as_of"))).JSON()
+	}
+
+	wallet, ex := a.service.GetWalletAsOf(c.UserContext(), id, asOf)
+	if ex != nil {
+		return response.New(c).Error(ex).JSON()
+	}
+
+	return response.New(c).Data(wallet).JSON()
+}
+
+func (a *Api) GetTransactions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	transactions, err := a.service.GetTransactions(c.UserContext(), id)
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(transactions).JSON()
+}
+
+// GetStatement streams statement of wallet, errors found before the statement starts are returned as usual and
+// later ones abort the response
+func (a *Api) GetStatement(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := &StatementRequest{From: c.Query("from"), To: c.Query("to")}
+	format := c.Query("format", CSVStatementFormat)
+
+	r, pw := io.Pipe()
+	w, err := NewStatementWriter(pw, format)
+	if err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	stream := &statementStream{StatementWriter: w, started: make(chan struct{})}
+	failed := make(chan *errr.Error, 1)
+	ctx := c.UserContext()
+	go func() {
+		if ex := a.service.WriteStatement(ctx, id, req, stream); ex != nil {
+			failed <- ex
+			_ = pw.CloseWithError(ex)
+			return
+		}
+		_ = pw.Close()
+	}()
+
+	select {
+	case ex := <-failed:
+		_ = r.Close()
+		return response.New(c).Error(ex).JSON()
+	case <-stream.started:
+	}
+
+	c.Set(fiber.HeaderContentType, StatementContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename=%q`, StatementFileName(id, format)))
+	// the pipe is closed when the response is written or the client is gone, which stops the statement
+	c.Context().SetBodyStream(r, -1)
+
+	return nil
+}
+
+// statementStream signals the response can start once the statement does
+type statementStream struct {
+	StatementWriter
+	started chan struct{}
+}
+
+func (s *statementStream) WriteHeader(statement *Statement) error {
+	close(s.started)
+	return s.StatementWriter.WriteHeader(statement)
+}
+
+func (a *Api) FindTransactions(c *fiber.Ctx) error {
+	transactions, err := a.service.FindTransactionsByReference(c.UserContext(), c.Query("reference"))
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(transactions).JSON()
+}
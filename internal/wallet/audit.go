@@ -0,0 +1,171 @@
+package wallet
+
+import (
+	"context"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"log/slog"
+	"time"
+)
+
+const (
+	CreateWalletAuditAction  = "wallet.create"
+	DepositMoneyAuditAction  = "wallet.deposit"
+	WithdrawMoneyAuditAction = "wallet.withdraw"
+	TransferMoneyAuditAction = "wallet.transfer"
+	FreezeWalletAuditAction  = "wallet.freeze"
+)
+
+// AuditingService is a Service decorator that records every mutating use case, succeeded or failed, to the
+// audit log with state of target wallets before and after the use case. State before is derived from the result by
+// undoing its changes, so failed use cases have no state
+type AuditingService struct {
+	service  Service
+	recorder audit.Recorder
+	log      *slog.Logger
+}
+
+// NewAuditingService creates new instance of AuditingService
+func NewAuditingService(service Service, recorder audit.Recorder, log *slog.Logger) *AuditingService {
+	return &AuditingService{service: service, recorder: recorder, log: log}
+}
+
+// record records r, use case already took effect when it is recorded so failure of recording is logged
+// instead of failing the use case
+func (s *AuditingService) record(ctx context.Context, r audit.Record, err *errr.Error) {
+	if err != nil {
+		r.Err = err
+	}
+	if e := s.recorder.Record(ctx, r); e != nil {
+		s.log.ErrorContext(ctx, "audit entry could not be recorded", "action", r.Action, "error", e)
+	}
+}
+
+// stateBefore returns state of wallet before the use case that returned it by undoing its events, nil is returned
+// if wallet is nil
+func stateBefore(wallet *Wallet) *Wallet {
+	if wallet == nil {
+		return nil
+	}
+
+	before := &Wallet{
+		ID:            wallet.ID,
+		Username:      wallet.Username,
+		Balance:       wallet.Balance,
+		Status:        wallet.Status,
+		CreatedAt:     wallet.CreatedAt,
+		Version:       wallet.Version - int64(len(wallet.Changes)),
+		StreamVersion: wallet.StreamVersion - int64(len(wallet.Events)),
+	}
+	for _, t := range wallet.Changes {
+		switch t.Type {
+		case DepositTransactionType:
+			t.Money.Sub(&before.Balance)
+		case WithdrawTransactionType:
+			t.Money.Add(&before.Balance)
+		}
+	}
+	for _, event := range wallet.Events {
+		if _, ok := event.(WalletFrozen); ok {
+			before.Status = ActiveWalletStatus
+		}
+	}
+
+	return before
+}
+
+// CreateWallet creates wallet
+func (s *AuditingService) CreateWallet(ctx context.Context, req *CreateWalletRequest) (*ID, *errr.Error) {
+	id, err := s.service.CreateWallet(ctx, req)
+
+	r := audit.Record{Action: CreateWalletAuditAction}
+	if err == nil {
+		r.WalletIDs = []string{id.Id}
+		r.After = &Wallet{ID: id.Id, Username: req.Username}
+	}
+	s.record(ctx, r, err)
+
+	return id, err
+}
+
+// DepositMoney provides to deposit(add) money to wallet
+func (s *AuditingService) DepositMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	wallet, err := s.service.DepositMoney(ctx, walletID, req)
+	s.record(ctx, audit.Record{
+		Action:    DepositMoneyAuditAction,
+		WalletIDs: []string{walletID},
+		Before:    stateBefore(wallet),
+		After:     wallet,
+	}, err)
+
+	return wallet, err
+}
+
+// WithdrawMoney provides to withdraw(sub) money from wallet
+func (s *AuditingService) WithdrawMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	wallet, err := s.service.WithdrawMoney(ctx, walletID, req)
+	s.record(ctx, audit.Record{
+		Action:    WithdrawMoneyAuditAction,
+		WalletIDs: []string{walletID},
+		Before:    stateBefore(wallet),
+		After:     wallet,
+	}, err)
+
+	return wallet, err
+}
+
+// TransferMoney provides to transfer money from wallet to another wallet
+func (s *AuditingService) TransferMoney(ctx context.Context, walletID string, req *TransferMoneyRequest) (*TransferMoneyResponse, *errr.Error) {
+	res, err := s.service.TransferMoney(ctx, walletID, req)
+
+	var before *TransferMoneyResponse
+	if res != nil {
+		before = &TransferMoneyResponse{From: stateBefore(res.From), To: stateBefore(res.To)}
+	}
+	s.record(ctx, audit.Record{
+		Action:    TransferMoneyAuditAction,
+		WalletIDs: []string{walletID, req.ToWalletID},
+		Before:    before,
+		After:     res,
+	}, err)
+
+	return res, err
+}
+
+// FreezeWallet freezes wallet so that money can't be deposited to or withdrawn from it
+func (s *AuditingService) FreezeWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	wallet, err := s.service.FreezeWallet(ctx, walletID)
+	s.record(ctx, audit.Record{
+		Action:    FreezeWalletAuditAction,
+		WalletIDs: []string{walletID},
+		Before:    stateBefore(wallet),
+		After:     wallet,
+	}, err)
+
+	return wallet, err
+}
+
+// GetWallet gets wallet with current state
+func (s *AuditingService) GetWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	return s.service.GetWallet(ctx, walletID)
+}
+
+// GetWalletAsOf gets wallet with its state as of a time in the past
+func (s *AuditingService) GetWalletAsOf(ctx context.Context, walletID string, asOf time.Time) (*Wallet, *errr.Error) {
+	return s.service.GetWalletAsOf(ctx, walletID, asOf)
+}
+
+// GetTransactions gets transaction history of wallet
+func (s *AuditingService) GetTransactions(ctx context.Context, walletID string) ([]Transaction, *errr.Error) {
+	return s.service.GetTransactions(ctx, walletID)
+}
+
+// FindTransactionsByReference finds transactions of every wallet with reference
+func (s *AuditingService) FindTransactionsByReference(ctx context.Context, reference string) ([]Transaction, *errr.Error) {
+	return s.service.FindTransactionsByReference(ctx, reference)
+}
+
+// WriteStatement writes statement of wallet
+func (s *AuditingService) WriteStatement(ctx context.Context, walletID string, req *StatementRequest, w StatementWriter) *errr.Error {
+	return s.service.WriteStatement(ctx, walletID, req, w)
+}
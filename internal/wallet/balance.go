@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"time"
+)
+
+type (
+	// BalanceReportLine is the balance of a wallet as of the time of a report
+	BalanceReportLine struct {
+		WalletID string `json:"wallet_id"`
+		Username string `json:"username"`
+		Balance  Money  `json:"balance"`
+		// Transactions is the number of transactions before the time of the report
+		Transactions int64 `json:"transactions"`
+	}
+
+	// BalanceReportTotal is the number of wallets of a report and the sum of their balances in minor units
+	BalanceReportTotal struct {
+		AsOf    time.Time `json:"as_of"`
+		Wallets int       `json:"wallets"`
+		Total   int64     `json:"total"`
+	}
+)
+
+// BalanceReport calls fn with balance as of asOf of every wallet created before it in ascending creation order,
+// states are loaded by LoadWalletAsOf from the latest snapshots of wallets before asOf
+func BalanceReport(ctx context.Context, repository Repository, events eventstore.Store, snapshots SnapshotRepository,
+	asOf time.Time, fn func(l BalanceReportLine) error) (BalanceReportTotal, error) {
+	total := BalanceReportTotal{AsOf: asOf}
+
+	err := repository.IterateWallets(ctx, func(w *Wallet) error {
+		if !w.CreatedAt.Before(asOf) {
+			return nil
+		}
+		wallet, err := LoadWalletAsOf(ctx, events, snapshots, w.ID, asOf)
+		if err != nil {
+			return err
+		}
+		if wallet == nil {
+			return fmt.Errorf(ErrWalletNotFoundAsOf, w.ID, asOf.Format(time.RFC3339))
+		}
+
+		total.Wallets++
+		total.Total += wallet.Balance.MinorUnits()
+		return fn(BalanceReportLine{
+			WalletID:     wallet.ID,
+			Username:     wallet.Username,
+			Balance:      wallet.Balance,
+			Transactions: wallet.Version,
+		})
+	})
+
+	return total, err
+}
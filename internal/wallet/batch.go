@@ -0,0 +1,555 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/utility"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// MaxIdempotencyKeyLength is the max length of idempotency keys of batch items
+const MaxIdempotencyKeyLength = 128
+
+const (
+	// batchIdempotencyKeyPrefix keeps idempotency keys of batch items apart from the other idempotency keys
+	batchIdempotencyKeyPrefix = "batch:"
+	// batchProgressInterval is how many items are processed between saves of the progress of a batch
+	batchProgressInterval = 100
+	// maxAtomicBatchDuration bounds the unit of work of an atomic batch below the 60s transaction lifetime of mongo
+	maxAtomicBatchDuration = 50 * time.Second
+	// batchAuditTimeout bounds recording the audit records of a unit of work, which goes on if the processing stops
+	batchAuditTimeout = 5 * time.Second
+)
+
+type (
+	// Batch is a set of deposits, withdrawals and transfers that is processed in the background. Every item is
+	// applied in its own unit of work and failed items don't stop the others. Items of an atomic batch are applied
+	// in one unit of work, if one of them fails none is applied
+	Batch struct {
+		ID     string      `bson:"_id" json:"id"`
+		Atomic bool        `bson:"atomic" json:"atomic"`
+		Status BatchStatus `bson:"status" json:"status"`
+		// Error is why an atomic batch failed
+		Error   string       `bson:"error,omitempty" json:"error,omitempty"`
+		Summary BatchSummary `bson:"summary" json:"summary"`
+		Items   []BatchItem  `bson:"items" json:"items"`
+		// Submitter is who submitted the batch and how, items are audited as their calls
+		Submitter   audit.Metadata `bson:"submitter" json:"-"`
+		CreatedAt   time.Time      `bson:"created_at" json:"created_at"`
+		StartedAt   *time.Time     `bson:"started_at,omitempty" json:"started_at,omitempty"`
+		CompletedAt *time.Time     `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+		// LeaseID is the processing that holds the batch until LeaseUntil, another one takes the batch over after it
+		LeaseID    string    `bson:"lease_id,omitempty" json:"-"`
+		LeaseUntil time.Time `bson:"lease_until,omitempty" json:"-"`
+	}
+
+	// BatchItem is an item of a batch with its status, TransactionIDs are the transactions a succeeded item made
+	BatchItem struct {
+		BatchItemRequest `bson:",inline"`
+		Status           BatchItemStatus `bson:"status" json:"status"`
+		Error            string          `bson:"error,omitempty" json:"error,omitempty"`
+		TransactionIDs   []string        `bson:"transaction_ids,omitempty" json:"transaction_ids,omitempty"`
+	}
+
+	// BatchSummary is the number of items of a batch by status
+	BatchSummary struct {
+		Pending   int `bson:"pending" json:"pending"`
+		Succeeded int `bson:"succeeded" json:"succeeded"`
+		Failed    int `bson:"failed" json:"failed"`
+		Duplicate int `bson:"duplicate" json:"duplicate"`
+		Aborted   int `bson:"aborted" json:"aborted"`
+	}
+
+	// BatchLimits are the max numbers of items of batches, atomic batches are disabled if MaxAtomicItems is 0
+	BatchLimits struct {
+		MaxItems       int
+		MaxAtomicItems int
+	}
+
+	// BatchService submits batches to be processed by BatchProcessor and gets their statuses
+	BatchService struct {
+		repository BatchRepository
+		limits     BatchLimits
+	}
+)
+
+// NewBatchService creates new instance of BatchService
+func NewBatchService(repository BatchRepository, limits BatchLimits) *BatchService {
+	return &BatchService{repository: repository, limits: limits}
+}
+
+// SubmitBatch checks items of req and saves them as a pending batch, whoever made the call of ctx is the submitter
+// of the batch. Items are checked as far as they can be without wallets, the rest is checked while processing
+func (s *BatchService) SubmitBatch(ctx context.Context, req *BatchRequest) (*Batch, *errr.Error) {
+	if ex := s.checkLimits(req); ex != nil {
+		return nil, ex
+	}
+
+	batch := &Batch{
+		ID:        uuid.NewString(),
+		Atomic:    req.Atomic,
+		Status:    PendingBatchStatus,
+		Items:     make([]BatchItem, len(req.Items)),
+		Submitter: audit.MetadataFrom(ctx),
+		CreatedAt: time.Now(),
+	}
+	used := make(map[string]int, len(req.Items))
+	for i, item := range req.Items {
+		item.IdempotencyKey = strings.TrimSpace(item.IdempotencyKey)
+		if item.IdempotencyKey == "" {
+			item.IdempotencyKey = fmt.Sprintf("%s:%d", batch.ID, i)
+		}
+		if err := checkBatchItem(&item); err != nil {
+			return nil, errr.ThrowBadRequestError(fmt.Errorf(ErrInvalidBatchItem, i, err))
+		}
+		if j, ok := used[item.IdempotencyKey]; ok {
+			return nil, errr.ThrowBadRequestError(fmt.Errorf(ErrInvalidBatchItem, i, fmt.Sprintf(ErrRepeatedIdempotencyKey, item.IdempotencyKey, j)))
+		}
+		used[item.IdempotencyKey] = i
+
+		batch.Items[i] = BatchItem{BatchItemRequest: item, Status: PendingBatchItemStatus}
+	}
+	batch.Summary = batchSummaryOf(batch.Items)
+
+	if err := s.repository.InsertBatch(ctx, batch); err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+
+	return batch, nil
+}
+
+// GetBatch gets batch with statuses of its items
+func (s *BatchService) GetBatch(ctx context.Context, id string) (*Batch, *errr.Error) {
+	batch, err := s.repository.FindBatchByID(ctx, id)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if batch == nil {
+		return nil, errr.ThrowNotFoundError(fmt.Errorf(ErrBatchNotFound, id))
+	}
+
+	return batch, nil
+}
+
+func (s *BatchService) checkLimits(req *BatchRequest) *errr.Error {
+	switch {
+	case len(req.Items) == 0:
+		return errr.ThrowBadRequestError(errors.New(ErrEmptyBatch))
+	case len(req.Items) > s.limits.MaxItems:
+		return errr.ThrowBadRequestError(fmt.Errorf(ErrTooManyBatchItems, s.limits.MaxItems))
+	case req.Atomic && s.limits.MaxAtomicItems == 0:
+		return errr.ThrowBadRequestError(errors.New(ErrAtomicBatchesDisabled))
+	case req.Atomic && len(req.Items) > s.limits.MaxAtomicItems:
+		return errr.ThrowBadRequestError(fmt.Errorf(ErrTooManyAtomicItems, s.limits.MaxAtomicItems))
+	}
+
+	return nil
+}
+
+// checkBatchItem checks item like its use case checks its request before wallets are loaded
+func checkBatchItem(item *BatchItemRequest) error {
+	if len(item.IdempotencyKey) > MaxIdempotencyKeyLength {
+		return fmt.Errorf(ErrInvalidIdempotencyKey, MaxIdempotencyKeyLength)
+	}
+	if utility.IsStrEmpty(item.WalletID) {
+		return errors.New(ErrInvalidWalletID)
+	}
+	if item.Amount <= 0 {
+		return errors.New(ErrInvalidMoneyAmount)
+	}
+	if ex := checkMinorUnits(item.Amount); ex != nil {
+		return ex
+	}
+
+	switch item.Type {
+	case DepositBatchItemType, WithdrawBatchItemType:
+		if _, _, ex := moneyTransactionOf(item.moneyTransactionRequest()); ex != nil {
+			return ex
+		}
+	case TransferBatchItemType:
+		if utility.IsStrEmpty(item.ToWalletID) {
+			return errors.New(ErrInvalidWalletID)
+		}
+		if item.ToWalletID == item.WalletID {
+			return errors.New(ErrSameWalletTransfer)
+		}
+		if item.Reference != "" || item.Description != "" || len(item.Metadata) > 0 || item.UniqueReference {
+			return errors.New(ErrTransferDetails)
+		}
+	default:
+		return fmt.Errorf(ErrInvalidBatchItemType, item.Type)
+	}
+
+	return nil
+}
+
+func (r *BatchItemRequest) moneyTransactionRequest() *MoneyTransactionRequest {
+	return &MoneyTransactionRequest{
+		Amount:          r.Amount,
+		Reference:       r.Reference,
+		Description:     r.Description,
+		Metadata:        r.Metadata,
+		UniqueReference: r.UniqueReference,
+	}
+}
+
+// batchSummaryOf counts items by status
+func batchSummaryOf(items []BatchItem) BatchSummary {
+	var s BatchSummary
+	for _, item := range items {
+		switch item.Status {
+		case PendingBatchItemStatus:
+			s.Pending++
+		case SucceededBatchItemStatus:
+			s.Succeeded++
+		case FailedBatchItemStatus:
+			s.Failed++
+		case DuplicateBatchItemStatus:
+			s.Duplicate++
+		case AbortedBatchItemStatus:
+			s.Aborted++
+		}
+	}
+
+	return s
+}
+
+type (
+	// BatchProcessor processes batches through service in units of work of repository. Calls of items are audited
+	// to recorder as calls of the submitter and recorded to metrics once their units of work are over, only the
+	// failed calls are recorded if they are rolled back. So service must not audit or measure them itself, it
+	// shouldn't cache states of wallets either since they may be rolled back.
+	//
+	// A batch is processed by one processing at a time while it holds the lease of the batch, which is renewed as
+	// progress is saved. Items record their idempotency keys in their units of work, so the items that a stopped
+	// processing applied are recognized by the one that takes over. Idempotency relies on mongo transactions,
+	// without them an item can be applied again if the processing stops after its call
+	BatchProcessor struct {
+		batches    BatchRepository
+		repository Repository
+		service    Service
+		recorder   audit.Recorder
+		metrics    *Metrics
+		lease      time.Duration
+		log        *slog.Logger
+	}
+
+	// batchItemResult is the result of applying an item of a batch in a unit of work
+	batchItemResult struct {
+		status         BatchItemStatus
+		transactionIDs []string
+		err            *errr.Error
+		// records are audit records of calls of the item, they are recorded after the unit of work
+		records []audit.Record
+		// callErr is the error of the call of the item and wallets are the wallets it changed, they are recorded to
+		// metrics after the unit of work
+		callErr *errr.Error
+		wallets []*Wallet
+	}
+
+	// recordBuffer is an audit recorder that keeps records to be recorded later
+	recordBuffer struct {
+		records []audit.Record
+	}
+)
+
+// NewBatchProcessor creates new instance of BatchProcessor, a batch is held for lease after it is claimed or its
+// progress is saved
+func NewBatchProcessor(batches BatchRepository, repository Repository, service Service, recorder audit.Recorder,
+	metrics *Metrics, lease time.Duration, log *slog.Logger) *BatchProcessor {
+	return &BatchProcessor{
+		batches:    batches,
+		repository: repository,
+		service:    service,
+		recorder:   recorder,
+		metrics:    metrics,
+		lease:      lease,
+		log:        log,
+	}
+}
+
+// Run processes batches until ctx is done, it waits interval whenever there is no batch to process
+func (p *BatchProcessor) Run(ctx context.Context, interval time.Duration) {
+	p.log.Info("batch processor is running")
+	for {
+		processed, err := p.Step(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			p.log.Error("batch processing failed", "error", err)
+		}
+		if err == nil && processed {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Step claims the oldest pending batch, or one whose processing stopped, and processes its pending items. False
+// is returned if there is no batch to process
+func (p *BatchProcessor) Step(ctx context.Context) (bool, error) {
+	batch, err := p.batches.ClaimBatch(ctx, time.Now(), p.lease)
+	if err != nil || batch == nil {
+		return false, err
+	}
+
+	p.log.InfoContext(ctx, "batch is processing", "batch_id", batch.ID, "items", len(batch.Items), "atomic", batch.Atomic)
+	ctx = audit.WithMetadata(ctx, batch.Submitter)
+	if batch.StartedAt == nil {
+		now := time.Now()
+		batch.StartedAt = &now
+	}
+
+	if batch.Atomic {
+		p.processAtomic(ctx, batch)
+	} else if err = p.processItems(ctx, batch); err != nil {
+		return true, fmt.Errorf("batch %s: %w", batch.ID, err)
+	}
+
+	now := time.Now()
+	batch.CompletedAt = &now
+	if err = p.save(ctx, batch); err != nil {
+		return true, fmt.Errorf("batch %s: %w", batch.ID, err)
+	}
+	p.log.InfoContext(ctx, "batch is processed", "batch_id", batch.ID, "status", batch.Status,
+		"succeeded", batch.Summary.Succeeded, "failed", batch.Summary.Failed+batch.Summary.Duplicate)
+
+	return true, nil
+}
+
+// processItems applies pending items of batch in their own units of work and saves progress regularly
+func (p *BatchProcessor) processItems(ctx context.Context, batch *Batch) error {
+	processed := 0
+	for i := range batch.Items {
+		item := &batch.Items[i]
+		if item.Status != PendingBatchItemStatus {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var res batchItemResult
+		err := p.repository.WithinTransaction(ctx, func(ctx context.Context) error {
+			if res = p.apply(ctx, batch, i); res.err != nil {
+				return res.err
+			}
+			return nil
+		})
+		p.record(ctx, res.records, err == nil)
+		p.observe(item, res, err == nil)
+		finishItem(item, res, err)
+
+		if processed++; processed%batchProgressInterval == 0 {
+			if err = p.save(ctx, batch); err != nil {
+				return err
+			}
+		}
+	}
+	batch.Status = CompletedBatchStatus
+
+	return nil
+}
+
+// processAtomic applies every item of batch in one unit of work, the first failed item aborts the others. The unit
+// of work is aborted if it isn't over in half of the lease, so that the batch isn't taken over while its items are
+// applied, or in the lifetime of a mongo transaction
+func (p *BatchProcessor) processAtomic(ctx context.Context, batch *Batch) {
+	timeout := min(p.lease/2, maxAtomicBatchDuration)
+	unitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]batchItemResult, len(batch.Items))
+	failed := -1
+	err := p.repository.WithinTransaction(unitCtx, func(ctx context.Context) error {
+		failed = -1
+		for i := range batch.Items {
+			if results[i] = p.apply(ctx, batch, i); results[i].err != nil {
+				failed = i
+				return results[i].err
+			}
+		}
+		return nil
+	})
+	if err != nil && unitCtx.Err() != nil && ctx.Err() == nil {
+		// the item that failed by the timeout is aborted like the others
+		failed, err = -1, fmt.Errorf(ErrAtomicBatchTimeout, timeout)
+	}
+
+	for i := range batch.Items {
+		switch {
+		case err == nil:
+			p.record(ctx, results[i].records, true)
+			p.observe(&batch.Items[i], results[i], true)
+			finishItem(&batch.Items[i], results[i], nil)
+		case i == failed:
+			p.record(ctx, results[i].records, false)
+			p.observe(&batch.Items[i], results[i], false)
+			finishItem(&batch.Items[i], results[i], err)
+		default:
+			batch.Items[i].Status, batch.Items[i].TransactionIDs = AbortedBatchItemStatus, nil
+		}
+	}
+
+	switch {
+	case err == nil:
+		batch.Status = CompletedBatchStatus
+	case failed >= 0:
+		batch.Status, batch.Error = FailedBatchStatus, fmt.Sprintf(ErrAtomicBatchFailed, failed, batch.Items[failed].Error)
+	default:
+		batch.Status, batch.Error = FailedBatchStatus, err.Error()
+	}
+}
+
+// apply applies item at index of batch unless its idempotency key is used. Key used by the item itself means it is
+// applied by an earlier processing, it succeeded with the transactions recorded by the key
+func (p *BatchProcessor) apply(ctx context.Context, batch *Batch, index int) batchItemResult {
+	item := &batch.Items[index]
+	key := batchIdempotencyKeyPrefix + item.IdempotencyKey
+	used, err := p.batches.FindIdempotencyKey(ctx, key)
+	if err != nil {
+		return batchItemResult{status: FailedBatchItemStatus, err: errr.ThrowInternalServerError(err)}
+	}
+	if used != nil {
+		if used.BatchID == batch.ID && used.Item == index {
+			return batchItemResult{status: SucceededBatchItemStatus, transactionIDs: used.TransactionIDs}
+		}
+		return batchItemResult{
+			status: DuplicateBatchItemStatus,
+			err:    errr.ThrowConflictError(fmt.Errorf(ErrDuplicateBatchItem, item.IdempotencyKey, used.BatchID)),
+		}
+	}
+
+	buffer := &recordBuffer{}
+	wallets, ex := p.call(ctx, NewAuditingService(p.service, buffer, p.log), item)
+	if ex != nil {
+		return batchItemResult{status: FailedBatchItemStatus, err: ex, records: buffer.records, callErr: ex}
+	}
+
+	var transactionIDs []string
+	for _, wallet := range wallets {
+		for _, t := range wallet.Changes {
+			transactionIDs = append(transactionIDs, t.ID)
+		}
+	}
+	err = p.batches.InsertIdempotencyKey(ctx, &IdempotencyKey{
+		Key:            key,
+		BatchID:        batch.ID,
+		Item:           index,
+		TransactionIDs: transactionIDs,
+		CreatedAt:      time.Now(),
+	})
+	switch {
+	case errors.Is(err, ErrDuplicateIdempotencyKey):
+		// another batch used the key after it is found unused, the unit of work is rolled back
+		return batchItemResult{
+			status:  DuplicateBatchItemStatus,
+			err:     errr.ThrowConflictError(fmt.Errorf(ErrConcurrentBatchItem, item.IdempotencyKey)),
+			records: buffer.records,
+		}
+	case err != nil:
+		return batchItemResult{status: FailedBatchItemStatus, err: errr.ThrowInternalServerError(err), records: buffer.records}
+	}
+
+	return batchItemResult{
+		status:         SucceededBatchItemStatus,
+		transactionIDs: transactionIDs,
+		records:        buffer.records,
+		wallets:        wallets,
+	}
+}
+
+// call calls the use case of item through service and returns the wallets it changed
+func (p *BatchProcessor) call(ctx context.Context, service Service, item *BatchItem) ([]*Wallet, *errr.Error) {
+	var wallets []*Wallet
+	switch item.Type {
+	case DepositBatchItemType:
+		wallet, ex := service.DepositMoney(ctx, item.WalletID, item.moneyTransactionRequest())
+		if ex != nil {
+			return nil, ex
+		}
+		wallets = append(wallets, wallet)
+	case WithdrawBatchItemType:
+		wallet, ex := service.WithdrawMoney(ctx, item.WalletID, item.moneyTransactionRequest())
+		if ex != nil {
+			return nil, ex
+		}
+		wallets = append(wallets, wallet)
+	case TransferBatchItemType:
+		res, ex := service.TransferMoney(ctx, item.WalletID, &TransferMoneyRequest{ToWalletID: item.ToWalletID, Amount: item.Amount})
+		if ex != nil {
+			return nil, ex
+		}
+		wallets = append(wallets, res.From, res.To)
+	default:
+		return nil, errr.ThrowBadRequestError(fmt.Errorf(ErrInvalidBatchItemType, item.Type))
+	}
+
+	return wallets, nil
+}
+
+// record records audit records of a unit of work, only the failed calls are recorded if it is rolled back. They
+// are recorded even if ctx is done, since the unit of work is over
+func (p *BatchProcessor) record(ctx context.Context, records []audit.Record, committed bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), batchAuditTimeout)
+	defer cancel()
+
+	for _, r := range records {
+		if !committed && r.Err == nil {
+			continue
+		}
+		if err := p.recorder.Record(ctx, r); err != nil {
+			p.log.ErrorContext(ctx, "audit entry could not be recorded", "action", r.Action, "error", err)
+		}
+	}
+}
+
+// observe records the call of item to metrics, only a failed call is recorded if its unit of work is rolled back.
+// Items applied by an earlier processing aren't called, so they aren't recorded
+func (p *BatchProcessor) observe(item *BatchItem, res batchItemResult, committed bool) {
+	switch {
+	case res.callErr != nil:
+		p.metrics.recordOperation(item.Type, res.callErr)
+	case committed && len(res.wallets) > 0:
+		p.metrics.recordOperation(item.Type, nil, res.wallets...)
+	}
+}
+
+// save saves progress of batch and renews its lease
+func (p *BatchProcessor) save(ctx context.Context, batch *Batch) error {
+	batch.Summary = batchSummaryOf(batch.Items)
+	batch.LeaseUntil = time.Now().Add(p.lease)
+
+	return p.batches.SaveBatch(ctx, batch)
+}
+
+// finishItem sets status of item by the result of its unit of work, err is the error of the unit of work
+func finishItem(item *BatchItem, res batchItemResult, err error) {
+	item.TransactionIDs, item.Error = nil, ""
+	switch {
+	case err == nil:
+		item.Status, item.TransactionIDs = res.status, res.transactionIDs
+	case res.err != nil:
+		item.Status, item.Error = res.status, res.err.Message
+	default:
+		item.Status, item.Error = FailedBatchItemStatus, err.Error()
+	}
+}
+
+// Record keeps r to be recorded later
+func (b *recordBuffer) Record(_ context.Context, r audit.Record) error {
+	b.records = append(b.records, r)
+	return nil
+}
@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/response"
+)
+
+// BatchApi is the api of batch operations
+type BatchApi struct {
+	batches *BatchService
+}
+
+func NewBatchApi(batches *BatchService) *BatchApi {
+	return &BatchApi{batches: batches}
+}
+
+func (a *BatchApi) AddRoutesTo(r fiber.Router) {
+	batches := r.Group("batches")
+
+	batches.Post("/", a.SubmitBatch)
+	batches.Get("/:id", a.GetBatch)
+}
+
+// Routes describes the routes added by AddRoutesTo for the api documentation
+func (a *BatchApi) Routes() []openapi.Route {
+	tags := []string{"batches"}
+
+	return []openapi.Route{
+		{
+			Method: fiber.MethodPost,
+			Path:   "/batches/",
+			Summary: "Submit deposits, withdrawals and transfers to be processed in the background, items of an atomic " +
+				"batch are applied all or none",
+			Tags:     tags,
+			Request:  BatchRequest{},
+			Response: Batch{},
+			Status:   fiber.StatusAccepted,
+			Errors:   []int{fiber.StatusBadRequest, fiber.StatusInternalServerError},
+		},
+		{
+			Method:   fiber.MethodGet,
+			Path:     "/batches/:id",
+			Summary:  "Get batch with statuses of its items",
+			Tags:     tags,
+			Response: Batch{},
+			Errors:   []int{fiber.StatusNotFound, fiber.StatusInternalServerError},
+		},
+	}
+}
+
+// SubmitBatch submits batch, it is accepted to be processed later
+func (a *BatchApi) SubmitBatch(c *fiber.Ctx) error {
+	req := new(BatchRequest)
+	if err := c.BodyParser(req); err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	batch, err := a.batches.SubmitBatch(c.UserContext(), req)
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	c.Status(fiber.StatusAccepted)
+	return response.New(c).Data(batch).JSON()
+}
+
+func (a *BatchApi) GetBatch(c *fiber.Ctx) error {
+	batch, err := a.batches.GetBatch(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return response.New(c).Error(err).JSON()
+	}
+
+	return response.New(c).Data(batch).JSON()
+}
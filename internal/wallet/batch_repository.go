@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+//go:generate mockgen -source=batch_repository.go -destination=./test/batch_repository_mock.go -package=wallet
+
+const batchesCollection = "batches"
+
+var (
+	// ErrDuplicateIdempotencyKey is returned when an idempotency key is inserted again
+	ErrDuplicateIdempotencyKey = errors.New("idempotency key is already used")
+	// ErrBatchLeaseLost is returned when a batch is saved by a processing whose lease is taken over
+	ErrBatchLeaseLost = errors.New("lease of batch is taken over by another processing")
+)
+
+type (
+	// BatchRepository stores batches and idempotency keys of their items. Idempotency keys are written with the
+	// context of the unit of work of their item, so they are committed or aborted with it
+	BatchRepository interface {
+		// InsertBatch inserts batch
+		InsertBatch(ctx context.Context, batch *Batch) error
+		// FindBatchByID finds batch by id, nil is returned if it can't be found
+		FindBatchByID(ctx context.Context, id string) (*Batch, error)
+		// ClaimBatch leases the oldest batch that is pending or whose lease is over at now for lease, nil is
+		// returned if there is none
+		ClaimBatch(ctx context.Context, now time.Time, lease time.Duration) (*Batch, error)
+		// SaveBatch replaces batch if its lease is still held, ErrBatchLeaseLost is returned otherwise
+		SaveBatch(ctx context.Context, batch *Batch) error
+		// FindIdempotencyKey finds idempotency key, nil is returned if it isn't used
+		FindIdempotencyKey(ctx context.Context, key string) (*IdempotencyKey, error)
+		// InsertIdempotencyKey inserts idempotency key, ErrDuplicateIdempotencyKey is returned if it is used
+		InsertIdempotencyKey(ctx context.Context, key *IdempotencyKey) error
+	}
+
+	// IdempotencyKey records the batch item a key is used by and the transactions the item made
+	IdempotencyKey struct {
+		Key            string    `bson:"key"`
+		BatchID        string    `bson:"batch_id"`
+		Item           int       `bson:"item"`
+		TransactionIDs []string  `bson:"transaction_ids"`
+		CreatedAt      time.Time `bson:"created_at"`
+	}
+
+	// MongoBatchRepository is a concrete implementation of BatchRepository interface
+	MongoBatchRepository struct {
+		batches         *mongo.Collection
+		idempotencyKeys *mongo.Collection
+	}
+)
+
+// NewMongoBatchRepository creates instance of MongoBatchRepository
+func NewMongoBatchRepository(db *mongo.Database) *MongoBatchRepository {
+	return &MongoBatchRepository{
+		batches:         db.Collection(batchesCollection),
+		idempotencyKeys: db.Collection(idempotencyKeysCollection),
+	}
+}
+
+// InsertBatch inserts batch
+func (r *MongoBatchRepository) InsertBatch(ctx context.Context, batch *Batch) error {
+	_, err := r.batches.InsertOne(ctx, batch)
+	return err
+}
+
+// FindBatchByID finds batch by id, nil is returned if it can't be found
+func (r *MongoBatchRepository) FindBatchByID(ctx context.Context, id string) (*Batch, error) {
+	batch := new(Batch)
+	if err := r.batches.FindOne(ctx, bson.M{"_id": id}).Decode(batch); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// ClaimBatch leases the oldest batch that is pending or whose lease is over at now for lease, nil is returned if
+// there is none
+func (r *MongoBatchRepository) ClaimBatch(ctx context.Context, now time.Time, lease time.Duration) (*Batch, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": PendingBatchStatus},
+		bson.M{"status": ProcessingBatchStatus, "lease_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{"$set": bson.M{
+		"status":      ProcessingBatchStatus,
+		"lease_id":    uuid.NewString(),
+		"lease_until": now.Add(lease),
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	batch := new(Batch)
+	if err := r.batches.FindOneAndUpdate(ctx, filter, update, opts).Decode(batch); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// SaveBatch replaces batch if its lease is still held, ErrBatchLeaseLost is returned otherwise
+func (r *MongoBatchRepository) SaveBatch(ctx context.Context, batch *Batch) error {
+	res, err := r.batches.ReplaceOne(ctx, bson.M{"_id": batch.ID, "lease_id": batch.LeaseID}, batch)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrBatchLeaseLost
+	}
+
+	return nil
+}
+
+// FindIdempotencyKey finds idempotency key, nil is returned if it isn't used
+func (r *MongoBatchRepository) FindIdempotencyKey(ctx context.Context, key string) (*IdempotencyKey, error) {
+	k := new(IdempotencyKey)
+	if err := r.idempotencyKeys.FindOne(ctx, bson.M{"key": key}).Decode(k); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// InsertIdempotencyKey inserts idempotency key, ErrDuplicateIdempotencyKey is returned if it is used
+func (r *MongoBatchRepository) InsertIdempotencyKey(ctx context.Context, key *IdempotencyKey) error {
+	if _, err := r.idempotencyKeys.InsertOne(ctx, key); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateIdempotencyKey
+		}
+		return err
+	}
+
+	return nil
+}
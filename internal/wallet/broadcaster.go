@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	subscriberBufferSize = 16
+	// publisherBatchSize is how many events BalancePublisher reads at a time
+	publisherBatchSize = 100
+)
+
+type (
+	// BalanceUpdate is published when balance of a wallet is changed by a transaction
+	BalanceUpdate struct {
+		WalletID    string
+		Balance     Money
+		Transaction Transaction
+	}
+
+	// Broadcaster fans out balance updates to subscribers of wallets
+	Broadcaster struct {
+		sync.RWMutex
+		subscribers map[string]map[chan BalanceUpdate]struct{}
+		closed      bool
+	}
+
+	// BalancePublisher publishes balance updates of transactions to broadcaster in the order of their positions in
+	// the event store, so watchers get the transactions of every instance and never the ones that are rolled back.
+	// States of watched wallets are kept to apply their next events to, they are loaded from their streams when a
+	// watch begins or an event of their streams is missed
+	BalancePublisher struct {
+		events      eventstore.Store
+		broadcaster *Broadcaster
+		log         *slog.Logger
+		// after is the position of the last published event, it is the last position of events when started
+		after   int64
+		started bool
+		wallets map[string]*Wallet
+	}
+)
+
+// NewBroadcaster creates new instance of Broadcaster
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: map[string]map[chan BalanceUpdate]struct{}{}}
+}
+
+// Subscribe subscribes to balance updates of wallet, returned func must be called to unsubscribe
+func (b *Broadcaster) Subscribe(walletID string) (<-chan BalanceUpdate, func()) {
+	b.Lock()
+	defer b.Unlock()
+
+	ch := make(chan BalanceUpdate, subscriberBufferSize)
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subscribers[walletID] == nil {
+		b.subscribers[walletID] = map[chan BalanceUpdate]struct{}{}
+	}
+	b.subscribers[walletID][ch] = struct{}{}
+
+	return ch, func() {
+		b.Lock()
+		defer b.Unlock()
+
+		if _, ok := b.subscribers[walletID][ch]; !ok {
+			return
+		}
+		delete(b.subscribers[walletID], ch)
+		if len(b.subscribers[walletID]) == 0 {
+			delete(b.subscribers, walletID)
+		}
+		close(ch)
+	}
+}
+
+// Close closes channels of all subscribers, it is called on shutdown to end streams
+func (b *Broadcaster) Close() {
+	b.Lock()
+	defer b.Unlock()
+
+	for walletID, subscribers := range b.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(b.subscribers, walletID)
+	}
+	b.closed = true
+}
+
+// watched reports whether wallet has subscribers
+func (b *Broadcaster) watched(walletID string) bool {
+	b.RLock()
+	defer b.RUnlock()
+
+	return len(b.subscribers[walletID]) > 0
+}
+
+// Publish sends update to subscribers of the wallet, update is dropped for subscribers that are not keeping up
+func (b *Broadcaster) Publish(update BalanceUpdate) {
+	b.RLock()
+	defer b.RUnlock()
+
+	for ch := range b.subscribers[update.WalletID] {
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+// NewBalancePublisher creates new instance of BalancePublisher, transactions appended before it is run aren't
+// published
+func NewBalancePublisher(events eventstore.Store, broadcaster *Broadcaster, log *slog.Logger) *BalancePublisher {
+	return &BalancePublisher{events: events, broadcaster: broadcaster, log: log, wallets: map[string]*Wallet{}}
+}
+
+// Run publishes balance updates until ctx is done, it waits interval after publishing every event or failing
+func (p *BalancePublisher) Run(ctx context.Context, interval time.Duration) {
+	for {
+		published, err := p.Step(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			p.log.Error("balance updates could not be published", "error", err)
+		}
+		if err == nil && published >= publisherBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Step publishes balance updates of transactions of watched wallets among at most publisherBatchSize events after
+// the last published one and returns how many events it read. The first step starts after the last event
+func (p *BalancePublisher) Step(ctx context.Context) (int, error) {
+	if !p.started {
+		after, err := p.events.LastPosition(ctx)
+		if err != nil {
+			return 0, err
+		}
+		p.after, p.started = after, true
+	}
+
+	events, err := p.events.ReadAll(ctx, p.after, publisherBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for walletID := range p.wallets {
+		if !p.broadcaster.watched(walletID) {
+			delete(p.wallets, walletID)
+		}
+	}
+	for _, e := range events {
+		if err = p.publish(ctx, e); err != nil {
+			return 0, fmt.Errorf("event %s at position %d: %w", e.ID, e.Position, err)
+		}
+		p.after = e.Position
+	}
+
+	return len(events), nil
+}
+
+// publish applies event to the state of its wallet if the wallet is watched and publishes its balance update if it
+// is a transaction
+func (p *BalancePublisher) publish(ctx context.Context, e eventstore.Event) error {
+	walletID, ok := walletIDOf(e.Stream)
+	if !ok || !p.broadcaster.watched(walletID) {
+		return nil
+	}
+
+	payload, err := Events.Decode(e)
+	if err != nil {
+		return err
+	}
+	wallet := p.wallets[walletID]
+	if wallet != nil && wallet.StreamVersion == e.Version-1 {
+		if err = wallet.apply(payload); err != nil {
+			return err
+		}
+		wallet.StreamVersion = e.Version
+	} else {
+		if wallet, err = loadWalletAt(ctx, p.events, walletID, e.Version); err != nil {
+			return err
+		}
+		p.wallets[walletID] = wallet
+	}
+
+	var t Transaction
+	switch event := payload.(type) {
+	case MoneyDeposited:
+		t = event.Transaction
+	case MoneyWithdrawn:
+		t = event.Transaction
+	default:
+		return nil
+	}
+	p.broadcaster.Publish(BalanceUpdate{WalletID: walletID, Balance: wallet.Balance, Transaction: t})
+
+	return nil
+}
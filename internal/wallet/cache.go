@@ -0,0 +1,133 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ybalcin/wallet-service/pkg/cache"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/utility"
+	"strconv"
+)
+
+type (
+	// CachingService is a Service decorator that serves GetWallet from states of wallets cached by wallet id and
+	// version. The wallet is always read to get its current version, so a state older than the wallet is never
+	// served however writers interleave, and only the transactions are skipped on hits. Errors of the cache are
+	// misses, they don't fail use cases
+	CachingService struct {
+		Service
+		repository Repository
+		cache      cache.Cache
+		metrics    *Metrics
+	}
+
+	// walletState is the state of a wallet computed from its transactions at a version
+	walletState struct {
+		Balance Money `json:"balance"`
+	}
+)
+
+// NewCachingService creates new instance of CachingService, repository is used to read versions of wallets
+func NewCachingService(service Service, repository Repository, cache cache.Cache, metrics *Metrics) *CachingService {
+	return &CachingService{Service: service, repository: repository, cache: cache, metrics: metrics}
+}
+
+// GetWallet gets wallet with cached state of its version, the state is computed and cached on misses
+func (s *CachingService) GetWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	if utility.IsStrEmpty(walletID) {
+		return s.Service.GetWallet(ctx, walletID)
+	}
+
+	wallet, err := s.repository.FindWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if wallet != nil {
+		if state, ok := s.get(ctx, wallet.ID, wallet.Version); ok {
+			wallet.Balance = state.Balance
+			return wallet, nil
+		}
+	}
+
+	// version of the computed wallet is the number of its transactions, it can't be mixed with a newer state
+	wallet, ex := s.Service.GetWallet(ctx, walletID)
+	if ex != nil {
+		return nil, ex
+	}
+	s.set(ctx, wallet)
+
+	return wallet, nil
+}
+
+// DepositMoney deposits money and invalidates the previous state of wallet
+func (s *CachingService) DepositMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	wallet, err := s.Service.DepositMoney(ctx, walletID, req)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, wallet)
+
+	return wallet, nil
+}
+
+// WithdrawMoney withdraws money and invalidates the previous state of wallet
+func (s *CachingService) WithdrawMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	wallet, err := s.Service.WithdrawMoney(ctx, walletID, req)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, wallet)
+
+	return wallet, nil
+}
+
+// TransferMoney transfers money and invalidates the previous states of both wallets
+func (s *CachingService) TransferMoney(ctx context.Context, walletID string, req *TransferMoneyRequest) (*TransferMoneyResponse, *errr.Error) {
+	res, err := s.Service.TransferMoney(ctx, walletID, req)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, res.From)
+	s.invalidate(ctx, res.To)
+
+	return res, nil
+}
+
+func (s *CachingService) get(ctx context.Context, walletID string, version int64) (*walletState, bool) {
+	state := new(walletState)
+	b, ok, err := s.cache.Get(ctx, stateKey(walletID, version))
+	if err == nil && ok {
+		err = json.Unmarshal(b, state)
+	}
+
+	switch {
+	case err != nil:
+		s.metrics.cacheRequests.WithLabelValues("error").Inc()
+	case !ok:
+		s.metrics.cacheRequests.WithLabelValues("miss").Inc()
+	default:
+		s.metrics.cacheRequests.WithLabelValues("hit").Inc()
+		return state, true
+	}
+
+	return nil, false
+}
+
+func (s *CachingService) set(ctx context.Context, wallet *Wallet) {
+	b, err := json.Marshal(walletState{Balance: wallet.Balance})
+	if err == nil {
+		_ = s.cache.Set(ctx, stateKey(wallet.ID, wallet.Version), b)
+	}
+}
+
+// invalidate deletes state of wallet before its changes were appended, states are keyed by version so it is
+// never served again anyway, it is deleted to free the cache
+func (s *CachingService) invalidate(ctx context.Context, wallet *Wallet) {
+	if len(wallet.Changes) > 0 {
+		_ = s.cache.Delete(ctx, stateKey(wallet.ID, wallet.Version-int64(len(wallet.Changes))))
+	}
+}
+
+func stateKey(walletID string, version int64) string {
+	return "wallet:" + walletID + ":" + strconv.FormatInt(version, 10)
+}
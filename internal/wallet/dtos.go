@@ -6,6 +6,74 @@ type (
 	}
 
 	MoneyTransactionRequest struct {
-		Amount float32 `json:"amount"`
+		Amount      float32           `json:"amount"`
+		Reference   string            `json:"reference,omitempty"`
+		Description string            `json:"description,omitempty"`
+		Metadata    map[string]string `json:"metadata,omitempty"`
+		// UniqueReference rejects the transaction if its wallet already has a transaction with the reference
+		UniqueReference bool `json:"unique_reference,omitempty"`
+	}
+
+	TransferMoneyRequest struct {
+		ToWalletID string  `json:"to_wallet_id"`
+		Amount     float32 `json:"amount"`
+	}
+
+	// StatementRequest is the period of a statement, from is inclusive and to is exclusive. They are dates,
+	// e.g. 2024-03-01, or RFC3339 times, from defaults to the start of the current month and to to now
+	StatementRequest struct {
+		From string `json:"from,omitempty" query:"from"`
+		To   string `json:"to,omitempty" query:"to"`
+	}
+
+	// FindWalletsRequest filters, sorts and pages wallets of the back-office listing. Username is a prefix, times
+	// are dates or RFC3339 times with created_to exclusive and balances are amounts, e.g. 10.50. Sort is
+	// created_at, username or balance with - prefix for descending order
+	FindWalletsRequest struct {
+		Username    string `query:"username"`
+		Status      string `query:"status"`
+		Currency    string `query:"currency"`
+		CreatedFrom string `query:"created_from"`
+		CreatedTo   string `query:"created_to"`
+		MinBalance  string `query:"min_balance"`
+		MaxBalance  string `query:"max_balance"`
+		Sort        string `query:"sort"`
+		Cursor      string `query:"cursor"`
+		Limit       int    `query:"limit"`
+	}
+
+	// FeedRequest pages the transaction feed after a position, Wait is how long to wait for transactions if there
+	// are none yet, e.g. 10s
+	FeedRequest struct {
+		After int64  `query:"after"`
+		Limit int    `query:"limit"`
+		Wait  string `query:"wait"`
+	}
+
+	// BatchRequest is a batch of deposits, withdrawals and transfers, items of an atomic batch are applied all or
+	// none
+	BatchRequest struct {
+		Atomic bool               `json:"atomic,omitempty"`
+		Items  []BatchItemRequest `json:"items"`
+	}
+
+	// BatchItemRequest is a deposit, withdrawal or transfer of a batch, it is stored with its status. Transfers move
+	// amount from wallet to another wallet and don't have details. IdempotencyKey applies the item at most once
+	// among items of every batch, it defaults to one that is unique to the item
+	BatchItemRequest struct {
+		Type            BatchItemType     `bson:"type" json:"type"`
+		WalletID        string            `bson:"wallet_id" json:"wallet_id"`
+		ToWalletID      string            `bson:"to_wallet_id,omitempty" json:"to_wallet_id,omitempty"`
+		Amount          float32           `bson:"amount" json:"amount"`
+		Reference       string            `bson:"reference,omitempty" json:"reference,omitempty"`
+		Description     string            `bson:"description,omitempty" json:"description,omitempty"`
+		Metadata        map[string]string `bson:"metadata,omitempty" json:"metadata,omitempty"`
+		UniqueReference bool              `bson:"unique_reference,omitempty" json:"unique_reference,omitempty"`
+		IdempotencyKey  string            `bson:"idempotency_key" json:"idempotency_key,omitempty"`
+	}
+
+	TransferMoneyResponse struct {
+		From *Wallet `json:"from"`
+		To   *Wallet `json:"to"`
 	}
 )
@@ -6,3 +6,37 @@ const (
 	DepositTransactionType  TransactionType = "deposit"
 	WithdrawTransactionType TransactionType = "withdraw"
 )
+
+type WalletStatus string
+
+const (
+	ActiveWalletStatus WalletStatus = "active"
+	FrozenWalletStatus WalletStatus = "frozen"
+)
+
+type BatchStatus string
+
+const (
+	PendingBatchStatus    BatchStatus = "pending"
+	ProcessingBatchStatus BatchStatus = "processing"
+	CompletedBatchStatus  BatchStatus = "completed"
+	FailedBatchStatus     BatchStatus = "failed"
+)
+
+type BatchItemType string
+
+const (
+	DepositBatchItemType  BatchItemType = "deposit"
+	WithdrawBatchItemType BatchItemType = "withdraw"
+	TransferBatchItemType BatchItemType = "transfer"
+)
+
+type BatchItemStatus string
+
+const (
+	PendingBatchItemStatus   BatchItemStatus = "pending"
+	SucceededBatchItemStatus BatchItemStatus = "succeeded"
+	FailedBatchItemStatus    BatchItemStatus = "failed"
+	DuplicateBatchItemStatus BatchItemStatus = "duplicate"
+	AbortedBatchItemStatus   BatchItemStatus = "aborted"
+)
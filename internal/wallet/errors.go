@@ -6,4 +6,45 @@ const (
 	ErrInsufficientMoneyAmount = "you don't have this amount of money in your wallet"
 	ErrInvalidWalletID         = "provide valid wallet id"
 	ErrWalletNotFound          = "wallet with id %s not found"
+	ErrSameWalletTransfer      = "you can't transfer money to the same wallet"
+	ErrWalletFrozen            = "wallet is frozen"
+	ErrWalletAlreadyFrozen     = "wallet is already frozen"
+	ErrInvalidReference        = "provide valid reference of at most %d characters"
+	ErrInvalidDescription      = "provide valid description of at most %d characters"
+	ErrTooManyMetadataKeys     = "provide at most %d metadata keys"
+	ErrInvalidMetadataKey      = "metadata key %q is invalid, keys are at most %d characters without dots or dollar signs"
+	ErrInvalidMetadataValue    = "metadata value of %q is longer than %d characters"
+	ErrEmptyReference          = "provide reference"
+	ErrDuplicateReference      = "wallet already has a transaction with reference %s"
+	ErrInvalidTime             = "provide valid %s as date, e.g. 2024-03-01, or RFC3339 time"
+	ErrInvalidStatementPeriod  = "provide valid statement period, from must be before to"
+	ErrUnknownStatementFormat  = "statement format %q is unknown, use csv, json or text"
+	ErrWalletNotFoundAsOf      = "wallet with id %s didn't exist as of %s"
+	ErrInvalidWalletStatus     = "status %q is unknown, use active or frozen"
+	ErrInvalidBalance          = "provide valid %s as amount, e.g. 10.50"
+	ErrInvalidWalletsSort      = "sort %q is unknown, sort by created_at, username or balance with - prefix for descending order"
+	ErrInvalidCursor           = "provide cursor returned by the previous page"
+	ErrInvalidWalletsLimit     = "provide limit between 1 and %d"
+	ErrInvalidFeedPosition     = "provide valid position as after, 0 starts from the first transaction"
+	ErrInvalidFeedLimit        = "provide limit between 1 and %d"
+	ErrInvalidFeedWait         = "wait %q is invalid, provide a duration, e.g. 10s"
+	ErrConcurrentUpdate        = "wallet %s is changed concurrently, try again"
+	ErrEmptyBatch              = "provide at least one batch item"
+	ErrTooManyBatchItems       = "provide at most %d batch items"
+	ErrTooManyAtomicItems      = "provide at most %d items in an atomic batch"
+	ErrAtomicBatchesDisabled   = "atomic batches are disabled"
+	ErrInvalidBatchItem        = "item %d: %s"
+	ErrInvalidBatchItemType    = "type %q is unknown, use deposit, withdraw or transfer"
+	ErrTransferDetails         = "transfers don't have reference, description, metadata or unique reference"
+	ErrInvalidIdempotencyKey   = "provide valid idempotency key of at most %d characters"
+	ErrRepeatedIdempotencyKey  = "idempotency key %s is repeated by item %d"
+	ErrDuplicateBatchItem      = "idempotency key %s is already used by batch %s"
+	ErrConcurrentBatchItem     = "idempotency key %s is used by another batch concurrently"
+	ErrBatchNotFound           = "batch with id %s not found"
+	ErrAtomicBatchFailed       = "item %d failed, no item is applied: %s"
+	ErrAtomicBatchTimeout      = "items can't be applied in %s, no item is applied, submit fewer items"
+
+	ErrGraphqlOperationNotFound = "graphql operation %s not found"
+	ErrGraphqlMaxDepth          = "query depth %d exceeds the limit of %d"
+	ErrGraphqlMaxComplexity     = "query complexity %d exceeds the limit of %d"
 )
@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"strings"
+	"time"
+)
+
+// Event types of wallet streams
+const (
+	WalletCreatedEvent  = "wallet_created"
+	MoneyDepositedEvent = "money_deposited"
+	MoneyWithdrawnEvent = "money_withdrawn"
+	WalletFrozenEvent   = "wallet_frozen"
+)
+
+// walletStreamPrefix prefixes ids of wallets in names of their streams
+const walletStreamPrefix = "wallet-"
+
+// streamPageSize is how many events of a wallet stream are read at a time while loading it
+const streamPageSize = 500
+
+type (
+	// WalletCreated is the first event of every wallet stream
+	WalletCreated struct {
+		Username  string    `bson:"username"`
+		CreatedAt time.Time `bson:"created_at"`
+	}
+
+	// MoneyDeposited is raised when money is deposited to wallet, its event id is the id of Transaction
+	MoneyDeposited struct {
+		Transaction Transaction `bson:"transaction"`
+	}
+
+	// MoneyWithdrawn is raised when money is withdrawn from wallet, its event id is the id of Transaction
+	MoneyWithdrawn struct {
+		Transaction Transaction `bson:"transaction"`
+	}
+
+	// WalletFrozen is raised when wallet is frozen
+	WalletFrozen struct {
+		FrozenAt time.Time `bson:"frozen_at"`
+	}
+)
+
+// Events is the registry of event types of wallet streams, new event types are registered here and applied
+// by Wallet.apply
+var Events = newEventRegistry()
+
+func newEventRegistry() *eventstore.Registry {
+	r := eventstore.NewRegistry()
+	r.Register(WalletCreatedEvent, 1, WalletCreated{})
+	r.Register(MoneyDepositedEvent, 1, MoneyDeposited{})
+	r.Register(MoneyWithdrawnEvent, 1, MoneyWithdrawn{})
+	r.Register(WalletFrozenEvent, 1, WalletFrozen{})
+
+	return r
+}
+
+// StreamOf returns name of event stream of wallet
+func StreamOf(walletID string) string {
+	return walletStreamPrefix + walletID
+}
+
+// walletIDOf returns id of wallet of stream, false is returned if stream isn't a wallet stream
+func walletIDOf(stream string) (string, bool) {
+	return strings.CutPrefix(stream, walletStreamPrefix)
+}
+
+// LoadWallet loads wallet by applying events of its stream, nil is returned if it has none
+func LoadWallet(ctx context.Context, store eventstore.Store, walletID string) (*Wallet, error) {
+	return loadWallet(ctx, store, walletID, nil, func(eventstore.Event, any) bool { return true })
+}
+
+// loadWalletAt loads wallet by applying events of its stream up to version, nil is returned if it has none
+func loadWalletAt(ctx context.Context, store eventstore.Store, walletID string, version int64) (*Wallet, error) {
+	return loadWallet(ctx, store, walletID, nil, func(e eventstore.Event, _ any) bool { return e.Version <= version })
+}
+
+// LoadWalletAsOf loads wallet with its state as of asOf by applying events of its stream that occurred before
+// asOf to its latest snapshot before asOf, or from the first event if it has none. Nil is returned if it wasn't
+// created before asOf
+func LoadWalletAsOf(ctx context.Context, store eventstore.Store, snapshots SnapshotRepository, walletID string, asOf time.Time) (*Wallet, error) {
+	snapshot, err := snapshots.FindSnapshot(ctx, walletID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := loadWallet(ctx, store, walletID, snapshot, func(_ eventstore.Event, payload any) bool {
+		return occurredAt(payload).Before(asOf)
+	})
+	if wallet != nil {
+		wallet.AsOf = &asOf
+	}
+
+	return wallet, err
+}
+
+// loadWallet loads wallet by applying events of its stream that include accepts to snapshot, or from the first
+// event if snapshot is nil. Nil is returned if there is no snapshot and none is applied
+func loadWallet(ctx context.Context, store eventstore.Store, walletID string, snapshot *Snapshot, include func(e eventstore.Event, payload any) bool) (*Wallet, error) {
+	var wallet *Wallet
+	from := int64(1)
+	if snapshot != nil {
+		wallet, from = snapshot.wallet(), snapshot.StreamVersion+1
+	}
+
+	events, err := eventstore.ReadStreamForward(ctx, store, StreamOf(walletID), from, streamPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range events {
+		payload, err := Events.Decode(e)
+		if err != nil {
+			return nil, err
+		}
+		if !include(e, payload) {
+			continue
+		}
+		if wallet == nil {
+			wallet = &Wallet{ID: walletID}
+		}
+		if err = wallet.apply(payload); err != nil {
+			return nil, fmt.Errorf("event %s of wallet %s can't be applied: %w", e.ID, walletID, err)
+		}
+		wallet.StreamVersion = e.Version
+	}
+
+	return wallet, nil
+}
+
+// occurredAt returns the time event occurred at in the domain, which is kept for imported and migrated history
+// unlike the time it is recorded at
+func occurredAt(event any) time.Time {
+	switch e := event.(type) {
+	case WalletCreated:
+		return e.CreatedAt
+	case MoneyDeposited:
+		return e.Transaction.CreatedAt
+	case MoneyWithdrawn:
+		return e.Transaction.CreatedAt
+	case WalletFrozen:
+		return e.FrozenAt
+	}
+
+	return time.Time{}
+}
+
+// encodeEvents returns events raised by wallet encoded for its stream
+func encodeEvents(wallet *Wallet) ([]eventstore.Event, error) {
+	events := make([]eventstore.Event, len(wallet.Events))
+	for i, payload := range wallet.Events {
+		e, err := Events.Encode(payload)
+		if err != nil {
+			return nil, err
+		}
+		switch p := payload.(type) {
+		case MoneyDeposited:
+			e.ID = p.Transaction.ID
+		case MoneyWithdrawn:
+			e.ID = p.Transaction.ID
+		}
+		events[i] = e
+	}
+
+	return events, nil
+}
+
+// historyOf returns wallet with the events that record state of a wallet saved without them, e.g. an imported one.
+// Frozen wallets are frozen at frozenAt
+func historyOf(id, username string, createdAt time.Time, status WalletStatus, frozenAt time.Time, transactions []Transaction) *Wallet {
+	wallet := &Wallet{ID: id}
+	wallet.raise(WalletCreated{Username: username, CreatedAt: createdAt})
+	for _, t := range transactions {
+		if t.Type == WithdrawTransactionType {
+			wallet.raise(MoneyWithdrawn{Transaction: t})
+		} else {
+			wallet.raise(MoneyDeposited{Transaction: t})
+		}
+	}
+	if status == FrozenWalletStatus {
+		wallet.raise(WalletFrozen{FrozenAt: frozenAt})
+	}
+
+	return wallet
+}
+
+// appendHistory appends events of wallet returned by historyOf to its stream, which must have no events
+func appendHistory(ctx context.Context, store eventstore.Store, wallet *Wallet) error {
+	events, err := encodeEvents(wallet)
+	if err != nil {
+		return err
+	}
+	_, err = store.Append(ctx, StreamOf(wallet.ID), eventstore.NoStream, events...)
+
+	return err
+}
@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"io"
+	"time"
+)
+
+type (
+	// ExportRecord is a wallet with its transactions, exports have one json record per line
+	ExportRecord struct {
+		ID           string        `json:"id"`
+		Username     string        `json:"username"`
+		Status       WalletStatus  `json:"status,omitempty"`
+		CreatedAt    time.Time     `json:"created_at"`
+		Transactions []Transaction `json:"transactions"`
+	}
+
+	// ImportResult is the number of imported wallets and wallets skipped since they already exist
+	ImportResult struct {
+		Imported int `json:"imported"`
+		Skipped  int `json:"skipped"`
+	}
+)
+
+// Export writes every wallet with its transactions to w, it returns the number of exported wallets
+func Export(ctx context.Context, repository Repository, w io.Writer) (int, error) {
+	encoder := json.NewEncoder(w)
+	exported := 0
+
+	err := repository.IterateWallets(ctx, func(wallet *Wallet) error {
+		transactions, err := repository.FindTransactionsByWalletID(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		if transactions == nil {
+			transactions = []Transaction{}
+		}
+
+		exported++
+		return encoder.Encode(ExportRecord{
+			ID:           wallet.ID,
+			Username:     wallet.Username,
+			Status:       wallet.Status,
+			CreatedAt:    wallet.CreatedAt,
+			Transactions: transactions,
+		})
+	})
+
+	return exported, err
+}
+
+// Import imports records written by Export from r. Wallets that already exist are skipped with their
+// transactions, a wallet is inserted with its transactions, their journal entries and its event stream in one unit
+// of work so that a skipped wallet is always complete
+func Import(ctx context.Context, repository Repository, events eventstore.Store, l *ledger.Ledger, r io.Reader) (ImportResult, error) {
+	var result ImportResult
+	decoder := json.NewDecoder(r)
+
+	for n := 1; ; n++ {
+		record := new(ExportRecord)
+		if err := decoder.Decode(record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			return result, fmt.Errorf("record %d is invalid: %w", n, err)
+		}
+		if err := record.validate(); err != nil {
+			return result, fmt.Errorf("record %d is invalid: %w", n, err)
+		}
+
+		existing, err := repository.FindWalletByID(ctx, record.ID)
+		if err != nil {
+			return result, err
+		}
+		if existing != nil {
+			result.Skipped++
+			continue
+		}
+
+		if err = repository.WithinTransaction(ctx, func(ctx context.Context) error {
+			history := historyOf(record.ID, record.Username, record.CreatedAt, record.Status, record.CreatedAt, record.Transactions)
+			if err := appendHistory(ctx, events, history); err != nil {
+				return err
+			}
+			if len(record.Transactions) > 0 {
+				if err := repository.InsertTransactions(ctx, record.Transactions...); err != nil {
+					return err
+				}
+				entries, ex := journalEntriesOf(record.Transactions...)
+				if ex != nil {
+					return ex
+				}
+				if err := l.Post(ctx, entries...); err != nil {
+					return err
+				}
+			}
+			return repository.InsertWallet(ctx, &Wallet{
+				ID:        record.ID,
+				Username:  record.Username,
+				Status:    record.Status,
+				CreatedAt: record.CreatedAt,
+				UpdatedAt: record.CreatedAt,
+				Version:   int64(len(record.Transactions)),
+			})
+		}); err != nil {
+			return result, err
+		}
+		result.Imported++
+	}
+}
+
+func (r *ExportRecord) validate() error {
+	if r.ID == "" {
+		return errors.New(ErrInvalidWalletID)
+	}
+	if r.Username == "" {
+		return errors.New(ErrInvalidUsername)
+	}
+	for _, t := range r.Transactions {
+		if t.ID == "" || t.WalletID != r.ID {
+			return fmt.Errorf("transaction %q doesn't belong to wallet %s", t.ID, r.ID)
+		}
+		if t.Type != DepositTransactionType && t.Type != WithdrawTransactionType {
+			return fmt.Errorf("transaction %s has unknown type %q", t.ID, t.Type)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"time"
+)
+
+// Limits of the transaction feed
+const (
+	DefaultFeedLimit = 100
+	MaxFeedLimit     = 1000
+)
+
+type (
+	// Feed serves transactions of every wallet in the order of their positions in the event store. Positions only
+	// become readable in ascending order, so consumers resume after the last position they processed
+	Feed struct {
+		events       eventstore.Store
+		maxWait      time.Duration
+		pollInterval time.Duration
+	}
+
+	// FeedEntry is a transaction of the feed with its position
+	FeedEntry struct {
+		Position    int64       `json:"position"`
+		Transaction Transaction `json:"transaction"`
+	}
+
+	// FeedPage is a page of the feed, the next page is requested after Next. Next moves past events that aren't
+	// transactions, e.g. wallet creations, so it can be ahead of the last transaction of the page
+	FeedPage struct {
+		Transactions []FeedEntry `json:"transactions"`
+		Next         int64       `json:"next"`
+	}
+)
+
+// NewFeed creates new instance of Feed, long polls wait at most maxWait and read events every pollInterval
+func NewFeed(events eventstore.Store, maxWait, pollInterval time.Duration) *Feed {
+	return &Feed{events: events, maxWait: maxWait, pollInterval: pollInterval}
+}
+
+// Read reads a page of transactions after the position of req. If there are none and req waits, events are
+// polled until a transaction is appended or the wait, at most the max wait of feed, is over
+func (f *Feed) Read(ctx context.Context, req *FeedRequest) (*FeedPage, *errr.Error) {
+	limit, wait, err := f.feedQueryOf(req)
+	if err != nil {
+		return nil, errr.ThrowBadRequestError(err)
+	}
+
+	page, err := f.read(ctx, req.After, limit)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if len(page.Transactions) > 0 || wait == 0 {
+		return page, nil
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return page, nil
+		case <-deadline.C:
+			return page, nil
+		case <-ticker.C:
+		}
+
+		if page, err = f.read(ctx, page.Next, limit); err != nil {
+			return nil, errr.ThrowInternalServerError(err)
+		}
+		if len(page.Transactions) > 0 {
+			return page, nil
+		}
+	}
+}
+
+// read reads at most limit transactions after position, events that aren't transactions are skipped
+func (f *Feed) read(ctx context.Context, after int64, limit int) (*FeedPage, error) {
+	page := &FeedPage{Transactions: []FeedEntry{}, Next: after}
+	for {
+		events, err := f.events.ReadAll(ctx, page.Next, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if e.Type == MoneyDepositedEvent || e.Type == MoneyWithdrawnEvent {
+				payload, err := Events.Decode(e)
+				if err != nil {
+					return nil, err
+				}
+				switch p := payload.(type) {
+				case MoneyDeposited:
+					page.Transactions = append(page.Transactions, FeedEntry{Position: e.Position, Transaction: p.Transaction})
+				case MoneyWithdrawn:
+					page.Transactions = append(page.Transactions, FeedEntry{Position: e.Position, Transaction: p.Transaction})
+				}
+			}
+			page.Next = e.Position
+			if len(page.Transactions) == limit {
+				return page, nil
+			}
+		}
+		if len(events) < limit {
+			return page, nil
+		}
+	}
+}
+
+// feedQueryOf validates req and returns its limit and wait, waits longer than the max wait are shortened
+func (f *Feed) feedQueryOf(req *FeedRequest) (int, time.Duration, error) {
+	if req.After < 0 {
+		return 0, 0, errors.New(ErrInvalidFeedPosition)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultFeedLimit
+	}
+	if limit < 1 || limit > MaxFeedLimit {
+		return 0, 0, fmt.Errorf(ErrInvalidFeedLimit, MaxFeedLimit)
+	}
+
+	var wait time.Duration
+	if req.Wait != "" {
+		var err error
+		if wait, err = time.ParseDuration(req.Wait); err != nil || wait < 0 {
+			return 0, 0, fmt.Errorf(ErrInvalidFeedWait, req.Wait)
+		}
+	}
+
+	return limit, min(wait, f.maxWait), nil
+}
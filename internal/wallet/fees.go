@@ -0,0 +1,37 @@
+package wallet
+
+import "math"
+
+// feeDescription is the description of fee withdrawals
+const feeDescription = "fee"
+
+type (
+	// FeeSchedule is the fee of an operation, Rate of its amount plus Fixed, at most Max unless Max is 0
+	FeeSchedule struct {
+		Fixed float32
+		Rate  float64
+		Max   float32
+	}
+
+	// FeeSchedules are fee schedules of withdrawals and transfers, zero schedules charge no fee
+	FeeSchedules struct {
+		Withdraw FeeSchedule
+		Transfer FeeSchedule
+	}
+)
+
+// FeeOf returns fee of money, it is rounded to the nearest minor unit
+func (f FeeSchedule) FeeOf(money Money) Money {
+	fee := math.Round(float64(Money{Amount: f.Fixed}.MinorUnits()) + f.Rate*float64(money.MinorUnits()))
+	if max := float64(Money{Amount: f.Max}.MinorUnits()); max > 0 && fee > max {
+		fee = max
+	}
+
+	return Money{Amount: float32(fee / 100)}
+}
+
+// chargeFee charges fee of schedule for the last transaction of wallet
+func chargeFee(wallet *Wallet, schedule FeeSchedule) error {
+	t := wallet.Changes[len(wallet.Changes)-1]
+	return wallet.ChargeFee(schedule.FeeOf(t.Money), t)
+}
@@ -0,0 +1,502 @@
+package wallet
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/ybalcin/wallet-service/pkg/dataloader"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/openapi"
+	"github.com/ybalcin/wallet-service/pkg/response"
+	"sort"
+	"sync/atomic"
+)
+
+// DefaultGraphqlLimits is the default GraphqlLimits of GraphqlApi
+var DefaultGraphqlLimits = GraphqlLimits{MaxDepth: 8, MaxComplexity: 2000}
+
+type (
+	GraphqlRequest struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+
+	// GraphqlApi is the graphql transport of wallet use cases
+	GraphqlApi struct {
+		service    Service
+		repository Repository
+		schema     graphql.Schema
+		limits     atomic.Pointer[GraphqlLimits]
+	}
+
+	// GraphqlLimits limits depth and complexity of graphql operations
+	GraphqlLimits struct {
+		MaxDepth      int
+		MaxComplexity int
+	}
+
+	// walletStats is aggregated stats of wallet transactions
+	walletStats struct {
+		TransactionCount int
+		DepositCount     int
+		WithdrawCount    int
+		TotalDeposited   Money
+		TotalWithdrawn   Money
+	}
+
+	// metadataEntry is a key and value of transaction metadata
+	metadataEntry struct {
+		Key   string
+		Value string
+	}
+
+	// graphqlLoaders batches repository reads of a graphql request
+	graphqlLoaders struct {
+		wallets      *dataloader.Loader[string, *Wallet]
+		transactions *dataloader.Loader[string, []Transaction]
+	}
+
+	graphqlLoadersKey struct{}
+)
+
+// NewGraphqlApi creates new instance of GraphqlApi
+func NewGraphqlApi(service Service, repository Repository, limits GraphqlLimits) (*GraphqlApi, error) {
+	a := &GraphqlApi{service: service, repository: repository}
+	a.SetLimits(limits)
+
+	schema, err := a.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	a.schema = schema
+
+	return a, nil
+}
+
+// SetLimits sets limits of operations, it takes effect on the next operation
+func (a *GraphqlApi) SetLimits(limits GraphqlLimits) {
+	a.limits.Store(&limits)
+}
+
+func (a *GraphqlApi) AddRoutesTo(r fiber.Router) {
+	r.Post("/graphql", a.Execute)
+}
+
+// Routes describes the routes added by AddRoutesTo for the api documentation
+func (a *GraphqlApi) Routes() []openapi.Route {
+	return []openapi.Route{
+		{
+			Method:   fiber.MethodPost,
+			Path:     "/graphql",
+			Summary:  "Execute graphql operation over wallets and transactions",
+			Tags:     []string{"graphql"},
+			Request:  GraphqlRequest{},
+			Response: graphql.Result{},
+			Errors:   []int{fiber.StatusBadRequest},
+		},
+	}
+}
+
+func (a *GraphqlApi) Execute(c *fiber.Ctx) error {
+	req := new(GraphqlRequest)
+	if err := c.BodyParser(req); err != nil {
+		return response.New(c).Error(errr.ThrowBadRequestError(err)).JSON()
+	}
+
+	return response.New(c).Data(a.Do(c.UserContext(), req)).JSON()
+}
+
+// Do validates request against limits and executes it
+func (a *GraphqlApi) Do(ctx context.Context, req *GraphqlRequest) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if err = checkGraphqlLimits(a.schema, doc, req.OperationName, req.Variables, *a.limits.Load()); err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	return graphql.Do(graphql.Params{
+		Schema:         a.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        context.WithValue(ctx, graphqlLoadersKey{}, a.newLoaders()),
+	})
+}
+
+func (a *GraphqlApi) newLoaders() *graphqlLoaders {
+	return &graphqlLoaders{
+		wallets: dataloader.New(func(ctx context.Context, ids []string) (map[string]*Wallet, error) {
+			wallets, err := a.repository.FindWalletsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			res := make(map[string]*Wallet, len(wallets))
+			for _, w := range wallets {
+				res[w.ID] = w
+			}
+			return res, nil
+		}),
+		transactions: dataloader.New(func(ctx context.Context, walletIDs []string) (map[string][]Transaction, error) {
+			transactions, err := a.repository.FindTransactionsByWalletIDs(ctx, walletIDs)
+			if err != nil {
+				return nil, err
+			}
+
+			res := make(map[string][]Transaction, len(walletIDs))
+			for _, t := range transactions {
+				res[t.WalletID] = append(res[t.WalletID], t)
+			}
+			return res, nil
+		}),
+	}
+}
+
+func loadersFrom(ctx context.Context) *graphqlLoaders {
+	return ctx.Value(graphqlLoadersKey{}).(*graphqlLoaders)
+}
+
+func (a *GraphqlApi) newSchema() (graphql.Schema, error) {
+	moneyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Money",
+		Fields: graphql.Fields{
+			"amount": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	transactionTypeEnum := graphql.NewEnum(graphql.EnumConfig{
+		Name: "TransactionType",
+		Values: graphql.EnumValueConfigMap{
+			"DEPOSIT":  &graphql.EnumValueConfig{Value: DepositTransactionType},
+			"WITHDRAW": &graphql.EnumValueConfig{Value: WithdrawTransactionType},
+		},
+	})
+
+	metadataEntryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "MetadataEntry",
+		Fields: graphql.Fields{
+			"key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	metadataEntryInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "MetadataEntryInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"key":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	// details are embedded in Transaction, the default resolver doesn't look into embedded structs
+	transactionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Transaction",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"walletId":  &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"type":      &graphql.Field{Type: graphql.NewNonNull(transactionTypeEnum)},
+			"money":     &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"reference": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nonEmpty(transactionDetailsOf(p.Source).Reference), nil
+				},
+			},
+			"description": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nonEmpty(transactionDetailsOf(p.Source).Description), nil
+				},
+			},
+			"metadata": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(metadataEntryType))),
+				Description: "Metadata of transaction in ascending key order",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return metadataEntries(transactionDetailsOf(p.Source).Metadata), nil
+				},
+			},
+		},
+	})
+
+	statsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "WalletStats",
+		Fields: graphql.Fields{
+			"transactionCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"depositCount":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"withdrawCount":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"totalDeposited":   &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+			"totalWithdrawn":   &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		},
+	})
+
+	walletType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Wallet",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"username": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"balance":  &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+			"transactions": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(transactionType))),
+				Description: "Transactions of wallet from newest to oldest, limited to the last n transactions if last is given",
+				Args: graphql.FieldConfigArgument{
+					"last": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: a.resolveTransactions,
+			},
+			"stats": &graphql.Field{
+				Type:    graphql.NewNonNull(statsType),
+				Resolve: a.resolveStats,
+			},
+		},
+	})
+
+	transferType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TransferResult",
+		Fields: graphql.Fields{
+			"from": &graphql.Field{Type: graphql.NewNonNull(walletType)},
+			"to":   &graphql.Field{Type: graphql.NewNonNull(walletType)},
+		},
+	})
+
+	moneyArgs := graphql.FieldConfigArgument{
+		"walletId":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		"amount":          &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+		"reference":       &graphql.ArgumentConfig{Type: graphql.String},
+		"description":     &graphql.ArgumentConfig{Type: graphql.String},
+		"metadata":        &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(metadataEntryInput))},
+		"uniqueReference": &graphql.ArgumentConfig{Type: graphql.Boolean},
+	}
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"wallet": &graphql.Field{
+					Type: walletType,
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					},
+					Resolve: a.resolveWallet,
+				},
+				"wallets": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(walletType)),
+					Args: graphql.FieldConfigArgument{
+						"ids": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+					},
+					Resolve: a.resolveWallets,
+				},
+			},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"deposit": &graphql.Field{
+					Type:    graphql.NewNonNull(walletType),
+					Args:    moneyArgs,
+					Resolve: a.resolveDeposit,
+				},
+				"withdraw": &graphql.Field{
+					Type:    graphql.NewNonNull(walletType),
+					Args:    moneyArgs,
+					Resolve: a.resolveWithdraw,
+				},
+				"transfer": &graphql.Field{
+					Type: graphql.NewNonNull(transferType),
+					Args: graphql.FieldConfigArgument{
+						"fromWalletId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"toWalletId":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"amount":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+					},
+					Resolve: a.resolveTransfer,
+				},
+			},
+		}),
+	})
+}
+
+// loadWallet loads wallet and its transactions in batches and returns thunk of wallet with current state
+func (a *GraphqlApi) loadWallet(ctx context.Context, id string) func() (*Wallet, error) {
+	loaders := loadersFrom(ctx)
+	walletThunk := loaders.wallets.Load(ctx, id)
+	transactionsThunk := loaders.transactions.Load(ctx, id)
+
+	return func() (*Wallet, error) {
+		w, err := walletThunk()
+		if err != nil || w == nil {
+			return nil, err
+		}
+		transactions, err := transactionsThunk()
+		if err != nil {
+			return nil, err
+		}
+
+		wallet := &Wallet{ID: w.ID, Username: w.Username, CreatedAt: w.CreatedAt}
+		wallet.Mutate(transactions...)
+
+		return wallet, nil
+	}
+}
+
+func (a *GraphqlApi) resolveWallet(p graphql.ResolveParams) (interface{}, error) {
+	thunk := a.loadWallet(p.Context, p.Args["id"].(string))
+
+	return func() (interface{}, error) {
+		wallet, err := thunk()
+		if err != nil || wallet == nil {
+			return nil, err
+		}
+		return wallet, nil
+	}, nil
+}
+
+func (a *GraphqlApi) resolveWallets(p graphql.ResolveParams) (interface{}, error) {
+	ids := p.Args["ids"].([]interface{})
+	res := make([]interface{}, len(ids))
+	for i, id := range ids {
+		thunk := a.loadWallet(p.Context, id.(string))
+		res[i] = func() (interface{}, error) {
+			wallet, err := thunk()
+			if err != nil || wallet == nil {
+				return nil, err
+			}
+			return wallet, nil
+		}
+	}
+
+	return res, nil
+}
+
+func (a *GraphqlApi) resolveTransactions(p graphql.ResolveParams) (interface{}, error) {
+	wallet := p.Source.(*Wallet)
+	thunk := loadersFrom(p.Context).transactions.Load(p.Context, wallet.ID)
+
+	return func() (interface{}, error) {
+		transactions, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+
+		n := len(transactions)
+		if last, ok := p.Args["last"].(int); ok && last >= 0 && last < n {
+			n = last
+		}
+
+		res := make([]Transaction, 0, n)
+		for i := len(transactions) - 1; i >= len(transactions)-n; i-- {
+			res = append(res, transactions[i])
+		}
+		return res, nil
+	}, nil
+}
+
+func (a *GraphqlApi) resolveStats(p graphql.ResolveParams) (interface{}, error) {
+	wallet := p.Source.(*Wallet)
+	thunk := loadersFrom(p.Context).transactions.Load(p.Context, wallet.ID)
+
+	return func() (interface{}, error) {
+		transactions, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+
+		stats := &walletStats{TransactionCount: len(transactions)}
+		for _, t := range transactions {
+			switch t.Type {
+			case DepositTransactionType:
+				stats.DepositCount++
+				t.Money.Add(&stats.TotalDeposited)
+			case WithdrawTransactionType:
+				stats.WithdrawCount++
+				t.Money.Add(&stats.TotalWithdrawn)
+			}
+		}
+		return stats, nil
+	}, nil
+}
+
+func (a *GraphqlApi) resolveDeposit(p graphql.ResolveParams) (interface{}, error) {
+	wallet, err := a.service.DepositMoney(p.Context, p.Args["walletId"].(string), moneyTransactionRequestOf(p.Args))
+
+	return wallet, graphqlError(err)
+}
+
+func (a *GraphqlApi) resolveWithdraw(p graphql.ResolveParams) (interface{}, error) {
+	wallet, err := a.service.WithdrawMoney(p.Context, p.Args["walletId"].(string), moneyTransactionRequestOf(p.Args))
+
+	return wallet, graphqlError(err)
+}
+
+// moneyTransactionRequestOf creates request of deposit or withdraw args, optional args may be missing
+func moneyTransactionRequestOf(args map[string]interface{}) *MoneyTransactionRequest {
+	req := &MoneyTransactionRequest{Amount: float32(args["amount"].(float64))}
+	req.Reference, _ = args["reference"].(string)
+	req.Description, _ = args["description"].(string)
+	req.UniqueReference, _ = args["uniqueReference"].(bool)
+	if entries, ok := args["metadata"].([]interface{}); ok {
+		req.Metadata = make(map[string]string, len(entries))
+		for _, e := range entries {
+			entry := e.(map[string]interface{})
+			req.Metadata[entry["key"].(string)] = entry["value"].(string)
+		}
+	}
+
+	return req
+}
+
+// transactionDetailsOf returns details of transaction source of a field
+func transactionDetailsOf(source interface{}) TransactionDetails {
+	switch t := source.(type) {
+	case Transaction:
+		return t.TransactionDetails
+	case *Transaction:
+		return t.TransactionDetails
+	}
+
+	return TransactionDetails{}
+}
+
+func metadataEntries(metadata map[string]string) []metadataEntry {
+	entries := make([]metadataEntry, 0, len(metadata))
+	for k, v := range metadata {
+		entries = append(entries, metadataEntry{Key: k, Value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	return entries
+}
+
+// nonEmpty returns nil for empty strings so that they are null in graphql
+func nonEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+func (a *GraphqlApi) resolveTransfer(p graphql.ResolveParams) (interface{}, error) {
+	res, err := a.service.TransferMoney(p.Context, p.Args["fromWalletId"].(string), &TransferMoneyRequest{
+		ToWalletID: p.Args["toWalletId"].(string),
+		Amount:     float32(p.Args["amount"].(float64)),
+	})
+
+	return res, graphqlError(err)
+}
+
+// graphqlError converts nil *errr.Error to untyped nil error
+func graphqlError(err *errr.Error) error {
+	if err == nil {
+		return nil
+	}
+
+	return err
+}
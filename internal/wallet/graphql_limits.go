@@ -0,0 +1,185 @@
+package wallet
+
+import (
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"strconv"
+)
+
+// unboundedListSize is the assumed size of list fields that are not bounded by an argument
+const unboundedListSize = 50
+
+type graphqlAnalyzer struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// checkGraphqlLimits returns error if depth or complexity of the executed operation of doc exceeds limits.
+// Complexity is the count of resolved fields where fields of list items are multiplied by the list size.
+func checkGraphqlLimits(schema graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}, limits GraphqlLimits) error {
+	a := &graphqlAnalyzer{
+		schema:    schema,
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+	}
+
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch d := def.(type) {
+		case *ast.FragmentDefinition:
+			a.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		// graphql validation reports missing or ambiguous operations
+		if operationName == "" {
+			return nil
+		}
+		return fmt.Errorf(ErrGraphqlOperationNotFound, operationName)
+	}
+
+	var root graphql.Type = schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+
+	depth, complexity := a.analyze(root, operation.SelectionSet, 1, map[string]bool{})
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return fmt.Errorf(ErrGraphqlMaxDepth, depth, limits.MaxDepth)
+	}
+	if limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+		return fmt.Errorf(ErrGraphqlMaxComplexity, complexity, limits.MaxComplexity)
+	}
+
+	return nil
+}
+
+// analyze returns depth and complexity of selection set of parent type
+func (a *graphqlAnalyzer) analyze(parent graphql.Type, set *ast.SelectionSet, depth int, visiting map[string]bool) (int, int) {
+	if set == nil {
+		return depth - 1, 0
+	}
+
+	maxDepth, complexity := depth, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			fieldType, isList := a.fieldType(parent, s.Name.Value)
+			d, c = a.analyze(fieldType, s.SelectionSet, depth+1, visiting)
+			if s.SelectionSet == nil {
+				d = depth
+			}
+			c = 1 + a.multiplier(s, isList)*c
+		case *ast.InlineFragment:
+			t := parent
+			if s.TypeCondition != nil {
+				t = a.schema.Type(s.TypeCondition.Name.Value)
+			}
+			d, c = a.analyze(t, s.SelectionSet, depth, visiting)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := a.fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			d, c = a.analyze(a.schema.Type(fragment.TypeCondition.Name.Value), fragment.SelectionSet, depth, visiting)
+			delete(visiting, name)
+		}
+
+		if d > maxDepth {
+			maxDepth = d
+		}
+		complexity += c
+	}
+
+	return maxDepth, complexity
+}
+
+// fieldType returns named type of field of parent and whether field is a list
+func (a *graphqlAnalyzer) fieldType(parent graphql.Type, name string) (graphql.Type, bool) {
+	object, ok := parent.(*graphql.Object)
+	if !ok {
+		return nil, false
+	}
+	field, ok := object.Fields()[name]
+	if !ok {
+		return nil, false
+	}
+
+	t, isList := field.Type, false
+	for {
+		switch wrapper := t.(type) {
+		case *graphql.NonNull:
+			t = wrapper.OfType
+			continue
+		case *graphql.List:
+			t, isList = wrapper.OfType, true
+			continue
+		}
+		return t, isList
+	}
+}
+
+// multiplier returns how many times the selection set of field is resolved
+func (a *graphqlAnalyzer) multiplier(field *ast.Field, isList bool) int {
+	if !isList {
+		return 1
+	}
+	// metadata of transactions is bounded when it is saved
+	if field.Name.Value == "metadata" {
+		return MaxMetadataKeys
+	}
+
+	for _, arg := range field.Arguments {
+		switch arg.Name.Value {
+		case "last":
+			if n, ok := a.intValue(arg.Value); ok && n >= 0 {
+				return n
+			}
+		case "ids":
+			if n, ok := a.listLength(arg.Value); ok {
+				return n
+			}
+		}
+	}
+
+	return unboundedListSize
+}
+
+func (a *graphqlAnalyzer) intValue(v ast.Value) (int, bool) {
+	switch value := v.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(value.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := a.variables[value.Name.Value].(type) {
+		case int:
+			return n, true
+		case float64:
+			return int(n), true
+		}
+	}
+
+	return 0, false
+}
+
+func (a *graphqlAnalyzer) listLength(v ast.Value) (int, bool) {
+	switch value := v.(type) {
+	case *ast.ListValue:
+		return len(value.Values), true
+	case *ast.Variable:
+		if list, ok := a.variables[value.Name.Value].([]interface{}); ok {
+			return len(list), true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,161 @@
+package wallet
+
+import (
+	"context"
+	walletv1 "github.com/ybalcin/wallet-service/pkg/pb/wallet/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GrpcServer is the grpc transport of wallet use cases
+type GrpcServer struct {
+	walletv1.UnimplementedWalletServiceServer
+
+	service     Service
+	broadcaster *Broadcaster
+}
+
+// NewGrpcServer creates new instance of GrpcServer
+func NewGrpcServer(service Service, broadcaster *Broadcaster) *GrpcServer {
+	return &GrpcServer{service: service, broadcaster: broadcaster}
+}
+
+// CreateWallet creates wallet
+func (s *GrpcServer) CreateWallet(ctx context.Context, req *walletv1.CreateWalletRequest) (*walletv1.CreateWalletResponse, error) {
+	id, err := s.service.CreateWallet(ctx, &CreateWalletRequest{Username: req.GetUsername()})
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletv1.CreateWalletResponse{Id: id.Id}, nil
+}
+
+// DepositMoney deposits(adds) money to wallet
+func (s *GrpcServer) DepositMoney(ctx context.Context, req *walletv1.DepositMoneyRequest) (*walletv1.DepositMoneyResponse, error) {
+	wallet, err := s.service.DepositMoney(ctx, req.GetWalletId(), &MoneyTransactionRequest{
+		Amount:          req.GetAmount(),
+		Reference:       req.GetReference(),
+		Description:     req.GetDescription(),
+		Metadata:        req.GetMetadata(),
+		UniqueReference: req.GetUniqueReference(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletv1.DepositMoneyResponse{Wallet: walletToProto(wallet)}, nil
+}
+
+// WithdrawMoney withdraws(subs) money from wallet
+func (s *GrpcServer) WithdrawMoney(ctx context.Context, req *walletv1.WithdrawMoneyRequest) (*walletv1.WithdrawMoneyResponse, error) {
+	wallet, err := s.service.WithdrawMoney(ctx, req.GetWalletId(), &MoneyTransactionRequest{
+		Amount:          req.GetAmount(),
+		Reference:       req.GetReference(),
+		Description:     req.GetDescription(),
+		Metadata:        req.GetMetadata(),
+		UniqueReference: req.GetUniqueReference(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletv1.WithdrawMoneyResponse{Wallet: walletToProto(wallet)}, nil
+}
+
+// GetWallet gets wallet with current state
+func (s *GrpcServer) GetWallet(ctx context.Context, req *walletv1.GetWalletRequest) (*walletv1.GetWalletResponse, error) {
+	wallet, err := s.service.GetWallet(ctx, req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletv1.GetWalletResponse{Wallet: walletToProto(wallet)}, nil
+}
+
+// ListTransactions lists transaction history of wallet
+func (s *GrpcServer) ListTransactions(ctx context.Context, req *walletv1.ListTransactionsRequest) (*walletv1.ListTransactionsResponse, error) {
+	transactions, err := s.service.GetTransactions(ctx, req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &walletv1.ListTransactionsResponse{Transactions: make([]*walletv1.Transaction, len(transactions))}
+	for i, t := range transactions {
+		res.Transactions[i] = transactionToProto(t)
+	}
+
+	return res, nil
+}
+
+// WatchBalance streams current balance of wallet and every balance update after it
+func (s *GrpcServer) WatchBalance(req *walletv1.WatchBalanceRequest, stream walletv1.WalletService_WatchBalanceServer) error {
+	ctx := stream.Context()
+
+	// subscribe before reading current state to not miss updates in between
+	updates, unsubscribe := s.broadcaster.Subscribe(req.GetWalletId())
+	defer unsubscribe()
+
+	wallet, ex := s.service.GetWallet(ctx, req.GetWalletId())
+	if ex != nil {
+		return ex
+	}
+	if err := stream.Send(&walletv1.WatchBalanceResponse{
+		WalletId: wallet.ID,
+		Balance:  moneyToProto(wallet.Balance),
+	}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&walletv1.WatchBalanceResponse{
+				WalletId:    update.WalletID,
+				Balance:     moneyToProto(update.Balance),
+				Transaction: transactionToProto(update.Transaction),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func walletToProto(w *Wallet) *walletv1.Wallet {
+	return &walletv1.Wallet{
+		Id:       w.ID,
+		Username: w.Username,
+		Balance:  moneyToProto(w.Balance),
+	}
+}
+
+func moneyToProto(m Money) *walletv1.Money {
+	return &walletv1.Money{Amount: m.Amount}
+}
+
+func transactionToProto(t Transaction) *walletv1.Transaction {
+	return &walletv1.Transaction{
+		Id:          t.ID,
+		WalletId:    t.WalletID,
+		Type:        transactionTypeToProto(t.Type),
+		Money:       moneyToProto(t.Money),
+		CreatedAt:   timestamppb.New(t.CreatedAt),
+		Reference:   t.Reference,
+		Description: t.Description,
+		Metadata:    t.Metadata,
+	}
+}
+
+func transactionTypeToProto(t TransactionType) walletv1.TransactionType {
+	switch t {
+	case DepositTransactionType:
+		return walletv1.TransactionType_TRANSACTION_TYPE_DEPOSIT
+	case WithdrawTransactionType:
+		return walletv1.TransactionType_TRANSACTION_TYPE_WITHDRAW
+	}
+
+	return walletv1.TransactionType_TRANSACTION_TYPE_UNSPECIFIED
+}
@@ -0,0 +1,61 @@
+package wallet
+
+import (
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// idempotencyKeysCollection is the collection of idempotency keys of requests
+const idempotencyKeysCollection = "idempotency_keys"
+
+// IdempotencyKeyTTL is how long idempotency keys are kept
+const IdempotencyKeyTTL = 24 * time.Hour
+
+var (
+	// WalletIndexes serve lookups by username and iterating wallets in creation order
+	WalletIndexes = []migration.Index{
+		{Collection: walletsCollection, Keys: bson.D{{Key: "username", Value: 1}}},
+		{Collection: walletsCollection, Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+
+	// WalletViewIndexes serve the back-office listing of wallet views by status and sorted by creation time,
+	// balance or username
+	WalletViewIndexes = []migration.Index{
+		{Collection: walletViewsCollection, Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{Collection: walletViewsCollection, Keys: bson.D{{Key: "username", Value: 1}, {Key: "_id", Value: 1}}},
+		{Collection: walletViewsCollection, Keys: bson.D{{Key: "balance", Value: 1}, {Key: "_id", Value: 1}}},
+		{Collection: walletViewsCollection, Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+
+	// SnapshotIndexes serve the latest snapshots of wallets
+	SnapshotIndexes = []migration.Index{
+		{Collection: walletSnapshotsCollection, Keys: bson.D{{Key: "wallet_id", Value: 1}, {Key: "stream_version", Value: -1}}},
+	}
+
+	// TransactionIndexes serve transaction history of wallets in creation order
+	TransactionIndexes = []migration.Index{
+		{Collection: transactionsCollection, Keys: bson.D{{Key: "wallet_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	}
+
+	// TransactionCreationIndexes serve transactions of all wallets in a time range
+	TransactionCreationIndexes = []migration.Index{
+		{Collection: transactionsCollection, Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+
+	// TransactionReferenceIndexes serve transactions by reference, transactions without reference aren't indexed
+	TransactionReferenceIndexes = []migration.Index{
+		{Collection: transactionsCollection, Keys: bson.D{{Key: "reference", Value: 1}, {Key: "wallet_id", Value: 1}}, Sparse: true},
+	}
+
+	// BatchIndexes serve claiming pending batches and batches with expired leases in submission order
+	BatchIndexes = []migration.Index{
+		{Collection: batchesCollection, Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+
+	// IdempotencyKeyIndexes keep idempotency keys unique and delete them after IdempotencyKeyTTL
+	IdempotencyKeyIndexes = []migration.Index{
+		{Collection: idempotencyKeysCollection, Keys: bson.D{{Key: "key", Value: 1}}, Unique: true},
+		{Collection: idempotencyKeysCollection, Keys: bson.D{{Key: "created_at", Value: 1}}, ExpireAfter: IdempotencyKeyTTL},
+	}
+)
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+)
+
+// LedgerService is a Service decorator that posts journal entries of money movements in the unit of work of the
+// movement, so transactions of wallets are never saved without their entries. Deposits are received through
+// cash-in clearing, withdrawals are paid through cash-out clearing, transfers move money between wallets and
+// fees are credited to the fees account
+type LedgerService struct {
+	Service
+	repository Repository
+	ledger     *ledger.Ledger
+}
+
+// NewLedgerService creates new instance of LedgerService, repository provides the unit of work
+func NewLedgerService(service Service, repository Repository, l *ledger.Ledger) *LedgerService {
+	return &LedgerService{Service: service, repository: repository, ledger: l}
+}
+
+// DepositMoney deposits money and posts its journal entry
+func (s *LedgerService) DepositMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	if ex := checkMinorUnits(req.Amount); ex != nil {
+		return nil, ex
+	}
+
+	var wallet *Wallet
+	ex := s.inTransaction(ctx, func(ctx context.Context) ([]*ledger.JournalEntry, *errr.Error) {
+		var ex *errr.Error
+		if wallet, ex = s.Service.DepositMoney(ctx, walletID, req); ex != nil {
+			return nil, ex
+		}
+		return journalEntriesOf(wallet.Changes...)
+	})
+	if ex != nil {
+		return nil, ex
+	}
+
+	return wallet, nil
+}
+
+// WithdrawMoney withdraws money and posts its journal entry
+func (s *LedgerService) WithdrawMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	if ex := checkMinorUnits(req.Amount); ex != nil {
+		return nil, ex
+	}
+
+	var wallet *Wallet
+	ex := s.inTransaction(ctx, func(ctx context.Context) ([]*ledger.JournalEntry, *errr.Error) {
+		var ex *errr.Error
+		if wallet, ex = s.Service.WithdrawMoney(ctx, walletID, req); ex != nil {
+			return nil, ex
+		}
+		return journalEntriesOf(wallet.Changes...)
+	})
+	if ex != nil {
+		return nil, ex
+	}
+
+	return wallet, nil
+}
+
+// TransferMoney transfers money and posts one journal entry that moves it from wallet to wallet, and the entry of
+// its fee if it is charged
+func (s *LedgerService) TransferMoney(ctx context.Context, walletID string, req *TransferMoneyRequest) (*TransferMoneyResponse, *errr.Error) {
+	if ex := checkMinorUnits(req.Amount); ex != nil {
+		return nil, ex
+	}
+
+	var res *TransferMoneyResponse
+	ex := s.inTransaction(ctx, func(ctx context.Context) ([]*ledger.JournalEntry, *errr.Error) {
+		var ex *errr.Error
+		if res, ex = s.Service.TransferMoney(ctx, walletID, req); ex != nil {
+			return nil, ex
+		}
+		if len(res.From.Changes) == 0 || len(res.To.Changes) != 1 {
+			return nil, errr.ThrowInternalServerError(errors.New("transfer must change each wallet once"))
+		}
+
+		entry, err := transferJournalEntry(res.From.Changes[0], res.To.Changes[0])
+		if err != nil {
+			return nil, errr.ThrowInternalServerError(err)
+		}
+		fees, ex := journalEntriesOf(res.From.Changes[1:]...)
+		if ex != nil {
+			return nil, ex
+		}
+		return append([]*ledger.JournalEntry{entry}, fees...), nil
+	})
+	if ex != nil {
+		return nil, ex
+	}
+
+	return res, nil
+}
+
+// inTransaction runs op and posts the entries it returns in one unit of work
+func (s *LedgerService) inTransaction(ctx context.Context, op func(ctx context.Context) ([]*ledger.JournalEntry, *errr.Error)) *errr.Error {
+	var ex *errr.Error
+	err := s.repository.WithinTransaction(ctx, func(ctx context.Context) error {
+		var entries []*ledger.JournalEntry
+		if entries, ex = op(ctx); ex != nil {
+			return ex
+		}
+		return s.ledger.Post(ctx, entries...)
+	})
+	if ex != nil {
+		return ex
+	}
+	if err != nil {
+		return errr.ThrowInternalServerError(err)
+	}
+
+	return nil
+}
+
+// checkMinorUnits rejects amounts that aren't whole minor units of currency before anything is saved since the
+// ledger can't record them, other invalid amounts are rejected by the service
+func checkMinorUnits(amount float32) *errr.Error {
+	if !(Money{Amount: amount}).inWholeMinorUnits() {
+		return errr.ThrowBadRequestError(errors.New(ErrInvalidMoneyAmount))
+	}
+
+	return nil
+}
+
+// journalEntriesOf returns journal entries of transactions
+func journalEntriesOf(transactions ...Transaction) ([]*ledger.JournalEntry, *errr.Error) {
+	entries := make([]*ledger.JournalEntry, len(transactions))
+	for i, t := range transactions {
+		var err error
+		if entries[i], err = journalEntryOf(t); err != nil {
+			return nil, errr.ThrowInternalServerError(err)
+		}
+	}
+
+	return entries, nil
+}
+
+// journalEntryOf returns journal entry that records transaction against the clearing account of its type, fees
+// are recorded against the fees account
+func journalEntryOf(t Transaction) (*ledger.JournalEntry, error) {
+	amount := t.Money.MinorUnits()
+	if amount <= 0 {
+		return nil, errors.New(ErrInvalidMoneyAmount)
+	}
+
+	account := ledger.WalletAccount(t.WalletID)
+	switch {
+	case t.Type == DepositTransactionType:
+		return ledger.NewJournalEntry(fmt.Sprintf("deposit to wallet %s", t.WalletID), []string{t.ID},
+			ledger.Debit(ledger.CashInClearingAccount, DefaultCurrency, amount),
+			ledger.Credit(account, DefaultCurrency, amount))
+	case t.Type == WithdrawTransactionType && t.FeeOf != "":
+		return ledger.NewJournalEntry(fmt.Sprintf("fee of transaction %s of wallet %s", t.FeeOf, t.WalletID), []string{t.ID},
+			ledger.Debit(account, DefaultCurrency, amount),
+			ledger.Credit(ledger.FeesAccount, DefaultCurrency, amount))
+	case t.Type == WithdrawTransactionType:
+		return ledger.NewJournalEntry(fmt.Sprintf("withdrawal from wallet %s", t.WalletID), []string{t.ID},
+			ledger.Debit(account, DefaultCurrency, amount),
+			ledger.Credit(ledger.CashOutClearingAccount, DefaultCurrency, amount))
+	}
+
+	return nil, fmt.Errorf("transaction %s has unknown type %q", t.ID, t.Type)
+}
+
+// transferJournalEntry returns journal entry that records withdrawal and deposit of a transfer as one movement
+// between wallets
+func transferJournalEntry(withdrawal, deposit Transaction) (*ledger.JournalEntry, error) {
+	amount := withdrawal.Money.MinorUnits()
+	if amount <= 0 {
+		return nil, errors.New(ErrInvalidMoneyAmount)
+	}
+
+	return ledger.NewJournalEntry(fmt.Sprintf("transfer from wallet %s to wallet %s", withdrawal.WalletID, deposit.WalletID),
+		[]string{withdrawal.ID, deposit.ID},
+		ledger.Debit(ledger.WalletAccount(withdrawal.WalletID), DefaultCurrency, amount),
+		ledger.Credit(ledger.WalletAccount(deposit.WalletID), DefaultCurrency, deposit.Money.MinorUnits()))
+}
@@ -0,0 +1,147 @@
+package wallet
+
+import (
+	"context"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/logger"
+	"log/slog"
+	"time"
+)
+
+const (
+	OperationLogKey  = "operation"
+	WalletIDLogKey   = "wallet_id"
+	ToWalletIDLogKey = "to_wallet_id"
+	UsernameLogKey   = "username"
+	AmountLogKey     = "amount"
+)
+
+// LoggingService is a Service decorator that attaches operation and wallet id to the context of use cases,
+// so they are added to every record logged with it, and logs outcome of use cases
+type LoggingService struct {
+	service Service
+	log     *slog.Logger
+}
+
+// NewLoggingService creates new instance of LoggingService
+func NewLoggingService(service Service, log *slog.Logger) *LoggingService {
+	return &LoggingService{service: service, log: log}
+}
+
+func (s *LoggingService) done(ctx context.Context, err *errr.Error, attrs ...slog.Attr) {
+	if err == nil {
+		s.log.LogAttrs(ctx, slog.LevelInfo, "wallet operation succeeded", attrs...)
+		return
+	}
+
+	level := slog.LevelWarn
+	if err.Code >= 500 {
+		level = slog.LevelError
+	}
+	s.log.LogAttrs(ctx, level, "wallet operation failed", append(attrs, slog.String("error", err.Message))...)
+}
+
+// CreateWallet creates wallet
+func (s *LoggingService) CreateWallet(ctx context.Context, req *CreateWalletRequest) (*ID, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "CreateWallet")
+	id, err := s.service.CreateWallet(ctx, req)
+
+	attrs := []slog.Attr{slog.String(UsernameLogKey, req.Username)}
+	if err == nil {
+		attrs = append(attrs, slog.String(WalletIDLogKey, id.Id))
+	}
+	s.done(ctx, err, attrs...)
+
+	return id, err
+}
+
+// DepositMoney provides to deposit(add) money to wallet
+func (s *LoggingService) DepositMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "DepositMoney", WalletIDLogKey, walletID)
+	wallet, err := s.service.DepositMoney(ctx, walletID, req)
+	s.done(ctx, err, slog.Float64(AmountLogKey, float64(req.Amount)))
+
+	return wallet, err
+}
+
+// WithdrawMoney provides to withdraw(sub) money from wallet
+func (s *LoggingService) WithdrawMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "WithdrawMoney", WalletIDLogKey, walletID)
+	wallet, err := s.service.WithdrawMoney(ctx, walletID, req)
+	s.done(ctx, err, slog.Float64(AmountLogKey, float64(req.Amount)))
+
+	return wallet, err
+}
+
+// TransferMoney provides to transfer money from wallet to another wallet
+func (s *LoggingService) TransferMoney(ctx context.Context, walletID string, req *TransferMoneyRequest) (*TransferMoneyResponse, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "TransferMoney", WalletIDLogKey, walletID, ToWalletIDLogKey, req.ToWalletID)
+	res, err := s.service.TransferMoney(ctx, walletID, req)
+	s.done(ctx, err, slog.Float64(AmountLogKey, float64(req.Amount)))
+
+	return res, err
+}
+
+// FreezeWallet freezes wallet so that money can't be deposited to or withdrawn from it
+func (s *LoggingService) FreezeWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "FreezeWallet", WalletIDLogKey, walletID)
+	wallet, err := s.service.FreezeWallet(ctx, walletID)
+	s.done(ctx, err)
+
+	return wallet, err
+}
+
+// GetWallet gets wallet with current state
+func (s *LoggingService) GetWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "GetWallet", WalletIDLogKey, walletID)
+	wallet, err := s.service.GetWallet(ctx, walletID)
+	if err != nil {
+		s.done(ctx, err)
+	}
+
+	return wallet, err
+}
+
+// GetWalletAsOf gets wallet with its state as of a time in the past
+func (s *LoggingService) GetWalletAsOf(ctx context.Context, walletID string, asOf time.Time) (*Wallet, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "GetWalletAsOf", WalletIDLogKey, walletID)
+	wallet, err := s.service.GetWalletAsOf(ctx, walletID, asOf)
+	if err != nil {
+		s.done(ctx, err)
+	}
+
+	return wallet, err
+}
+
+// GetTransactions gets transaction history of wallet
+func (s *LoggingService) GetTransactions(ctx context.Context, walletID string) ([]Transaction, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "GetTransactions", WalletIDLogKey, walletID)
+	transactions, err := s.service.GetTransactions(ctx, walletID)
+	if err != nil {
+		s.done(ctx, err)
+	}
+
+	return transactions, err
+}
+
+// FindTransactionsByReference finds transactions of every wallet with reference
+func (s *LoggingService) FindTransactionsByReference(ctx context.Context, reference string) ([]Transaction, *errr.Error) {
+	ctx = logger.With(ctx, OperationLogKey, "FindTransactionsByReference")
+	transactions, err := s.service.FindTransactionsByReference(ctx, reference)
+	if err != nil {
+		s.done(ctx, err)
+	}
+
+	return transactions, err
+}
+
+// WriteStatement writes statement of wallet
+func (s *LoggingService) WriteStatement(ctx context.Context, walletID string, req *StatementRequest, w StatementWriter) *errr.Error {
+	ctx = logger.With(ctx, OperationLogKey, "WriteStatement", WalletIDLogKey, walletID)
+	err := s.service.WriteStatement(ctx, walletID, req, w)
+	if err != nil {
+		s.done(ctx, err)
+	}
+
+	return err
+}
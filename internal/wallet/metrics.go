@@ -0,0 +1,308 @@
+package wallet
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"net/http"
+	"time"
+)
+
+type (
+	// Metrics is the collection of wallet domain and repository metrics
+	Metrics struct {
+		deposits         prometheus.Counter
+		withdrawals      prometheus.Counter
+		transfers        prometheus.Counter
+		failedOperations *prometheus.CounterVec
+		moneyMoved       *prometheus.CounterVec
+
+		repositoryDuration *prometheus.HistogramVec
+		repositoryErrors   *prometheus.CounterVec
+
+		cacheRequests *prometheus.CounterVec
+	}
+
+	// InstrumentedService is a Service decorator that records domain metrics
+	InstrumentedService struct {
+		Service
+		metrics *Metrics
+	}
+
+	// InstrumentedRepository is a Repository decorator that records latency and errors of operations
+	InstrumentedRepository struct {
+		repository Repository
+		metrics    *Metrics
+	}
+)
+
+// NewMetrics creates wallet metrics and registers them to reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		deposits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "wallet_deposits_total",
+			Help: "Total number of successful deposits.",
+		}),
+		withdrawals: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "wallet_withdrawals_total",
+			Help: "Total number of successful withdrawals.",
+		}),
+		transfers: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "wallet_transfers_total",
+			Help: "Total number of successful transfers.",
+		}),
+		failedOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_failed_operations_total",
+			Help: "Total number of failed deposits, withdrawals and transfers by reason.",
+		}, []string{"operation", "reason"}),
+		moneyMoved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_money_moved_total",
+			Help: "Total amount of money moved by transaction type and currency.",
+		}, []string{"type", "currency"}),
+		repositoryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "wallet_repository_operation_duration_seconds",
+			Help:    "Latency of wallet repository operations.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation"}),
+		repositoryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_repository_operation_errors_total",
+			Help: "Total number of failed wallet repository operations.",
+		}, []string{"operation"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "wallet_cache_requests_total",
+			Help: "Total number of wallet state cache lookups by result, hit, miss or error.",
+		}, []string{"result"}),
+	}
+	reg.MustRegister(m.deposits, m.withdrawals, m.transfers, m.failedOperations, m.moneyMoved,
+		m.repositoryDuration, m.repositoryErrors, m.cacheRequests)
+
+	return m
+}
+
+// NewInstrumentedService creates new instance of InstrumentedService
+func NewInstrumentedService(service Service, metrics *Metrics) *InstrumentedService {
+	return &InstrumentedService{Service: service, metrics: metrics}
+}
+
+// DepositMoney deposits money and records deposit metrics
+func (s *InstrumentedService) DepositMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	wallet, err := s.Service.DepositMoney(ctx, walletID, req)
+	if err != nil {
+		s.metrics.recordOperation(DepositBatchItemType, err)
+		return nil, err
+	}
+
+	s.metrics.recordOperation(DepositBatchItemType, nil, wallet)
+	return wallet, nil
+}
+
+// WithdrawMoney withdraws money and records withdrawal metrics
+func (s *InstrumentedService) WithdrawMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	wallet, err := s.Service.WithdrawMoney(ctx, walletID, req)
+	if err != nil {
+		s.metrics.recordOperation(WithdrawBatchItemType, err)
+		return nil, err
+	}
+
+	s.metrics.recordOperation(WithdrawBatchItemType, nil, wallet)
+	return wallet, nil
+}
+
+// TransferMoney transfers money and records transfer metrics
+func (s *InstrumentedService) TransferMoney(ctx context.Context, walletID string, req *TransferMoneyRequest) (*TransferMoneyResponse, *errr.Error) {
+	res, err := s.Service.TransferMoney(ctx, walletID, req)
+	if err != nil {
+		s.metrics.recordOperation(TransferBatchItemType, err)
+		return nil, err
+	}
+
+	s.metrics.recordOperation(TransferBatchItemType, nil, res.From, res.To)
+	return res, nil
+}
+
+// recordOperation records a deposit, withdrawal or transfer that failed with err or changed wallets, operations
+// are labeled like the types of batch items
+func (m *Metrics) recordOperation(operation BatchItemType, err *errr.Error, wallets ...*Wallet) {
+	if err != nil {
+		m.failedOperations.WithLabelValues(string(operation), failureReason(err)).Inc()
+		return
+	}
+
+	switch operation {
+	case DepositBatchItemType:
+		m.deposits.Inc()
+	case WithdrawBatchItemType:
+		m.withdrawals.Inc()
+	case TransferBatchItemType:
+		m.transfers.Inc()
+	}
+	for _, wallet := range wallets {
+		for _, t := range wallet.Changes {
+			m.moneyMoved.WithLabelValues(string(t.Type), DefaultCurrency).Add(float64(t.Money.Amount))
+		}
+	}
+}
+
+// failureReason returns low cardinality reason label of err
+func failureReason(err *errr.Error) string {
+	switch err.Message {
+	case ErrInsufficientMoneyAmount:
+		return "insufficient_funds"
+	case ErrInvalidMoneyAmount:
+		return "invalid_amount"
+	case ErrInvalidWalletID:
+		return "invalid_wallet_id"
+	case ErrSameWalletTransfer:
+		return "same_wallet"
+	case ErrWalletFrozen:
+		return "wallet_frozen"
+	}
+
+	switch err.Code {
+	case http.StatusNotFound:
+		return "wallet_not_found"
+	case http.StatusBadRequest:
+		return "bad_request"
+	}
+
+	return "internal"
+}
+
+// NewInstrumentedRepository creates new instance of InstrumentedRepository
+func NewInstrumentedRepository(repository Repository, metrics *Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{repository: repository, metrics: metrics}
+}
+
+func (r *InstrumentedRepository) observe(operation string, start time.Time, err error) {
+	r.metrics.repositoryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.metrics.repositoryErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// InsertWallet inserts wallet to collection
+func (r *InstrumentedRepository) InsertWallet(ctx context.Context, w *Wallet) error {
+	start := time.Now()
+	err := r.repository.InsertWallet(ctx, w)
+	r.observe("InsertWallet", start, err)
+
+	return err
+}
+
+// FindWalletByID finds wallet by id
+func (r *InstrumentedRepository) FindWalletByID(ctx context.Context, id string) (*Wallet, error) {
+	start := time.Now()
+	wallet, err := r.repository.FindWalletByID(ctx, id)
+	r.observe("FindWalletByID", start, err)
+
+	return wallet, err
+}
+
+// FindTransactionsByWalletID finds transaction by wallet id
+func (r *InstrumentedRepository) FindTransactionsByWalletID(ctx context.Context, walletID string) ([]Transaction, error) {
+	start := time.Now()
+	transactions, err := r.repository.FindTransactionsByWalletID(ctx, walletID)
+	r.observe("FindTransactionsByWalletID", start, err)
+
+	return transactions, err
+}
+
+// FindWalletsByIDs finds wallets by ids
+func (r *InstrumentedRepository) FindWalletsByIDs(ctx context.Context, ids []string) ([]*Wallet, error) {
+	start := time.Now()
+	wallets, err := r.repository.FindWalletsByIDs(ctx, ids)
+	r.observe("FindWalletsByIDs", start, err)
+
+	return wallets, err
+}
+
+// FindTransactionsByWalletIDs finds transactions of wallets by wallet ids
+func (r *InstrumentedRepository) FindTransactionsByWalletIDs(ctx context.Context, walletIDs []string) ([]Transaction, error) {
+	start := time.Now()
+	transactions, err := r.repository.FindTransactionsByWalletIDs(ctx, walletIDs)
+	r.observe("FindTransactionsByWalletIDs", start, err)
+
+	return transactions, err
+}
+
+// FindTransactionsByIDs finds transactions by ids
+func (r *InstrumentedRepository) FindTransactionsByIDs(ctx context.Context, ids []string) ([]Transaction, error) {
+	start := time.Now()
+	transactions, err := r.repository.FindTransactionsByIDs(ctx, ids)
+	r.observe("FindTransactionsByIDs", start, err)
+
+	return transactions, err
+}
+
+// FindTransactionsByReferences finds transactions of every wallet by their references
+func (r *InstrumentedRepository) FindTransactionsByReferences(ctx context.Context, references []string) ([]Transaction, error) {
+	start := time.Now()
+	transactions, err := r.repository.FindTransactionsByReferences(ctx, references)
+	r.observe("FindTransactionsByReferences", start, err)
+
+	return transactions, err
+}
+
+// FindTransactionsBetween finds transactions created in [from, to) in ascending creation order
+func (r *InstrumentedRepository) FindTransactionsBetween(ctx context.Context, from, to time.Time) ([]Transaction, error) {
+	start := time.Now()
+	transactions, err := r.repository.FindTransactionsBetween(ctx, from, to)
+	r.observe("FindTransactionsBetween", start, err)
+
+	return transactions, err
+}
+
+// InsertTransactions inserts transactions to collection
+func (r *InstrumentedRepository) InsertTransactions(ctx context.Context, transactions ...Transaction) error {
+	start := time.Now()
+	err := r.repository.InsertTransactions(ctx, transactions...)
+	r.observe("InsertTransactions", start, err)
+
+	return err
+}
+
+// IterateTransactionsByWalletID calls fn for every transaction of wallet created before `before` in ascending
+// creation order until fn returns error
+func (r *InstrumentedRepository) IterateTransactionsByWalletID(ctx context.Context, walletID string, before time.Time, fn func(t *Transaction) error) error {
+	start := time.Now()
+	err := r.repository.IterateTransactionsByWalletID(ctx, walletID, before, fn)
+	r.observe("IterateTransactionsByWalletID", start, err)
+
+	return err
+}
+
+// IterateWallets calls fn for every wallet in ascending creation order until fn returns error
+func (r *InstrumentedRepository) IterateWallets(ctx context.Context, fn func(w *Wallet) error) error {
+	start := time.Now()
+	err := r.repository.IterateWallets(ctx, fn)
+	r.observe("IterateWallets", start, err)
+
+	return err
+}
+
+// UpdateWalletStatus updates status and update time of wallet
+func (r *InstrumentedRepository) UpdateWalletStatus(ctx context.Context, id string, status WalletStatus) error {
+	start := time.Now()
+	err := r.repository.UpdateWalletStatus(ctx, id, status)
+	r.observe("UpdateWalletStatus", start, err)
+
+	return err
+}
+
+// WithinTransaction runs fn as a unit of work, its duration includes operations of fn and retries
+func (r *InstrumentedRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	err := r.repository.WithinTransaction(ctx, fn)
+	r.observe("WithinTransaction", start, err)
+
+	return err
+}
+
+// Ping checks the database is reachable
+func (r *InstrumentedRepository) Ping(ctx context.Context) error {
+	start := time.Now()
+	err := r.repository.Ping(ctx)
+	r.observe("Ping", start, err)
+
+	return err
+}
@@ -0,0 +1,132 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"github.com/ybalcin/wallet-service/pkg/projection"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BackfillVersions returns migration step that sets versions of wallets to the number of their transactions,
+// wallets without transactions are left at version zero
+func BackfillVersions(db *mongo.Database) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		cursor, err := db.Collection(transactionsCollection).Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$group", Value: bson.M{"_id": "$wallet_id", "version": bson.M{"$sum": 1}}}},
+			{{Key: "$merge", Value: bson.M{
+				"into":           walletsCollection,
+				"on":             "_id",
+				"whenMatched":    "merge",
+				"whenNotMatched": "discard",
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+
+		return cursor.Close(ctx)
+	}
+}
+
+// DropVersions returns migration step that removes versions of wallets
+func DropVersions(db *mongo.Database) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := db.Collection(walletsCollection).UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"version": ""}})
+		return err
+	}
+}
+
+// BackfillJournal returns migration step that posts journal entries of transactions saved before the ledger,
+// transactions that are already recorded are skipped. Transfers are recorded as a withdrawal and a deposit
+// through clearing accounts since their sides can't be matched
+func BackfillJournal(db *mongo.Database, l *ledger.Ledger) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		cursor, err := db.Collection(transactionsCollection).Find(ctx, bson.M{},
+			options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var t Transaction
+			if err = cursor.Decode(&t); err != nil {
+				return err
+			}
+			entry, err := journalEntryOf(t)
+			if err != nil {
+				return fmt.Errorf("transaction %s can't be recorded: %w", t.ID, err)
+			}
+			if err = l.Post(ctx, entry); err != nil && !errors.Is(err, ledger.ErrDuplicateEntry) {
+				return err
+			}
+		}
+
+		return cursor.Err()
+	}
+}
+
+// BackfillEvents returns migration step that appends event streams of wallets saved before events, wallets that
+// have a stream are skipped. Frozen wallets are frozen at their update times, or creation times of wallets frozen
+// before update times are recorded
+func BackfillEvents(db *mongo.Database, store eventstore.Store) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		cursor, err := db.Collection(walletsCollection).Find(ctx, bson.M{},
+			options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		transactions := db.Collection(transactionsCollection)
+		for cursor.Next(ctx) {
+			w := new(Wallet)
+			if err = cursor.Decode(w); err != nil {
+				return err
+			}
+			events, err := store.ReadStream(ctx, StreamOf(w.ID), 0, eventstore.Forward, 1)
+			if err != nil {
+				return err
+			}
+			if len(events) > 0 {
+				continue
+			}
+
+			tc, err := transactions.Find(ctx, bson.M{"wallet_id": w.ID},
+				options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+			if err != nil {
+				return err
+			}
+			var history []Transaction
+			if err = tc.All(ctx, &history); err != nil {
+				return err
+			}
+			frozenAt := w.UpdatedAt
+			if frozenAt.IsZero() {
+				frozenAt = w.CreatedAt
+			}
+			if err = appendHistory(ctx, store, historyOf(w.ID, w.Username, w.CreatedAt, w.Status, frozenAt, history)); err != nil {
+				return fmt.Errorf("events of wallet %s can't be appended: %w", w.ID, err)
+			}
+		}
+
+		return cursor.Err()
+	}
+}
+
+// ResetViews returns migration step that deletes wallet views and their checkpoint, so that they are projected from
+// the event store from scratch
+func ResetViews(db *mongo.Database) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := NewMongoViewRepository(db).DeleteViews(ctx); err != nil {
+			return err
+		}
+
+		return projection.NewMongoCheckpointStore(db).Delete(ctx, WalletViewProjection)
+	}
+}
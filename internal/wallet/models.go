@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/ybalcin/wallet-service/pkg/utility"
 	"sync"
@@ -11,20 +12,41 @@ import (
 type (
 	Wallet struct {
 		sync.Mutex `bson:"-" json:"-"`
-		ID         string    `bson:"_id" json:"id"`
-		Username   string    `bson:"username" json:"username"`
-		Balance    Money     `bson:"-" json:"balance"`
-		CreatedAt  time.Time `bson:"created_at" json:"-"`
+		ID         string `bson:"_id" json:"id"`
+		Username   string `bson:"username" json:"username"`
+		Balance    Money  `bson:"-" json:"balance"`
+		// Status is empty for wallets created before statuses, they are active
+		Status    WalletStatus `bson:"status,omitempty" json:"status,omitempty"`
+		CreatedAt time.Time    `bson:"created_at" json:"-"`
+		// Version is the version of transaction stream of wallet, the number of its transactions
+		Version int64 `bson:"version" json:"-"`
+		// UpdatedAt is the time wallet is created or its status is changed at, it is zero for wallets whose status
+		// is changed before update times are recorded
+		UpdatedAt time.Time `bson:"updated_at" json:"-"`
+		// AsOf is set if Balance is the balance at a time in the past instead of the current one
+		AsOf *time.Time `bson:"-" json:"as_of,omitempty"`
+		// StreamVersion is the version of event stream of wallet the state is loaded or saved at
+		StreamVersion int64 `bson:"-" json:"-"`
+		// occurredUntil is the latest time applied events occurred at
+		occurredUntil time.Time
 
+		// Events are events raised by the use case, Changes are transactions of its money events
+		Events  []any         `bson:"-" json:"-"`
 		Changes []Transaction `bson:"-" json:"-"`
 	}
 
 	Transaction struct {
-		ID        string          `bson:"_id"`
-		WalletID  string          `bson:"wallet_id"`
-		Type      TransactionType `bson:"type"`
-		Money     Money           `bson:"money"`
-		CreatedAt time.Time       `bson:"created_at"`
+		ID       string          `bson:"_id" json:"id"`
+		WalletID string          `bson:"wallet_id" json:"wallet_id"`
+		Type     TransactionType `bson:"type" json:"type"`
+		Money    Money           `bson:"money" json:"money"`
+		// TransactionDetails are stored and returned next to the other fields
+		TransactionDetails `bson:",inline"`
+		// CounterpartyWalletID is the other wallet of a transfer, it is empty for transactions with the outside
+		CounterpartyWalletID string `bson:"counterparty_wallet_id,omitempty" json:"counterparty_wallet_id,omitempty"`
+		// FeeOf is the transaction a fee withdrawal is charged for, it is empty for other transactions
+		FeeOf     string    `bson:"fee_of,omitempty" json:"fee_of,omitempty"`
+		CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	}
 )
 
@@ -34,52 +56,99 @@ func New(username string) (*Wallet, error) {
 		return nil, errors.New(ErrInvalidUsername)
 	}
 
-	return &Wallet{
-		ID:        uuid.NewString(),
-		Username:  username,
-		CreatedAt: time.Now(),
-	}, nil
+	wallet := &Wallet{ID: uuid.NewString()}
+	wallet.raise(WalletCreated{Username: username, CreatedAt: time.Now()})
+
+	return wallet, nil
 }
 
-// WithdrawMoney withdraw(sub) money from wallet and adds transaction to the changes
-func (w *Wallet) WithdrawMoney(money Money) error {
-	if money.Amount == 0 {
+// WithdrawMoney withdraw(sub) money from wallet and adds transaction with details to the changes
+func (w *Wallet) WithdrawMoney(money Money, details TransactionDetails) error {
+	return w.withdrawMoney(Transaction{Money: money, TransactionDetails: details})
+}
+
+// TransferMoney withdraws money from wallet and deposits it to `to`, both transactions have the other wallet as
+// counterparty
+func (w *Wallet) TransferMoney(to *Wallet, money Money) error {
+	if err := w.withdrawMoney(Transaction{Money: money, CounterpartyWalletID: to.ID}); err != nil {
+		return err
+	}
+
+	return to.depositMoney(money, TransactionDetails{}, w.ID)
+}
+
+// ChargeFee withdraws fee of transaction of wallet from it, zero fee isn't charged
+func (w *Wallet) ChargeFee(fee Money, of Transaction) error {
+	if fee.Amount == 0 {
+		return nil
+	}
+
+	return w.withdrawMoney(Transaction{Money: fee, TransactionDetails: TransactionDetails{Description: feeDescription}, FeeOf: of.ID})
+}
+
+// withdrawMoney withdraws money of t, which is completed as a withdrawal transaction of wallet
+func (w *Wallet) withdrawMoney(t Transaction) error {
+	if w.IsFrozen() {
+		return errors.New(ErrWalletFrozen)
+	}
+	if t.Money.Amount == 0 {
 		return errors.New(ErrInvalidMoneyAmount)
 	}
-	if w.Balance.Amount < money.Amount {
+	if w.Balance.Amount < t.Money.Amount {
 		return errors.New(ErrInsufficientMoneyAmount)
 	}
 
-	t := Transaction{
-		ID:        uuid.NewString(),
-		WalletID:  w.ID,
-		Type:      WithdrawTransactionType,
-		Money:     money,
-		CreatedAt: time.Now(),
-	}
-	w.apply(t)
+	t.ID = uuid.NewString()
+	t.WalletID = w.ID
+	t.Type = WithdrawTransactionType
+	t.CreatedAt = time.Now()
+	w.raise(MoneyWithdrawn{Transaction: t})
 
 	return nil
 }
 
-// DepositMoney deposit(add) money to the wallet and adds transaction to the changes
-func (w *Wallet) DepositMoney(money Money) error {
+// DepositMoney deposit(add) money to the wallet and adds transaction with details to the changes
+func (w *Wallet) DepositMoney(money Money, details TransactionDetails) error {
+	return w.depositMoney(money, details, "")
+}
+
+func (w *Wallet) depositMoney(money Money, details TransactionDetails, counterpartyWalletID string) error {
+	if w.IsFrozen() {
+		return errors.New(ErrWalletFrozen)
+	}
 	if money.Amount == 0 {
 		return errors.New(ErrInvalidMoneyAmount)
 	}
 
 	t := Transaction{
-		ID:        uuid.NewString(),
-		WalletID:  w.ID,
-		Type:      DepositTransactionType,
-		Money:     money,
-		CreatedAt: time.Now(),
+		ID:                   uuid.NewString(),
+		WalletID:             w.ID,
+		Type:                 DepositTransactionType,
+		Money:                money,
+		TransactionDetails:   details,
+		CounterpartyWalletID: counterpartyWalletID,
+		CreatedAt:            time.Now(),
 	}
-	w.apply(t)
+	w.raise(MoneyDeposited{Transaction: t})
 
 	return nil
 }
 
+// Freeze freezes wallet, money can't be deposited to or withdrawn from frozen wallets
+func (w *Wallet) Freeze() error {
+	if w.IsFrozen() {
+		return errors.New(ErrWalletAlreadyFrozen)
+	}
+	w.raise(WalletFrozen{FrozenAt: time.Now()})
+
+	return nil
+}
+
+// IsFrozen reports whether wallet is frozen
+func (w *Wallet) IsFrozen() bool {
+	return w.Status == FrozenWalletStatus
+}
+
 // Mutate mutates wallet current state by transactions
 func (w *Wallet) Mutate(transactions ...Transaction) {
 	for _, transaction := range transactions {
@@ -92,10 +161,54 @@ func (w *Wallet) Mutate(transactions ...Transaction) {
 	}
 }
 
-func (w *Wallet) apply(transaction Transaction) {
+// BalanceChange returns change of transaction to balance of its wallet in minor units
+func (t Transaction) BalanceChange() int64 {
+	if t.Type == WithdrawTransactionType {
+		return -t.Money.MinorUnits()
+	}
+
+	return t.Money.MinorUnits()
+}
+
+// raise applies event to wallet and adds it to the events, transactions of money events are added to the changes
+func (w *Wallet) raise(event any) {
 	w.Lock()
 	defer w.Unlock()
 
-	w.Changes = append(w.Changes, transaction)
-	w.Mutate(transaction)
+	// events raised by wallet are always known
+	_ = w.apply(event)
+	w.Events = append(w.Events, event)
+	switch e := event.(type) {
+	case MoneyDeposited:
+		w.Changes = append(w.Changes, e.Transaction)
+	case MoneyWithdrawn:
+		w.Changes = append(w.Changes, e.Transaction)
+	}
+}
+
+// apply mutates wallet state by event, Version counts money events so that it stays the number of transactions
+func (w *Wallet) apply(event any) error {
+	if at := occurredAt(event); at.After(w.occurredUntil) {
+		w.occurredUntil = at
+	}
+	switch e := event.(type) {
+	case WalletCreated:
+		w.Username = e.Username
+		w.Status = ActiveWalletStatus
+		w.CreatedAt = e.CreatedAt
+		w.UpdatedAt = e.CreatedAt
+	case MoneyDeposited:
+		w.Mutate(e.Transaction)
+		w.Version++
+	case MoneyWithdrawn:
+		w.Mutate(e.Transaction)
+		w.Version++
+	case WalletFrozen:
+		w.Status = FrozenWalletStatus
+		w.UpdatedAt = e.FrozenAt
+	default:
+		return fmt.Errorf("event %T is unknown", event)
+	}
+
+	return nil
 }
@@ -0,0 +1,68 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReplayResult is a wallet with balance recomputed from its transactions, Problem is set if the
+// transactions are inconsistent
+type ReplayResult struct {
+	Wallet       *Wallet `json:"wallet"`
+	Transactions int     `json:"transactions"`
+	Problem      string  `json:"problem,omitempty"`
+}
+
+// Replay recomputes balances of wallets of ids, or of every wallet if ids is empty, by replaying their
+// transactions and calls fn with the result of each
+func Replay(ctx context.Context, repository Repository, ids []string, fn func(r ReplayResult) error) error {
+	replay := func(wallet *Wallet) error {
+		transactions, err := repository.FindTransactionsByWalletID(ctx, wallet.ID)
+		if err != nil {
+			return err
+		}
+
+		return fn(replayTransactions(wallet, transactions))
+	}
+
+	if len(ids) == 0 {
+		return repository.IterateWallets(ctx, replay)
+	}
+
+	for _, id := range ids {
+		wallet, err := repository.FindWalletByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if wallet == nil {
+			return fmt.Errorf(ErrWalletNotFound, id)
+		}
+		if err = replay(wallet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replayTransactions mutates wallet by transactions one by one so that the first inconsistent transaction
+// is reported
+func replayTransactions(wallet *Wallet, transactions []Transaction) ReplayResult {
+	result := ReplayResult{Wallet: wallet, Transactions: len(transactions)}
+	for _, t := range transactions {
+		var problem string
+		if t.Type != DepositTransactionType && t.Type != WithdrawTransactionType {
+			problem = fmt.Sprintf("transaction %s has unknown type %q", t.ID, t.Type)
+		}
+
+		wallet.Mutate(t)
+		if wallet.Balance.Amount < 0 {
+			problem = fmt.Sprintf("balance is negative after transaction %s", t.ID)
+		}
+		if result.Problem == "" {
+			result.Problem = problem
+		}
+	}
+
+	return result
+}
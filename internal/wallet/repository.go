@@ -2,9 +2,14 @@ package wallet
 
 import (
 	"context"
+	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"time"
 )
 
 //go:generate mockgen -source=repository.go -destination=./test/repository_mock.go -package=wallet
@@ -12,6 +17,9 @@ import (
 const (
 	walletsCollection      = "wallets"
 	transactionsCollection = "transactions"
+
+	transientTransactionErrorLabel      = "TransientTransactionError"
+	unknownTransactionCommitResultLabel = "UnknownTransactionCommitResult"
 )
 
 type (
@@ -23,29 +31,92 @@ type (
 		FindWalletByID(ctx context.Context, id string) (*Wallet, error)
 		// FindTransactionsByWalletID finds transaction by wallet id
 		FindTransactionsByWalletID(ctx context.Context, walletID string) ([]Transaction, error)
+		// FindWalletsByIDs finds wallets by ids
+		FindWalletsByIDs(ctx context.Context, ids []string) ([]*Wallet, error)
+		// FindTransactionsByWalletIDs finds transactions of wallets by wallet ids
+		FindTransactionsByWalletIDs(ctx context.Context, walletIDs []string) ([]Transaction, error)
+		// FindTransactionsByIDs finds transactions by ids
+		FindTransactionsByIDs(ctx context.Context, ids []string) ([]Transaction, error)
+		// FindTransactionsByReferences finds transactions of every wallet by their references
+		FindTransactionsByReferences(ctx context.Context, references []string) ([]Transaction, error)
+		// FindTransactionsBetween finds transactions created in [from, to) in ascending creation order
+		FindTransactionsBetween(ctx context.Context, from, to time.Time) ([]Transaction, error)
+		// IterateTransactionsByWalletID calls fn for every transaction of wallet created before `before` in ascending
+		// creation order until fn returns error
+		IterateTransactionsByWalletID(ctx context.Context, walletID string, before time.Time, fn func(t *Transaction) error) error
+		// IterateWallets calls fn for every wallet in ascending creation order until fn returns error
+		IterateWallets(ctx context.Context, fn func(w *Wallet) error) error
+		// UpdateWalletStatus updates status and update time of wallet
+		UpdateWalletStatus(ctx context.Context, id string, status WalletStatus) error
 
-		// InsertTransactions inserts transactions to collection
+		// InsertTransactions inserts transactions to collection and increments versions of their wallets by the
+		// number of their transactions
 		InsertTransactions(ctx context.Context, transactions ...Transaction) error
+
+		// WithinTransaction runs fn as a unit of work, calls made with the context given to fn are committed
+		// or aborted together. fn may run more than once and nested calls join the outer unit of work
+		WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+		// Ping checks the database is reachable
+		Ping(ctx context.Context) error
+	}
+
+	// MongoOptions are transaction settings and options of operations of MongoRepository
+	MongoOptions struct {
+		// Transactions runs units of work in mongo transactions, they run without one if it is false since
+		// transactions require a replica set
+		Transactions bool
+		// TransactionRetries is how many times a transaction is retried on transient errors
+		TransactionRetries int
+		// Operations are options of operations by method name, e.g. FindTransactionsByWalletID
+		Operations map[string]OperationOptions
+	}
+
+	// OperationOptions are read preference and concerns of an operation, nil ones are the defaults of the client.
+	// They are ignored in transactions which always read from primary with snapshot and write with majority
+	OperationOptions struct {
+		ReadPreference *readpref.ReadPref
+		ReadConcern    *readconcern.ReadConcern
+		WriteConcern   *writeconcern.WriteConcern
 	}
 
 	// MongoRepository is a concrete implementation of Repository interface
 	MongoRepository struct {
-		wallets      *mongo.Collection
-		transactions *mongo.Collection
+		client  *mongo.Client
+		options MongoOptions
+		// collections are collections by name and by name of operation and collection if operation has options
+		collections map[string]*mongo.Collection
 	}
 )
 
 // NewMongoRepository creates instance of MongoRepository
-func NewMongoRepository(db *mongo.Database) *MongoRepository {
-	return &MongoRepository{
-		wallets:      db.Collection(walletsCollection),
-		transactions: db.Collection(transactionsCollection),
+func NewMongoRepository(db *mongo.Database, opts MongoOptions) *MongoRepository {
+	r := &MongoRepository{client: db.Client(), options: opts, collections: map[string]*mongo.Collection{}}
+	for _, name := range []string{walletsCollection, transactionsCollection} {
+		r.collections[name] = db.Collection(name)
+		for operation, o := range opts.Operations {
+			r.collections[operation+"/"+name] = db.Collection(name, options.Collection().
+				SetReadPreference(o.ReadPreference).
+				SetReadConcern(o.ReadConcern).
+				SetWriteConcern(o.WriteConcern))
+		}
+	}
+
+	return r
+}
+
+// collection returns collection of name with options of operation
+func (r *MongoRepository) collection(operation, name string) *mongo.Collection {
+	if c, ok := r.collections[operation+"/"+name]; ok {
+		return c
 	}
+
+	return r.collections[name]
 }
 
 // InsertWallet inserts wallet to collection
 func (r *MongoRepository) InsertWallet(ctx context.Context, w *Wallet) error {
-	_, err := r.wallets.InsertOne(ctx, w)
+	_, err := r.collection("InsertWallet", walletsCollection).InsertOne(ctx, w)
 	if err != nil {
 		return err
 	}
@@ -53,24 +124,40 @@ func (r *MongoRepository) InsertWallet(ctx context.Context, w *Wallet) error {
 	return nil
 }
 
-// InsertTransactions inserts transactions to collection
+// InsertTransactions inserts transactions to collection and increments versions of their wallets by the number of
+// their transactions. Wallets are updated after the insert so that a wallet is never ahead of its transactions
+// when they aren't in a transaction
 func (r *MongoRepository) InsertTransactions(ctx context.Context, transactions ...Transaction) error {
 	documents := make([]interface{}, len(transactions))
+	counts := map[string]int64{}
+	var walletIDs []string
 	for i, t := range transactions {
 		documents[i] = t
+		if counts[t.WalletID] == 0 {
+			walletIDs = append(walletIDs, t.WalletID)
+		}
+		counts[t.WalletID]++
 	}
 
-	_, err := r.transactions.InsertMany(ctx, documents)
+	_, err := r.collection("InsertTransactions", transactionsCollection).InsertMany(ctx, documents)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	updates := make([]mongo.WriteModel, len(walletIDs))
+	for i, id := range walletIDs {
+		updates[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$inc": bson.M{"version": counts[id]}})
+	}
+	_, err = r.collection("InsertTransactions", walletsCollection).BulkWrite(ctx, updates)
+
+	return err
 }
 
 // FindWalletByID finds wallet by id
 func (r *MongoRepository) FindWalletByID(ctx context.Context, id string) (*Wallet, error) {
-	res := r.wallets.FindOne(ctx, bson.M{"_id": id})
+	res := r.collection("FindWalletByID", walletsCollection).FindOne(ctx, bson.M{"_id": id})
 	if res.Err() != nil {
 		if res.Err() == mongo.ErrNoDocuments {
 			return nil, nil
@@ -91,7 +178,7 @@ func (r *MongoRepository) FindTransactionsByWalletID(ctx context.Context, wallet
 	opts := options.Find()
 	opts.Sort = bson.M{"created_at": 1}
 
-	cursor, err := r.transactions.Find(ctx, bson.M{
+	cursor, err := r.collection("FindTransactionsByWalletID", transactionsCollection).Find(ctx, bson.M{
 		"wallet_id": walletID,
 	}, opts)
 	if err != nil {
@@ -105,3 +192,216 @@ func (r *MongoRepository) FindTransactionsByWalletID(ctx context.Context, wallet
 
 	return transactions, nil
 }
+
+// FindWalletsByIDs finds wallets by ids
+func (r *MongoRepository) FindWalletsByIDs(ctx context.Context, ids []string) ([]*Wallet, error) {
+	cursor, err := r.collection("FindWalletsByIDs", walletsCollection).Find(ctx, bson.M{
+		"_id": bson.M{"$in": ids},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var wallets []*Wallet
+	if err = cursor.All(ctx, &wallets); err != nil {
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// FindTransactionsByWalletIDs finds transactions of wallets by wallet ids
+func (r *MongoRepository) FindTransactionsByWalletIDs(ctx context.Context, walletIDs []string) ([]Transaction, error) {
+	opts := options.Find()
+	opts.Sort = bson.M{"created_at": 1}
+
+	cursor, err := r.collection("FindTransactionsByWalletIDs", transactionsCollection).Find(ctx, bson.M{
+		"wallet_id": bson.M{"$in": walletIDs},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// FindTransactionsByIDs finds transactions by ids
+func (r *MongoRepository) FindTransactionsByIDs(ctx context.Context, ids []string) ([]Transaction, error) {
+	cursor, err := r.collection("FindTransactionsByIDs", transactionsCollection).Find(ctx, bson.M{
+		"_id": bson.M{"$in": ids},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// FindTransactionsByReferences finds transactions of every wallet by their references
+func (r *MongoRepository) FindTransactionsByReferences(ctx context.Context, references []string) ([]Transaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection("FindTransactionsByReferences", transactionsCollection).Find(ctx, bson.M{
+		"reference": bson.M{"$in": references},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// FindTransactionsBetween finds transactions created in [from, to) in ascending creation order
+func (r *MongoRepository) FindTransactionsBetween(ctx context.Context, from, to time.Time) ([]Transaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection("FindTransactionsBetween", transactionsCollection).Find(ctx, bson.M{
+		"created_at": bson.M{"$gte": from, "$lt": to},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []Transaction
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// IterateTransactionsByWalletID calls fn for every transaction of wallet created before `before` in ascending
+// creation order until fn returns error
+func (r *MongoRepository) IterateTransactionsByWalletID(ctx context.Context, walletID string, before time.Time, fn func(t *Transaction) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection("IterateTransactionsByWalletID", transactionsCollection).Find(ctx, bson.M{
+		"wallet_id":  walletID,
+		"created_at": bson.M{"$lt": before},
+	}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		transaction := new(Transaction)
+		if err = cursor.Decode(transaction); err != nil {
+			return err
+		}
+		if err = fn(transaction); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// IterateWallets calls fn for every wallet in ascending creation order until fn returns error
+func (r *MongoRepository) IterateWallets(ctx context.Context, fn func(w *Wallet) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection("IterateWallets", walletsCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		wallet := new(Wallet)
+		if err = cursor.Decode(wallet); err != nil {
+			return err
+		}
+		if err = fn(wallet); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// UpdateWalletStatus updates status and update time of wallet
+func (r *MongoRepository) UpdateWalletStatus(ctx context.Context, id string, status WalletStatus) error {
+	res, err := r.collection("UpdateWalletStatus", walletsCollection).
+		UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// WithinTransaction runs fn as a unit of work, calls made with the context given to fn are committed
+// or aborted together. The transaction is retried on transient errors so fn may run more than once,
+// nested calls join the outer transaction
+func (r *MongoRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !r.options.Transactions || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	for attempt := 0; ; attempt++ {
+		err = r.runTransaction(ctx, session, fn)
+		if err == nil || attempt >= r.options.TransactionRetries || !hasErrorLabel(err, transientTransactionErrorLabel) {
+			return err
+		}
+	}
+}
+
+func (r *MongoRepository) runTransaction(ctx context.Context, session mongo.Session, fn func(ctx context.Context) error) error {
+	opts := options.Transaction().
+		SetReadPreference(readpref.Primary()).
+		SetReadConcern(readconcern.Snapshot()).
+		SetWriteConcern(writeconcern.Majority())
+	if err := session.StartTransaction(opts); err != nil {
+		return err
+	}
+
+	sc := mongo.NewSessionContext(ctx, session)
+	if err := fn(sc); err != nil {
+		// abort is not bound to ctx, so that the transaction is aborted even if ctx is done
+		_ = session.AbortTransaction(context.Background())
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := session.CommitTransaction(sc)
+		if err == nil || attempt >= r.options.TransactionRetries || !hasErrorLabel(err, unknownTransactionCommitResultLabel) {
+			return err
+		}
+	}
+}
+
+// hasErrorLabel reports whether err is a mongo error with label
+func hasErrorLabel(err error, label string) bool {
+	var se mongo.ServerError
+	return errors.As(err, &se) && se.HasErrorLabel(label)
+}
+
+// Ping checks the database is reachable
+func (r *MongoRepository) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx, readpref.Primary())
+}
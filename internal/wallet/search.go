@@ -0,0 +1,245 @@
+package wallet
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits of wallet listing
+const (
+	DefaultWalletsLimit = 20
+	MaxWalletsLimit     = 100
+)
+
+// walletSortFields are fields wallets can be sorted by
+var walletSortFields = map[string]bool{"created_at": true, "username": true, "balance": true}
+
+type (
+	// Search lists wallets of the back-office from wallet views, so wallets aren't replayed to be filtered or
+	// sorted by balance. Views lag behind writes until their projection catches up
+	Search struct {
+		repository ViewRepository
+	}
+
+	// WalletQuery is a parsed FindWalletsRequest, balances are in minor units and zero times are unbounded
+	WalletQuery struct {
+		UsernamePrefix string
+		Status         WalletStatus
+		CreatedFrom    time.Time
+		CreatedTo      time.Time
+		MinBalance     *int64
+		MaxBalance     *int64
+		SortField      string
+		Descending     bool
+		// After is the last wallet of the previous page
+		After *WalletCursor
+		Limit int64
+	}
+
+	// WalletCursor is the position of a wallet in a sort order, it is given to clients encoded
+	WalletCursor struct {
+		Sort      string    `json:"s"`
+		ID        string    `json:"id"`
+		CreatedAt time.Time `json:"c,omitempty"`
+		Username  string    `json:"u,omitempty"`
+		Balance   int64     `json:"b,omitempty"`
+	}
+
+	// WalletSummary is a wallet of the listing with its projected balance
+	WalletSummary struct {
+		ID        string       `json:"id"`
+		Username  string       `json:"username"`
+		Status    WalletStatus `json:"status"`
+		Currency  string       `json:"currency"`
+		Balance   Money        `json:"balance"`
+		CreatedAt time.Time    `json:"created_at"`
+	}
+
+	// WalletPage is a page of wallets with the total number of wallets matching the filters, NextCursor is empty
+	// on the last page
+	WalletPage struct {
+		Wallets    []WalletSummary `json:"wallets"`
+		Total      int64           `json:"total"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+)
+
+// NewSearch creates new instance of Search
+func NewSearch(repository ViewRepository) *Search {
+	return &Search{repository: repository}
+}
+
+// FindWallets finds a page of wallets matching req with the total number of them
+func (s *Search) FindWallets(ctx context.Context, req *FindWalletsRequest) (*WalletPage, *errr.Error) {
+	query, err := walletQueryOf(req)
+	if err != nil {
+		return nil, errr.ThrowBadRequestError(err)
+	}
+	// every wallet is in DefaultCurrency
+	if req.Currency != "" && !strings.EqualFold(req.Currency, DefaultCurrency) {
+		return &WalletPage{Wallets: []WalletSummary{}}, nil
+	}
+
+	// one more wallet is read to know if there is a next page
+	query.Limit++
+	views, err := s.repository.FindViews(ctx, query)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	query.Limit--
+
+	page := &WalletPage{Wallets: make([]WalletSummary, 0, len(views))}
+	if int64(len(views)) > query.Limit {
+		views = views[:query.Limit]
+		page.NextCursor = cursorOf(query, views[len(views)-1]).encode()
+	}
+	for _, v := range views {
+		page.Wallets = append(page.Wallets, summaryOf(v))
+	}
+
+	if page.Total, err = s.repository.CountViews(ctx, query); err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+
+	return page, nil
+}
+
+func walletQueryOf(req *FindWalletsRequest) (*WalletQuery, error) {
+	query := &WalletQuery{UsernamePrefix: strings.TrimSpace(req.Username), SortField: "created_at", Limit: int64(req.Limit)}
+
+	if req.Status != "" {
+		query.Status = WalletStatus(req.Status)
+		if query.Status != ActiveWalletStatus && query.Status != FrozenWalletStatus {
+			return nil, fmt.Errorf(ErrInvalidWalletStatus, req.Status)
+		}
+	}
+
+	var err error
+	if req.CreatedFrom != "" {
+		if query.CreatedFrom, err = ParseDateOrTime(req.CreatedFrom); err != nil {
+			return nil, fmt.Errorf(ErrInvalidTime, "created_from")
+		}
+	}
+	if req.CreatedTo != "" {
+		if query.CreatedTo, err = ParseDateOrTime(req.CreatedTo); err != nil {
+			return nil, fmt.Errorf(ErrInvalidTime, "created_to")
+		}
+	}
+	if query.MinBalance, err = minorUnitsOf(req.MinBalance, "min_balance"); err != nil {
+		return nil, err
+	}
+	if query.MaxBalance, err = minorUnitsOf(req.MaxBalance, "max_balance"); err != nil {
+		return nil, err
+	}
+
+	if req.Sort != "" {
+		query.SortField, query.Descending = strings.TrimPrefix(req.Sort, "-"), strings.HasPrefix(req.Sort, "-")
+		if !walletSortFields[query.SortField] {
+			return nil, fmt.Errorf(ErrInvalidWalletsSort, req.Sort)
+		}
+	}
+	if req.Cursor != "" {
+		if query.After, err = decodeCursor(req.Cursor); err != nil || query.After.Sort != sortOf(query) {
+			return nil, errors.New(ErrInvalidCursor)
+		}
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultWalletsLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxWalletsLimit {
+		return nil, fmt.Errorf(ErrInvalidWalletsLimit, MaxWalletsLimit)
+	}
+
+	return query, nil
+}
+
+// minorUnitsOf parses amount, e.g. 10.50, in minor units, nil is returned for empty amounts
+func minorUnitsOf(amount, param string) (*int64, error) {
+	if amount == "" {
+		return nil, nil
+	}
+
+	v, err := strconv.ParseFloat(amount, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf(ErrInvalidBalance, param)
+	}
+	units := int64(math.Round(v * 100))
+
+	return &units, nil
+}
+
+func summaryOf(v *WalletView) WalletSummary {
+	return WalletSummary{
+		ID:        v.ID,
+		Username:  v.Username,
+		Status:    v.Status,
+		Currency:  DefaultCurrency,
+		Balance:   Money{Amount: float32(v.Balance) / 100},
+		CreatedAt: v.CreatedAt,
+	}
+}
+
+func sortOf(query *WalletQuery) string {
+	if query.Descending {
+		return "-" + query.SortField
+	}
+
+	return query.SortField
+}
+
+func cursorOf(query *WalletQuery, v *WalletView) *WalletCursor {
+	c := &WalletCursor{Sort: sortOf(query), ID: v.ID}
+	switch query.SortField {
+	case "created_at":
+		c.CreatedAt = v.CreatedAt
+	case "username":
+		c.Username = v.Username
+	case "balance":
+		c.Balance = v.Balance
+	}
+
+	return c
+}
+
+// value returns value of the sorted field of the wallet at cursor
+func (c *WalletCursor) value(field string) interface{} {
+	switch field {
+	case "username":
+		return c.Username
+	case "balance":
+		return c.Balance
+	}
+
+	return c.CreatedAt
+}
+
+func (c *WalletCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(v string) (*WalletCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(WalletCursor)
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	if c.ID == "" {
+		return nil, errors.New(ErrInvalidCursor)
+	}
+
+	return c, nil
+}
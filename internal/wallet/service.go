@@ -5,7 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
 	"github.com/ybalcin/wallet-service/pkg/utility"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type (
@@ -17,19 +21,68 @@ type (
 		DepositMoney(ctx context.Context, id string, req *MoneyTransactionRequest) (*Wallet, *errr.Error)
 		// WithdrawMoney provides to withdraw(sub) monet from wallet
 		WithdrawMoney(ctx context.Context, id string, req *MoneyTransactionRequest) (*Wallet, *errr.Error)
+		// TransferMoney provides to transfer money from wallet to another wallet
+		TransferMoney(ctx context.Context, id string, req *TransferMoneyRequest) (*TransferMoneyResponse, *errr.Error)
+		// FreezeWallet freezes wallet so that money can't be deposited to or withdrawn from it
+		FreezeWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error)
 		// GetWallet gets wallet with current state
 		GetWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error)
+		// GetWalletAsOf gets wallet with its state as of a time in the past
+		GetWalletAsOf(ctx context.Context, walletID string, asOf time.Time) (*Wallet, *errr.Error)
+		// GetTransactions gets transaction history of wallet
+		GetTransactions(ctx context.Context, walletID string) ([]Transaction, *errr.Error)
+		// FindTransactionsByReference finds transactions of every wallet with reference
+		FindTransactionsByReference(ctx context.Context, reference string) ([]Transaction, *errr.Error)
+		// WriteStatement writes statement of wallet for the period of req to w, transactions are streamed
+		WriteStatement(ctx context.Context, walletID string, req *StatementRequest, w StatementWriter) *errr.Error
 	}
 
-	// ServiceImplementation is an implementation of Service interface
+	// ServiceImplementation is an implementation of Service interface. Use cases that change wallets load them from
+	// their event streams and append the raised events, wallets and transactions collections are read models that
+	// are updated in the same unit of work. Snapshots of wallets are saved every snapshotInterval events for
+	// states as of past times. Fees of withdrawals and transfers are withdrawn with their money
 	ServiceImplementation struct {
 		repository Repository
+		events     eventstore.Store
+		snapshots  SnapshotRepository
+		fees       atomic.Pointer[FeeSchedules]
 	}
 )
 
-// NewService creates new instance of ServiceImplementation
-func NewService(repository Repository) *ServiceImplementation {
-	return &ServiceImplementation{repository: repository}
+// NewService creates new instance of ServiceImplementation, events are appended to and loaded from events
+func NewService(repository Repository, events eventstore.Store, snapshots SnapshotRepository) *ServiceImplementation {
+	return &ServiceImplementation{repository: repository, events: events, snapshots: snapshots}
+}
+
+// SetFees sets fee schedules, it takes effect on the next withdrawal or transfer. No fee is charged until it is set
+func (s *ServiceImplementation) SetFees(fees FeeSchedules) {
+	s.fees.Store(&fees)
+}
+
+// feeSchedules returns fee schedules in use
+func (s *ServiceImplementation) feeSchedules() FeeSchedules {
+	if fees := s.fees.Load(); fees != nil {
+		return *fees
+	}
+
+	return FeeSchedules{}
+}
+
+// loadWallet loads wallet from its event stream to change it
+func (s *ServiceImplementation) loadWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	if utility.IsStrEmpty(walletID) {
+		return nil, errr.ThrowBadRequestError(errors.New(ErrInvalidWalletID))
+	}
+
+	wallet, err := LoadWallet(ctx, s.events, walletID)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if wallet == nil {
+		return nil, errr.ThrowNotFoundError(fmt.Errorf(ErrWalletNotFound, walletID))
+	}
+
+	return wallet, nil
 }
 
 func (s *ServiceImplementation) findWalletWithCurrentState(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
@@ -49,8 +102,13 @@ func (s *ServiceImplementation) findWalletWithCurrentState(ctx context.Context,
 	if err != nil {
 		return nil, errr.ThrowInternalServerError(err)
 	}
+
 	wallet.Mutate(transactions...)
 
+	// version of the state is the number of replayed transactions, the wallet document read before them may be
+	// behind if a transaction is appended in between
+	wallet.Version = int64(len(transactions))
+
 	return wallet, nil
 }
 
@@ -61,8 +119,10 @@ func (s *ServiceImplementation) CreateWallet(ctx context.Context, req *CreateWal
 		return nil, errr.ThrowBadRequestError(err)
 	}
 
-	if err = s.repository.InsertWallet(ctx, wallet); err != nil {
-		return nil, errr.ThrowInternalServerError(err)
+	if ex := s.inTransaction(ctx, func(ctx context.Context) *errr.Error {
+		return s.saveWalletChanges(ctx, wallet)
+	}); ex != nil {
+		return nil, ex
 	}
 
 	return NewID(wallet.ID), nil
@@ -70,40 +130,121 @@ func (s *ServiceImplementation) CreateWallet(ctx context.Context, req *CreateWal
 
 // DepositMoney provides to deposit(add) money to wallet
 func (s *ServiceImplementation) DepositMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
-	money, err := NewMoney(req.Amount)
-	if err != nil {
-		return nil, errr.ThrowBadRequestError(err)
-	}
-
-	wallet, ex := s.findWalletWithCurrentState(ctx, walletID)
+	money, details, ex := moneyTransactionOf(req)
 	if ex != nil {
 		return nil, ex
 	}
 
-	if err = wallet.DepositMoney(*money); err != nil {
-		return nil, errr.ThrowBadRequestError(err)
+	var wallet *Wallet
+	ex = s.inTransaction(ctx, func(ctx context.Context) *errr.Error {
+		var ex *errr.Error
+		if wallet, ex = s.loadWallet(ctx, walletID); ex != nil {
+			return ex
+		}
+		if ex = s.checkUniqueReference(ctx, wallet.ID, req); ex != nil {
+			return ex
+		}
+		if err := wallet.DepositMoney(*money, *details); err != nil {
+			return errr.ThrowBadRequestError(err)
+		}
+
+		return s.saveWalletChanges(ctx, wallet)
+	})
+	if ex != nil {
+		return nil, ex
 	}
 
-	return s.saveWalletChanges(ctx, wallet)
+	return wallet, nil
 }
 
 // WithdrawMoney provides to withdraw(sub) monet from wallet
 func (s *ServiceImplementation) WithdrawMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	money, details, ex := moneyTransactionOf(req)
+	if ex != nil {
+		return nil, ex
+	}
+
+	var wallet *Wallet
+	ex = s.inTransaction(ctx, func(ctx context.Context) *errr.Error {
+		var ex *errr.Error
+		if wallet, ex = s.loadWallet(ctx, walletID); ex != nil {
+			return ex
+		}
+		if ex = s.checkUniqueReference(ctx, wallet.ID, req); ex != nil {
+			return ex
+		}
+		if err := wallet.WithdrawMoney(*money, *details); err != nil {
+			return errr.ThrowBadRequestError(err)
+		}
+		if err := chargeFee(wallet, s.feeSchedules().Withdraw); err != nil {
+			return errr.ThrowBadRequestError(err)
+		}
+
+		return s.saveWalletChanges(ctx, wallet)
+	})
+	if ex != nil {
+		return nil, ex
+	}
+
+	return wallet, nil
+}
+
+// TransferMoney provides to transfer money from wallet to another wallet, both sides are saved in one
+// transaction
+func (s *ServiceImplementation) TransferMoney(ctx context.Context, walletID string, req *TransferMoneyRequest) (*TransferMoneyResponse, *errr.Error) {
 	money, err := NewMoney(req.Amount)
 	if err != nil {
 		return nil, errr.ThrowBadRequestError(err)
 	}
+	if walletID == req.ToWalletID {
+		return nil, errr.ThrowBadRequestError(errors.New(ErrSameWalletTransfer))
+	}
+
+	res := new(TransferMoneyResponse)
+	ex := s.inTransaction(ctx, func(ctx context.Context) *errr.Error {
+		var ex *errr.Error
+		if res.From, ex = s.loadWallet(ctx, walletID); ex != nil {
+			return ex
+		}
+		if res.To, ex = s.loadWallet(ctx, req.ToWalletID); ex != nil {
+			return ex
+		}
+
+		if err := res.From.TransferMoney(res.To, *money); err != nil {
+			return errr.ThrowBadRequestError(err)
+		}
+		if err := chargeFee(res.From, s.feeSchedules().Transfer); err != nil {
+			return errr.ThrowBadRequestError(err)
+		}
 
-	wallet, ex := s.findWalletWithCurrentState(ctx, walletID)
+		return s.saveWalletChanges(ctx, res.From, res.To)
+	})
 	if ex != nil {
 		return nil, ex
 	}
 
-	if err = wallet.WithdrawMoney(*money); err != nil {
-		return nil, errr.ThrowBadRequestError(err)
+	return res, nil
+}
+
+// FreezeWallet freezes wallet so that money can't be deposited to or withdrawn from it
+func (s *ServiceImplementation) FreezeWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	var wallet *Wallet
+	ex := s.inTransaction(ctx, func(ctx context.Context) *errr.Error {
+		var ex *errr.Error
+		if wallet, ex = s.loadWallet(ctx, walletID); ex != nil {
+			return ex
+		}
+		if err := wallet.Freeze(); err != nil {
+			return errr.ThrowBadRequestError(err)
+		}
+
+		return s.saveWalletChanges(ctx, wallet)
+	})
+	if ex != nil {
+		return nil, ex
 	}
 
-	return s.saveWalletChanges(ctx, wallet)
+	return wallet, nil
 }
 
 // GetWallet gets wallet with current state
@@ -111,12 +252,247 @@ func (s *ServiceImplementation) GetWallet(ctx context.Context, walletID string)
 	return s.findWalletWithCurrentState(ctx, walletID)
 }
 
-func (s *ServiceImplementation) saveWalletChanges(ctx context.Context, wallet *Wallet) (*Wallet, *errr.Error) {
-	if len(wallet.Changes) > 0 {
-		if err := s.repository.InsertTransactions(ctx, wallet.Changes...); err != nil {
-			return nil, errr.ThrowInternalServerError(err)
-		}
+// GetWalletAsOf gets wallet with its state as of asOf, events of its stream that occurred before asOf are applied
+// so balance, status and update time are all as of asOf. Wallets created after asOf aren't found
+func (s *ServiceImplementation) GetWalletAsOf(ctx context.Context, walletID string, asOf time.Time) (*Wallet, *errr.Error) {
+	if utility.IsStrEmpty(walletID) {
+		return nil, errr.ThrowBadRequestError(errors.New(ErrInvalidWalletID))
+	}
+
+	wallet, err := LoadWalletAsOf(ctx, s.events, s.snapshots, walletID, asOf)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if wallet == nil {
+		return nil, errr.ThrowNotFoundError(fmt.Errorf(ErrWalletNotFoundAsOf, walletID, asOf.Format(time.RFC3339)))
 	}
 
 	return wallet, nil
 }
+
+// GetTransactions gets transaction history of wallet
+func (s *ServiceImplementation) GetTransactions(ctx context.Context, walletID string) ([]Transaction, *errr.Error) {
+	if utility.IsStrEmpty(walletID) {
+		return nil, errr.ThrowBadRequestError(errors.New(ErrInvalidWalletID))
+	}
+
+	wallet, err := s.repository.FindWalletByID(ctx, walletID)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if wallet == nil {
+		return nil, errr.ThrowNotFoundError(fmt.Errorf(ErrWalletNotFound, walletID))
+	}
+
+	transactions, err := s.repository.FindTransactionsByWalletID(ctx, wallet.ID)
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if transactions == nil {
+		transactions = []Transaction{}
+	}
+
+	return transactions, nil
+}
+
+// FindTransactionsByReference finds transactions of every wallet with reference
+func (s *ServiceImplementation) FindTransactionsByReference(ctx context.Context, reference string) ([]Transaction, *errr.Error) {
+	if utility.IsStrEmpty(reference) {
+		return nil, errr.ThrowBadRequestError(errors.New(ErrEmptyReference))
+	}
+
+	transactions, err := s.repository.FindTransactionsByReferences(ctx, []string{strings.TrimSpace(reference)})
+	if err != nil {
+		return nil, errr.ThrowInternalServerError(err)
+	}
+	if transactions == nil {
+		transactions = []Transaction{}
+	}
+
+	return transactions, nil
+}
+
+// checkUniqueReference rejects req if it requires a unique reference and wallet already has a transaction with it.
+// It runs in the unit of work that saves the transaction, a concurrent one with the same reference conflicts on
+// the stream version of wallet and is retried or rejected
+func (s *ServiceImplementation) checkUniqueReference(ctx context.Context, walletID string, req *MoneyTransactionRequest) *errr.Error {
+	if !req.UniqueReference {
+		return nil
+	}
+
+	reference := strings.TrimSpace(req.Reference)
+	transactions, err := s.repository.FindTransactionsByReferences(ctx, []string{reference})
+	if err != nil {
+		return errr.ThrowInternalServerError(err)
+	}
+	for _, t := range transactions {
+		if t.WalletID == walletID {
+			return errr.ThrowConflictError(fmt.Errorf(ErrDuplicateReference, reference))
+		}
+	}
+
+	return nil
+}
+
+// moneyTransactionOf validates money and details of req
+func moneyTransactionOf(req *MoneyTransactionRequest) (*Money, *TransactionDetails, *errr.Error) {
+	money, err := NewMoney(req.Amount)
+	if err != nil {
+		return nil, nil, errr.ThrowBadRequestError(err)
+	}
+	details, err := NewTransactionDetails(req.Reference, req.Description, req.Metadata)
+	if err != nil {
+		return nil, nil, errr.ThrowBadRequestError(err)
+	}
+	if req.UniqueReference && details.Reference == "" {
+		return nil, nil, errr.ThrowBadRequestError(errors.New(ErrEmptyReference))
+	}
+
+	return money, details, nil
+}
+
+// saveWalletChanges appends events of wallets to their streams at the versions they are loaded at and updates
+// the read models by them. A concurrent use case that appended to a stream first fails the unit of work
+func (s *ServiceImplementation) saveWalletChanges(ctx context.Context, wallets ...*Wallet) *errr.Error {
+	var changes []Transaction
+	for _, wallet := range wallets {
+		if len(wallet.Events) == 0 {
+			continue
+		}
+
+		events, err := encodeEvents(wallet)
+		if err != nil {
+			return errr.ThrowInternalServerError(err)
+		}
+		loaded := wallet.StreamVersion
+		appended, err := s.events.Append(ctx, StreamOf(wallet.ID), loaded, events...)
+		if errors.Is(err, eventstore.ErrVersionConflict) {
+			return errr.ThrowAbortedError(fmt.Errorf(ErrConcurrentUpdate, wallet.ID))
+		}
+		if err != nil {
+			return errr.ThrowInternalServerError(err)
+		}
+		wallet.StreamVersion = appended[len(appended)-1].Version
+		if snapshotDue(loaded, wallet.StreamVersion) {
+			if err = s.snapshots.SaveSnapshot(ctx, snapshotOf(wallet)); err != nil {
+				return errr.ThrowInternalServerError(err)
+			}
+		}
+
+		if ex := s.updateReadModels(ctx, wallet); ex != nil {
+			return ex
+		}
+		changes = append(changes, wallet.Changes...)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err := s.repository.InsertTransactions(ctx, changes...); err != nil {
+		return errr.ThrowInternalServerError(err)
+	}
+
+	return nil
+}
+
+// updateReadModels updates wallets collection by events of wallet. Created wallets are inserted at version 0 since
+// transactions of money events are inserted by saveWalletChanges at once and move versions of their wallets
+func (s *ServiceImplementation) updateReadModels(ctx context.Context, wallet *Wallet) *errr.Error {
+	for _, event := range wallet.Events {
+		var err error
+		switch event.(type) {
+		case WalletCreated:
+			err = s.repository.InsertWallet(ctx, &Wallet{
+				ID:        wallet.ID,
+				Username:  wallet.Username,
+				Status:    wallet.Status,
+				CreatedAt: wallet.CreatedAt,
+				UpdatedAt: wallet.UpdatedAt,
+			})
+		case WalletFrozen:
+			err = s.repository.UpdateWalletStatus(ctx, wallet.ID, wallet.Status)
+		}
+		if err != nil {
+			return errr.ThrowInternalServerError(err)
+		}
+	}
+
+	return nil
+}
+
+// inTransaction runs fn in a unit of work of repository, changes of fn are aborted if it fails
+func (s *ServiceImplementation) inTransaction(ctx context.Context, fn func(ctx context.Context) *errr.Error) *errr.Error {
+	var ex *errr.Error
+	err := s.repository.WithinTransaction(ctx, func(ctx context.Context) error {
+		if ex = fn(ctx); ex != nil {
+			return ex
+		}
+		return nil
+	})
+	if ex != nil {
+		return ex
+	}
+	if err != nil {
+		return errr.ThrowInternalServerError(err)
+	}
+
+	return nil
+}
+
+// WriteStatement writes statement of wallet for the period of req to w. Transactions are iterated from the
+// beginning so that the opening balance is computed the same way as the balance of the wallet
+func (s *ServiceImplementation) WriteStatement(ctx context.Context, walletID string, req *StatementRequest, w StatementWriter) *errr.Error {
+	if utility.IsStrEmpty(walletID) {
+		return errr.ThrowBadRequestError(errors.New(ErrInvalidWalletID))
+	}
+	from, to, err := statementPeriodOf(req, time.Now())
+	if err != nil {
+		return errr.ThrowBadRequestError(err)
+	}
+
+	wallet, err := s.repository.FindWalletByID(ctx, walletID)
+	if err != nil {
+		return errr.ThrowInternalServerError(err)
+	}
+	if wallet == nil {
+		return errr.ThrowNotFoundError(fmt.Errorf(ErrWalletNotFound, walletID))
+	}
+
+	statement := &Statement{WalletID: wallet.ID, Username: wallet.Username, From: from, To: to}
+	state, opened := new(Wallet), false
+	open := func() error {
+		opened = true
+		statement.OpeningBalance = state.Balance
+		return w.WriteHeader(statement)
+	}
+
+	if err = s.repository.IterateTransactionsByWalletID(ctx, wallet.ID, to, func(t *Transaction) error {
+		if t.CreatedAt.Before(from) {
+			state.Mutate(*t)
+			return nil
+		}
+		if !opened {
+			if err := open(); err != nil {
+				return err
+			}
+		}
+
+		state.Mutate(*t)
+		statement.Transactions++
+		return w.WriteLine(&StatementLine{Transaction: *t, Balance: state.Balance})
+	}); err != nil {
+		return errr.ThrowInternalServerError(err)
+	}
+	if !opened {
+		if err = open(); err != nil {
+			return errr.ThrowInternalServerError(err)
+		}
+	}
+
+	statement.ClosingBalance = state.Balance
+	if err = w.WriteFooter(statement); err != nil {
+		return errr.ThrowInternalServerError(err)
+	}
+
+	return nil
+}
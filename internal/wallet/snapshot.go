@@ -0,0 +1,60 @@
+package wallet
+
+import (
+	"fmt"
+	"time"
+)
+
+// snapshotInterval is how many events are appended to a wallet stream between its snapshots
+const snapshotInterval = 100
+
+// Snapshot is the state of a wallet after the events of its stream up to StreamVersion. OccurredUntil is the latest
+// time those events occurred at, so the snapshot is the start of states as of any time after it
+type Snapshot struct {
+	ID            string       `bson:"_id"`
+	WalletID      string       `bson:"wallet_id"`
+	StreamVersion int64        `bson:"stream_version"`
+	OccurredUntil time.Time    `bson:"occurred_until"`
+	Username      string       `bson:"username"`
+	Status        WalletStatus `bson:"status"`
+	Balance       Money        `bson:"balance"`
+	Transactions  int64        `bson:"transactions"`
+	CreatedAt     time.Time    `bson:"created_at"`
+	UpdatedAt     time.Time    `bson:"updated_at"`
+}
+
+// snapshotOf returns snapshot of the state of wallet at its stream version
+func snapshotOf(w *Wallet) *Snapshot {
+	return &Snapshot{
+		ID:            fmt.Sprintf("%s:%d", w.ID, w.StreamVersion),
+		WalletID:      w.ID,
+		StreamVersion: w.StreamVersion,
+		OccurredUntil: w.occurredUntil,
+		Username:      w.Username,
+		Status:        w.Status,
+		Balance:       w.Balance,
+		Transactions:  w.Version,
+		CreatedAt:     w.CreatedAt,
+		UpdatedAt:     w.UpdatedAt,
+	}
+}
+
+// wallet returns wallet with the state of snapshot, events after its stream version are applied to it
+func (s *Snapshot) wallet() *Wallet {
+	return &Wallet{
+		ID:            s.WalletID,
+		Username:      s.Username,
+		Balance:       s.Balance,
+		Status:        s.Status,
+		CreatedAt:     s.CreatedAt,
+		Version:       s.Transactions,
+		UpdatedAt:     s.UpdatedAt,
+		StreamVersion: s.StreamVersion,
+		occurredUntil: s.OccurredUntil,
+	}
+}
+
+// snapshotDue reports whether a snapshot is taken of a stream whose version moved from one to another
+func snapshotDue(from, to int64) bool {
+	return to/snapshotInterval > from/snapshotInterval
+}
@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+//go:generate mockgen -source=snapshot_repository.go -destination=./test/snapshot_repository_mock.go -package=wallet
+
+const walletSnapshotsCollection = "wallet_snapshots"
+
+type (
+	// SnapshotRepository stores snapshots of wallet states
+	SnapshotRepository interface {
+		// FindSnapshot finds the latest snapshot of wallet whose events occurred before `before`, nil is returned
+		// if it has none
+		FindSnapshot(ctx context.Context, walletID string, before time.Time) (*Snapshot, error)
+		// SaveSnapshot saves snapshot, saving a snapshot of the same stream version again replaces it
+		SaveSnapshot(ctx context.Context, s *Snapshot) error
+	}
+
+	// MongoSnapshotRepository is a concrete implementation of SnapshotRepository interface
+	MongoSnapshotRepository struct {
+		snapshots *mongo.Collection
+	}
+)
+
+// NewMongoSnapshotRepository creates instance of MongoSnapshotRepository
+func NewMongoSnapshotRepository(db *mongo.Database) *MongoSnapshotRepository {
+	return &MongoSnapshotRepository{snapshots: db.Collection(walletSnapshotsCollection)}
+}
+
+// FindSnapshot finds the latest snapshot of wallet whose events occurred before `before`. Times events occurred
+// at grow with stream versions of snapshots, so the one with the highest version is the latest
+func (r *MongoSnapshotRepository) FindSnapshot(ctx context.Context, walletID string, before time.Time) (*Snapshot, error) {
+	s := new(Snapshot)
+	err := r.snapshots.FindOne(ctx, bson.M{"wallet_id": walletID, "occurred_until": bson.M{"$lt": before}},
+		options.FindOne().SetSort(bson.D{{Key: "stream_version", Value: -1}})).Decode(s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SaveSnapshot saves snapshot, saving a snapshot of the same stream version again replaces it
+func (r *MongoSnapshotRepository) SaveSnapshot(ctx context.Context, s *Snapshot) error {
+	_, err := r.snapshots.ReplaceOne(ctx, bson.M{"_id": s.ID}, s, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,253 @@
+package wallet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Formats of statements
+const (
+	CSVStatementFormat  = "csv"
+	JSONStatementFormat = "json"
+	TextStatementFormat = "text"
+)
+
+type (
+	// Statement is the opening balance, transactions and closing balance of a wallet in [From, To)
+	Statement struct {
+		WalletID       string    `json:"wallet_id"`
+		Username       string    `json:"username"`
+		From           time.Time `json:"from"`
+		To             time.Time `json:"to"`
+		OpeningBalance Money     `json:"opening_balance"`
+		ClosingBalance Money     `json:"closing_balance"`
+		// Transactions is the number of transactions in the period
+		Transactions int `json:"transactions"`
+	}
+
+	// StatementLine is a transaction of a statement with the balance of wallet after it
+	StatementLine struct {
+		Transaction
+		Balance Money `json:"balance"`
+	}
+
+	// StatementDocument is the json format of statements
+	StatementDocument struct {
+		Statement
+		Lines []StatementLine `json:"lines"`
+	}
+
+	// StatementWriter writes statements as they are computed so that histories aren't held in memory.
+	// WriteHeader is called once with the opening balance, then WriteLine for every transaction and WriteFooter
+	// once with the closing balance
+	StatementWriter interface {
+		WriteHeader(s *Statement) error
+		WriteLine(l *StatementLine) error
+		WriteFooter(s *Statement) error
+	}
+
+	csvStatementWriter struct {
+		w *csv.Writer
+	}
+
+	jsonStatementWriter struct {
+		w     *bufio.Writer
+		lines int
+	}
+
+	textStatementWriter struct {
+		w *bufio.Writer
+	}
+)
+
+var statementContentTypes = map[string]string{
+	CSVStatementFormat:  "text/csv; charset=utf-8",
+	JSONStatementFormat: "application/json",
+	TextStatementFormat: "text/plain; charset=utf-8",
+}
+
+// NewStatementWriter creates StatementWriter of format that writes to w, output is flushed by WriteFooter
+func NewStatementWriter(w io.Writer, format string) (StatementWriter, error) {
+	switch format {
+	case CSVStatementFormat:
+		return &csvStatementWriter{w: csv.NewWriter(w)}, nil
+	case JSONStatementFormat:
+		return &jsonStatementWriter{w: bufio.NewWriter(w)}, nil
+	case TextStatementFormat:
+		return &textStatementWriter{w: bufio.NewWriter(w)}, nil
+	}
+
+	return nil, fmt.Errorf(ErrUnknownStatementFormat, format)
+}
+
+// StatementContentType returns media type of statements of format
+func StatementContentType(format string) string {
+	return statementContentTypes[format]
+}
+
+// StatementFileName returns name of statement file of wallet in format
+func StatementFileName(walletID, format string) string {
+	ext := format
+	if format == TextStatementFormat {
+		ext = "txt"
+	}
+
+	return "statement-" + walletID + "." + ext
+}
+
+// Amount returns amount of line, it is negative for withdrawals
+func (l *StatementLine) Amount() float32 {
+	if l.Type == WithdrawTransactionType {
+		return -l.Money.Amount
+	}
+
+	return l.Money.Amount
+}
+
+// statementPeriodOf parses period of req, now is the default of to and its month the default of from
+func statementPeriodOf(req *StatementRequest, now time.Time) (from, to time.Time, err error) {
+	now = now.UTC()
+	to, from = now, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if req == nil {
+		return from, to, nil
+	}
+
+	if req.From != "" {
+		if from, err = ParseDateOrTime(req.From); err != nil {
+			return from, to, fmt.Errorf(ErrInvalidTime, "from")
+		}
+	}
+	if req.To != "" {
+		if to, err = ParseDateOrTime(req.To); err != nil {
+			return from, to, fmt.Errorf(ErrInvalidTime, "to")
+		}
+	}
+	if !from.Before(to) {
+		return from, to, errors.New(ErrInvalidStatementPeriod)
+	}
+
+	return from, to, nil
+}
+
+// ParseDateOrTime parses v as date, e.g. 2024-03-01, at midnight in UTC or as RFC3339 time
+func ParseDateOrTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	return t.UTC(), err
+}
+
+func formatStatementAmount(amount float32) string {
+	return strconv.FormatFloat(float64(amount), 'f', 2, 32)
+}
+
+var statementColumns = []string{"date", "transaction_id", "type", "reference", "description", "amount", "balance"}
+
+func (w *csvStatementWriter) WriteHeader(s *Statement) error {
+	if err := w.w.Write(statementColumns); err != nil {
+		return err
+	}
+
+	return w.w.Write([]string{s.From.Format(time.RFC3339), "", "opening_balance", "", "", "", formatStatementAmount(s.OpeningBalance.Amount)})
+}
+
+func (w *csvStatementWriter) WriteLine(l *StatementLine) error {
+	return w.w.Write([]string{
+		l.CreatedAt.UTC().Format(time.RFC3339),
+		l.ID,
+		string(l.Type),
+		l.Reference,
+		l.Description,
+		formatStatementAmount(l.Amount()),
+		formatStatementAmount(l.Balance.Amount),
+	})
+}
+
+func (w *csvStatementWriter) WriteFooter(s *Statement) error {
+	if err := w.w.Write([]string{s.To.Format(time.RFC3339), "", "closing_balance", "", "", "", formatStatementAmount(s.ClosingBalance.Amount)}); err != nil {
+		return err
+	}
+	w.w.Flush()
+
+	return w.w.Error()
+}
+
+// WriteHeader opens the StatementDocument with fields known before the transactions, the rest are written by
+// WriteFooter
+func (w *jsonStatementWriter) WriteHeader(s *Statement) error {
+	b, err := json.Marshal(struct {
+		WalletID       string    `json:"wallet_id"`
+		Username       string    `json:"username"`
+		From           time.Time `json:"from"`
+		To             time.Time `json:"to"`
+		OpeningBalance Money     `json:"opening_balance"`
+	}{s.WalletID, s.Username, s.From, s.To, s.OpeningBalance})
+	if err != nil {
+		return err
+	}
+
+	// drop the closing brace to continue the object
+	_, err = fmt.Fprintf(w.w, `%s,"lines":[`, b[:len(b)-1])
+	return err
+}
+
+func (w *jsonStatementWriter) WriteLine(l *StatementLine) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if w.lines > 0 {
+		if err = w.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	w.lines++
+
+	_, err = w.w.Write(b)
+	return err
+}
+
+func (w *jsonStatementWriter) WriteFooter(s *Statement) error {
+	closing, err := json.Marshal(s.ClosingBalance)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(w.w, `],"closing_balance":%s,"transactions":%d}`+"\n", closing, s.Transactions); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+func (w *textStatementWriter) WriteHeader(s *Statement) error {
+	_, err := fmt.Fprintf(w.w, "Statement of wallet %s (%s)\nPeriod: %s - %s\n\n%-20s  %-8s  %14s  %14s  %s\n%-20s  %-8s  %14s  %14s\n",
+		s.WalletID, s.Username, s.From.Format(time.RFC3339), s.To.Format(time.RFC3339),
+		"DATE", "TYPE", "AMOUNT", "BALANCE", "REFERENCE",
+		s.From.Format(time.RFC3339), "opening", "", formatStatementAmount(s.OpeningBalance.Amount))
+	return err
+}
+
+func (w *textStatementWriter) WriteLine(l *StatementLine) error {
+	_, err := fmt.Fprintf(w.w, "%-20s  %-8s  %14s  %14s  %s\n",
+		l.CreatedAt.UTC().Format(time.RFC3339), l.Type, formatStatementAmount(l.Amount()),
+		formatStatementAmount(l.Balance.Amount), l.Reference)
+	return err
+}
+
+func (w *textStatementWriter) WriteFooter(s *Statement) error {
+	if _, err := fmt.Fprintf(w.w, "%-20s  %-8s  %14s  %14s\n\nTransactions: %d\nClosing balance: %s\n",
+		s.To.Format(time.RFC3339), "closing", "", formatStatementAmount(s.ClosingBalance.Amount),
+		s.Transactions, formatStatementAmount(s.ClosingBalance.Amount)); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+type recorder struct {
+	records []audit.Record
+	err     error
+}
+
+func (r *recorder) Record(ctx context.Context, record audit.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.records = append(r.records, record)
+	return r.err
+}
+
+func TestAuditingService(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+	rec := &recorder{}
+	events := eventstore.NewMemoryStore()
+	service := wallet.NewAuditingService(wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), rec, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	w := &wallet.Wallet{ID: uuid.NewString(), Username: "user"}
+	seedEvents(t, events, w)
+
+	// state before is derived from the result, the mock fails on any read of the wallet
+	t.Run("should record state before and after succeeded use case", func(t *testing.T) {
+		mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil)
+
+		_, err := service.DepositMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 10})
+		assert.Nil(t, err)
+
+		r := rec.records[len(rec.records)-1]
+		assert.Equal(t, wallet.DepositMoneyAuditAction, r.Action)
+		assert.Equal(t, []string{w.ID}, r.WalletIDs)
+		assert.Nil(t, r.Err)
+		assert.Equal(t, float32(0), r.Before.(*wallet.Wallet).Balance.Amount)
+		assert.Equal(t, int64(0), r.Before.(*wallet.Wallet).Version)
+		assert.Equal(t, float32(10), r.After.(*wallet.Wallet).Balance.Amount)
+		assert.Equal(t, int64(1), r.After.(*wallet.Wallet).Version)
+	})
+
+	t.Run("should record state before and after transfer", func(t *testing.T) {
+		to := &wallet.Wallet{ID: uuid.NewString(), Username: "other"}
+		seedEvents(t, events, to)
+		mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := service.TransferMoney(ctx, w.ID, &wallet.TransferMoneyRequest{ToWalletID: to.ID, Amount: 4})
+		assert.Nil(t, err)
+
+		r := rec.records[len(rec.records)-1]
+		before, after := r.Before.(*wallet.TransferMoneyResponse), r.After.(*wallet.TransferMoneyResponse)
+		assert.Equal(t, float32(10), before.From.Balance.Amount)
+		assert.Equal(t, float32(0), before.To.Balance.Amount)
+		assert.Equal(t, float32(6), after.From.Balance.Amount)
+		assert.Equal(t, float32(4), after.To.Balance.Amount)
+	})
+
+	t.Run("should record failed use case", func(t *testing.T) {
+		_, err := service.WithdrawMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 100})
+		assert.NotNil(t, err)
+
+		r := rec.records[len(rec.records)-1]
+		assert.Equal(t, wallet.WithdrawMoneyAuditAction, r.Action)
+		assert.NotNil(t, r.Err)
+		assert.Equal(t, wallet.ErrInsufficientMoneyAmount, r.Err.Error())
+		assert.Nil(t, r.Before.(*wallet.Wallet))
+		assert.Nil(t, r.After.(*wallet.Wallet))
+	})
+
+	t.Run("should not fail use case when recording fails", func(t *testing.T) {
+		rec.err = errors.New("audit is down")
+		defer func() { rec.err = nil }()
+
+		mockRepo.EXPECT().InsertWallet(ctx, gomock.Any()).Return(nil)
+
+		id, err := service.CreateWallet(ctx, &wallet.CreateWalletRequest{Username: "user"})
+		assert.Nil(t, err)
+
+		r := rec.records[len(rec.records)-1]
+		assert.Equal(t, wallet.CreateWalletAuditAction, r.Action)
+		assert.Equal(t, []string{id.Id}, r.WalletIDs)
+	})
+
+	t.Run("should record state before and after freeze", func(t *testing.T) {
+		mockRepo.EXPECT().UpdateWalletStatus(ctx, w.ID, wallet.FrozenWalletStatus).Return(nil)
+
+		_, err := service.FreezeWallet(ctx, w.ID)
+		assert.Nil(t, err)
+
+		r := rec.records[len(rec.records)-1]
+		assert.Equal(t, wallet.ActiveWalletStatus, r.Before.(*wallet.Wallet).Status)
+		assert.Equal(t, float32(6), r.Before.(*wallet.Wallet).Balance.Amount)
+		assert.Equal(t, wallet.FrozenWalletStatus, r.After.(*wallet.Wallet).Status)
+	})
+}
@@ -0,0 +1,155 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBalanceAsOf(t *testing.T) {
+	ctx := context.Background()
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	olderID, newerID := uuid.NewString(), uuid.NewString()
+	// older and newer return new documents on every call since the service mutates them
+	older := func() *wallet.Wallet {
+		return &wallet.Wallet{ID: olderID, Username: "older", CreatedAt: april.AddDate(0, -2, 0)}
+	}
+	newer := func() *wallet.Wallet {
+		return &wallet.Wallet{ID: newerID, Username: "newer", CreatedAt: april.AddDate(0, 0, 3)}
+	}
+	// olderHistory returns a store with the stream of older, it is frozen and has a deposit after april
+	olderHistory := func(t *testing.T) eventstore.Store {
+		events := eventstore.NewMemoryStore()
+		appendEvents(t, events, olderID,
+			wallet.WalletCreated{Username: "older", CreatedAt: april.AddDate(0, -2, 0)},
+			wallet.MoneyDeposited{Transaction: wallet.Transaction{ID: uuid.NewString(), WalletID: olderID,
+				Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 100.5}, CreatedAt: april.AddDate(0, -1, 0)}},
+			wallet.MoneyWithdrawn{Transaction: wallet.Transaction{ID: uuid.NewString(), WalletID: olderID,
+				Type: wallet.WithdrawTransactionType, Money: wallet.Money{Amount: 40}, CreatedAt: april.AddDate(0, 0, -1)}},
+			wallet.MoneyDeposited{Transaction: wallet.Transaction{ID: uuid.NewString(), WalletID: olderID,
+				Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 7}, CreatedAt: april}},
+			wallet.WalletFrozen{FrozenAt: april.AddDate(0, 0, 1)},
+		)
+
+		return events
+	}
+
+	t.Run("should get wallet with state as of time", func(t *testing.T) {
+		service := wallet.NewService(setupMockRepo(t), olderHistory(t), noSnapshots(t))
+
+		res, err := service.GetWalletAsOf(ctx, olderID, april)
+		assert.Nil(t, err)
+		assert.Equal(t, float32(60.5), res.Balance.Amount)
+		assert.Equal(t, int64(2), res.Version)
+		assert.Equal(t, wallet.ActiveWalletStatus, res.Status, "status must be as of time, not the current one")
+		assert.Equal(t, april, *res.AsOf)
+
+		res, err = service.GetWalletAsOf(ctx, olderID, april.AddDate(0, 0, 2))
+		assert.Nil(t, err)
+		assert.Equal(t, float32(67.5), res.Balance.Amount)
+		assert.Equal(t, wallet.FrozenWalletStatus, res.Status)
+		assert.Equal(t, april.AddDate(0, 0, 1), res.UpdatedAt)
+	})
+
+	t.Run("should return not found if wallet is created after time", func(t *testing.T) {
+		events := eventstore.NewMemoryStore()
+		appendEvents(t, events, newerID, wallet.WalletCreated{Username: "newer", CreatedAt: april.AddDate(0, 0, 3)})
+
+		res, err := wallet.NewService(setupMockRepo(t), events, noSnapshots(t)).GetWalletAsOf(ctx, newerID, april)
+		assert.Nil(t, res)
+		assert.Equal(t, 404, err.Code)
+
+		res, err = wallet.NewService(setupMockRepo(t), events, noSnapshots(t)).GetWalletAsOf(ctx, uuid.NewString(), april)
+		assert.Nil(t, res)
+		assert.Equal(t, 404, err.Code)
+	})
+
+	t.Run("should replay events after snapshot", func(t *testing.T) {
+		mockSnapshots := NewMockSnapshotRepository(gomock.NewController(t))
+		mockSnapshots.EXPECT().FindSnapshot(ctx, olderID, april.AddDate(0, 0, 2)).Return(&wallet.Snapshot{
+			WalletID: olderID, StreamVersion: 3, OccurredUntil: april.AddDate(0, 0, -1), Username: "snapshot",
+			Status: wallet.ActiveWalletStatus, Balance: wallet.Money{Amount: 1000}, Transactions: 2,
+			CreatedAt: april.AddDate(0, -2, 0), UpdatedAt: april.AddDate(0, 0, -1),
+		}, nil)
+
+		w, err := wallet.LoadWalletAsOf(ctx, olderHistory(t), mockSnapshots, olderID, april.AddDate(0, 0, 2))
+		assert.Nil(t, err)
+		assert.Equal(t, "snapshot", w.Username, "wallet must start from snapshot")
+		assert.Equal(t, float32(1007), w.Balance.Amount)
+		assert.Equal(t, wallet.FrozenWalletStatus, w.Status)
+		assert.Equal(t, int64(5), w.StreamVersion)
+	})
+
+	t.Run("should report balances of wallets created before time", func(t *testing.T) {
+		mockRepo := setupMockRepo(t)
+		mockRepo.EXPECT().IterateWallets(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, fn func(w *wallet.Wallet) error) error {
+			for _, w := range []*wallet.Wallet{older(), newer()} {
+				if err := fn(w); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		mockSnapshots := NewMockSnapshotRepository(gomock.NewController(t))
+		mockSnapshots.EXPECT().FindSnapshot(ctx, olderID, april).Return(nil, nil)
+
+		var lines []wallet.BalanceReportLine
+		total, err := wallet.BalanceReport(ctx, mockRepo, olderHistory(t), mockSnapshots, april, func(l wallet.BalanceReportLine) error {
+			lines = append(lines, l)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []wallet.BalanceReportLine{
+			{WalletID: olderID, Username: "older", Balance: wallet.Money{Amount: 60.5}, Transactions: 2},
+		}, lines)
+		assert.Equal(t, wallet.BalanceReportTotal{AsOf: april, Wallets: 1, Total: 6050}, total)
+	})
+
+	t.Run("should get wallet as of time over http", func(t *testing.T) {
+		app := fiber.New()
+		wallet.NewApi(wallet.NewService(setupMockRepo(t), olderHistory(t), noSnapshots(t))).AddRoutesTo(app)
+
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/wallets/"+olderID+"?as_of=2024-04-01", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		var body struct {
+			Balance wallet.Money `json:"balance"`
+			AsOf    time.Time    `json:"as_of"`
+		}
+		assert.Nil(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, float32(60.5), body.Balance.Amount)
+		assert.Equal(t, april, body.AsOf)
+
+		res, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/wallets/"+olderID+"?as_of=yesterday", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
+
+// noSnapshots creates mock snapshot repository without any snapshot
+func noSnapshots(t *testing.T) *MockSnapshotRepository {
+	snapshots := NewMockSnapshotRepository(gomock.NewController(t))
+	snapshots.EXPECT().FindSnapshot(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	return snapshots
+}
+
+// appendEvents appends payloads to the stream of wallet
+func appendEvents(t *testing.T, store eventstore.Store, walletID string, payloads ...any) {
+	events := make([]eventstore.Event, len(payloads))
+	for i, payload := range payloads {
+		var err error
+		events[i], err = wallet.Events.Encode(payload)
+		assert.Nil(t, err)
+	}
+	_, err := store.Append(context.Background(), wallet.StreamOf(walletID), eventstore.NoStream, events...)
+	assert.Nil(t, err)
+}
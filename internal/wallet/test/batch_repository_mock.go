@@ -0,0 +1,124 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: batch_repository.go
+
+// Package wallet is a generated GoMock package.
+package wallet
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	wallet "github.com/ybalcin/wallet-service/internal/wallet"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBatchRepository is a mock of BatchRepository interface.
+type MockBatchRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchRepositoryMockRecorder
+}
+
+// MockBatchRepositoryMockRecorder is the mock recorder for MockBatchRepository.
+type MockBatchRepositoryMockRecorder struct {
+	mock *MockBatchRepository
+}
+
+// NewMockBatchRepository creates a new mock instance.
+func NewMockBatchRepository(ctrl *gomock.Controller) *MockBatchRepository {
+	mock := &MockBatchRepository{ctrl: ctrl}
+	mock.recorder = &MockBatchRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchRepository) EXPECT() *MockBatchRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimBatch mocks base method.
+func (m *MockBatchRepository) ClaimBatch(ctx context.Context, now time.Time, lease time.Duration) (*wallet.Batch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimBatch", ctx, now, lease)
+	ret0, _ := ret[0].(*wallet.Batch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimBatch indicates an expected call of ClaimBatch.
+func (mr *MockBatchRepositoryMockRecorder) ClaimBatch(ctx, now, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimBatch", reflect.TypeOf((*MockBatchRepository)(nil).ClaimBatch), ctx, now, lease)
+}
+
+// FindBatchByID mocks base method.
+func (m *MockBatchRepository) FindBatchByID(ctx context.Context, id string) (*wallet.Batch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindBatchByID", ctx, id)
+	ret0, _ := ret[0].(*wallet.Batch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindBatchByID indicates an expected call of FindBatchByID.
+func (mr *MockBatchRepositoryMockRecorder) FindBatchByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBatchByID", reflect.TypeOf((*MockBatchRepository)(nil).FindBatchByID), ctx, id)
+}
+
+// FindIdempotencyKey mocks base method.
+func (m *MockBatchRepository) FindIdempotencyKey(ctx context.Context, key string) (*wallet.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(*wallet.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIdempotencyKey indicates an expected call of FindIdempotencyKey.
+func (mr *MockBatchRepositoryMockRecorder) FindIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIdempotencyKey", reflect.TypeOf((*MockBatchRepository)(nil).FindIdempotencyKey), ctx, key)
+}
+
+// InsertBatch mocks base method.
+func (m *MockBatchRepository) InsertBatch(ctx context.Context, batch *wallet.Batch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertBatch", ctx, batch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertBatch indicates an expected call of InsertBatch.
+func (mr *MockBatchRepositoryMockRecorder) InsertBatch(ctx, batch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBatch", reflect.TypeOf((*MockBatchRepository)(nil).InsertBatch), ctx, batch)
+}
+
+// InsertIdempotencyKey mocks base method.
+func (m *MockBatchRepository) InsertIdempotencyKey(ctx context.Context, key *wallet.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertIdempotencyKey indicates an expected call of InsertIdempotencyKey.
+func (mr *MockBatchRepositoryMockRecorder) InsertIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertIdempotencyKey", reflect.TypeOf((*MockBatchRepository)(nil).InsertIdempotencyKey), ctx, key)
+}
+
+// SaveBatch mocks base method.
+func (m *MockBatchRepository) SaveBatch(ctx context.Context, batch *wallet.Batch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBatch", ctx, batch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveBatch indicates an expected call of SaveBatch.
+func (mr *MockBatchRepositoryMockRecorder) SaveBatch(ctx, batch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBatch", reflect.TypeOf((*MockBatchRepository)(nil).SaveBatch), ctx, batch)
+}
@@ -0,0 +1,373 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/audit"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryBatches is a batch repository that keeps batches and idempotency keys in memory, batches are copied in
+// and out like the database does
+type memoryBatches struct {
+	batches map[string]*wallet.Batch
+	order   []string
+	keys    map[string]*wallet.IdempotencyKey
+}
+
+func newMemoryBatches() *memoryBatches {
+	return &memoryBatches{batches: map[string]*wallet.Batch{}, keys: map[string]*wallet.IdempotencyKey{}}
+}
+
+func (r *memoryBatches) InsertBatch(_ context.Context, batch *wallet.Batch) error {
+	r.batches[batch.ID] = copyBatch(batch)
+	r.order = append(r.order, batch.ID)
+	return nil
+}
+
+func (r *memoryBatches) FindBatchByID(_ context.Context, id string) (*wallet.Batch, error) {
+	if batch, ok := r.batches[id]; ok {
+		return copyBatch(batch), nil
+	}
+	return nil, nil
+}
+
+func (r *memoryBatches) ClaimBatch(_ context.Context, now time.Time, lease time.Duration) (*wallet.Batch, error) {
+	for _, id := range r.order {
+		batch := r.batches[id]
+		if batch.Status == wallet.PendingBatchStatus || batch.Status == wallet.ProcessingBatchStatus && batch.LeaseUntil.Before(now) {
+			batch.Status, batch.LeaseID, batch.LeaseUntil = wallet.ProcessingBatchStatus, uuid.NewString(), now.Add(lease)
+			return copyBatch(batch), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryBatches) SaveBatch(_ context.Context, batch *wallet.Batch) error {
+	if r.batches[batch.ID].LeaseID != batch.LeaseID {
+		return wallet.ErrBatchLeaseLost
+	}
+	r.batches[batch.ID] = copyBatch(batch)
+	return nil
+}
+
+func (r *memoryBatches) FindIdempotencyKey(_ context.Context, key string) (*wallet.IdempotencyKey, error) {
+	return r.keys[key], nil
+}
+
+func (r *memoryBatches) InsertIdempotencyKey(_ context.Context, key *wallet.IdempotencyKey) error {
+	if _, ok := r.keys[key.Key]; ok {
+		return wallet.ErrDuplicateIdempotencyKey
+	}
+	r.keys[key.Key] = key
+	return nil
+}
+
+func copyBatch(batch *wallet.Batch) *wallet.Batch {
+	c := *batch
+	c.Items = append([]wallet.BatchItem(nil), batch.Items...)
+	return &c
+}
+
+func TestBatchService(t *testing.T) {
+	ctx := audit.WithMetadata(context.Background(), audit.Metadata{Actor: "payroll"})
+	batches := newMemoryBatches()
+	service := wallet.NewBatchService(batches, wallet.BatchLimits{MaxItems: 3, MaxAtomicItems: 2})
+
+	t.Run("should save pending batch of submitter", func(t *testing.T) {
+		batch, err := service.SubmitBatch(ctx, &wallet.BatchRequest{Items: []wallet.BatchItemRequest{
+			{Type: wallet.DepositBatchItemType, WalletID: "a", Amount: 10, Reference: "march", IdempotencyKey: " march-a "},
+			{Type: wallet.TransferBatchItemType, WalletID: "a", ToWalletID: "b", Amount: 5},
+		}})
+		assert.Nil(t, err)
+		assert.Equal(t, wallet.PendingBatchStatus, batch.Status)
+		assert.Equal(t, wallet.BatchSummary{Pending: 2}, batch.Summary)
+		assert.Equal(t, "march-a", batch.Items[0].IdempotencyKey)
+		assert.Equal(t, batch.ID+":1", batch.Items[1].IdempotencyKey, "key must default to one of the item")
+		assert.Equal(t, wallet.PendingBatchItemStatus, batch.Items[1].Status)
+
+		saved, err := service.GetBatch(ctx, batch.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, "payroll", saved.Submitter.Actor)
+		assert.Equal(t, batch.Items, saved.Items)
+	})
+
+	t.Run("should reject batches over the limits", func(t *testing.T) {
+		item := wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: "a", Amount: 1}
+
+		_, err := service.SubmitBatch(ctx, &wallet.BatchRequest{})
+		assert.Equal(t, wallet.ErrEmptyBatch, err.Message)
+		_, err = service.SubmitBatch(ctx, &wallet.BatchRequest{Items: []wallet.BatchItemRequest{item, item, item, item}})
+		assert.Equal(t, fmt.Sprintf(wallet.ErrTooManyBatchItems, 3), err.Message)
+		_, err = service.SubmitBatch(ctx, &wallet.BatchRequest{Atomic: true, Items: []wallet.BatchItemRequest{item, item, item}})
+		assert.Equal(t, fmt.Sprintf(wallet.ErrTooManyAtomicItems, 2), err.Message)
+
+		_, err = wallet.NewBatchService(batches, wallet.BatchLimits{MaxItems: 3}).
+			SubmitBatch(ctx, &wallet.BatchRequest{Atomic: true, Items: []wallet.BatchItemRequest{item}})
+		assert.Equal(t, wallet.ErrAtomicBatchesDisabled, err.Message)
+	})
+
+	t.Run("should reject invalid items with their indexes", func(t *testing.T) {
+		valid := wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: "a", Amount: 1, IdempotencyKey: "k"}
+		cases := map[string]wallet.BatchItemRequest{
+			fmt.Sprintf(wallet.ErrInvalidBatchItemType, "refund"): {Type: "refund", WalletID: "a", Amount: 1},
+			wallet.ErrInvalidWalletID:                             {Type: wallet.WithdrawBatchItemType, Amount: 1},
+			wallet.ErrInvalidMoneyAmount:                          {Type: wallet.DepositBatchItemType, WalletID: "a", Amount: 0.001},
+			wallet.ErrSameWalletTransfer:                          {Type: wallet.TransferBatchItemType, WalletID: "a", ToWalletID: "a", Amount: 1},
+			wallet.ErrTransferDetails:                             {Type: wallet.TransferBatchItemType, WalletID: "a", ToWalletID: "b", Amount: 1, Reference: "r"},
+			fmt.Sprintf(wallet.ErrRepeatedIdempotencyKey, "k", 0): {Type: wallet.DepositBatchItemType, WalletID: "b", Amount: 1, IdempotencyKey: "k"},
+		}
+
+		for message, item := range cases {
+			_, err := service.SubmitBatch(ctx, &wallet.BatchRequest{Items: []wallet.BatchItemRequest{valid, item}})
+			assert.Equal(t, fmt.Sprintf(wallet.ErrInvalidBatchItem, 1, message), err.Message)
+		}
+	})
+
+	t.Run("should return not found for unknown batch", func(t *testing.T) {
+		_, err := service.GetBatch(ctx, "unknown")
+		assert.Equal(t, fiber.StatusNotFound, err.Code)
+	})
+}
+
+func TestBatchProcessor(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+	mockRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockRepo.EXPECT().FindWalletByID(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	events := eventstore.NewMemoryStore()
+	rich := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+	poor := &wallet.Wallet{ID: uuid.NewString()}
+	seedEvents(t, events, rich, poor)
+
+	batches := newMemoryBatches()
+	rec := &recorder{}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reg := prometheus.NewRegistry()
+	metrics := wallet.NewMetrics(reg)
+	processor := wallet.NewBatchProcessor(batches, mockRepo, wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), rec, metrics, time.Minute, log)
+	service := wallet.NewBatchService(batches, wallet.BatchLimits{MaxItems: 10, MaxAtomicItems: 10})
+
+	submit := func(t *testing.T, atomic bool, items ...wallet.BatchItemRequest) *wallet.Batch {
+		batch, err := service.SubmitBatch(audit.WithMetadata(ctx, audit.Metadata{Actor: "payroll"}),
+			&wallet.BatchRequest{Atomic: atomic, Items: items})
+		assert.Nil(t, err)
+		return batch
+	}
+	process := func(t *testing.T, id string) *wallet.Batch {
+		processed, err := processor.Step(ctx)
+		assert.Nil(t, err)
+		assert.True(t, processed)
+		batch, _ := batches.FindBatchByID(ctx, id)
+		return batch
+	}
+	balanceOf := func(t *testing.T, id string) float32 {
+		w, err := wallet.LoadWallet(ctx, events, id)
+		assert.Nil(t, err)
+		return w.Balance.Amount
+	}
+
+	t.Run("should apply every item and report failed ones", func(t *testing.T) {
+		rec.records = nil
+		missing := uuid.NewString()
+		batch := process(t, submit(t, false,
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: rich.ID, Amount: 5, IdempotencyKey: "salary"},
+			wallet.BatchItemRequest{Type: wallet.WithdrawBatchItemType, WalletID: poor.ID, Amount: 3},
+			wallet.BatchItemRequest{Type: wallet.TransferBatchItemType, WalletID: rich.ID, ToWalletID: poor.ID, Amount: 2},
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: missing, Amount: 1},
+		).ID)
+
+		assert.Equal(t, wallet.CompletedBatchStatus, batch.Status)
+		assert.Equal(t, wallet.BatchSummary{Succeeded: 2, Failed: 2}, batch.Summary)
+		assert.NotNil(t, batch.StartedAt)
+		assert.NotNil(t, batch.CompletedAt)
+
+		assert.Equal(t, wallet.SucceededBatchItemStatus, batch.Items[0].Status)
+		assert.Len(t, batch.Items[0].TransactionIDs, 1)
+		assert.Equal(t, wallet.FailedBatchItemStatus, batch.Items[1].Status)
+		assert.Equal(t, wallet.ErrInsufficientMoneyAmount, batch.Items[1].Error)
+		assert.Len(t, batch.Items[2].TransactionIDs, 2, "transfer must record transactions of both wallets")
+		assert.Equal(t, fmt.Sprintf(wallet.ErrWalletNotFound, missing), batch.Items[3].Error)
+
+		assert.Equal(t, float32(13), balanceOf(t, rich.ID))
+		assert.Equal(t, float32(2), balanceOf(t, poor.ID))
+
+		assert.Len(t, rec.records, 4, "every call must be audited")
+		assert.Equal(t, wallet.DepositMoneyAuditAction, rec.records[0].Action)
+		assert.Error(t, rec.records[1].Err)
+
+		processed, err := processor.Step(ctx)
+		assert.Nil(t, err)
+		assert.False(t, processed, "processed batch must not be claimed again")
+	})
+
+	t.Run("should mark items whose idempotency key is used by another batch as duplicate", func(t *testing.T) {
+		batch := process(t, submit(t, false,
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: rich.ID, Amount: 5, IdempotencyKey: "salary"},
+		).ID)
+
+		assert.Equal(t, wallet.DuplicateBatchItemStatus, batch.Items[0].Status)
+		assert.Contains(t, batch.Items[0].Error, "salary")
+		assert.Equal(t, float32(13), balanceOf(t, rich.ID))
+	})
+
+	t.Run("should recognize items applied by a stopped processing", func(t *testing.T) {
+		batch := submit(t, false, wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: rich.ID, Amount: 5})
+		// the processing stopped after the unit of work of the item is committed and before its progress is saved
+		assert.Nil(t, batches.InsertIdempotencyKey(ctx, &wallet.IdempotencyKey{
+			Key: "batch:" + batch.Items[0].IdempotencyKey, BatchID: batch.ID, TransactionIDs: []string{"t1"},
+		}))
+
+		batch = process(t, batch.ID)
+		assert.Equal(t, wallet.SucceededBatchItemStatus, batch.Items[0].Status)
+		assert.Equal(t, []string{"t1"}, batch.Items[0].TransactionIDs)
+		assert.Equal(t, float32(13), balanceOf(t, rich.ID), "applied item must not be applied again")
+	})
+
+	t.Run("should fail atomic batch and abort its other items if an item fails", func(t *testing.T) {
+		rec.records = nil
+		batch := process(t, submit(t, true,
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: poor.ID, Amount: 1},
+			wallet.BatchItemRequest{Type: wallet.WithdrawBatchItemType, WalletID: poor.ID, Amount: 100},
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: poor.ID, Amount: 1},
+		).ID)
+
+		assert.Equal(t, wallet.FailedBatchStatus, batch.Status)
+		assert.Equal(t, fmt.Sprintf(wallet.ErrAtomicBatchFailed, 1, wallet.ErrInsufficientMoneyAmount), batch.Error)
+		assert.Equal(t, wallet.BatchSummary{Failed: 1, Aborted: 2}, batch.Summary)
+		assert.Empty(t, batch.Items[0].TransactionIDs)
+
+		// units of work of the mock aren't rolled back, only the calls that would be kept are checked
+		assert.Len(t, rec.records, 1, "rolled back calls must not be audited")
+		assert.Equal(t, wallet.WithdrawMoneyAuditAction, rec.records[0].Action)
+		assert.Nil(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP wallet_deposits_total Total number of successful deposits.
+# TYPE wallet_deposits_total counter
+wallet_deposits_total 1
+# HELP wallet_failed_operations_total Total number of failed deposits, withdrawals and transfers by reason.
+# TYPE wallet_failed_operations_total counter
+wallet_failed_operations_total{operation="deposit",reason="wallet_not_found"} 1
+wallet_failed_operations_total{operation="withdraw",reason="insufficient_funds"} 2
+`), "wallet_deposits_total", "wallet_failed_operations_total"), "rolled back calls must not be measured")
+	})
+
+	t.Run("should complete atomic batch if every item succeeds", func(t *testing.T) {
+		batch := process(t, submit(t, true,
+			wallet.BatchItemRequest{Type: wallet.TransferBatchItemType, WalletID: rich.ID, ToWalletID: poor.ID, Amount: 1},
+			wallet.BatchItemRequest{Type: wallet.WithdrawBatchItemType, WalletID: rich.ID, Amount: 1},
+		).ID)
+
+		assert.Equal(t, wallet.CompletedBatchStatus, batch.Status)
+		assert.Equal(t, wallet.BatchSummary{Succeeded: 2}, batch.Summary)
+	})
+
+	t.Run("should audit items whose units of work are over when processing stops", func(t *testing.T) {
+		stepCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		stoppingRepo := NewMockRepository(gomock.NewController(t))
+		// the processing stops once the unit of work of the first item is over, units of work of the service nest
+		nested := false
+		stoppingRepo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+				if nested {
+					return fn(ctx)
+				}
+				nested = true
+				err := fn(ctx)
+				stop()
+				return err
+			}).AnyTimes()
+		stoppingRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).Return(nil)
+		rec.records = nil
+		submit(t, false,
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: poor.ID, Amount: 1},
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: poor.ID, Amount: 1},
+		)
+
+		_, err := wallet.NewBatchProcessor(batches, stoppingRepo, wallet.NewService(stoppingRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), rec, metrics, time.Minute, log).Step(stepCtx)
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Len(t, rec.records, 1, "call of the committed item must be audited")
+	})
+
+	t.Run("should fail atomic batch if its items aren't applied in half of the lease", func(t *testing.T) {
+		slowRepo := NewMockRepository(gomock.NewController(t))
+		slowRepo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ func(ctx context.Context) error) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+		batch := submit(t, true,
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: poor.ID, Amount: 1},
+			wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: poor.ID, Amount: 1},
+		)
+
+		processed, err := wallet.NewBatchProcessor(batches, slowRepo, wallet.NewService(slowRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), rec, metrics, 20*time.Millisecond, log).Step(ctx)
+		assert.Nil(t, err)
+		assert.True(t, processed)
+		batch, _ = batches.FindBatchByID(ctx, batch.ID)
+		assert.Equal(t, wallet.FailedBatchStatus, batch.Status)
+		assert.Equal(t, fmt.Sprintf(wallet.ErrAtomicBatchTimeout, 10*time.Millisecond), batch.Error)
+		assert.Equal(t, wallet.BatchSummary{Aborted: 2}, batch.Summary)
+	})
+
+	t.Run("should fail step if lease of batch is taken over", func(t *testing.T) {
+		mockBatches := NewMockBatchRepository(gomock.NewController(t))
+		mockBatches.EXPECT().ClaimBatch(ctx, gomock.Any(), time.Minute).Return(&wallet.Batch{
+			ID:     uuid.NewString(),
+			Status: wallet.ProcessingBatchStatus,
+			Items: []wallet.BatchItem{{
+				BatchItemRequest: wallet.BatchItemRequest{Type: wallet.DepositBatchItemType, WalletID: rich.ID, Amount: 1, IdempotencyKey: "k"},
+				Status:           wallet.PendingBatchItemStatus,
+			}},
+		}, nil)
+		mockBatches.EXPECT().FindIdempotencyKey(gomock.Any(), "batch:k").Return(nil, nil)
+		mockBatches.EXPECT().InsertIdempotencyKey(gomock.Any(), gomock.Any()).Return(nil)
+		mockBatches.EXPECT().SaveBatch(gomock.Any(), gomock.Any()).Return(wallet.ErrBatchLeaseLost)
+
+		processed, err := wallet.NewBatchProcessor(mockBatches, mockRepo, wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), rec, metrics, time.Minute, log).Step(ctx)
+		assert.True(t, processed)
+		assert.True(t, errors.Is(err, wallet.ErrBatchLeaseLost))
+	})
+}
+
+func TestBatchApi(t *testing.T) {
+	app := fiber.New()
+	wallet.NewBatchApi(wallet.NewBatchService(newMemoryBatches(), wallet.BatchLimits{MaxItems: 10})).AddRoutesTo(app)
+
+	body, _ := json.Marshal(wallet.BatchRequest{Items: []wallet.BatchItemRequest{
+		{Type: wallet.DepositBatchItemType, WalletID: "a", Amount: 10},
+	}})
+	req := httptest.NewRequest(fiber.MethodPost, "/batches", bytes.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	res, err := app.Test(req)
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusAccepted, res.StatusCode)
+	var batch wallet.Batch
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&batch))
+	assert.Equal(t, wallet.PendingBatchStatus, batch.Status)
+
+	res, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/batches/"+batch.ID, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	res, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/batches/unknown", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+}
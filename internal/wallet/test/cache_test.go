@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/cache"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+)
+
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("cache is down")
+}
+
+func (failingCache) Set(context.Context, string, []byte) error {
+	return errors.New("cache is down")
+}
+
+func (failingCache) Delete(context.Context, string) error {
+	return errors.New("cache is down")
+}
+
+func TestCachingService(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.NewString()
+	deposit := func(amount float32) wallet.Transaction {
+		return wallet.Transaction{ID: uuid.NewString(), WalletID: id, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: amount}}
+	}
+	// document returns a new wallet document at version on every call since the service mutates it
+	document := func(version int64) func(context.Context, string) (*wallet.Wallet, error) {
+		return func(context.Context, string) (*wallet.Wallet, error) {
+			return &wallet.Wallet{ID: id, Username: "user", Version: version}, nil
+		}
+	}
+	setup := func(t *testing.T, c cache.Cache) (*MockRepository, *wallet.CachingService, *prometheus.Registry) {
+		mockRepo := setupMockRepo(t)
+		reg := prometheus.NewRegistry()
+		// the stream has the first deposit of the wallet, use cases that change it load it from the stream
+		events := eventstore.NewMemoryStore()
+		seedEvents(t, events, &wallet.Wallet{ID: id, Username: "user", Balance: wallet.Money{Amount: 10}})
+		return mockRepo, wallet.NewCachingService(wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), mockRepo, c, wallet.NewMetrics(reg)), reg
+	}
+
+	t.Run("should serve state of the same version from cache", func(t *testing.T) {
+		mockRepo, service, reg := setup(t, cache.NewLRU(10, 0))
+		mockRepo.EXPECT().FindWalletByID(ctx, id).DoAndReturn(document(1)).Times(3)
+		mockRepo.EXPECT().FindTransactionsByWalletID(ctx, id).Return([]wallet.Transaction{deposit(10)}, nil).Times(1)
+
+		for i := 0; i < 2; i++ {
+			w, err := service.GetWallet(ctx, id)
+			assert.Nil(t, err)
+			assert.Equal(t, float32(10), w.Balance.Amount)
+			assert.Equal(t, int64(1), w.Version)
+			assert.Equal(t, "user", w.Username)
+		}
+
+		expected := `
+# HELP wallet_cache_requests_total Total number of wallet state cache lookups by result, hit, miss or error.
+# TYPE wallet_cache_requests_total counter
+wallet_cache_requests_total{result="hit"} 1
+wallet_cache_requests_total{result="miss"} 1
+`
+		assert.Nil(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "wallet_cache_requests_total"))
+	})
+
+	t.Run("should compute state again when version changes", func(t *testing.T) {
+		mockRepo, service, _ := setup(t, cache.NewLRU(10, 0))
+		gomock.InOrder(
+			mockRepo.EXPECT().FindWalletByID(ctx, id).DoAndReturn(document(1)).Times(2),
+			mockRepo.EXPECT().FindWalletByID(ctx, id).DoAndReturn(document(2)).Times(2),
+		)
+		gomock.InOrder(
+			mockRepo.EXPECT().FindTransactionsByWalletID(ctx, id).Return([]wallet.Transaction{deposit(10)}, nil),
+			mockRepo.EXPECT().FindTransactionsByWalletID(ctx, id).Return([]wallet.Transaction{deposit(10), deposit(5)}, nil),
+		)
+
+		_, err := service.GetWallet(ctx, id)
+		assert.Nil(t, err)
+		w, err := service.GetWallet(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, float32(15), w.Balance.Amount)
+	})
+
+	t.Run("should key state by number of replayed transactions if a writer appends in between", func(t *testing.T) {
+		c := cache.NewLRU(10, 0)
+		mockRepo, service, _ := setup(t, c)
+		mockRepo.EXPECT().FindWalletByID(ctx, id).DoAndReturn(document(1)).Times(2)
+		mockRepo.EXPECT().FindTransactionsByWalletID(ctx, id).Return([]wallet.Transaction{deposit(10), deposit(5)}, nil)
+
+		w, err := service.GetWallet(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), w.Version)
+
+		_, ok, _ := c.Get(ctx, "wallet:"+id+":1")
+		assert.False(t, ok, "state of two transactions must not be served for version 1")
+		_, ok, _ = c.Get(ctx, "wallet:"+id+":2")
+		assert.True(t, ok)
+	})
+
+	t.Run("should invalidate previous state on append", func(t *testing.T) {
+		c := cache.NewLRU(10, 0)
+		mockRepo, service, _ := setup(t, c)
+		mockRepo.EXPECT().FindWalletByID(ctx, id).DoAndReturn(document(1)).Times(2)
+		mockRepo.EXPECT().FindTransactionsByWalletID(ctx, id).Return([]wallet.Transaction{deposit(10)}, nil)
+		mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil)
+
+		_, err := service.GetWallet(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, c.Len())
+
+		w, err := service.DepositMoney(ctx, id, &wallet.MoneyTransactionRequest{Amount: 5})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), w.Version)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("should fall back to repository if cache fails", func(t *testing.T) {
+		mockRepo, service, reg := setup(t, failingCache{})
+		mockRepo.EXPECT().FindWalletByID(ctx, id).DoAndReturn(document(1)).Times(2)
+		mockRepo.EXPECT().FindTransactionsByWalletID(ctx, id).Return([]wallet.Transaction{deposit(10)}, nil)
+
+		w, err := service.GetWallet(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, float32(10), w.Balance.Amount)
+
+		expected := `
+# HELP wallet_cache_requests_total Total number of wallet state cache lookups by result, hit, miss or error.
+# TYPE wallet_cache_requests_total counter
+wallet_cache_requests_total{result="error"} 1
+`
+		assert.Nil(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "wallet_cache_requests_total"))
+	})
+
+	t.Run("should return not found if wallet doesn't exist", func(t *testing.T) {
+		mockRepo, service, _ := setup(t, cache.NewLRU(10, 0))
+		mockRepo.EXPECT().FindWalletByID(ctx, id).Return(nil, nil).Times(2)
+
+		w, err := service.GetWallet(ctx, id)
+		assert.Nil(t, w)
+		assert.Equal(t, 404, err.Code)
+	})
+}
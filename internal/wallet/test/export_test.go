@@ -0,0 +1,112 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	ledgermock "github.com/ybalcin/wallet-service/internal/ledger/test"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+
+	w := &wallet.Wallet{
+		ID:        uuid.NewString(),
+		Username:  "user",
+		Status:    wallet.FrozenWalletStatus,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	transactions := []wallet.Transaction{
+		{ID: uuid.NewString(), WalletID: w.ID, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 10}, CreatedAt: w.CreatedAt},
+	}
+	empty := &wallet.Wallet{ID: uuid.NewString(), Username: "empty", CreatedAt: w.CreatedAt}
+
+	mockRepo.EXPECT().IterateWallets(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, fn func(*wallet.Wallet) error) error {
+		for _, w := range []*wallet.Wallet{w, empty} {
+			if err := fn(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	mockRepo.EXPECT().FindTransactionsByWalletID(ctx, w.ID).Return(transactions, nil)
+	mockRepo.EXPECT().FindTransactionsByWalletID(ctx, empty.ID).Return(nil, nil)
+
+	exported := new(bytes.Buffer)
+	n, err := wallet.Export(ctx, mockRepo, exported)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, strings.Count(exported.String(), "\n"), "every wallet must be a line")
+
+	ledgerRepo := ledgermock.NewMockRepository(gomock.NewController(t))
+	l := ledger.New(ledgerRepo)
+
+	t.Run("imports wallets that don't exist", func(t *testing.T) {
+		mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(nil, nil)
+		gomock.InOrder(
+			mockRepo.EXPECT().InsertTransactions(ctx, transactions[0]).Return(nil),
+			ledgerRepo.EXPECT().InsertEntries(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, entries ...*ledger.JournalEntry) error {
+				assert.Equal(t, []string{transactions[0].ID}, entries[0].TransactionIDs)
+				assert.Equal(t, []ledger.Posting{
+					ledger.Debit(ledger.CashInClearingAccount, wallet.DefaultCurrency, 1000),
+					ledger.Credit(ledger.WalletAccount(w.ID), wallet.DefaultCurrency, 1000),
+				}, entries[0].Postings)
+				return nil
+			}),
+			mockRepo.EXPECT().InsertWallet(ctx, &wallet.Wallet{ID: w.ID, Username: w.Username, Status: w.Status, CreatedAt: w.CreatedAt, UpdatedAt: w.CreatedAt,
+				Version: 1}).Return(nil),
+		)
+		mockRepo.EXPECT().FindWalletByID(ctx, empty.ID).Return(empty, nil)
+
+		events := eventstore.NewMemoryStore()
+		res, err := wallet.Import(ctx, mockRepo, events, l, bytes.NewReader(exported.Bytes()))
+		assert.Nil(t, err)
+		assert.Equal(t, wallet.ImportResult{Imported: 1, Skipped: 1}, res)
+
+		imported, err := wallet.LoadWallet(ctx, events, w.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, float32(10), imported.Balance.Amount)
+		assert.True(t, imported.IsFrozen())
+		assert.Equal(t, int64(3), imported.StreamVersion, "stream must record creation, the deposit and the freeze")
+	})
+
+	t.Run("should return error if transaction belongs to another wallet", func(t *testing.T) {
+		record := `{"id":"a","username":"user","transactions":[{"id":"t","wallet_id":"b","type":"deposit"}]}`
+
+		_, err := wallet.Import(ctx, mockRepo, eventstore.NewMemoryStore(), l, strings.NewReader(record))
+		assert.ErrorContains(t, err, "record 1 is invalid")
+	})
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+
+	w := &wallet.Wallet{ID: uuid.NewString()}
+	mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(w, nil)
+	mockRepo.EXPECT().FindTransactionsByWalletID(ctx, w.ID).Return([]wallet.Transaction{
+		{ID: "1", WalletID: w.ID, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 10}},
+		{ID: "2", WalletID: w.ID, Type: wallet.WithdrawTransactionType, Money: wallet.Money{Amount: 15}},
+		{ID: "3", WalletID: w.ID, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 10}},
+	}, nil)
+
+	var results []wallet.ReplayResult
+	err := wallet.Replay(ctx, mockRepo, []string{w.ID}, func(r wallet.ReplayResult) error {
+		results = append(results, r)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, float32(5), results[0].Wallet.Balance.Amount)
+	assert.Equal(t, 3, results[0].Transactions)
+	assert.Equal(t, "balance is negative after transaction 2", results[0].Problem)
+}
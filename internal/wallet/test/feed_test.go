@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFeed(t *testing.T) {
+	ctx := context.Background()
+	events := eventstore.NewMemoryStore()
+	feed := wallet.NewFeed(events, time.Second, time.Millisecond)
+
+	// deposit appends a deposit of amount to a new wallet, the stream starts with its creation
+	deposit := func(t *testing.T, amount float32) wallet.Transaction {
+		w := &wallet.Wallet{ID: uuid.NewString()}
+		seedEvents(t, events, w)
+
+		tr := wallet.Transaction{ID: uuid.NewString(), WalletID: w.ID, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: amount}}
+		e, err := wallet.Events.Encode(wallet.MoneyDeposited{Transaction: tr})
+		assert.Nil(t, err)
+		e.ID = tr.ID
+		_, err = events.Append(ctx, wallet.StreamOf(w.ID), 1, e)
+		assert.Nil(t, err)
+
+		return tr
+	}
+	first, second, third := deposit(t, 1), deposit(t, 2), deposit(t, 3)
+
+	t.Run("should read transactions of every wallet in position order", func(t *testing.T) {
+		page, err := feed.Read(ctx, &wallet.FeedRequest{Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, []wallet.FeedEntry{{Position: 2, Transaction: first}, {Position: 4, Transaction: second}}, page.Transactions)
+		assert.Equal(t, int64(4), page.Next)
+
+		page, err = feed.Read(ctx, &wallet.FeedRequest{After: page.Next, Limit: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, []wallet.FeedEntry{{Position: 6, Transaction: third}}, page.Transactions)
+		assert.Equal(t, int64(6), page.Next)
+	})
+
+	t.Run("should move past events that aren't transactions", func(t *testing.T) {
+		seedEvents(t, events, &wallet.Wallet{ID: uuid.NewString()})
+
+		page, err := feed.Read(ctx, &wallet.FeedRequest{After: 6})
+		assert.Nil(t, err)
+		assert.Empty(t, page.Transactions)
+		assert.Equal(t, int64(7), page.Next)
+	})
+
+	t.Run("should wait for transactions appended later", func(t *testing.T) {
+		appended := make(chan wallet.Transaction, 1)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			appended <- deposit(t, 4)
+		}()
+
+		page, err := feed.Read(ctx, &wallet.FeedRequest{After: 7, Wait: "1s"})
+		assert.Nil(t, err)
+		assert.Len(t, page.Transactions, 1)
+		assert.Equal(t, <-appended, page.Transactions[0].Transaction)
+		assert.Equal(t, int64(9), page.Next)
+	})
+
+	t.Run("should return empty page when wait is over", func(t *testing.T) {
+		started := time.Now()
+		page, err := wallet.NewFeed(events, 30*time.Millisecond, time.Millisecond).
+			Read(ctx, &wallet.FeedRequest{After: 9, Wait: "1m"})
+		assert.Nil(t, err)
+		assert.Empty(t, page.Transactions)
+		assert.Equal(t, int64(9), page.Next)
+		assert.Less(t, time.Since(started), time.Second, "wait must be shortened to the max wait")
+	})
+
+	t.Run("should reject invalid requests", func(t *testing.T) {
+		_, err := feed.Read(ctx, &wallet.FeedRequest{After: -1})
+		assert.Equal(t, wallet.ErrInvalidFeedPosition, err.Message)
+		_, err = feed.Read(ctx, &wallet.FeedRequest{Limit: wallet.MaxFeedLimit + 1})
+		assert.Equal(t, fmt.Sprintf(wallet.ErrInvalidFeedLimit, wallet.MaxFeedLimit), err.Message)
+		_, err = feed.Read(ctx, &wallet.FeedRequest{Wait: "soon"})
+		assert.Equal(t, fmt.Sprintf(wallet.ErrInvalidFeedWait, "soon"), err.Message)
+	})
+
+	t.Run("should serve feed from admin api", func(t *testing.T) {
+		app := fiber.New()
+		wallet.NewAdminApi(nil, feed).AddRoutesTo(app)
+
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/admin/transactions/feed?after=2&limit=1", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		var page wallet.FeedPage
+		assert.Nil(t, json.NewDecoder(res.Body).Decode(&page))
+		assert.Len(t, page.Transactions, 1)
+		assert.Equal(t, second.ID, page.Transactions[0].Transaction.ID)
+		assert.Equal(t, int64(4), page.Next)
+
+		res, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/admin/transactions/feed?after=first", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
@@ -0,0 +1,175 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func setupGraphqlApi(t *testing.T, mockRepo *MockRepository, events eventstore.Store, limits wallet.GraphqlLimits) *wallet.GraphqlApi {
+	api, err := wallet.NewGraphqlApi(wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), mockRepo, limits)
+	assert.Nil(t, err)
+
+	return api
+}
+
+func TestGraphqlApi(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+	events := eventstore.NewMemoryStore()
+	api := setupGraphqlApi(t, mockRepo, events, wallet.DefaultGraphqlLimits)
+
+	t.Run("should batch repository reads of wallets", func(t *testing.T) {
+		first := &wallet.Wallet{ID: uuid.NewString(), Username: "first"}
+		second := &wallet.Wallet{ID: uuid.NewString(), Username: "second"}
+		missing := uuid.NewString()
+		now := time.Now()
+
+		mockRepo.EXPECT().
+			FindWalletsByIDs(gomock.Any(), []string{first.ID, second.ID, missing}).
+			Return([]*wallet.Wallet{first, second}, nil).
+			Times(1)
+		mockRepo.EXPECT().
+			FindTransactionsByWalletIDs(gomock.Any(), []string{first.ID, second.ID, missing}).
+			Return([]wallet.Transaction{
+				{ID: "1", WalletID: first.ID, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 10}, CreatedAt: now},
+				{ID: "2", WalletID: first.ID, Type: wallet.WithdrawTransactionType, Money: wallet.Money{Amount: 4}, CreatedAt: now},
+				{ID: "3", WalletID: second.ID, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 1}, CreatedAt: now},
+			}, nil).
+			Times(1)
+
+		res := api.Do(ctx, &wallet.GraphqlRequest{
+			Query: `query($ids: [ID!]!) {
+				wallets(ids: $ids) {
+					id
+					username
+					balance { amount }
+					transactions(last: 1) { id type walletId }
+					stats { transactionCount depositCount withdrawCount totalDeposited { amount } }
+				}
+			}`,
+			Variables: map[string]interface{}{"ids": []interface{}{first.ID, second.ID, missing}},
+		})
+		assert.Empty(t, res.Errors)
+
+		wallets := res.Data.(map[string]interface{})["wallets"].([]interface{})
+		assert.Len(t, wallets, 3)
+		assert.Nil(t, wallets[2])
+
+		w := wallets[0].(map[string]interface{})
+		assert.Equal(t, "first", w["username"])
+		assert.Equal(t, float32(6), w["balance"].(map[string]interface{})["amount"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"id": "2", "type": "WITHDRAW", "walletId": first.ID}}, w["transactions"])
+		assert.Equal(t, map[string]interface{}{
+			"transactionCount": 2,
+			"depositCount":     1,
+			"withdrawCount":    1,
+			"totalDeposited":   map[string]interface{}{"amount": float32(10)},
+		}, w["stats"])
+	})
+
+	t.Run("should delegate transfer mutation to service", func(t *testing.T) {
+		from := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+		to := &wallet.Wallet{ID: uuid.NewString()}
+		seedEvents(t, events, from, to)
+
+		mockRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		res := api.Do(ctx, &wallet.GraphqlRequest{
+			Query: fmt.Sprintf(`mutation {
+				transfer(fromWalletId: %q, toWalletId: %q, amount: 3) {
+					from { balance { amount } }
+					to { balance { amount } }
+				}
+			}`, from.ID, to.ID),
+		})
+		assert.Empty(t, res.Errors)
+		assert.Equal(t, map[string]interface{}{
+			"transfer": map[string]interface{}{
+				"from": map[string]interface{}{"balance": map[string]interface{}{"amount": float32(7)}},
+				"to":   map[string]interface{}{"balance": map[string]interface{}{"amount": float32(3)}},
+			},
+		}, res.Data)
+	})
+
+	t.Run("should deposit with details and return them in transactions", func(t *testing.T) {
+		w := &wallet.Wallet{ID: uuid.NewString()}
+		var saved wallet.Transaction
+		seedEvents(t, events, w)
+
+		mockRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, t ...wallet.Transaction) error {
+			saved = t[0]
+			return nil
+		})
+		res := api.Do(ctx, &wallet.GraphqlRequest{
+			Query: fmt.Sprintf(`mutation {
+				deposit(walletId: %q, amount: 3, reference: "psp-1", metadata: [{key: "merchant", value: "m1"}]) { id }
+			}`, w.ID),
+		})
+		assert.Empty(t, res.Errors)
+		assert.Equal(t, wallet.TransactionDetails{Reference: "psp-1", Metadata: map[string]string{"merchant": "m1"}}, saved.TransactionDetails)
+
+		mockRepo.EXPECT().FindWalletsByIDs(gomock.Any(), []string{w.ID}).Return([]*wallet.Wallet{w}, nil)
+		mockRepo.EXPECT().FindTransactionsByWalletIDs(gomock.Any(), []string{w.ID}).Return([]wallet.Transaction{saved}, nil)
+		res = api.Do(ctx, &wallet.GraphqlRequest{
+			Query: fmt.Sprintf(`{ wallet(id: %q) { transactions(last: 1) { reference description metadata { key value } } } }`, w.ID),
+		})
+		assert.Empty(t, res.Errors)
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"reference":   "psp-1",
+			"description": nil,
+			"metadata":    []interface{}{map[string]interface{}{"key": "merchant", "value": "m1"}},
+		}}, res.Data.(map[string]interface{})["wallet"].(map[string]interface{})["transactions"])
+	})
+
+	t.Run("should return service error", func(t *testing.T) {
+		res := api.Do(ctx, &wallet.GraphqlRequest{
+			Query: `mutation { deposit(walletId: "id", amount: -1) { id } }`,
+		})
+		assert.Len(t, res.Errors, 1)
+		assert.Equal(t, wallet.ErrInvalidMoneyAmount, res.Errors[0].Message)
+	})
+}
+
+func TestGraphqlApi_Limits(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+
+	t.Run("should reject query deeper than max depth", func(t *testing.T) {
+		api := setupGraphqlApi(t, mockRepo, eventstore.NewMemoryStore(), wallet.GraphqlLimits{MaxDepth: 3})
+
+		res := api.Do(ctx, &wallet.GraphqlRequest{
+			Query: `{ wallet(id: "id") { ...tx } } fragment tx on Wallet { transactions { money { amount } } }`,
+		})
+		assert.Len(t, res.Errors, 1)
+		assert.Equal(t, fmt.Sprintf(wallet.ErrGraphqlMaxDepth, 4, 3), res.Errors[0].Message)
+	})
+
+	t.Run("should reject query more complex than max complexity", func(t *testing.T) {
+		api := setupGraphqlApi(t, mockRepo, eventstore.NewMemoryStore(), wallet.GraphqlLimits{MaxComplexity: 100})
+
+		res := api.Do(ctx, &wallet.GraphqlRequest{
+			Query: `{ wallets(ids: ["a", "b"]) { id transactions { id } } }`,
+		})
+		assert.Len(t, res.Errors, 1)
+		assert.Equal(t, fmt.Sprintf(wallet.ErrGraphqlMaxComplexity, 105, 100), res.Errors[0].Message)
+	})
+
+	t.Run("should accept query bounded by arguments", func(t *testing.T) {
+		api := setupGraphqlApi(t, mockRepo, eventstore.NewMemoryStore(), wallet.GraphqlLimits{MaxDepth: 3, MaxComplexity: 100})
+
+		mockRepo.EXPECT().FindWalletsByIDs(gomock.Any(), gomock.Any()).Return([]*wallet.Wallet{{ID: "a"}, {ID: "b"}}, nil)
+		mockRepo.EXPECT().FindTransactionsByWalletIDs(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		res := api.Do(ctx, &wallet.GraphqlRequest{
+			Query: `{ wallets(ids: ["a", "b"]) { id transactions(last: 10) { id } } }`,
+		})
+		assert.Empty(t, res.Errors)
+	})
+}
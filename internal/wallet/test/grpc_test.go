@@ -0,0 +1,138 @@
+package wallet
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	walletv1 "github.com/ybalcin/wallet-service/pkg/pb/wallet/v1"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+)
+
+func setupGrpcClient(t *testing.T, service wallet.Service, broadcaster *wallet.Broadcaster) walletv1.WalletServiceClient {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	walletv1.RegisterWalletServiceServer(server, wallet.NewGrpcServer(service, broadcaster))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return walletv1.NewWalletServiceClient(conn)
+}
+
+func TestGrpcServer(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+	broadcaster := wallet.NewBroadcaster()
+	events := eventstore.NewMemoryStore()
+	service := wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t)))
+	publisher := wallet.NewBalancePublisher(events, broadcaster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	client := setupGrpcClient(t, service, broadcaster)
+
+	t.Run("CreateWallet", func(t *testing.T) {
+		mockRepo.EXPECT().InsertWallet(gomock.Any(), gomock.Any()).Return(nil)
+
+		res, err := client.CreateWallet(ctx, &walletv1.CreateWalletRequest{Username: "user"})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, res.GetId())
+	})
+
+	t.Run("should map errr.Error to grpc status", func(t *testing.T) {
+		t.Run("invalid argument", func(t *testing.T) {
+			_, err := client.CreateWallet(ctx, &walletv1.CreateWalletRequest{Username: " "})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			assert.Equal(t, wallet.ErrInvalidUsername, status.Convert(err).Message())
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			id := uuid.NewString()
+			mockRepo.EXPECT().FindWalletByID(gomock.Any(), id).Return(nil, nil)
+
+			_, err := client.GetWallet(ctx, &walletv1.GetWalletRequest{WalletId: id})
+			assert.Equal(t, codes.NotFound, status.Code(err))
+		})
+
+		t.Run("already exists", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString()}
+			seedEvents(t, events, w)
+			mockRepo.EXPECT().FindTransactionsByReferences(gomock.Any(), []string{"psp-1"}).
+				Return([]wallet.Transaction{{WalletID: w.ID}}, nil)
+
+			_, err := client.DepositMoney(ctx, &walletv1.DepositMoneyRequest{WalletId: w.ID, Amount: 5, Reference: "psp-1", UniqueReference: true})
+			assert.Equal(t, codes.AlreadyExists, status.Code(err))
+		})
+	})
+
+	t.Run("ListTransactions", func(t *testing.T) {
+		w := &wallet.Wallet{ID: uuid.NewString()}
+		transactions := []wallet.Transaction{
+			{ID: uuid.NewString(), WalletID: w.ID, Type: wallet.WithdrawTransactionType, Money: wallet.Money{Amount: 10},
+				TransactionDetails: wallet.TransactionDetails{Reference: "psp-1", Metadata: map[string]string{"merchant": "m1"}}},
+		}
+
+		mockRepo.EXPECT().FindWalletByID(gomock.Any(), w.ID).Return(w, nil)
+		mockRepo.EXPECT().FindTransactionsByWalletID(gomock.Any(), w.ID).Return(transactions, nil)
+
+		res, err := client.ListTransactions(ctx, &walletv1.ListTransactionsRequest{WalletId: w.ID})
+		assert.Nil(t, err)
+		assert.Len(t, res.GetTransactions(), 1)
+		assert.Equal(t, walletv1.TransactionType_TRANSACTION_TYPE_WITHDRAW, res.GetTransactions()[0].GetType())
+		assert.Equal(t, "psp-1", res.GetTransactions()[0].GetReference())
+		assert.Equal(t, map[string]string{"merchant": "m1"}, res.GetTransactions()[0].GetMetadata())
+	})
+
+	t.Run("WatchBalance", func(t *testing.T) {
+		w := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+		seedEvents(t, events, w)
+
+		mockRepo.EXPECT().FindWalletByID(gomock.Any(), w.ID).Return(w, nil)
+		mockRepo.EXPECT().FindTransactionsByWalletID(gomock.Any(), w.ID).Return(nil, nil)
+
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stream, err := client.WatchBalance(streamCtx, &walletv1.WatchBalanceRequest{WalletId: w.ID})
+		assert.Nil(t, err)
+
+		initial, err := stream.Recv()
+		assert.Nil(t, err)
+		assert.Equal(t, float32(10), initial.GetBalance().GetAmount())
+		assert.Nil(t, initial.GetTransaction())
+
+		_, err = publisher.Step(ctx)
+		assert.Nil(t, err)
+		mockRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).Return(nil)
+
+		// the deposit is made by another instance that shares the event store
+		other := wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t)))
+		_, ex := other.DepositMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 5})
+		assert.Nil(t, ex)
+		published, err := publisher.Step(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, published)
+
+		update, err := stream.Recv()
+		assert.Nil(t, err)
+		assert.Equal(t, float32(15), update.GetBalance().GetAmount())
+		assert.Equal(t, walletv1.TransactionType_TRANSACTION_TYPE_DEPOSIT, update.GetTransaction().GetType())
+
+		broadcaster.Close()
+		_, err = stream.Recv()
+		assert.NotNil(t, err)
+	})
+}
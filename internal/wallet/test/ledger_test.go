@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/ledger"
+	ledgermock "github.com/ybalcin/wallet-service/internal/ledger/test"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestLedgerService(t *testing.T) {
+	ctx := context.Background()
+	events := eventstore.NewMemoryStore()
+	setup := func(t *testing.T) (*MockRepository, *ledgermock.MockRepository, *wallet.LedgerService) {
+		mockRepo := setupMockRepo(t)
+		ledgerRepo := ledgermock.NewMockRepository(gomock.NewController(t))
+		return mockRepo, ledgerRepo, wallet.NewLedgerService(wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), mockRepo, ledger.New(ledgerRepo))
+	}
+	// posted makes ledgerRepo store inserted entries to entries
+	posted := func(ledgerRepo *ledgermock.MockRepository, entries *[]*ledger.JournalEntry) {
+		ledgerRepo.EXPECT().InsertEntries(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, e ...*ledger.JournalEntry) error {
+			*entries = append(*entries, e...)
+			return nil
+		})
+	}
+
+	t.Run("DepositMoney", func(t *testing.T) {
+		mockRepo, ledgerRepo, service := setup(t)
+		w := &wallet.Wallet{ID: uuid.NewString()}
+		seedEvents(t, events, w)
+		mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil)
+		var entries []*ledger.JournalEntry
+		posted(ledgerRepo, &entries)
+
+		res, err := service.DepositMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 12.5})
+		assert.Nil(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, []string{res.Changes[0].ID}, entries[0].TransactionIDs)
+		assert.Equal(t, []ledger.Posting{
+			ledger.Debit(ledger.CashInClearingAccount, wallet.DefaultCurrency, 1250),
+			ledger.Credit(ledger.WalletAccount(w.ID), wallet.DefaultCurrency, 1250),
+		}, entries[0].Postings)
+	})
+
+	t.Run("WithdrawMoney", func(t *testing.T) {
+		mockRepo, ledgerRepo, service := setup(t)
+		w := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+		seedEvents(t, events, w)
+		mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil)
+		var entries []*ledger.JournalEntry
+		posted(ledgerRepo, &entries)
+
+		_, err := service.WithdrawMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 4})
+		assert.Nil(t, err)
+		assert.Equal(t, []ledger.Posting{
+			ledger.Debit(ledger.WalletAccount(w.ID), wallet.DefaultCurrency, 400),
+			ledger.Credit(ledger.CashOutClearingAccount, wallet.DefaultCurrency, 400),
+		}, entries[0].Postings)
+	})
+
+	t.Run("TransferMoney", func(t *testing.T) {
+		t.Run("should post one entry from wallet to wallet", func(t *testing.T) {
+			mockRepo, ledgerRepo, service := setup(t)
+			from := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+			to := &wallet.Wallet{ID: uuid.NewString()}
+			seedEvents(t, events, from)
+			seedEvents(t, events, to)
+			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any(), gomock.Any()).Return(nil)
+			var entries []*ledger.JournalEntry
+			posted(ledgerRepo, &entries)
+
+			res, err := service.TransferMoney(ctx, from.ID, &wallet.TransferMoneyRequest{ToWalletID: to.ID, Amount: 3})
+			assert.Nil(t, err)
+			assert.Len(t, entries, 1)
+			assert.Equal(t, []string{res.From.Changes[0].ID, res.To.Changes[0].ID}, entries[0].TransactionIDs)
+			assert.Equal(t, []ledger.Posting{
+				ledger.Debit(ledger.WalletAccount(from.ID), wallet.DefaultCurrency, 300),
+				ledger.Credit(ledger.WalletAccount(to.ID), wallet.DefaultCurrency, 300),
+			}, entries[0].Postings)
+		})
+
+		t.Run("should fail the unit of work if entry can't be posted", func(t *testing.T) {
+			mockRepo := NewMockRepository(gomock.NewController(t))
+			ledgerRepo := ledgermock.NewMockRepository(gomock.NewController(t))
+			service := wallet.NewLedgerService(wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), mockRepo, ledger.New(ledgerRepo))
+			from := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+			to := &wallet.Wallet{ID: uuid.NewString()}
+			postErr := errors.New("write conflict")
+
+			mockRepo.EXPECT().WithinTransaction(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					err := fn(ctx)
+					assert.ErrorIs(t, err, postErr, "failure of posting must reach the repository")
+					return err
+				})
+			// the unit of work of the service joins the outer one
+			mockRepo.EXPECT().WithinTransaction(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) })
+			seedEvents(t, events, from)
+			seedEvents(t, events, to)
+			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any(), gomock.Any()).Return(nil)
+			ledgerRepo.EXPECT().InsertEntries(ctx, gomock.Any()).Return(postErr)
+
+			res, err := service.TransferMoney(ctx, from.ID, &wallet.TransferMoneyRequest{ToWalletID: to.ID, Amount: 3})
+			assert.Nil(t, res)
+			assert.Equal(t, errr.ThrowInternalServerError(postErr), err)
+		})
+	})
+
+	t.Run("should post fees to the fees account", func(t *testing.T) {
+		mockRepo := setupMockRepo(t)
+		ledgerRepo := ledgermock.NewMockRepository(gomock.NewController(t))
+		core := wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t)))
+		core.SetFees(wallet.FeeSchedules{Withdraw: wallet.FeeSchedule{Fixed: 1}, Transfer: wallet.FeeSchedule{Fixed: 0.5}})
+		service := wallet.NewLedgerService(core, mockRepo, ledger.New(ledgerRepo))
+		from := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+		to := &wallet.Wallet{ID: uuid.NewString()}
+		seedEvents(t, events, from, to)
+		mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil).Times(2)
+		var entries []*ledger.JournalEntry
+		posted(ledgerRepo, &entries)
+		posted(ledgerRepo, &entries)
+
+		withdrawn, err := service.WithdrawMoney(ctx, from.ID, &wallet.MoneyTransactionRequest{Amount: 4})
+		assert.Nil(t, err)
+		transferred, err := service.TransferMoney(ctx, from.ID, &wallet.TransferMoneyRequest{ToWalletID: to.ID, Amount: 3})
+		assert.Nil(t, err)
+
+		assert.Len(t, entries, 4)
+		assert.Equal(t, []string{withdrawn.Changes[1].ID}, entries[1].TransactionIDs)
+		assert.Equal(t, []ledger.Posting{
+			ledger.Debit(ledger.WalletAccount(from.ID), wallet.DefaultCurrency, 100),
+			ledger.Credit(ledger.FeesAccount, wallet.DefaultCurrency, 100),
+		}, entries[1].Postings)
+		assert.Equal(t, []string{transferred.From.Changes[0].ID, transferred.To.Changes[0].ID}, entries[2].TransactionIDs)
+		assert.Equal(t, []ledger.Posting{
+			ledger.Debit(ledger.WalletAccount(from.ID), wallet.DefaultCurrency, 50),
+			ledger.Credit(ledger.FeesAccount, wallet.DefaultCurrency, 50),
+		}, entries[3].Postings)
+	})
+
+	t.Run("should reject amounts below the minor unit before saving anything", func(t *testing.T) {
+		_, _, service := setup(t)
+
+		w, err := service.DepositMoney(ctx, uuid.NewString(), &wallet.MoneyTransactionRequest{Amount: 0.001})
+		assert.Nil(t, w)
+		assert.Equal(t, wallet.ErrInvalidMoneyAmount, err.Message)
+	})
+
+	t.Run("should reject amounts with a fraction of the minor unit before saving anything", func(t *testing.T) {
+		_, _, service := setup(t)
+
+		w, err := service.WithdrawMoney(ctx, uuid.NewString(), &wallet.MoneyTransactionRequest{Amount: 0.015})
+		assert.Nil(t, w)
+		assert.Equal(t, wallet.ErrInvalidMoneyAmount, err.Message)
+
+		res, err := service.TransferMoney(ctx, uuid.NewString(), &wallet.TransferMoneyRequest{ToWalletID: uuid.NewString(), Amount: 10.005})
+		assert.Nil(t, res)
+		assert.Equal(t, wallet.ErrInvalidMoneyAmount, err.Message)
+	})
+
+	t.Run("should not post entries of failed operations", func(t *testing.T) {
+		_, _, service := setup(t)
+		w := &wallet.Wallet{ID: uuid.NewString()}
+		seedEvents(t, events, w)
+
+		res, err := service.WithdrawMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 4})
+		assert.Nil(t, res)
+		assert.Equal(t, wallet.ErrInsufficientMoneyAmount, err.Message)
+	})
+}
@@ -0,0 +1,79 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+)
+
+func TestInstrumentedService(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+	reg := prometheus.NewRegistry()
+	metrics := wallet.NewMetrics(reg)
+	events := eventstore.NewMemoryStore()
+	service := wallet.NewInstrumentedService(wallet.NewService(mockRepo, events, NewMockSnapshotRepository(gomock.NewController(t))), metrics)
+
+	w := &wallet.Wallet{ID: uuid.NewString()}
+	seedEvents(t, events, w)
+	mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil)
+
+	_, err := service.DepositMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 10})
+	assert.Nil(t, err)
+	_, err = service.WithdrawMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 100})
+	assert.NotNil(t, err)
+	_, err = service.WithdrawMoney(ctx, "", &wallet.MoneyTransactionRequest{Amount: 1})
+	assert.NotNil(t, err)
+
+	expected := `
+# HELP wallet_deposits_total Total number of successful deposits.
+# TYPE wallet_deposits_total counter
+wallet_deposits_total 1
+# HELP wallet_withdrawals_total Total number of successful withdrawals.
+# TYPE wallet_withdrawals_total counter
+wallet_withdrawals_total 0
+# HELP wallet_failed_operations_total Total number of failed deposits, withdrawals and transfers by reason.
+# TYPE wallet_failed_operations_total counter
+wallet_failed_operations_total{operation="withdraw",reason="insufficient_funds"} 1
+wallet_failed_operations_total{operation="withdraw",reason="invalid_wallet_id"} 1
+# HELP wallet_money_moved_total Total amount of money moved by transaction type and currency.
+# TYPE wallet_money_moved_total counter
+wallet_money_moved_total{currency="TRY",type="deposit"} 10
+`
+	assert.Nil(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"wallet_deposits_total", "wallet_withdrawals_total", "wallet_failed_operations_total", "wallet_money_moved_total"))
+}
+
+func TestInstrumentedRepository(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+	reg := prometheus.NewRegistry()
+	repo := wallet.NewInstrumentedRepository(mockRepo, wallet.NewMetrics(reg))
+
+	e := errors.New("")
+	mockRepo.EXPECT().FindWalletByID(ctx, "id").Return(nil, e)
+	mockRepo.EXPECT().InsertWallet(ctx, gomock.Any()).Return(nil)
+
+	_, err := repo.FindWalletByID(ctx, "id")
+	assert.Equal(t, e, err)
+	assert.Nil(t, repo.InsertWallet(ctx, &wallet.Wallet{}))
+
+	expected := `
+# HELP wallet_repository_operation_errors_total Total number of failed wallet repository operations.
+# TYPE wallet_repository_operation_errors_total counter
+wallet_repository_operation_errors_total{operation="FindWalletByID"} 1
+`
+	assert.Nil(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "wallet_repository_operation_errors_total"))
+
+	count, err := testutil.GatherAndCount(reg, "wallet_repository_operation_duration_seconds")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+}
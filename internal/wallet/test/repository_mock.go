@@ -7,6 +7,7 @@ package wallet
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	wallet "github.com/ybalcin/wallet-service/internal/wallet"
 	gomock "go.uber.org/mock/gomock"
@@ -35,6 +36,51 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// FindTransactionsBetween mocks base method.
+func (m *MockRepository) FindTransactionsBetween(ctx context.Context, from, to time.Time) ([]wallet.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTransactionsBetween", ctx, from, to)
+	ret0, _ := ret[0].([]wallet.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTransactionsBetween indicates an expected call of FindTransactionsBetween.
+func (mr *MockRepositoryMockRecorder) FindTransactionsBetween(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTransactionsBetween", reflect.TypeOf((*MockRepository)(nil).FindTransactionsBetween), ctx, from, to)
+}
+
+// FindTransactionsByIDs mocks base method.
+func (m *MockRepository) FindTransactionsByIDs(ctx context.Context, ids []string) ([]wallet.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTransactionsByIDs", ctx, ids)
+	ret0, _ := ret[0].([]wallet.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTransactionsByIDs indicates an expected call of FindTransactionsByIDs.
+func (mr *MockRepositoryMockRecorder) FindTransactionsByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTransactionsByIDs", reflect.TypeOf((*MockRepository)(nil).FindTransactionsByIDs), ctx, ids)
+}
+
+// FindTransactionsByReferences mocks base method.
+func (m *MockRepository) FindTransactionsByReferences(ctx context.Context, references []string) ([]wallet.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTransactionsByReferences", ctx, references)
+	ret0, _ := ret[0].([]wallet.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTransactionsByReferences indicates an expected call of FindTransactionsByReferences.
+func (mr *MockRepositoryMockRecorder) FindTransactionsByReferences(ctx, references interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTransactionsByReferences", reflect.TypeOf((*MockRepository)(nil).FindTransactionsByReferences), ctx, references)
+}
+
 // FindTransactionsByWalletID mocks base method.
 func (m *MockRepository) FindTransactionsByWalletID(ctx context.Context, walletID string) ([]wallet.Transaction, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +96,21 @@ func (mr *MockRepositoryMockRecorder) FindTransactionsByWalletID(ctx, walletID i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTransactionsByWalletID", reflect.TypeOf((*MockRepository)(nil).FindTransactionsByWalletID), ctx, walletID)
 }
 
+// FindTransactionsByWalletIDs mocks base method.
+func (m *MockRepository) FindTransactionsByWalletIDs(ctx context.Context, walletIDs []string) ([]wallet.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTransactionsByWalletIDs", ctx, walletIDs)
+	ret0, _ := ret[0].([]wallet.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTransactionsByWalletIDs indicates an expected call of FindTransactionsByWalletIDs.
+func (mr *MockRepositoryMockRecorder) FindTransactionsByWalletIDs(ctx, walletIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTransactionsByWalletIDs", reflect.TypeOf((*MockRepository)(nil).FindTransactionsByWalletIDs), ctx, walletIDs)
+}
+
 // FindWalletByID mocks base method.
 func (m *MockRepository) FindWalletByID(ctx context.Context, id string) (*wallet.Wallet, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +126,21 @@ func (mr *MockRepositoryMockRecorder) FindWalletByID(ctx, id interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWalletByID", reflect.TypeOf((*MockRepository)(nil).FindWalletByID), ctx, id)
 }
 
+// FindWalletsByIDs mocks base method.
+func (m *MockRepository) FindWalletsByIDs(ctx context.Context, ids []string) ([]*wallet.Wallet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindWalletsByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*wallet.Wallet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindWalletsByIDs indicates an expected call of FindWalletsByIDs.
+func (mr *MockRepositoryMockRecorder) FindWalletsByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindWalletsByIDs", reflect.TypeOf((*MockRepository)(nil).FindWalletsByIDs), ctx, ids)
+}
+
 // InsertTransactions mocks base method.
 func (m *MockRepository) InsertTransactions(ctx context.Context, transactions ...wallet.Transaction) error {
 	m.ctrl.T.Helper()
@@ -97,3 +173,73 @@ func (mr *MockRepositoryMockRecorder) InsertWallet(ctx, w interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertWallet", reflect.TypeOf((*MockRepository)(nil).InsertWallet), ctx, w)
 }
+
+// IterateTransactionsByWalletID mocks base method.
+func (m *MockRepository) IterateTransactionsByWalletID(ctx context.Context, walletID string, before time.Time, fn func(*wallet.Transaction) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IterateTransactionsByWalletID", ctx, walletID, before, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IterateTransactionsByWalletID indicates an expected call of IterateTransactionsByWalletID.
+func (mr *MockRepositoryMockRecorder) IterateTransactionsByWalletID(ctx, walletID, before, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterateTransactionsByWalletID", reflect.TypeOf((*MockRepository)(nil).IterateTransactionsByWalletID), ctx, walletID, before, fn)
+}
+
+// IterateWallets mocks base method.
+func (m *MockRepository) IterateWallets(ctx context.Context, fn func(*wallet.Wallet) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IterateWallets", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IterateWallets indicates an expected call of IterateWallets.
+func (mr *MockRepositoryMockRecorder) IterateWallets(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterateWallets", reflect.TypeOf((*MockRepository)(nil).IterateWallets), ctx, fn)
+}
+
+// Ping mocks base method.
+func (m *MockRepository) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockRepositoryMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRepository)(nil).Ping), ctx)
+}
+
+// UpdateWalletStatus mocks base method.
+func (m *MockRepository) UpdateWalletStatus(ctx context.Context, id string, status wallet.WalletStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWalletStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWalletStatus indicates an expected call of UpdateWalletStatus.
+func (mr *MockRepositoryMockRecorder) UpdateWalletStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWalletStatus", reflect.TypeOf((*MockRepository)(nil).UpdateWalletStatus), ctx, id, status)
+}
+
+// WithinTransaction mocks base method.
+func (m *MockRepository) WithinTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockRepositoryMockRecorder) WithinTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockRepository)(nil).WithinTransaction), ctx, fn)
+}
@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	views := func(n int) []*wallet.WalletView {
+		var vs []*wallet.WalletView
+		for i := 0; i < n; i++ {
+			vs = append(vs, &wallet.WalletView{ID: fmt.Sprintf("w%02d", i), Username: fmt.Sprintf("user%02d", i),
+				Status: wallet.ActiveWalletStatus, Balance: int64(100 * i), CreatedAt: created.Add(time.Duration(i) * time.Hour)})
+		}
+		return vs
+	}
+	setupMockViews := func(t *testing.T) *MockViewRepository {
+		return NewMockViewRepository(gomock.NewController(t))
+	}
+	units := func(v int64) *int64 { return &v }
+
+	t.Run("should find page of wallets with filters, total and next cursor", func(t *testing.T) {
+		mockRepo := setupMockViews(t)
+		expected := &wallet.WalletQuery{
+			UsernamePrefix: "user",
+			Status:         wallet.ActiveWalletStatus,
+			CreatedFrom:    created,
+			CreatedTo:      created.AddDate(0, 1, 0),
+			MinBalance:     units(1050),
+			MaxBalance:     units(100000),
+			SortField:      "balance",
+			Descending:     true,
+			Limit:          3,
+		}
+		mockRepo.EXPECT().FindViews(ctx, expected).Return(views(3), nil)
+		mockRepo.EXPECT().CountViews(ctx, gomock.Any()).Return(int64(7), nil)
+
+		page, err := wallet.NewSearch(mockRepo).FindWallets(ctx, &wallet.FindWalletsRequest{
+			Username: "user", Status: "active", Currency: "try", CreatedFrom: "2024-03-01", CreatedTo: "2024-04-01",
+			MinBalance: "10.50", MaxBalance: "1000", Sort: "-balance", Limit: 2,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7), page.Total)
+		assert.Len(t, page.Wallets, 2)
+		assert.Equal(t, wallet.WalletSummary{ID: "w01", Username: "user01", Status: wallet.ActiveWalletStatus,
+			Currency: wallet.DefaultCurrency, Balance: wallet.Money{Amount: 1}, CreatedAt: created.Add(time.Hour)}, page.Wallets[1])
+		assert.NotEmpty(t, page.NextCursor)
+
+		mockRepo.EXPECT().FindViews(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, q *wallet.WalletQuery) ([]*wallet.WalletView, error) {
+			assert.Equal(t, &wallet.WalletCursor{Sort: "-balance", ID: "w01", Balance: 100}, q.After)
+			return views(1), nil
+		})
+		mockRepo.EXPECT().CountViews(ctx, gomock.Any()).Return(int64(7), nil)
+
+		page, err = wallet.NewSearch(mockRepo).FindWallets(ctx, &wallet.FindWalletsRequest{Sort: "-balance", Cursor: page.NextCursor})
+		assert.Nil(t, err)
+		assert.Len(t, page.Wallets, 1)
+		assert.Empty(t, page.NextCursor, "last page has no next cursor")
+	})
+
+	t.Run("should find no wallets of other currencies", func(t *testing.T) {
+		page, err := wallet.NewSearch(setupMockViews(t)).FindWallets(ctx, &wallet.FindWalletsRequest{Currency: "USD"})
+		assert.Nil(t, err)
+		assert.Empty(t, page.Wallets)
+		assert.Zero(t, page.Total)
+	})
+
+	t.Run("should return bad request for invalid requests", func(t *testing.T) {
+		search := wallet.NewSearch(setupMockViews(t))
+		for _, req := range []*wallet.FindWalletsRequest{
+			{Status: "closed"},
+			{Sort: "password"},
+			{CreatedFrom: "March"},
+			{MinBalance: "ten"},
+			{Limit: 101},
+			{Cursor: "not a cursor"},
+			{Sort: "username", Cursor: "eyJzIjoiY3JlYXRlZF9hdCIsImlkIjoidzAxIn0"},
+		} {
+			_, err := search.FindWallets(ctx, req)
+			if assert.NotNil(t, err, "%+v", req) {
+				assert.Equal(t, 400, err.Code)
+			}
+		}
+	})
+
+	t.Run("should list wallets over http", func(t *testing.T) {
+		mockRepo := setupMockViews(t)
+		mockRepo.EXPECT().FindViews(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, q *wallet.WalletQuery) ([]*wallet.WalletView, error) {
+			assert.Equal(t, wallet.FrozenWalletStatus, q.Status)
+			assert.Equal(t, int64(6), q.Limit)
+			return []*wallet.WalletView{{ID: "w", Username: "user", Status: wallet.FrozenWalletStatus, Balance: 1250}}, nil
+		})
+		mockRepo.EXPECT().CountViews(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+		app := fiber.New()
+		wallet.NewAdminApi(wallet.NewSearch(mockRepo), nil).AddRoutesTo(app)
+
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/admin/wallets?status=frozen&limit=5", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		var page wallet.WalletPage
+		assert.Nil(t, json.NewDecoder(res.Body).Decode(&page))
+		assert.Equal(t, int64(1), page.Total)
+		assert.Equal(t, float32(12.5), page.Wallets[0].Balance.Amount)
+
+		res, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/admin/wallets?limit=many", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
@@ -8,27 +8,92 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/ybalcin/wallet-service/internal/wallet"
 	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
 	"go.uber.org/mock/gomock"
 	"testing"
+	"time"
 )
 
+// setupMockRepo creates mock repository whose units of work run their function once
 func setupMockRepo(t *testing.T) *MockRepository {
-	return NewMockRepository(gomock.NewController(t))
+	repo := NewMockRepository(gomock.NewController(t))
+	repo.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }).
+		AnyTimes()
+
+	return repo
+}
+
+// seedEvents appends streams of wallets to store, balances of wallets are recorded as a deposit and statuses as
+// freezes
+func seedEvents(t *testing.T, store eventstore.Store, wallets ...*wallet.Wallet) {
+	for _, w := range wallets {
+		payloads := []any{wallet.WalletCreated{Username: w.Username, CreatedAt: w.CreatedAt}}
+		if w.Balance.Amount > 0 {
+			payloads = append(payloads, wallet.MoneyDeposited{Transaction: wallet.Transaction{
+				ID: uuid.NewString(), WalletID: w.ID, Type: wallet.DepositTransactionType, Money: w.Balance,
+			}})
+		}
+		if w.IsFrozen() {
+			payloads = append(payloads, wallet.WalletFrozen{FrozenAt: w.UpdatedAt})
+		}
+
+		var events []eventstore.Event
+		for _, payload := range payloads {
+			e, err := wallet.Events.Encode(payload)
+			assert.Nil(t, err)
+			events = append(events, e)
+		}
+		_, err := store.Append(context.Background(), wallet.StreamOf(w.ID), eventstore.NoStream, events...)
+		assert.Nil(t, err)
+	}
+}
+
+// failingStore is an event store whose reads and appends fail with err
+type failingStore struct {
+	err error
+}
+
+func (s failingStore) Append(context.Context, string, int64, ...eventstore.Event) ([]eventstore.Event, error) {
+	return nil, s.err
+}
+
+func (s failingStore) ReadStream(context.Context, string, int64, eventstore.Direction, int) ([]eventstore.Event, error) {
+	return nil, s.err
+}
+
+func (s failingStore) ReadAll(context.Context, int64, int) ([]eventstore.Event, error) {
+	return nil, s.err
+}
+
+func (s failingStore) LastPosition(context.Context) (int64, error) {
+	return 0, s.err
 }
 
 func TestServiceImplementation(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := setupMockRepo(t)
-	service := wallet.NewService(mockRepo)
+	events := eventstore.NewMemoryStore()
+	mockSnapshots := NewMockSnapshotRepository(gomock.NewController(t))
+	service := wallet.NewService(mockRepo, events, mockSnapshots)
 
 	t.Run("CreateWallet", func(t *testing.T) {
 		req := &wallet.CreateWalletRequest{Username: "user"}
 
-		mockRepo.EXPECT().InsertWallet(ctx, gomock.Any()).Return(nil)
+		mockRepo.EXPECT().InsertWallet(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, w *wallet.Wallet) error {
+			assert.Equal(t, "user", w.Username)
+			assert.Equal(t, wallet.ActiveWalletStatus, w.Status)
+			return nil
+		})
 
 		id, err := service.CreateWallet(ctx, req)
 		assert.Nil(t, err)
 		assert.NotNil(t, id)
+
+		created, loadErr := wallet.LoadWallet(ctx, events, id.Id)
+		assert.Nil(t, loadErr)
+		assert.Equal(t, "user", created.Username)
+		assert.Equal(t, int64(1), created.StreamVersion)
 	})
 
 	t.Run("DepositMoney", func(t *testing.T) {
@@ -38,40 +103,95 @@ func TestServiceImplementation(t *testing.T) {
 				ID:      uuid.NewString(),
 				Balance: wallet.Money{Amount: 10},
 			}
+			seedEvents(t, events, w)
 
-			mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(w, nil)
-			mockRepo.EXPECT().FindTransactionsByWalletID(ctx, w.ID).Return(nil, nil)
 			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil)
 
-			expected, err := service.DepositMoney(ctx, w.ID, req)
+			actual, err := service.DepositMoney(ctx, w.ID, req)
+			assert.Nil(t, err)
+			assert.Equal(t, float32(20), actual.Balance.Amount)
+			assert.Equal(t, int64(2), actual.Version)
+			assert.Equal(t, int64(3), actual.StreamVersion, "saved wallets must move to their new stream versions")
+
+			loaded, loadErr := wallet.LoadWallet(ctx, events, w.ID)
+			assert.Nil(t, loadErr)
+			assert.Equal(t, actual.Balance, loaded.Balance)
+		})
+
+		t.Run("should save snapshot of wallet every 100 events", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString(), CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+			payloads := []any{wallet.WalletCreated{CreatedAt: w.CreatedAt}}
+			for i := 1; i < 99; i++ {
+				payloads = append(payloads, wallet.MoneyDeposited{Transaction: wallet.Transaction{ID: uuid.NewString(),
+					WalletID: w.ID, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 1}, CreatedAt: w.CreatedAt.AddDate(0, 0, i)}})
+			}
+			appendEvents(t, events, w.ID, payloads...)
+
+			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil).Times(2)
+			mockSnapshots.EXPECT().SaveSnapshot(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *wallet.Snapshot) error {
+				assert.Equal(t, int64(100), s.StreamVersion)
+				assert.Equal(t, int64(99), s.Transactions)
+				assert.Equal(t, float32(108), s.Balance.Amount)
+				assert.True(t, s.OccurredUntil.After(w.CreatedAt.AddDate(0, 0, 98)), "snapshot must cover the deposit")
+				return nil
+			})
+
+			_, err := service.DepositMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 10})
+			assert.Nil(t, err)
+			_, err = service.DepositMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 10})
+			assert.Nil(t, err, "the next snapshot is due 100 events later")
+		})
+
+		t.Run("should save details of request on transaction", func(t *testing.T) {
+			req := &wallet.MoneyTransactionRequest{Amount: 10, Reference: " psp-1 ", Description: "top up",
+				Metadata: map[string]string{"merchant": "m1"}, UniqueReference: true}
+			w := &wallet.Wallet{ID: uuid.NewString()}
+			seedEvents(t, events, w)
+
+			mockRepo.EXPECT().FindTransactionsByReferences(ctx, []string{"psp-1"}).
+				Return([]wallet.Transaction{{ID: "other", WalletID: "other-wallet"}}, nil)
+			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, transactions ...wallet.Transaction) error {
+				assert.Equal(t, wallet.TransactionDetails{Reference: "psp-1", Description: "top up",
+					Metadata: map[string]string{"merchant": "m1"}}, transactions[0].TransactionDetails)
+				return nil
+			})
+
+			_, err := service.DepositMoney(ctx, w.ID, req)
 			assert.Nil(t, err)
-			assert.Equal(t, expected, w)
+		})
+
+		t.Run("should return conflict if wallet has a transaction with unique reference", func(t *testing.T) {
+			req := &wallet.MoneyTransactionRequest{Amount: 10, Reference: "psp-1", UniqueReference: true}
+			w := &wallet.Wallet{ID: uuid.NewString()}
+			seedEvents(t, events, w)
+
+			mockRepo.EXPECT().FindTransactionsByReferences(ctx, []string{"psp-1"}).
+				Return([]wallet.Transaction{{ID: "t", WalletID: w.ID}}, nil)
+
+			actual, err := service.DepositMoney(ctx, w.ID, req)
+			assert.Nil(t, actual)
+			assert.Equal(t, errr.ThrowConflictError(fmt.Errorf(wallet.ErrDuplicateReference, "psp-1")), err)
+		})
+
+		t.Run("should require reference if it must be unique", func(t *testing.T) {
+			actual, err := service.DepositMoney(ctx, uuid.NewString(), &wallet.MoneyTransactionRequest{Amount: 10, UniqueReference: true})
+			assert.Nil(t, actual)
+			assert.Equal(t, errr.ThrowBadRequestError(errors.New(wallet.ErrEmptyReference)), err)
 		})
 
 		t.Run("should return ErrInvalidWalletID if walletID is empty", func(t *testing.T) {
 			req := &wallet.MoneyTransactionRequest{Amount: 10}
-			w := &wallet.Wallet{
-				ID:      "",
-				Balance: wallet.Money{Amount: 10},
-			}
 
-			w, err := service.DepositMoney(ctx, w.ID, req)
+			w, err := service.DepositMoney(ctx, "", req)
 			assert.Nil(t, w)
 			assert.Equal(t, errr.ThrowBadRequestError(errors.New(wallet.ErrInvalidWalletID)), err)
 		})
 
-		t.Run("should return error if repository.FindWalletByID returns error", func(t *testing.T) {
-			req := &wallet.MoneyTransactionRequest{Amount: 10}
-			w := &wallet.Wallet{
-				ID:      uuid.NewString(),
-				Balance: wallet.Money{Amount: 10},
-			}
-
+		t.Run("should return error if events can't be read", func(t *testing.T) {
 			e := errors.New("")
+			service := wallet.NewService(mockRepo, failingStore{err: e}, NewMockSnapshotRepository(gomock.NewController(t)))
 
-			mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(w, e)
-
-			w, err := service.DepositMoney(ctx, w.ID, req)
+			w, err := service.DepositMoney(ctx, uuid.NewString(), &wallet.MoneyTransactionRequest{Amount: 10})
 			assert.Nil(t, w)
 			assert.Equal(t, errr.ThrowInternalServerError(e), err)
 		})
@@ -81,28 +201,28 @@ func TestServiceImplementation(t *testing.T) {
 
 			id := uuid.NewString()
 
-			mockRepo.EXPECT().FindWalletByID(ctx, id).Return(nil, nil)
-
 			w, err := service.DepositMoney(ctx, id, req)
 			assert.Nil(t, w)
 			assert.Equal(t, errr.ThrowNotFoundError(fmt.Errorf(wallet.ErrWalletNotFound, id)), err)
 		})
 
-		t.Run("should return error if repository.FindTransactionsByWalletID returns error", func(t *testing.T) {
-			req := &wallet.MoneyTransactionRequest{Amount: 10}
-			w := &wallet.Wallet{
-				ID:      uuid.NewString(),
-				Balance: wallet.Money{Amount: 10},
-			}
-
-			e := errors.New("")
-
-			mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(w, nil)
-			mockRepo.EXPECT().FindTransactionsByWalletID(ctx, w.ID).Return(nil, e)
-
-			w, err := service.DepositMoney(ctx, w.ID, req)
-			assert.Nil(t, w)
-			assert.Equal(t, errr.ThrowInternalServerError(e), err)
+		t.Run("should return conflict if stream is appended concurrently", func(t *testing.T) {
+			req := &wallet.MoneyTransactionRequest{Amount: 10, Reference: "psp-2", UniqueReference: true}
+			w := &wallet.Wallet{ID: uuid.NewString()}
+			seedEvents(t, events, w)
+
+			mockRepo.EXPECT().FindTransactionsByReferences(ctx, []string{"psp-2"}).
+				DoAndReturn(func(context.Context, []string) ([]wallet.Transaction, error) {
+					e, err := wallet.Events.Encode(wallet.WalletFrozen{})
+					assert.Nil(t, err)
+					_, err = events.Append(ctx, wallet.StreamOf(w.ID), eventstore.AnyVersion, e)
+					assert.Nil(t, err)
+					return nil, nil
+				})
+
+			actual, err := service.DepositMoney(ctx, w.ID, req)
+			assert.Nil(t, actual)
+			assert.Equal(t, errr.ThrowAbortedError(fmt.Errorf(wallet.ErrConcurrentUpdate, w.ID)), err)
 		})
 
 		t.Run("should return error if repository.InsertTransactions returns error", func(t *testing.T) {
@@ -111,11 +231,10 @@ func TestServiceImplementation(t *testing.T) {
 				ID:      uuid.NewString(),
 				Balance: wallet.Money{Amount: 10},
 			}
+			seedEvents(t, events, w)
 
 			e := errors.New("")
 
-			mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(w, nil)
-			mockRepo.EXPECT().FindTransactionsByWalletID(ctx, w.ID).Return(nil, nil)
 			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(e)
 
 			w, err := service.DepositMoney(ctx, w.ID, req)
@@ -130,14 +249,167 @@ func TestServiceImplementation(t *testing.T) {
 			ID:      uuid.NewString(),
 			Balance: wallet.Money{Amount: 10},
 		}
+		seedEvents(t, events, w)
 
-		mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(w, nil)
-		mockRepo.EXPECT().FindTransactionsByWalletID(ctx, w.ID).Return(nil, nil)
 		mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any()).Return(nil)
 
-		expected, err := service.WithdrawMoney(ctx, w.ID, req)
+		actual, err := service.WithdrawMoney(ctx, w.ID, req)
 		assert.Nil(t, err)
-		assert.Equal(t, expected, w)
+		assert.Equal(t, float32(9), actual.Balance.Amount)
+		assert.Len(t, actual.Changes, 1)
+	})
+
+	t.Run("Fees", func(t *testing.T) {
+		service := wallet.NewService(mockRepo, events, mockSnapshots)
+		service.SetFees(wallet.FeeSchedules{
+			Withdraw: wallet.FeeSchedule{Fixed: 0.5, Rate: 0.01},
+			Transfer: wallet.FeeSchedule{Rate: 0.1, Max: 1},
+		})
+
+		t.Run("should charge fee of withdrawal", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 20}}
+			seedEvents(t, events, w)
+			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Len(2)).Return(nil)
+
+			actual, err := service.WithdrawMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 10})
+			assert.Nil(t, err)
+			assert.Equal(t, float32(9.4), actual.Balance.Amount)
+			assert.Len(t, actual.Changes, 2)
+			fee := actual.Changes[1]
+			assert.Equal(t, wallet.WithdrawTransactionType, fee.Type)
+			assert.Equal(t, float32(0.6), fee.Money.Amount)
+			assert.Equal(t, actual.Changes[0].ID, fee.FeeOf)
+		})
+
+		t.Run("should charge fee of transfer to sender at most max", func(t *testing.T) {
+			req := &wallet.TransferMoneyRequest{ToWalletID: uuid.NewString(), Amount: 15}
+			from := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 20}}
+			seedEvents(t, events, from, &wallet.Wallet{ID: req.ToWalletID})
+			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Len(3)).Return(nil)
+
+			res, err := service.TransferMoney(ctx, from.ID, req)
+			assert.Nil(t, err)
+			assert.Equal(t, float32(4), res.From.Balance.Amount)
+			assert.Equal(t, float32(15), res.To.Balance.Amount)
+			assert.Equal(t, float32(1), res.From.Changes[1].Money.Amount)
+			assert.Equal(t, res.From.Changes[0].ID, res.From.Changes[1].FeeOf)
+		})
+
+		t.Run("should return ErrInsufficientMoneyAmount if balance doesn't cover the fee", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+			seedEvents(t, events, w)
+
+			actual, err := service.WithdrawMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 10})
+			assert.Nil(t, actual)
+			assert.Equal(t, errr.ThrowBadRequestError(errors.New(wallet.ErrInsufficientMoneyAmount)), err)
+		})
+
+		t.Run("should apply fees that are set later to the next operation", func(t *testing.T) {
+			service.SetFees(wallet.FeeSchedules{})
+			w := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+			seedEvents(t, events, w)
+			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Len(1)).Return(nil)
+
+			actual, err := service.WithdrawMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 10})
+			assert.Nil(t, err)
+			assert.Equal(t, float32(0), actual.Balance.Amount)
+		})
+	})
+
+	t.Run("TransferMoney", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			req := &wallet.TransferMoneyRequest{ToWalletID: uuid.NewString(), Amount: 4}
+			from := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+			to := &wallet.Wallet{ID: req.ToWalletID}
+			seedEvents(t, events, from, to)
+
+			mockRepo.EXPECT().InsertTransactions(ctx, gomock.Any(), gomock.Any()).Return(nil)
+
+			res, err := service.TransferMoney(ctx, from.ID, req)
+			assert.Nil(t, err)
+			assert.Equal(t, float32(6), res.From.Balance.Amount)
+			assert.Equal(t, float32(4), res.To.Balance.Amount)
+			assert.Equal(t, int64(2), res.From.Version, "saved wallets must move to their new versions")
+			assert.Equal(t, int64(1), res.To.Version)
+			assert.Equal(t, to.ID, res.From.Changes[0].CounterpartyWalletID, "both legs must be marked as transfer")
+			assert.Equal(t, from.ID, res.To.Changes[0].CounterpartyWalletID)
+		})
+
+		t.Run("should abort unit of work if transactions can't be saved", func(t *testing.T) {
+			repo := NewMockRepository(gomock.NewController(t))
+			service := wallet.NewService(repo, events, NewMockSnapshotRepository(gomock.NewController(t)))
+			req := &wallet.TransferMoneyRequest{ToWalletID: uuid.NewString(), Amount: 10}
+			from := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+			seedEvents(t, events, from, &wallet.Wallet{ID: req.ToWalletID})
+			insertErr := errors.New("write conflict")
+
+			repo.EXPECT().WithinTransaction(ctx, gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					err := fn(ctx)
+					assert.ErrorIs(t, err, insertErr, "failure of the unit of work must reach the repository")
+					return err
+				})
+			repo.EXPECT().InsertTransactions(ctx, gomock.Len(2)).Return(insertErr)
+
+			res, err := service.TransferMoney(ctx, from.ID, req)
+			assert.Nil(t, res)
+			assert.Equal(t, errr.ThrowInternalServerError(insertErr), err)
+		})
+
+		t.Run("should return ErrSameWalletTransfer if wallets are same", func(t *testing.T) {
+			id := uuid.NewString()
+			req := &wallet.TransferMoneyRequest{ToWalletID: id, Amount: 4}
+
+			res, err := service.TransferMoney(ctx, id, req)
+			assert.Nil(t, res)
+			assert.Equal(t, errr.ThrowBadRequestError(errors.New(wallet.ErrSameWalletTransfer)), err)
+		})
+
+		t.Run("should return ErrInsufficientMoneyAmount if balance is not enough", func(t *testing.T) {
+			req := &wallet.TransferMoneyRequest{ToWalletID: uuid.NewString(), Amount: 4}
+			from := &wallet.Wallet{ID: uuid.NewString()}
+			to := &wallet.Wallet{ID: req.ToWalletID}
+			seedEvents(t, events, from, to)
+
+			res, err := service.TransferMoney(ctx, from.ID, req)
+			assert.Nil(t, res)
+			assert.Equal(t, errr.ThrowBadRequestError(errors.New(wallet.ErrInsufficientMoneyAmount)), err)
+		})
+	})
+
+	t.Run("FreezeWallet", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString()}
+			seedEvents(t, events, w)
+
+			mockRepo.EXPECT().UpdateWalletStatus(ctx, w.ID, wallet.FrozenWalletStatus).Return(nil)
+
+			actual, err := service.FreezeWallet(ctx, w.ID)
+			assert.Nil(t, err)
+			assert.True(t, actual.IsFrozen())
+
+			loaded, loadErr := wallet.LoadWallet(ctx, events, w.ID)
+			assert.Nil(t, loadErr)
+			assert.True(t, loaded.IsFrozen())
+		})
+
+		t.Run("should return ErrWalletAlreadyFrozen if wallet is frozen", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString(), Status: wallet.FrozenWalletStatus}
+			seedEvents(t, events, w)
+
+			actual, err := service.FreezeWallet(ctx, w.ID)
+			assert.Nil(t, actual)
+			assert.Equal(t, errr.ThrowBadRequestError(errors.New(wallet.ErrWalletAlreadyFrozen)), err)
+		})
+
+		t.Run("should reject deposits to frozen wallet", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString(), Status: wallet.FrozenWalletStatus}
+			seedEvents(t, events, w)
+
+			actual, err := service.DepositMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 10})
+			assert.Nil(t, actual)
+			assert.Equal(t, errr.ThrowBadRequestError(errors.New(wallet.ErrWalletFrozen)), err)
+		})
 	})
 
 	t.Run("GetWallet", func(t *testing.T) {
@@ -153,4 +425,56 @@ func TestServiceImplementation(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, expected, w)
 	})
+	t.Run("GetTransactions", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString()}
+			transactions := []wallet.Transaction{
+				{WalletID: w.ID, Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: 10}},
+			}
+
+			mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(w, nil)
+			mockRepo.EXPECT().FindTransactionsByWalletID(ctx, w.ID).Return(transactions, nil)
+
+			actual, err := service.GetTransactions(ctx, w.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, transactions, actual)
+		})
+
+		t.Run("should return empty history if wallet has no transaction", func(t *testing.T) {
+			w := &wallet.Wallet{ID: uuid.NewString()}
+
+			mockRepo.EXPECT().FindWalletByID(ctx, w.ID).Return(w, nil)
+			mockRepo.EXPECT().FindTransactionsByWalletID(ctx, w.ID).Return(nil, nil)
+
+			actual, err := service.GetTransactions(ctx, w.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, []wallet.Transaction{}, actual)
+		})
+
+		t.Run("should return error if wallet not found", func(t *testing.T) {
+			id := uuid.NewString()
+
+			mockRepo.EXPECT().FindWalletByID(ctx, id).Return(nil, nil)
+
+			actual, err := service.GetTransactions(ctx, id)
+			assert.Nil(t, actual)
+			assert.Equal(t, errr.ThrowNotFoundError(fmt.Errorf(wallet.ErrWalletNotFound, id)), err)
+		})
+	})
+
+	t.Run("FindTransactionsByReference", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			mockRepo.EXPECT().FindTransactionsByReferences(ctx, []string{"psp-1"}).Return(nil, nil)
+
+			actual, err := service.FindTransactionsByReference(ctx, "psp-1")
+			assert.Nil(t, err)
+			assert.Equal(t, []wallet.Transaction{}, actual)
+		})
+
+		t.Run("should return ErrEmptyReference if reference is empty", func(t *testing.T) {
+			actual, err := service.FindTransactionsByReference(ctx, " ")
+			assert.Nil(t, actual)
+			assert.Equal(t, errr.ThrowBadRequestError(errors.New(wallet.ErrEmptyReference)), err)
+		})
+	})
 }
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: snapshot_repository.go
+
+// Package wallet is a generated GoMock package.
+package wallet
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	wallet "github.com/ybalcin/wallet-service/internal/wallet"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSnapshotRepository is a mock of SnapshotRepository interface.
+type MockSnapshotRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnapshotRepositoryMockRecorder
+}
+
+// MockSnapshotRepositoryMockRecorder is the mock recorder for MockSnapshotRepository.
+type MockSnapshotRepositoryMockRecorder struct {
+	mock *MockSnapshotRepository
+}
+
+// NewMockSnapshotRepository creates a new mock instance.
+func NewMockSnapshotRepository(ctrl *gomock.Controller) *MockSnapshotRepository {
+	mock := &MockSnapshotRepository{ctrl: ctrl}
+	mock.recorder = &MockSnapshotRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSnapshotRepository) EXPECT() *MockSnapshotRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindSnapshot mocks base method.
+func (m *MockSnapshotRepository) FindSnapshot(ctx context.Context, walletID string, before time.Time) (*wallet.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSnapshot", ctx, walletID, before)
+	ret0, _ := ret[0].(*wallet.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSnapshot indicates an expected call of FindSnapshot.
+func (mr *MockSnapshotRepositoryMockRecorder) FindSnapshot(ctx, walletID, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSnapshot", reflect.TypeOf((*MockSnapshotRepository)(nil).FindSnapshot), ctx, walletID, before)
+}
+
+// SaveSnapshot mocks base method.
+func (m *MockSnapshotRepository) SaveSnapshot(ctx context.Context, s *wallet.Snapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSnapshot", ctx, s)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSnapshot indicates an expected call of SaveSnapshot.
+func (mr *MockSnapshotRepositoryMockRecorder) SaveSnapshot(ctx, s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSnapshot", reflect.TypeOf((*MockSnapshotRepository)(nil).SaveSnapshot), ctx, s)
+}
@@ -0,0 +1,137 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteStatement(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.NewString()
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	transaction := func(typ wallet.TransactionType, amount float32, createdAt time.Time) wallet.Transaction {
+		return wallet.Transaction{ID: uuid.NewString(), WalletID: id, Type: typ, Money: wallet.Money{Amount: amount}, CreatedAt: createdAt}
+	}
+	history := []wallet.Transaction{
+		transaction(wallet.DepositTransactionType, 100, march.AddDate(0, -1, 0)),
+		transaction(wallet.WithdrawTransactionType, 30, march.AddDate(0, 0, 2)),
+		transaction(wallet.DepositTransactionType, 5, march.AddDate(0, 0, 20)),
+	}
+	history[1].Reference = "order-1"
+	setup := func(t *testing.T) wallet.Service {
+		mockRepo := setupMockRepo(t)
+		mockRepo.EXPECT().FindWalletByID(ctx, id).Return(&wallet.Wallet{ID: id, Username: "user"}, nil).AnyTimes()
+		mockRepo.EXPECT().IterateTransactionsByWalletID(ctx, id, march.AddDate(0, 1, 0), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ time.Time, fn func(t *wallet.Transaction) error) error {
+				for i := range history {
+					if err := fn(&history[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			}).AnyTimes()
+
+		return wallet.NewService(mockRepo, eventstore.NewMemoryStore(), NewMockSnapshotRepository(gomock.NewController(t)))
+	}
+	req := &wallet.StatementRequest{From: "2024-03-01", To: "2024-04-01"}
+
+	t.Run("should write opening balance, running balances and closing balance as json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		w, err := wallet.NewStatementWriter(buf, wallet.JSONStatementFormat)
+		assert.Nil(t, err)
+
+		assert.Nil(t, setup(t).WriteStatement(ctx, id, req, w))
+
+		var doc wallet.StatementDocument
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, id, doc.WalletID)
+		assert.Equal(t, march, doc.From)
+		assert.Equal(t, float32(100), doc.OpeningBalance.Amount)
+		assert.Equal(t, float32(75), doc.ClosingBalance.Amount)
+		assert.Equal(t, 2, doc.Transactions)
+		assert.Len(t, doc.Lines, 2)
+		assert.Equal(t, history[1].ID, doc.Lines[0].ID)
+		assert.Equal(t, "order-1", doc.Lines[0].Reference)
+		assert.Equal(t, float32(70), doc.Lines[0].Balance.Amount)
+		assert.Equal(t, float32(75), doc.Lines[1].Balance.Amount)
+	})
+
+	t.Run("should write signed amounts as csv", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		w, _ := wallet.NewStatementWriter(buf, wallet.CSVStatementFormat)
+
+		assert.Nil(t, setup(t).WriteStatement(ctx, id, req, w))
+
+		rows, err := csv.NewReader(buf).ReadAll()
+		assert.Nil(t, err)
+		assert.Len(t, rows, 5)
+		assert.Equal(t, []string{"2024-03-01T00:00:00Z", "", "opening_balance", "", "", "", "100.00"}, rows[1])
+		assert.Equal(t, []string{"2024-03-03T00:00:00Z", history[1].ID, "withdraw", "order-1", "", "-30.00", "70.00"}, rows[2])
+		assert.Equal(t, []string{"2024-04-01T00:00:00Z", "", "closing_balance", "", "", "", "75.00"}, rows[4])
+	})
+
+	t.Run("should write opening balance as closing balance if period has no transactions", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		w, _ := wallet.NewStatementWriter(buf, wallet.TextStatementFormat)
+		assert.Nil(t, setup(t).WriteStatement(ctx, id, &wallet.StatementRequest{From: "2024-03-25", To: "2024-04-01"}, w))
+		assert.Contains(t, buf.String(), "Transactions: 0\nClosing balance: 75.00\n")
+	})
+
+	t.Run("should return bad request for invalid period", func(t *testing.T) {
+		w, _ := wallet.NewStatementWriter(io.Discard, wallet.CSVStatementFormat)
+
+		err := setup(t).WriteStatement(ctx, id, &wallet.StatementRequest{From: "2024-04-01", To: "2024-03-01"}, w)
+		assert.Equal(t, 400, err.Code)
+		err = setup(t).WriteStatement(ctx, id, &wallet.StatementRequest{From: "March"}, w)
+		assert.Equal(t, 400, err.Code)
+	})
+
+	t.Run("should return not found if wallet doesn't exist", func(t *testing.T) {
+		mockRepo := setupMockRepo(t)
+		mockRepo.EXPECT().FindWalletByID(ctx, id).Return(nil, nil)
+		w, _ := wallet.NewStatementWriter(io.Discard, wallet.CSVStatementFormat)
+
+		err := wallet.NewService(mockRepo, eventstore.NewMemoryStore(), NewMockSnapshotRepository(gomock.NewController(t))).WriteStatement(ctx, id, req, w)
+		assert.Equal(t, 404, err.Code)
+	})
+
+	t.Run("should return error for unknown format", func(t *testing.T) {
+		_, err := wallet.NewStatementWriter(io.Discard, "pdf")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("should stream statement over http", func(t *testing.T) {
+		app := fiber.New()
+		wallet.NewApi(setup(t)).AddRoutesTo(app)
+
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/wallets/"+id+"/statements?from=2024-03-01&to=2024-04-01&format=text", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/plain; charset=utf-8", res.Header.Get(fiber.HeaderContentType))
+		assert.Contains(t, res.Header.Get(fiber.HeaderContentDisposition), "statement-"+id+".txt")
+		body, _ := io.ReadAll(res.Body)
+		assert.True(t, strings.HasPrefix(string(body), "Statement of wallet "+id+" (user)\n"))
+		assert.Contains(t, string(body), "Closing balance: 75.00\n")
+
+		res, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/wallets/"+id+"/statements?from=2024-04-01&to=2024-03-01", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+
+		res, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/wallets/"+id+"/statements?format=pdf", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, res.StatusCode)
+	})
+}
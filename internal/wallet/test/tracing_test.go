@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestTracingService(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := setupMockRepo(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	events := eventstore.NewMemoryStore()
+	service := wallet.NewTracingService(
+		wallet.NewService(wallet.NewTracingRepository(mockRepo, provider), wallet.NewTracingEventStore(events, provider), NewMockSnapshotRepository(gomock.NewController(t))),
+		provider,
+	)
+
+	w := &wallet.Wallet{ID: uuid.NewString(), Balance: wallet.Money{Amount: 10}}
+	seedEvents(t, events, w)
+	mockRepo.EXPECT().InsertTransactions(gomock.Any(), gomock.Any()).Return(nil)
+
+	_, err := service.WithdrawMoney(ctx, w.ID, &wallet.MoneyTransactionRequest{Amount: 1})
+	assert.Nil(t, err)
+
+	spans := recorder.Ended()
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name()
+	}
+	assert.Equal(t, []string{
+		"wallet.EventStore/ReadStream",
+		"wallet.EventStore/Append",
+		"wallet.Repository/InsertTransactions",
+		"wallet.Repository/WithinTransaction",
+		"wallet.Service/WithdrawMoney",
+	}, names)
+
+	root, unitOfWork := spans[len(spans)-1], spans[len(spans)-2]
+	assert.Contains(t, root.Attributes(), attribute.String("wallet.id", w.ID))
+	assert.Contains(t, root.Attributes(), attribute.String("wallet.transaction.type", "withdraw"))
+	assert.Equal(t, root.SpanContext().SpanID(), unitOfWork.Parent().SpanID())
+	for _, s := range spans[:len(spans)-2] {
+		assert.Equal(t, unitOfWork.SpanContext().SpanID(), s.Parent().SpanID())
+	}
+	assert.Contains(t, spans[0].Attributes(), attribute.String("eventstore.stream", wallet.StreamOf(w.ID)))
+	assert.Contains(t, spans[1].Attributes(), attribute.String("eventstore.stream", wallet.StreamOf(w.ID)))
+	assert.Contains(t, spans[2].Attributes(), attribute.String("wallet.id", w.ID))
+}
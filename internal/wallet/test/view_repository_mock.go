@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: view_repository.go
+
+// Package wallet is a generated GoMock package.
+package wallet
+
+import (
+	context "context"
+	reflect "reflect"
+
+	wallet "github.com/ybalcin/wallet-service/internal/wallet"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockViewRepository is a mock of ViewRepository interface.
+type MockViewRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockViewRepositoryMockRecorder
+}
+
+// MockViewRepositoryMockRecorder is the mock recorder for MockViewRepository.
+type MockViewRepositoryMockRecorder struct {
+	mock *MockViewRepository
+}
+
+// NewMockViewRepository creates a new mock instance.
+func NewMockViewRepository(ctrl *gomock.Controller) *MockViewRepository {
+	mock := &MockViewRepository{ctrl: ctrl}
+	mock.recorder = &MockViewRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockViewRepository) EXPECT() *MockViewRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ApplyViewChanges mocks base method.
+func (m *MockViewRepository) ApplyViewChanges(ctx context.Context, changes []wallet.ViewChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyViewChanges", ctx, changes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyViewChanges indicates an expected call of ApplyViewChanges.
+func (mr *MockViewRepositoryMockRecorder) ApplyViewChanges(ctx, changes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyViewChanges", reflect.TypeOf((*MockViewRepository)(nil).ApplyViewChanges), ctx, changes)
+}
+
+// CountViews mocks base method.
+func (m *MockViewRepository) CountViews(ctx context.Context, query *wallet.WalletQuery) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountViews", ctx, query)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountViews indicates an expected call of CountViews.
+func (mr *MockViewRepositoryMockRecorder) CountViews(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountViews", reflect.TypeOf((*MockViewRepository)(nil).CountViews), ctx, query)
+}
+
+// DeleteViews mocks base method.
+func (m *MockViewRepository) DeleteViews(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteViews", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteViews indicates an expected call of DeleteViews.
+func (mr *MockViewRepositoryMockRecorder) DeleteViews(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteViews", reflect.TypeOf((*MockViewRepository)(nil).DeleteViews), ctx)
+}
+
+// FindViews mocks base method.
+func (m *MockViewRepository) FindViews(ctx context.Context, query *wallet.WalletQuery) ([]*wallet.WalletView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindViews", ctx, query)
+	ret0, _ := ret[0].([]*wallet.WalletView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindViews indicates an expected call of FindViews.
+func (mr *MockViewRepositoryMockRecorder) FindViews(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindViews", reflect.TypeOf((*MockViewRepository)(nil).FindViews), ctx, query)
+}
+
+// FindViewsByIDs mocks base method.
+func (m *MockViewRepository) FindViewsByIDs(ctx context.Context, ids []string) ([]*wallet.WalletView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindViewsByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*wallet.WalletView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindViewsByIDs indicates an expected call of FindViewsByIDs.
+func (mr *MockViewRepositoryMockRecorder) FindViewsByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindViewsByIDs", reflect.TypeOf((*MockViewRepository)(nil).FindViewsByIDs), ctx, ids)
+}
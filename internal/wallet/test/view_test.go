@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/internal/wallet"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"github.com/ybalcin/wallet-service/pkg/projection"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestViewProjection(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	deposit := func(walletID string, amount float32, at time.Duration) wallet.MoneyDeposited {
+		return wallet.MoneyDeposited{Transaction: wallet.Transaction{ID: walletID + at.String(), WalletID: walletID,
+			Type: wallet.DepositTransactionType, Money: wallet.Money{Amount: amount}, CreatedAt: created.Add(at)}}
+	}
+	withdraw := func(walletID string, amount float32, at time.Duration) wallet.MoneyWithdrawn {
+		t := deposit(walletID, amount, at).Transaction
+		t.Type = wallet.WithdrawTransactionType
+		return wallet.MoneyWithdrawn{Transaction: t}
+	}
+	// history returns a store with streams of wallets a and b and a stream of another kind between them
+	history := func(t *testing.T) eventstore.Store {
+		events := eventstore.NewMemoryStore()
+		appendEvents(t, events, "a",
+			wallet.WalletCreated{Username: "user-a", CreatedAt: created},
+			deposit("a", 10, time.Minute),
+			withdraw("a", 2.5, 2*time.Minute),
+			wallet.WalletFrozen{FrozenAt: created.Add(3 * time.Minute)},
+		)
+		_, err := events.Append(ctx, "other", eventstore.NoStream, eventstore.Event{Type: "other"})
+		assert.Nil(t, err)
+		appendEvents(t, events, "b",
+			wallet.WalletCreated{Username: "user-b", CreatedAt: created},
+			deposit("b", 5, 4*time.Minute),
+		)
+
+		return events
+	}
+
+	t.Run("should project events after checkpoint", func(t *testing.T) {
+		mockRepo := NewMockViewRepository(gomock.NewController(t))
+		mockRepo.EXPECT().FindViewsByIDs(ctx, []string{"a", "b"}).Return([]*wallet.WalletView{{ID: "a"}}, nil)
+		mockRepo.EXPECT().ApplyViewChanges(ctx, []wallet.ViewChange{
+			{WalletID: "a", Username: "user-a", CreatedAt: created, Status: wallet.FrozenWalletStatus,
+				UpdatedAt: created.Add(3 * time.Minute), Balance: 750, Transactions: 2, To: 4},
+			{WalletID: "b", Username: "user-b", CreatedAt: created, Status: wallet.ActiveWalletStatus,
+				UpdatedAt: created, Balance: 500, Transactions: 1, To: 7},
+		}).Return(nil)
+
+		cp := &projection.Checkpoint{Projection: wallet.WalletViewProjection}
+		progress, err := wallet.NewViewProjection(history(t), mockRepo).Step(ctx, cp, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, projection.Progress{Projected: 7, CaughtUp: true}, progress)
+		assert.Equal(t, int64(7), cp.Position)
+	})
+
+	t.Run("should skip events that views have projected", func(t *testing.T) {
+		mockRepo := NewMockViewRepository(gomock.NewController(t))
+		mockRepo.EXPECT().FindViewsByIDs(ctx, []string{"a"}).Return([]*wallet.WalletView{{ID: "a", Position: 2}}, nil)
+		mockRepo.EXPECT().ApplyViewChanges(ctx, []wallet.ViewChange{
+			{WalletID: "a", Balance: -250, Transactions: 1, From: 2, To: 3},
+		}).Return(nil)
+
+		cp := &projection.Checkpoint{Projection: wallet.WalletViewProjection, Position: 1}
+		progress, err := wallet.NewViewProjection(history(t), mockRepo).Step(ctx, cp, 2)
+		assert.Nil(t, err)
+		assert.False(t, progress.CaughtUp, "a full batch may have more events after it")
+		assert.Equal(t, 2, progress.Projected)
+		assert.Equal(t, int64(3), cp.Position)
+	})
+
+	t.Run("should be caught up without events after checkpoint", func(t *testing.T) {
+		cp := &projection.Checkpoint{Projection: wallet.WalletViewProjection, Position: 7}
+		progress, err := wallet.NewViewProjection(history(t), NewMockViewRepository(gomock.NewController(t))).Step(ctx, cp, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, projection.Progress{CaughtUp: true}, progress)
+		assert.Equal(t, int64(7), cp.Position)
+	})
+
+	t.Run("should delete views on reset", func(t *testing.T) {
+		mockRepo := NewMockViewRepository(gomock.NewController(t))
+		mockRepo.EXPECT().DeleteViews(ctx).Return(nil)
+
+		assert.Nil(t, wallet.NewViewProjection(eventstore.NewMemoryStore(), mockRepo).Reset(ctx))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/ybalcin/wallet-service/internal/wallet"
+	"strings"
 	"testing"
 )
 
@@ -29,7 +30,7 @@ func TestWallet_WithdrawMoney(t *testing.T) {
 		money, _ := wallet.NewMoney(10)
 		w, _ := wallet.New("user")
 		w.Balance = wallet.Money{Amount: 20}
-		err := w.WithdrawMoney(*money)
+		err := w.WithdrawMoney(*money, wallet.TransactionDetails{})
 		assert.Nil(t, err)
 		assert.Equal(t, float32(10), w.Balance.Amount)
 		assert.Equal(t, 1, len(w.Changes))
@@ -58,7 +59,7 @@ func TestWallet_WithdrawMoney(t *testing.T) {
 				money, _ := wallet.NewMoney(tt.amount)
 				w, _ := wallet.New("user")
 				w.Balance = wallet.Money{Amount: 1}
-				actual := w.WithdrawMoney(*money)
+				actual := w.WithdrawMoney(*money, wallet.TransactionDetails{})
 				assert.Equal(t, tt.expected, actual)
 			})
 		}
@@ -69,16 +70,24 @@ func TestWallet_DepositMoney(t *testing.T) {
 	t.Run("should apply deposit and append transaction to changes", func(t *testing.T) {
 		money, _ := wallet.NewMoney(10)
 		w, _ := wallet.New("username")
-		err := w.DepositMoney(*money)
+		err := w.DepositMoney(*money, wallet.TransactionDetails{})
 		assert.Nil(t, err)
 		assert.Equal(t, float32(10), w.Balance.Amount)
 		assert.Equal(t, 1, len(w.Changes))
 	})
 
+	t.Run("should record details on transaction", func(t *testing.T) {
+		money, _ := wallet.NewMoney(10)
+		w, _ := wallet.New("username")
+		details := wallet.TransactionDetails{Reference: "psp-1", Metadata: map[string]string{"merchant": "m1"}}
+		assert.Nil(t, w.DepositMoney(*money, details))
+		assert.Equal(t, details, w.Changes[0].TransactionDetails)
+	})
+
 	t.Run("should return ErrInvalidMoneyAmount if money is 0", func(t *testing.T) {
 		money, _ := wallet.NewMoney(0)
 		w, _ := wallet.New("username")
-		actual := w.DepositMoney(*money)
+		actual := w.DepositMoney(*money, wallet.TransactionDetails{})
 		assert.Equal(t, errors.New(wallet.ErrInvalidMoneyAmount), actual)
 	})
 }
@@ -132,6 +141,22 @@ func TestNewMoney(t *testing.T) {
 		assert.Equal(t, errors.New(wallet.ErrInvalidMoneyAmount), err)
 	})
 
+	t.Run("should return ErrInvalidMoneyAmount error if amount isn't whole minor units", func(t *testing.T) {
+		for _, amount := range []float32{0.001, 0.015, 19.999} {
+			money, err := wallet.NewMoney(amount)
+			assert.Nil(t, money, "amount %v", amount)
+			assert.Equal(t, errors.New(wallet.ErrInvalidMoneyAmount), err, "amount %v", amount)
+		}
+	})
+
+	t.Run("should create instance of money in whole minor units", func(t *testing.T) {
+		for _, amount := range []float32{0, 0.01, 0.1, 19.99, 1234567.89} {
+			money, err := wallet.NewMoney(amount)
+			assert.Nil(t, err, "amount %v", amount)
+			assert.Equal(t, amount, money.Amount)
+		}
+	})
+
 	t.Run("should create instance of money", func(t *testing.T) {
 		money, err := wallet.NewMoney(10)
 		assert.Nil(t, err)
@@ -152,3 +177,68 @@ func TestMoney_Sub(t *testing.T) {
 	money.Sub(&from)
 	assert.Equal(t, float32(9), from.Amount)
 }
+
+func TestFeeSchedule_FeeOf(t *testing.T) {
+	cases := []struct {
+		name     string
+		schedule wallet.FeeSchedule
+		amount   float32
+		fee      float32
+	}{
+		{"zero schedule charges no fee", wallet.FeeSchedule{}, 10, 0},
+		{"fixed and rate are added", wallet.FeeSchedule{Fixed: 0.5, Rate: 0.01}, 10, 0.6},
+		{"fee is rounded to minor unit", wallet.FeeSchedule{Rate: 0.015}, 0.99, 0.01},
+		{"fee is at most max", wallet.FeeSchedule{Rate: 0.1, Max: 1}, 50, 1},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.fee, tt.schedule.FeeOf(wallet.Money{Amount: tt.amount}).Amount)
+		})
+	}
+}
+
+func TestNewTransactionDetails(t *testing.T) {
+	t.Run("should trim reference and description", func(t *testing.T) {
+		d, err := wallet.NewTransactionDetails(" psp-1 ", " top up ", map[string]string{})
+		assert.Nil(t, err)
+		assert.Equal(t, &wallet.TransactionDetails{Reference: "psp-1", Description: "top up"}, d)
+	})
+
+	tooManyKeys := map[string]string{}
+	for i := 0; i <= wallet.MaxMetadataKeys; i++ {
+		tooManyKeys[strings.Repeat("k", i+1)] = "v"
+	}
+	cases := []struct {
+		name        string
+		reference   string
+		description string
+		metadata    map[string]string
+		err         string
+	}{
+		{"if reference is too long", strings.Repeat("r", wallet.MaxReferenceLength+1), "", nil, "reference"},
+		{"if description is too long", "", strings.Repeat("d", wallet.MaxDescriptionLength+1), nil, "description"},
+		{"if metadata has too many keys", "", "", tooManyKeys, "metadata keys"},
+		{"if metadata key is empty", "", "", map[string]string{" ": "v"}, "metadata key"},
+		{"if metadata key has a dot", "", "", map[string]string{"merchant.id": "v"}, "metadata key"},
+		{"if metadata value is too long", "", "", map[string]string{"k": strings.Repeat("v", wallet.MaxMetadataValueLength+1)}, "metadata value"},
+	}
+	for _, tt := range cases {
+		t.Run("should return error "+tt.name, func(t *testing.T) {
+			d, err := wallet.NewTransactionDetails(tt.reference, tt.description, tt.metadata)
+			assert.Nil(t, d)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestWallet_Freeze(t *testing.T) {
+	w, _ := wallet.New("user")
+	money, _ := wallet.NewMoney(10)
+
+	assert.Nil(t, w.Freeze())
+	assert.True(t, w.IsFrozen())
+	assert.Equal(t, errors.New(wallet.ErrWalletAlreadyFrozen), w.Freeze())
+	assert.Equal(t, errors.New(wallet.ErrWalletFrozen), w.DepositMoney(*money, wallet.TransactionDetails{}))
+	assert.Equal(t, errors.New(wallet.ErrWalletFrozen), w.WithdrawMoney(*money, wallet.TransactionDetails{}))
+	assert.Empty(t, w.Changes)
+}
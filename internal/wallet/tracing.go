@@ -0,0 +1,371 @@
+package wallet
+
+import (
+	"context"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const tracerName = "github.com/ybalcin/wallet-service/internal/wallet"
+
+const (
+	walletIDKey        = attribute.Key("wallet.id")
+	toWalletIDKey      = attribute.Key("wallet.to_id")
+	transactionTypeKey = attribute.Key("wallet.transaction.type")
+	transactionsKey    = attribute.Key("wallet.transactions")
+	transactionIDsKey  = attribute.Key("wallet.transaction.ids")
+	walletStatusKey    = attribute.Key("wallet.status")
+	streamKey          = attribute.Key("eventstore.stream")
+	eventsKey          = attribute.Key("eventstore.events")
+	positionKey        = attribute.Key("eventstore.position")
+)
+
+type (
+	// TracingService is a Service decorator that starts a span for every use case
+	TracingService struct {
+		service Service
+		tracer  trace.Tracer
+	}
+
+	// TracingRepository is a Repository decorator that starts a client span for every db operation
+	TracingRepository struct {
+		repository Repository
+		tracer     trace.Tracer
+	}
+
+	// TracingEventStore is an eventstore.Store decorator that starts a client span for every operation, so loading
+	// and saving wallets by their streams are traced
+	TracingEventStore struct {
+		store  eventstore.Store
+		tracer trace.Tracer
+	}
+)
+
+// NewTracingService creates new instance of TracingService
+func NewTracingService(service Service, provider trace.TracerProvider) *TracingService {
+	return &TracingService{service: service, tracer: provider.Tracer(tracerName)}
+}
+
+func (s *TracingService) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "wallet.Service/"+name, trace.WithAttributes(attrs...))
+}
+
+// endServiceSpan records err to span and ends it, only internal errors mark span as failed
+func endServiceSpan(span trace.Span, err *errr.Error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(semconv.HTTPStatusCode(err.Code))
+		if err.Code >= 500 {
+			span.SetStatus(codes.Error, err.Message)
+		}
+	}
+	span.End()
+}
+
+// CreateWallet creates wallet
+func (s *TracingService) CreateWallet(ctx context.Context, req *CreateWalletRequest) (*ID, *errr.Error) {
+	ctx, span := s.start(ctx, "CreateWallet")
+	id, err := s.service.CreateWallet(ctx, req)
+	if err == nil {
+		span.SetAttributes(walletIDKey.String(id.Id))
+	}
+	endServiceSpan(span, err)
+
+	return id, err
+}
+
+// DepositMoney provides to deposit(add) money to wallet
+func (s *TracingService) DepositMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	ctx, span := s.start(ctx, "DepositMoney", walletIDKey.String(walletID), transactionTypeKey.String(string(DepositTransactionType)))
+	wallet, err := s.service.DepositMoney(ctx, walletID, req)
+	endServiceSpan(span, err)
+
+	return wallet, err
+}
+
+// WithdrawMoney provides to withdraw(sub) money from wallet
+func (s *TracingService) WithdrawMoney(ctx context.Context, walletID string, req *MoneyTransactionRequest) (*Wallet, *errr.Error) {
+	ctx, span := s.start(ctx, "WithdrawMoney", walletIDKey.String(walletID), transactionTypeKey.String(string(WithdrawTransactionType)))
+	wallet, err := s.service.WithdrawMoney(ctx, walletID, req)
+	endServiceSpan(span, err)
+
+	return wallet, err
+}
+
+// TransferMoney provides to transfer money from wallet to another wallet
+func (s *TracingService) TransferMoney(ctx context.Context, walletID string, req *TransferMoneyRequest) (*TransferMoneyResponse, *errr.Error) {
+	ctx, span := s.start(ctx, "TransferMoney", walletIDKey.String(walletID), toWalletIDKey.String(req.ToWalletID))
+	res, err := s.service.TransferMoney(ctx, walletID, req)
+	endServiceSpan(span, err)
+
+	return res, err
+}
+
+// FreezeWallet freezes wallet so that money can't be deposited to or withdrawn from it
+func (s *TracingService) FreezeWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	ctx, span := s.start(ctx, "FreezeWallet", walletIDKey.String(walletID))
+	wallet, err := s.service.FreezeWallet(ctx, walletID)
+	endServiceSpan(span, err)
+
+	return wallet, err
+}
+
+// GetWallet gets wallet with current state
+func (s *TracingService) GetWallet(ctx context.Context, walletID string) (*Wallet, *errr.Error) {
+	ctx, span := s.start(ctx, "GetWallet", walletIDKey.String(walletID))
+	wallet, err := s.service.GetWallet(ctx, walletID)
+	endServiceSpan(span, err)
+
+	return wallet, err
+}
+
+// GetWalletAsOf gets wallet with its state as of a time in the past
+func (s *TracingService) GetWalletAsOf(ctx context.Context, walletID string, asOf time.Time) (*Wallet, *errr.Error) {
+	ctx, span := s.start(ctx, "GetWalletAsOf", walletIDKey.String(walletID),
+		attribute.String("wallet.as_of", asOf.Format(time.RFC3339)))
+	wallet, err := s.service.GetWalletAsOf(ctx, walletID, asOf)
+	endServiceSpan(span, err)
+
+	return wallet, err
+}
+
+// GetTransactions gets transaction history of wallet
+func (s *TracingService) GetTransactions(ctx context.Context, walletID string) ([]Transaction, *errr.Error) {
+	ctx, span := s.start(ctx, "GetTransactions", walletIDKey.String(walletID))
+	transactions, err := s.service.GetTransactions(ctx, walletID)
+	endServiceSpan(span, err)
+
+	return transactions, err
+}
+
+// FindTransactionsByReference finds transactions of every wallet with reference
+func (s *TracingService) FindTransactionsByReference(ctx context.Context, reference string) ([]Transaction, *errr.Error) {
+	ctx, span := s.start(ctx, "FindTransactionsByReference")
+	transactions, err := s.service.FindTransactionsByReference(ctx, reference)
+	endServiceSpan(span, err)
+
+	return transactions, err
+}
+
+// WriteStatement writes statement of wallet
+func (s *TracingService) WriteStatement(ctx context.Context, walletID string, req *StatementRequest, w StatementWriter) *errr.Error {
+	ctx, span := s.start(ctx, "WriteStatement", walletIDKey.String(walletID))
+	err := s.service.WriteStatement(ctx, walletID, req, w)
+	endServiceSpan(span, err)
+
+	return err
+}
+
+// NewTracingRepository creates new instance of TracingRepository
+func NewTracingRepository(repository Repository, provider trace.TracerProvider) *TracingRepository {
+	return &TracingRepository{repository: repository, tracer: provider.Tracer(tracerName)}
+}
+
+func (r *TracingRepository) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, semconv.DBSystemMongoDB, semconv.DBOperation(operation))
+	return r.tracer.Start(ctx, "wallet.Repository/"+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func endRepositorySpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// InsertWallet inserts wallet to collection
+func (r *TracingRepository) InsertWallet(ctx context.Context, w *Wallet) error {
+	ctx, span := r.start(ctx, "InsertWallet", walletIDKey.String(w.ID))
+	err := r.repository.InsertWallet(ctx, w)
+	endRepositorySpan(span, err)
+
+	return err
+}
+
+// FindWalletByID finds wallet by id
+func (r *TracingRepository) FindWalletByID(ctx context.Context, id string) (*Wallet, error) {
+	ctx, span := r.start(ctx, "FindWalletByID", walletIDKey.String(id))
+	wallet, err := r.repository.FindWalletByID(ctx, id)
+	endRepositorySpan(span, err)
+
+	return wallet, err
+}
+
+// FindTransactionsByWalletID finds transaction by wallet id
+func (r *TracingRepository) FindTransactionsByWalletID(ctx context.Context, walletID string) ([]Transaction, error) {
+	ctx, span := r.start(ctx, "FindTransactionsByWalletID", walletIDKey.String(walletID))
+	transactions, err := r.repository.FindTransactionsByWalletID(ctx, walletID)
+	span.SetAttributes(transactionsKey.Int(len(transactions)))
+	endRepositorySpan(span, err)
+
+	return transactions, err
+}
+
+// FindWalletsByIDs finds wallets by ids
+func (r *TracingRepository) FindWalletsByIDs(ctx context.Context, ids []string) ([]*Wallet, error) {
+	ctx, span := r.start(ctx, "FindWalletsByIDs", walletIDKey.StringSlice(ids))
+	wallets, err := r.repository.FindWalletsByIDs(ctx, ids)
+	endRepositorySpan(span, err)
+
+	return wallets, err
+}
+
+// FindTransactionsByWalletIDs finds transactions of wallets by wallet ids
+func (r *TracingRepository) FindTransactionsByWalletIDs(ctx context.Context, walletIDs []string) ([]Transaction, error) {
+	ctx, span := r.start(ctx, "FindTransactionsByWalletIDs", walletIDKey.StringSlice(walletIDs))
+	transactions, err := r.repository.FindTransactionsByWalletIDs(ctx, walletIDs)
+	span.SetAttributes(transactionsKey.Int(len(transactions)))
+	endRepositorySpan(span, err)
+
+	return transactions, err
+}
+
+// FindTransactionsByIDs finds transactions by ids
+func (r *TracingRepository) FindTransactionsByIDs(ctx context.Context, ids []string) ([]Transaction, error) {
+	ctx, span := r.start(ctx, "FindTransactionsByIDs", transactionIDsKey.StringSlice(ids))
+	transactions, err := r.repository.FindTransactionsByIDs(ctx, ids)
+	span.SetAttributes(transactionsKey.Int(len(transactions)))
+	endRepositorySpan(span, err)
+
+	return transactions, err
+}
+
+// FindTransactionsByReferences finds transactions of every wallet by their references, references aren't
+// recorded since they are given by callers
+func (r *TracingRepository) FindTransactionsByReferences(ctx context.Context, references []string) ([]Transaction, error) {
+	ctx, span := r.start(ctx, "FindTransactionsByReferences", attribute.Int("wallet.transaction.references", len(references)))
+	transactions, err := r.repository.FindTransactionsByReferences(ctx, references)
+	span.SetAttributes(transactionsKey.Int(len(transactions)))
+	endRepositorySpan(span, err)
+
+	return transactions, err
+}
+
+// FindTransactionsBetween finds transactions created in [from, to) in ascending creation order
+func (r *TracingRepository) FindTransactionsBetween(ctx context.Context, from, to time.Time) ([]Transaction, error) {
+	ctx, span := r.start(ctx, "FindTransactionsBetween",
+		attribute.String("wallet.transactions.from", from.Format(time.RFC3339)),
+		attribute.String("wallet.transactions.to", to.Format(time.RFC3339)))
+	transactions, err := r.repository.FindTransactionsBetween(ctx, from, to)
+	span.SetAttributes(transactionsKey.Int(len(transactions)))
+	endRepositorySpan(span, err)
+
+	return transactions, err
+}
+
+// InsertTransactions inserts transactions to collection
+func (r *TracingRepository) InsertTransactions(ctx context.Context, transactions ...Transaction) error {
+	attrs := []attribute.KeyValue{transactionsKey.Int(len(transactions))}
+	if len(transactions) > 0 {
+		attrs = append(attrs, walletIDKey.String(transactions[0].WalletID), transactionTypeKey.String(string(transactions[0].Type)))
+	}
+
+	ctx, span := r.start(ctx, "InsertTransactions", attrs...)
+	err := r.repository.InsertTransactions(ctx, transactions...)
+	endRepositorySpan(span, err)
+
+	return err
+}
+
+// IterateTransactionsByWalletID calls fn for every transaction of wallet created before `before` in ascending
+// creation order until fn returns error
+func (r *TracingRepository) IterateTransactionsByWalletID(ctx context.Context, walletID string, before time.Time, fn func(t *Transaction) error) error {
+	ctx, span := r.start(ctx, "IterateTransactionsByWalletID", walletIDKey.String(walletID))
+	iterated := 0
+	err := r.repository.IterateTransactionsByWalletID(ctx, walletID, before, func(t *Transaction) error {
+		iterated++
+		return fn(t)
+	})
+	span.SetAttributes(transactionsKey.Int(iterated))
+	endRepositorySpan(span, err)
+
+	return err
+}
+
+// IterateWallets calls fn for every wallet in ascending creation order until fn returns error
+func (r *TracingRepository) IterateWallets(ctx context.Context, fn func(w *Wallet) error) error {
+	ctx, span := r.start(ctx, "IterateWallets")
+	err := r.repository.IterateWallets(ctx, fn)
+	endRepositorySpan(span, err)
+
+	return err
+}
+
+// UpdateWalletStatus updates status and update time of wallet
+func (r *TracingRepository) UpdateWalletStatus(ctx context.Context, id string, status WalletStatus) error {
+	ctx, span := r.start(ctx, "UpdateWalletStatus", walletIDKey.String(id), walletStatusKey.String(string(status)))
+	err := r.repository.UpdateWalletStatus(ctx, id, status)
+	endRepositorySpan(span, err)
+
+	return err
+}
+
+// WithinTransaction runs fn as a unit of work, spans of operations of fn are children of its span
+func (r *TracingRepository) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, span := r.start(ctx, "WithinTransaction")
+	err := r.repository.WithinTransaction(ctx, fn)
+	endRepositorySpan(span, err)
+
+	return err
+}
+
+// Ping checks the database is reachable, it isn't traced since readiness probes would flood traces with
+// root spans
+func (r *TracingRepository) Ping(ctx context.Context) error {
+	return r.repository.Ping(ctx)
+}
+
+// NewTracingEventStore creates new instance of TracingEventStore
+func NewTracingEventStore(store eventstore.Store, provider trace.TracerProvider) *TracingEventStore {
+	return &TracingEventStore{store: store, tracer: provider.Tracer(tracerName)}
+}
+
+func (s *TracingEventStore) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, semconv.DBSystemMongoDB, semconv.DBOperation(operation))
+	return s.tracer.Start(ctx, "wallet.EventStore/"+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// Append appends events to stream if it is at expectedVersion
+func (s *TracingEventStore) Append(ctx context.Context, stream string, expectedVersion int64, events ...eventstore.Event) ([]eventstore.Event, error) {
+	ctx, span := s.start(ctx, "Append", streamKey.String(stream), eventsKey.Int(len(events)))
+	appended, err := s.store.Append(ctx, stream, expectedVersion, events...)
+	endRepositorySpan(span, err)
+
+	return appended, err
+}
+
+// ReadStream reads at most limit events of stream starting from version in direction
+func (s *TracingEventStore) ReadStream(ctx context.Context, stream string, from int64, direction eventstore.Direction, limit int) ([]eventstore.Event, error) {
+	ctx, span := s.start(ctx, "ReadStream", streamKey.String(stream))
+	events, err := s.store.ReadStream(ctx, stream, from, direction, limit)
+	span.SetAttributes(eventsKey.Int(len(events)))
+	endRepositorySpan(span, err)
+
+	return events, err
+}
+
+// ReadAll reads at most limit events of every stream after position
+func (s *TracingEventStore) ReadAll(ctx context.Context, after int64, limit int) ([]eventstore.Event, error) {
+	ctx, span := s.start(ctx, "ReadAll", positionKey.Int64(after))
+	events, err := s.store.ReadAll(ctx, after, limit)
+	span.SetAttributes(eventsKey.Int(len(events)))
+	endRepositorySpan(span, err)
+
+	return events, err
+}
+
+// LastPosition returns position of the last event ReadAll reads
+func (s *TracingEventStore) LastPosition(ctx context.Context) (int64, error) {
+	ctx, span := s.start(ctx, "LastPosition")
+	position, err := s.store.LastPosition(ctx)
+	span.SetAttributes(positionKey.Int64(position))
+	endRepositorySpan(span, err)
+
+	return position, err
+}
@@ -1,14 +1,20 @@
 package wallet
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
 
 type Money struct {
 	Amount float32 `json:"amount" bson:"amount"`
 }
 
-// NewMoney creates new instance of money
+// NewMoney creates new instance of money, amount must be in whole minor units so the balance and the ledger don't
+// drift apart by rounding
 func NewMoney(amount float32) (*Money, error) {
-	if amount < 0 {
+	if amount < 0 || !(Money{Amount: amount}).inWholeMinorUnits() {
 		return nil, errors.New(ErrInvalidMoneyAmount)
 	}
 
@@ -25,6 +31,63 @@ func (m Money) Sub(from *Money) {
 	from.Amount -= m.Amount
 }
 
+// MinorUnits returns amount in minor units of DefaultCurrency, e.g. kuruş, rounded to the nearest unit
+func (m Money) MinorUnits() int64 {
+	return int64(math.Round(float64(m.Amount) * 100))
+}
+
+// inWholeMinorUnits reports whether amount has no fraction of a minor unit, e.g. 0.01 but not 0.015
+func (m Money) inWholeMinorUnits() bool {
+	return float32(float64(m.MinorUnits())/100) == m.Amount
+}
+
+// Limits of transaction details
+const (
+	MaxReferenceLength     = 128
+	MaxDescriptionLength   = 512
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+)
+
+// TransactionDetails are optional details given by the caller of a transaction, e.g. reference of the payment
+// provider or merchant info as metadata
+type TransactionDetails struct {
+	Reference   string            `json:"reference,omitempty" bson:"reference,omitempty"`
+	Description string            `json:"description,omitempty" bson:"description,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+}
+
+// NewTransactionDetails creates new instance of TransactionDetails, reference and description are trimmed
+func NewTransactionDetails(reference, description string, metadata map[string]string) (*TransactionDetails, error) {
+	d := &TransactionDetails{
+		Reference:   strings.TrimSpace(reference),
+		Description: strings.TrimSpace(description),
+	}
+	if len(d.Reference) > MaxReferenceLength {
+		return nil, fmt.Errorf(ErrInvalidReference, MaxReferenceLength)
+	}
+	if len(d.Description) > MaxDescriptionLength {
+		return nil, fmt.Errorf(ErrInvalidDescription, MaxDescriptionLength)
+	}
+	if len(metadata) > MaxMetadataKeys {
+		return nil, fmt.Errorf(ErrTooManyMetadataKeys, MaxMetadataKeys)
+	}
+	for k, v := range metadata {
+		if strings.TrimSpace(k) == "" || len(k) > MaxMetadataKeyLength || strings.ContainsAny(k, ".$") {
+			return nil, fmt.Errorf(ErrInvalidMetadataKey, k, MaxMetadataKeyLength)
+		}
+		if len(v) > MaxMetadataValueLength {
+			return nil, fmt.Errorf(ErrInvalidMetadataValue, k, MaxMetadataValueLength)
+		}
+	}
+	if len(metadata) > 0 {
+		d.Metadata = metadata
+	}
+
+	return d, nil
+}
+
 type ID struct {
 	Id string `json:"id"`
 }
@@ -32,3 +95,6 @@ type ID struct {
 func NewID(id string) *ID {
 	return &ID{id}
 }
+
+// DefaultCurrency is the currency of wallet balances
+const DefaultCurrency = "TRY"
@@ -0,0 +1,160 @@
+package wallet
+
+import (
+	"context"
+	"github.com/ybalcin/wallet-service/pkg/eventstore"
+	"github.com/ybalcin/wallet-service/pkg/projection"
+	"time"
+)
+
+// WalletViewProjection is the name of the projection of wallet views
+const WalletViewProjection = "wallet_views"
+
+type (
+	// WalletView is the denormalised read model of a wallet with its balance, it is eventually consistent with
+	// the event stream of the wallet which is authoritative
+	WalletView struct {
+		ID        string       `bson:"_id"`
+		Username  string       `bson:"username"`
+		Status    WalletStatus `bson:"status"`
+		CreatedAt time.Time    `bson:"created_at"`
+		// UpdatedAt is the update time of the projected wallet
+		UpdatedAt time.Time `bson:"updated_at"`
+		// Balance is in minor units
+		Balance      int64 `bson:"balance"`
+		Transactions int64 `bson:"transactions"`
+		// Position is the position of the last projected event of the wallet in the event store
+		Position int64 `bson:"position"`
+	}
+
+	// ViewChange is the change of projected events to a wallet view
+	ViewChange struct {
+		WalletID string
+		// Username and CreatedAt are set if the events create the wallet, Status and UpdatedAt if they change them
+		Username  string
+		CreatedAt time.Time
+		Status    WalletStatus
+		UpdatedAt time.Time
+		// Balance is in minor units
+		Balance      int64
+		Transactions int64
+		// From is the position of the view the change is applied to and To is the position of its last event
+		From int64
+		To   int64
+	}
+
+	// ViewProjection maintains wallet views from events of wallet streams in the order of their positions in the
+	// event store. Positions become readable in ascending order, so no event is skipped
+	ViewProjection struct {
+		events     eventstore.Store
+		repository ViewRepository
+		now        func() time.Time
+	}
+)
+
+// NewViewProjection creates new instance of ViewProjection
+func NewViewProjection(events eventstore.Store, repository ViewRepository) *ViewProjection {
+	return &ViewProjection{events: events, repository: repository, now: time.Now}
+}
+
+// Name is the name of the projection
+func (p *ViewProjection) Name() string {
+	return WalletViewProjection
+}
+
+// Step projects events after position of checkpoint. Events are applied to views once by their positions, so a
+// step that is repeated doesn't change views again
+func (p *ViewProjection) Step(ctx context.Context, checkpoint *projection.Checkpoint, limit int) (projection.Progress, error) {
+	events, err := p.events.ReadAll(ctx, checkpoint.Position, limit)
+	if err != nil {
+		return projection.Progress{}, err
+	}
+	if len(events) == 0 {
+		return projection.Progress{CaughtUp: true}, nil
+	}
+
+	changes, err := p.changesOf(ctx, events)
+	if err != nil {
+		return projection.Progress{}, err
+	}
+	if err = p.repository.ApplyViewChanges(ctx, changes); err != nil {
+		return projection.Progress{}, err
+	}
+
+	last := events[len(events)-1]
+	checkpoint.Position = last.Position
+	progress := projection.Progress{Projected: len(events), CaughtUp: len(events) < limit}
+	if !progress.CaughtUp {
+		progress.Lag = p.now().Sub(last.RecordedAt)
+	}
+
+	return progress, nil
+}
+
+// changesOf returns changes of events to views of their wallets, events at or before the positions of views are
+// already projected and skipped. Events of other streams are skipped too
+func (p *ViewProjection) changesOf(ctx context.Context, events []eventstore.Event) ([]ViewChange, error) {
+	var ids []string
+	seen := map[string]bool{}
+	for _, e := range events {
+		if id, ok := walletIDOf(e.Stream); ok && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	views, err := p.repository.FindViewsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	changes := make(map[string]*ViewChange, len(ids))
+	for _, id := range ids {
+		changes[id] = &ViewChange{WalletID: id}
+	}
+	for _, v := range views {
+		changes[v.ID].From = v.Position
+	}
+
+	for _, e := range events {
+		id, ok := walletIDOf(e.Stream)
+		if !ok || e.Position <= changes[id].From {
+			continue
+		}
+		payload, err := Events.Decode(e)
+		if err != nil {
+			return nil, err
+		}
+
+		c := changes[id]
+		switch event := payload.(type) {
+		case WalletCreated:
+			c.Username, c.CreatedAt, c.Status, c.UpdatedAt = event.Username, event.CreatedAt, ActiveWalletStatus, event.CreatedAt
+		case MoneyDeposited:
+			c.Balance += event.Transaction.BalanceChange()
+			c.Transactions++
+		case MoneyWithdrawn:
+			c.Balance += event.Transaction.BalanceChange()
+			c.Transactions++
+		case WalletFrozen:
+			c.Status, c.UpdatedAt = FrozenWalletStatus, event.FrozenAt
+		}
+		c.To = e.Position
+	}
+
+	result := make([]ViewChange, 0, len(ids))
+	for _, id := range ids {
+		if c := changes[id]; c.To != 0 {
+			result = append(result, *c)
+		}
+	}
+
+	return result, nil
+}
+
+// Reset deletes every wallet view
+func (p *ViewProjection) Reset(ctx context.Context) error {
+	return p.repository.DeleteViews(ctx)
+}
@@ -0,0 +1,185 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+)
+
+//go:generate mockgen -source=view_repository.go -destination=./test/view_repository_mock.go -package=wallet
+
+const (
+	walletViewsCollection = "wallet_views"
+
+	duplicateKeyCode = 11000
+)
+
+type (
+	// ViewRepository stores wallet views
+	ViewRepository interface {
+		// FindViewsByIDs finds wallet views by ids
+		FindViewsByIDs(ctx context.Context, ids []string) ([]*WalletView, error)
+		// ApplyViewChanges applies changes to views that are still at their From positions
+		ApplyViewChanges(ctx context.Context, changes []ViewChange) error
+		// DeleteViews deletes every wallet view
+		DeleteViews(ctx context.Context) error
+
+		// FindViews finds wallet views matching query in its order, at most its limit
+		FindViews(ctx context.Context, query *WalletQuery) ([]*WalletView, error)
+		// CountViews counts wallet views matching filters of query, its cursor and limit are ignored
+		CountViews(ctx context.Context, query *WalletQuery) (int64, error)
+	}
+
+	// MongoViewRepository is a concrete implementation of ViewRepository interface
+	MongoViewRepository struct {
+		views *mongo.Collection
+	}
+)
+
+// NewMongoViewRepository creates instance of MongoViewRepository
+func NewMongoViewRepository(db *mongo.Database) *MongoViewRepository {
+	return &MongoViewRepository{views: db.Collection(walletViewsCollection)}
+}
+
+// FindViewsByIDs finds wallet views by ids
+func (r *MongoViewRepository) FindViewsByIDs(ctx context.Context, ids []string) ([]*WalletView, error) {
+	cursor, err := r.views.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	var views []*WalletView
+	if err = cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+
+	return views, nil
+}
+
+// ApplyViewChanges applies changes to views that are still at their From positions, views are created if
+// wallets of changes have none. Changes of views that are at other positions are already applied and ignored
+func (r *MongoViewRepository) ApplyViewChanges(ctx context.Context, changes []ViewChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	updates := make([]mongo.WriteModel, len(changes))
+	for i, c := range changes {
+		set := bson.M{"position": c.To}
+		if !c.CreatedAt.IsZero() {
+			set["username"], set["created_at"] = c.Username, c.CreatedAt
+		}
+		if c.Status != "" {
+			set["status"] = c.Status
+		}
+		if !c.UpdatedAt.IsZero() {
+			set["updated_at"] = c.UpdatedAt
+		}
+		updates[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": c.WalletID, "position": c.From}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"balance": c.Balance, "transactions": c.Transactions},
+				"$set": set,
+			}).
+			SetUpsert(true)
+	}
+
+	_, err := r.views.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false))
+	return ignoreDuplicates(err)
+}
+
+// ignoreDuplicates ignores err if every write error of it is a duplicate key error, upserts of conditional
+// updates fail with them if the document exists without matching the condition
+func ignoreDuplicates(err error) error {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return err
+	}
+	for _, e := range bulkErr.WriteErrors {
+		if e.Code != duplicateKeyCode {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteViews deletes every wallet view, indexes of views are kept
+func (r *MongoViewRepository) DeleteViews(ctx context.Context) error {
+	_, err := r.views.DeleteMany(ctx, bson.M{})
+	return err
+}
+
+// FindViews finds wallet views matching query in its order, at most its limit. Pages continue after the view of
+// the cursor by the sorted field and id so that views with equal values aren't skipped
+func (r *MongoViewRepository) FindViews(ctx context.Context, query *WalletQuery) ([]*WalletView, error) {
+	field, order := query.SortField, 1
+	if query.Descending {
+		order = -1
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(query.Limit)
+
+	filters := viewFiltersOf(query)
+	if query.After != nil {
+		op := "$gt"
+		if query.Descending {
+			op = "$lt"
+		}
+		value := query.After.value(field)
+		filters = append(filters, bson.M{"$or": bson.A{
+			bson.M{field: bson.M{op: value}},
+			bson.M{field: value, "_id": bson.M{op: query.After.ID}},
+		}})
+	}
+
+	cursor, err := r.views.Find(ctx, andOf(filters), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var views []*WalletView
+	if err = cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+
+	return views, nil
+}
+
+// CountViews counts wallet views matching filters of query, its cursor and limit are ignored
+func (r *MongoViewRepository) CountViews(ctx context.Context, query *WalletQuery) (int64, error) {
+	return r.views.CountDocuments(ctx, andOf(viewFiltersOf(query)))
+}
+
+// viewFiltersOf returns filters of query, views whose wallet creations aren't projected aren't listed
+func viewFiltersOf(query *WalletQuery) bson.A {
+	filters := bson.A{bson.M{"created_at": bson.M{"$exists": true}}}
+	if query.UsernamePrefix != "" {
+		filters = append(filters, bson.M{"username": bson.M{"$regex": "^" + regexp.QuoteMeta(query.UsernamePrefix)}})
+	}
+	if query.Status != "" {
+		filters = append(filters, bson.M{"status": query.Status})
+	}
+	if !query.CreatedFrom.IsZero() {
+		filters = append(filters, bson.M{"created_at": bson.M{"$gte": query.CreatedFrom}})
+	}
+	if !query.CreatedTo.IsZero() {
+		filters = append(filters, bson.M{"created_at": bson.M{"$lt": query.CreatedTo}})
+	}
+	if query.MinBalance != nil {
+		filters = append(filters, bson.M{"balance": bson.M{"$gte": *query.MinBalance}})
+	}
+	if query.MaxBalance != nil {
+		filters = append(filters, bson.M{"balance": bson.M{"$lte": *query.MaxBalance}})
+	}
+
+	return filters
+}
+
+func andOf(filters bson.A) bson.M {
+	return bson.M{"$and": filters}
+}
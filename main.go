@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/ybalcin/wallet-service/cmd"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,11 +18,15 @@ func main() {
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		s := <-shutdown
-		fmt.Printf("%s signal received, server is shutting down...\n", s.String())
+		slog.Info("signal received, shutting down", "signal", s.String())
 		cancel()
 	}()
 
-	if err := cmd.RunApi(ctx); err != nil {
-		panic(err)
+	if err := cmd.Execute(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
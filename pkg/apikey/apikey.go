@@ -0,0 +1,164 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/response"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+)
+
+const (
+	Header = "X-API-Key"
+
+	ErrInvalidAPIKey    = "api key is invalid"
+	ErrAPIKeyRequired   = "api key is required"
+	ErrAdminKeyRequired = "api key is not allowed to use admin endpoints"
+)
+
+type (
+	// Key is an api key of a client, Name identifies the client in place of the key itself
+	Key struct {
+		Name  string
+		Value string
+		Admin bool
+	}
+
+	// Keys authenticates clients against a configured set of keys
+	Keys struct {
+		keys   []Key
+		hashes [][sha256.Size]byte
+	}
+
+	keyKey struct{}
+)
+
+// New creates new instance of Keys that authenticates keys
+func New(keys ...Key) *Keys {
+	k := &Keys{keys: keys}
+	for _, key := range keys {
+		k.hashes = append(k.hashes, sha256.Sum256([]byte(key.Value)))
+	}
+
+	return k
+}
+
+// Authenticate returns the key whose value is value, every key is compared in constant time so that the time taken
+// doesn't tell how much of a key matched
+func (k *Keys) Authenticate(value string) (Key, bool) {
+	if value == "" {
+		return Key{}, false
+	}
+
+	var (
+		found Key
+		ok    bool
+	)
+	hash := sha256.Sum256([]byte(value))
+	for i := range k.keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hashes[i][:]) == 1 {
+			found, ok = k.keys[i], true
+		}
+	}
+
+	return found, ok
+}
+
+// WithKey returns copy of ctx that carries authenticated key
+func WithKey(ctx context.Context, key Key) context.Context {
+	return context.WithValue(ctx, keyKey{}, key)
+}
+
+// FromContext returns key authenticated for ctx, false if the client sent no key
+func FromContext(ctx context.Context) (Key, bool) {
+	key, ok := ctx.Value(keyKey{}).(Key)
+	return key, ok
+}
+
+// Middleware returns fiber.Handler that authenticates X-API-Key header and attaches the key to context of handlers,
+// it responds 401 to invalid keys and lets requests without a key through
+func Middleware(k *Keys) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		value := c.Get(Header)
+		if value == "" {
+			return c.Next()
+		}
+
+		key, ok := k.Authenticate(value)
+		if !ok {
+			return response.New(c).Error(errr.ThrowUnauthorizedError(errors.New(ErrInvalidAPIKey))).JSON()
+		}
+		c.SetUserContext(WithKey(c.UserContext(), key))
+
+		return c.Next()
+	}
+}
+
+// RequireAdmin returns fiber.Handler that responds 401 to requests without a key and 403 to requests of keys that
+// aren't admin, it must be used after Middleware
+func RequireAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, ok := FromContext(c.UserContext())
+		if !ok {
+			return response.New(c).Error(errr.ThrowUnauthorizedError(errors.New(ErrAPIKeyRequired))).JSON()
+		}
+		if !key.Admin {
+			return response.New(c).Error(errr.ThrowForbiddenError(errors.New(ErrAdminKeyRequired))).JSON()
+		}
+
+		return c.Next()
+	}
+}
+
+// UnaryServerInterceptor returns grpc.UnaryServerInterceptor that authenticates x-api-key metadata like Middleware
+func UnaryServerInterceptor(k *Keys) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := k.authenticateIncoming(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming equivalent of UnaryServerInterceptor
+func StreamServerInterceptor(k *Keys) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := k.authenticateIncoming(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (k *Keys) authenticateIncoming(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(strings.ToLower(Header))
+	if len(values) == 0 || values[0] == "" {
+		return ctx, nil
+	}
+
+	key, ok := k.Authenticate(values[0])
+	if !ok {
+		return ctx, errr.ThrowUnauthorizedError(errors.New(ErrInvalidAPIKey))
+	}
+
+	return WithKey(ctx, key), nil
+}
@@ -0,0 +1,71 @@
+package apikey
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http/httptest"
+	"testing"
+)
+
+var keys = New(Key{Name: "payroll", Value: "payroll-key"}, Key{Name: "ops", Value: "ops-key", Admin: true})
+
+func TestKeys_Authenticate(t *testing.T) {
+	key, ok := keys.Authenticate("ops-key")
+	assert.True(t, ok)
+	assert.Equal(t, Key{Name: "ops", Value: "ops-key", Admin: true}, key)
+
+	_, ok = keys.Authenticate("ops")
+	assert.False(t, ok)
+	_, ok = New().Authenticate("")
+	assert.False(t, ok, "an empty key must not match anything")
+}
+
+func TestMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware(keys))
+	app.Get("/", func(c *fiber.Ctx) error {
+		key, _ := FromContext(c.UserContext())
+		return c.SendString(key.Name)
+	})
+	app.Get("/admin", RequireAdmin(), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	req := func(path, key string) int {
+		r := httptest.NewRequest(fiber.MethodGet, path, nil)
+		if key != "" {
+			r.Header.Set(Header, key)
+		}
+		res, err := app.Test(r)
+		assert.Nil(t, err)
+		return res.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, req("/", ""))
+	assert.Equal(t, fiber.StatusOK, req("/", "payroll-key"))
+	assert.Equal(t, fiber.StatusUnauthorized, req("/", "unknown"))
+
+	assert.Equal(t, fiber.StatusUnauthorized, req("/admin", ""))
+	assert.Equal(t, fiber.StatusForbidden, req("/admin", "payroll-key"))
+	assert.Equal(t, fiber.StatusOK, req("/admin", "ops-key"))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	intercept := func(key string) (any, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", key))
+		return UnaryServerInterceptor(keys)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+			key, _ := FromContext(ctx)
+			return key.Name, nil
+		})
+	}
+
+	name, err := intercept("payroll-key")
+	assert.Nil(t, err)
+	assert.Equal(t, "payroll", name)
+
+	_, err = intercept("unknown")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
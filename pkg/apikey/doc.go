@@ -0,0 +1,3 @@
+// Package apikey provides authentication of clients by api keys and its middlewares to use with fiber framework
+// and grpc
+package apikey
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// Cache is a key value cache, implementations must be safe for concurrent use. Values must not be modified
+	// after they are set or got
+	Cache interface {
+		// Get gets value of key, ok is false if key isn't cached or is expired
+		Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+		// Set caches value of key
+		Set(ctx context.Context, key string, value []byte) error
+		// Delete deletes key, deleting a key that isn't cached is not an error
+		Delete(ctx context.Context, key string) error
+	}
+
+	// LRU is an in-process Cache that evicts the least recently used key over its size and keys older than its ttl
+	LRU struct {
+		mu    sync.Mutex
+		size  int
+		ttl   time.Duration
+		items map[string]*list.Element
+		// order has the most recently used entry at front
+		order *list.List
+		now   func() time.Time
+	}
+
+	entry struct {
+		key       string
+		value     []byte
+		expiresAt time.Time
+	}
+)
+
+// NewLRU creates new instance of LRU that keeps at most size keys for ttl, zero ttl never expires keys
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:  size,
+		ttl:   ttl,
+		items: map[string]*list.Element{},
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+// Get gets value of key, expired keys are deleted on get
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*entry)
+	if !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+
+	return e.value, true, nil
+}
+
+// Set caches value of key and evicts the least recently used key if size is exceeded
+func (c *LRU) Set(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = c.now().Add(c.ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+
+	return nil
+}
+
+// Delete deletes key
+func (c *LRU) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	return nil
+}
+
+// Len returns number of cached keys including expired ones that aren't deleted yet
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
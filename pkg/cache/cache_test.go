@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should evict the least recently used key", func(t *testing.T) {
+		c := NewLRU(2, 0)
+		assert.Nil(t, c.Set(ctx, "a", []byte("1")))
+		assert.Nil(t, c.Set(ctx, "b", []byte("2")))
+		_, ok, _ := c.Get(ctx, "a")
+		assert.True(t, ok)
+
+		assert.Nil(t, c.Set(ctx, "c", []byte("3")))
+		_, ok, _ = c.Get(ctx, "b")
+		assert.False(t, ok, "b is the least recently used key")
+		v, ok, _ := c.Get(ctx, "a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), v)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("should replace value of existing key", func(t *testing.T) {
+		c := NewLRU(2, 0)
+		assert.Nil(t, c.Set(ctx, "a", []byte("1")))
+		assert.Nil(t, c.Set(ctx, "a", []byte("2")))
+
+		v, _, _ := c.Get(ctx, "a")
+		assert.Equal(t, []byte("2"), v)
+		assert.Equal(t, 1, c.Len())
+	})
+
+	t.Run("should expire keys after ttl", func(t *testing.T) {
+		now := time.Now()
+		c := NewLRU(2, time.Minute)
+		c.now = func() time.Time { return now }
+		assert.Nil(t, c.Set(ctx, "a", []byte("1")))
+
+		now = now.Add(time.Minute - time.Second)
+		_, ok, _ := c.Get(ctx, "a")
+		assert.True(t, ok)
+
+		now = now.Add(time.Second)
+		_, ok, _ = c.Get(ctx, "a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len(), "expired key must be deleted")
+	})
+
+	t.Run("should delete key", func(t *testing.T) {
+		c := NewLRU(2, 0)
+		assert.Nil(t, c.Set(ctx, "a", []byte("1")))
+		assert.Nil(t, c.Delete(ctx, "a"))
+		assert.Nil(t, c.Delete(ctx, "missing"))
+
+		_, ok, _ := c.Get(ctx, "a")
+		assert.False(t, ok)
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		c := NewLRU(10, time.Minute)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					key := strconv.Itoa((i + j) % 20)
+					_ = c.Set(ctx, key, []byte(key))
+					_, _, _ = c.Get(ctx, key)
+					_ = c.Delete(ctx, strconv.Itoa(j%20))
+				}
+			}(i)
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, c.Len(), 10)
+	})
+}
@@ -0,0 +1,2 @@
+// Package cache provides a Cache interface that external caches implement and an in-process LRU cache with TTL
+package cache
@@ -0,0 +1,2 @@
+// Package dataloader provides a Loader that batches loads of keys into one fetch per batch
+package dataloader
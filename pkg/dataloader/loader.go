@@ -0,0 +1,94 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// BatchFunc fetches values of keys, keys missing in the result are loaded as zero value
+	BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+	// Thunk returns the loaded value, the first call of a thunk dispatches the pending batch
+	Thunk[V any] func() (V, error)
+
+	// Loader batches loads of keys requested before any of their thunks is called into one BatchFunc call
+	// and caches loaded values, a Loader is meant to live as long as a single request
+	Loader[K comparable, V any] struct {
+		sync.Mutex
+		batch   BatchFunc[K, V]
+		pending []K
+		results map[K]*result[V]
+	}
+
+	result[V any] struct {
+		value V
+		err   error
+		done  bool
+	}
+)
+
+// New creates new instance of Loader
+func New[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		batch:   batch,
+		results: map[K]*result[V]{},
+	}
+}
+
+// Load adds key to the pending batch unless it is already loaded or pending
+func (l *Loader[K, V]) Load(ctx context.Context, key K) Thunk[V] {
+	l.Lock()
+	defer l.Unlock()
+
+	r, ok := l.results[key]
+	if !ok {
+		r = &result[V]{}
+		l.results[key] = r
+		l.pending = append(l.pending, key)
+	}
+
+	return func() (V, error) {
+		l.Lock()
+		defer l.Unlock()
+
+		if !r.done {
+			l.dispatch(ctx)
+		}
+
+		return r.value, r.err
+	}
+}
+
+// LoadMany loads keys and returns a thunk of values in the order of keys
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) Thunk[[]V] {
+	thunks := make([]Thunk[V], len(keys))
+	for i, key := range keys {
+		thunks[i] = l.Load(ctx, key)
+	}
+
+	return func() ([]V, error) {
+		values := make([]V, len(thunks))
+		for i, thunk := range thunks {
+			v, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+
+		return values, nil
+	}
+}
+
+// dispatch fetches pending keys, it must be called with lock held
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+
+	values, err := l.batch(ctx, keys)
+	for _, key := range keys {
+		r := l.results[key]
+		r.value, r.err, r.done = values[key], err, true
+	}
+}
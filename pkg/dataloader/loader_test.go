@@ -0,0 +1,64 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should batch pending keys into one call", func(t *testing.T) {
+		var calls [][]int
+		loader := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+			calls = append(calls, keys)
+			values := map[int]string{}
+			for _, k := range keys {
+				if k != 3 {
+					values[k] = string(rune('a' + k))
+				}
+			}
+			return values, nil
+		})
+
+		first := loader.Load(ctx, 0)
+		second := loader.Load(ctx, 1)
+		duplicate := loader.Load(ctx, 1)
+		missing := loader.Load(ctx, 3)
+
+		v, err := second()
+		assert.Nil(t, err)
+		assert.Equal(t, "b", v)
+		v, _ = first()
+		assert.Equal(t, "a", v)
+		v, _ = duplicate()
+		assert.Equal(t, "b", v)
+		v, _ = missing()
+		assert.Equal(t, "", v)
+		assert.Equal(t, [][]int{{0, 1, 3}}, calls)
+
+		t.Run("should serve loaded keys from cache", func(t *testing.T) {
+			many, err := loader.LoadMany(ctx, []int{1, 2})()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"b", "c"}, many)
+			assert.Equal(t, [][]int{{0, 1, 3}, {2}}, calls)
+		})
+	})
+
+	t.Run("should return batch error to every key", func(t *testing.T) {
+		e := errors.New("failed")
+		loader := New(func(ctx context.Context, keys []string) (map[string]int, error) {
+			return nil, e
+		})
+
+		first := loader.Load(ctx, "a")
+		second := loader.Load(ctx, "b")
+
+		_, err := first()
+		assert.Equal(t, e, err)
+		_, err = second()
+		assert.Equal(t, e, err)
+	})
+}
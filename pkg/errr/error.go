@@ -3,11 +3,16 @@ package errr
 type Error struct {
 	Message string `json:"message"`
 	Code    int    `json:"-"`
+
+	// cause is the error Error is created by, it is unwrapped by errors.Is and errors.As
+	cause error
+	// aborted marks conflicts of concurrent changes that can be retried, unlike conflicts with existing resources
+	aborted bool
 }
 
 // New creates new instance of Error
 func New(err error, code int) *Error {
-	e := &Error{Code: code}
+	e := &Error{Code: code, cause: err}
 	if err != nil {
 		e.Message = err.Error()
 	}
@@ -19,3 +24,8 @@ func New(err error, code int) *Error {
 func (e *Error) Error() string {
 	return e.Message
 }
+
+// Unwrap returns the error Error is created by
+func (e *Error) Unwrap() error {
+	return e.cause
+}
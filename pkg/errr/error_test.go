@@ -0,0 +1,16 @@
+package errr
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestError_Unwrap(t *testing.T) {
+	cause := errors.New("write conflict")
+	err := error(ThrowInternalServerError(cause))
+
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "write conflict", err.Error())
+	assert.Nil(t, New(nil, 500).Unwrap())
+}
@@ -0,0 +1,39 @@
+package errr
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+)
+
+// GRPCCode returns grpc status code equivalent of Error code
+func (e *Error) GRPCCode() codes.Code {
+	switch e.Code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		if e.aborted {
+			return codes.Aborted
+		}
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusInternalServerError:
+		return codes.Internal
+	}
+
+	return codes.Unknown
+}
+
+// GRPCStatus returns grpc status of Error, it makes Error usable as error of grpc handlers
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(e.GRPCCode(), e.Message)
+}
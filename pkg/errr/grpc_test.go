@@ -0,0 +1,35 @@
+package errr
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestError_GRPCStatus(t *testing.T) {
+	cases := []struct {
+		err      *Error
+		expected codes.Code
+	}{
+		{ThrowBadRequestError(errors.New("bad")), codes.InvalidArgument},
+		{ThrowUnauthorizedError(errors.New("unauthorized")), codes.Unauthenticated},
+		{ThrowForbiddenError(errors.New("forbidden")), codes.PermissionDenied},
+		{ThrowNotFoundError(errors.New("not found")), codes.NotFound},
+		{ThrowConflictError(errors.New("exists")), codes.AlreadyExists},
+		{ThrowAbortedError(errors.New("concurrent")), codes.Aborted},
+		{ThrowTooManyRequestsError(errors.New("slow down")), codes.ResourceExhausted},
+		{ThrowInternalServerError(nil), codes.Internal},
+		{New(errors.New("teapot"), 418), codes.Unknown},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.err.Message, func(t *testing.T) {
+			s, ok := status.FromError(tt.err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, s.Code())
+			assert.Equal(t, tt.err.Message, s.Message())
+		})
+	}
+}
@@ -35,3 +35,22 @@ func ThrowNotFoundError(e error) *Error {
 func ThrowUnauthorizedError(e error) *Error {
 	return New(e, http.StatusUnauthorized)
 }
+
+// ThrowConflictError throws Error with code http.StatusConflict
+func ThrowConflictError(e error) *Error {
+	return New(e, http.StatusConflict)
+}
+
+// ThrowAbortedError throws Error with code http.StatusConflict for a change aborted by a concurrent one, it can be
+// retried
+func ThrowAbortedError(e error) *Error {
+	err := New(e, http.StatusConflict)
+	err.aborted = true
+
+	return err
+}
+
+// ThrowTooManyRequestsError throws Error with code http.StatusTooManyRequests
+func ThrowTooManyRequestsError(e error) *Error {
+	return New(e, http.StatusTooManyRequests)
+}
@@ -0,0 +1,4 @@
+// Package eventstore provides an append-only store of event streams with optimistic concurrency and a global
+// order of events, a registry of event types that upcasts payloads of older schema versions, and mongo and
+// in-memory stores
+package eventstore
@@ -0,0 +1,105 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// ErrVersionConflict is returned by Append if the stream isn't at the expected version
+var ErrVersionConflict = errors.New("stream is not at the expected version")
+
+// Expected versions of Append besides versions of streams
+const (
+	// AnyVersion appends whatever version the stream is at
+	AnyVersion int64 = -1
+	// NoStream appends only if the stream has no events
+	NoStream int64 = 0
+)
+
+// Direction is the order streams are read in
+type Direction int
+
+const (
+	// Forward reads events in ascending version order
+	Forward Direction = iota
+	// Backward reads events in descending version order
+	Backward
+)
+
+type (
+	// Event is an event of a stream. Version is its version in the stream starting from 1 and is set by Append.
+	// Position is its position among events of every stream, a store may set it after Append, see MongoStore
+	Event struct {
+		ID       string `bson:"_id" json:"id"`
+		Stream   string `bson:"stream" json:"stream"`
+		Version  int64  `bson:"version" json:"version"`
+		Position int64  `bson:"position,omitempty" json:"position"`
+		Type     string `bson:"type" json:"type"`
+		// SchemaVersion is the version of the schema of payloads of Type that Data is written in
+		SchemaVersion int               `bson:"schema_version" json:"schema_version"`
+		Data          bson.Raw          `bson:"data" json:"-"`
+		Metadata      map[string]string `bson:"metadata,omitempty" json:"metadata,omitempty"`
+		RecordedAt    time.Time         `bson:"recorded_at" json:"recorded_at"`
+	}
+
+	// Store is an append-only store of event streams
+	Store interface {
+		// Append appends events to stream if it is at expectedVersion and returns them with their versions and
+		// record times. ErrVersionConflict is returned if stream is at another version
+		Append(ctx context.Context, stream string, expectedVersion int64, events ...Event) ([]Event, error)
+		// ReadStream reads at most limit events of stream starting from version in direction, from 0 starts at
+		// the first event forward and at the last one backward
+		ReadStream(ctx context.Context, stream string, from int64, direction Direction, limit int) ([]Event, error)
+		// ReadAll reads at most limit events of every stream after position in ascending position order. Positions
+		// become readable in ascending order, so a reader never misses an event behind a position it has passed
+		ReadAll(ctx context.Context, after int64, limit int) ([]Event, error)
+		// LastPosition returns position of the last event ReadAll reads, 0 if there is none
+		LastPosition(ctx context.Context) (int64, error)
+	}
+)
+
+// ReadStreamForward reads every event of stream starting from version from in ascending version order, pageSize
+// events at a time
+func ReadStreamForward(ctx context.Context, s Store, stream string, from int64, pageSize int) ([]Event, error) {
+	var events []Event
+	for {
+		page, err := s.ReadStream(ctx, stream, from, Forward, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if len(page) < pageSize {
+			return events, nil
+		}
+		from = page[len(page)-1].Version + 1
+	}
+}
+
+// Subscribe calls fn for every event after position in ascending position order and then for events appended
+// later, until ctx is done or fn fails. Events are read batchSize at a time and new ones are polled every
+// interval
+func Subscribe(ctx context.Context, s Store, after int64, batchSize int, interval time.Duration, fn func(e Event) error) error {
+	for {
+		events, err := s.ReadAll(ctx, after, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err = fn(e); err != nil {
+				return err
+			}
+			after = e.Position
+		}
+		if len(events) == batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+type (
+	created struct {
+		Name string `bson:"name"`
+	}
+
+	renamed struct {
+		First string `bson:"first"`
+		Last  string `bson:"last"`
+	}
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	appended, err := s.Append(ctx, "a", NoStream, Event{ID: "a1", Type: "t"}, Event{ID: "a2", Type: "t"})
+	assert.Nil(t, err)
+	assert.Len(t, appended, 2)
+	assert.Equal(t, "a", appended[1].Stream)
+	assert.Equal(t, int64(2), appended[1].Version)
+	assert.Equal(t, int64(2), appended[1].Position)
+	assert.False(t, appended[1].RecordedAt.IsZero())
+
+	_, err = s.Append(ctx, "b", NoStream, Event{Type: "t"})
+	assert.Nil(t, err)
+	_, err = s.Append(ctx, "a", 2, Event{ID: "a3", Type: "t"})
+	assert.Nil(t, err)
+
+	t.Run("should reject appends at another version", func(t *testing.T) {
+		_, err := s.Append(ctx, "a", 2, Event{Type: "t"})
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		_, err = s.Append(ctx, "b", NoStream, Event{Type: "t"})
+		assert.ErrorIs(t, err, ErrVersionConflict)
+	})
+
+	t.Run("should reject appended event ids", func(t *testing.T) {
+		_, err := s.Append(ctx, "c", AnyVersion, Event{ID: "a1", Type: "t"})
+		assert.ErrorIs(t, err, ErrVersionConflict)
+	})
+
+	t.Run("should read streams in both directions", func(t *testing.T) {
+		events, err := s.ReadStream(ctx, "a", 2, Forward, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a2", "a3"}, ids(events))
+
+		events, err = s.ReadStream(ctx, "a", 0, Backward, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a3", "a2"}, ids(events))
+
+		events, err = s.ReadStream(ctx, "a", 1, Backward, 10)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a1"}, ids(events))
+
+		events, err = ReadStreamForward(ctx, s, "a", 1, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a1", "a2", "a3"}, ids(events))
+	})
+
+	t.Run("should read every event by position", func(t *testing.T) {
+		events, err := s.ReadAll(ctx, 1, 2)
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int64(2), events[0].Position)
+		assert.Equal(t, "b", events[1].Stream)
+
+		events, err = s.ReadAll(ctx, 4, 10)
+		assert.Nil(t, err)
+		assert.Empty(t, events)
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	s := NewMemoryStore()
+	_, err := s.Append(context.Background(), "a", NoStream, Event{Type: "t"}, Event{Type: "t"}, Event{Type: "t"})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var positions []int64
+	stop := errors.New("stop")
+	err = Subscribe(ctx, s, 1, 1, time.Millisecond, func(e Event) error {
+		positions = append(positions, e.Position)
+		if e.Position == 2 {
+			_, err := s.Append(ctx, "b", NoStream, Event{Type: "t"})
+			assert.Nil(t, err)
+		}
+		if e.Position == 4 {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, []int64{2, 3, 4}, positions)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register("created", 1, created{})
+	r.Register("renamed", 2, renamed{})
+	r.Upcast("renamed", 1, func(data bson.M) (bson.M, error) {
+		return bson.M{"first": data["name"], "last": ""}, nil
+	})
+
+	t.Run("should encode and decode payloads", func(t *testing.T) {
+		e, err := r.Encode(renamed{First: "john", Last: "doe"})
+		assert.Nil(t, err)
+		assert.Equal(t, "renamed", e.Type)
+		assert.Equal(t, 2, e.SchemaVersion)
+
+		payload, err := r.Decode(e)
+		assert.Nil(t, err)
+		assert.Equal(t, renamed{First: "john", Last: "doe"}, payload)
+	})
+
+	t.Run("should upcast payloads of older versions", func(t *testing.T) {
+		data, _ := bson.Marshal(bson.M{"name": "john"})
+		payload, err := r.Decode(Event{Type: "renamed", SchemaVersion: 1, Data: data})
+		assert.Nil(t, err)
+		assert.Equal(t, renamed{First: "john"}, payload)
+	})
+
+	t.Run("should reject unknown types and newer versions", func(t *testing.T) {
+		_, err := r.Encode(struct{}{})
+		assert.Error(t, err)
+		_, err = r.Decode(Event{Type: "deleted", SchemaVersion: 1})
+		assert.Error(t, err)
+		_, err = r.Decode(Event{Type: "created", SchemaVersion: 2})
+		assert.Error(t, err)
+	})
+
+	t.Run("should panic on duplicate registrations", func(t *testing.T) {
+		assert.Panics(t, func() { r.Register("created", 1, renamed{}) })
+		assert.Panics(t, func() { r.Upcast("created", 1, nil) })
+	})
+}
+
+func ids(events []Event) []string {
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
@@ -0,0 +1,102 @@
+package eventstore
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore is a Store that keeps events in process, it serves tests and tools that don't need durability
+type MemoryStore struct {
+	mu      sync.RWMutex
+	events  []Event
+	streams map[string][]Event
+	ids     map[string]bool
+}
+
+// NewMemoryStore creates new instance of MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{streams: map[string][]Event{}, ids: map[string]bool{}}
+}
+
+// Append appends events to stream if it is at expectedVersion and returns them with their versions, positions
+// and record times. ErrVersionConflict is returned if stream is at another version or an event id is appended
+func (s *MemoryStore) Append(_ context.Context, stream string, expectedVersion int64, events ...Event) ([]Event, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version := int64(len(s.streams[stream]))
+	if expectedVersion != AnyVersion && version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+	for _, e := range events {
+		if s.ids[e.ID] {
+			return nil, ErrVersionConflict
+		}
+	}
+
+	appended := stamp(stream, version, int64(len(s.events))+1, events)
+	for _, e := range appended {
+		s.ids[e.ID] = true
+	}
+	s.events = append(s.events, appended...)
+	s.streams[stream] = append(s.streams[stream], appended...)
+
+	return appended, nil
+}
+
+// ReadStream reads at most limit events of stream starting from version in direction, from 0 starts at the
+// first event forward and at the last one backward
+func (s *MemoryStore) ReadStream(_ context.Context, stream string, from int64, direction Direction, limit int) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := s.streams[stream]
+	read := []Event{}
+	if direction == Backward {
+		last := int64(len(events))
+		if from > 0 && from < last {
+			last = from
+		}
+		for v := last; v >= 1 && len(read) < limit; v-- {
+			read = append(read, events[v-1])
+		}
+		return read, nil
+	}
+
+	if from < 1 {
+		from = 1
+	}
+	for v := from; v <= int64(len(events)) && len(read) < limit; v++ {
+		read = append(read, events[v-1])
+	}
+
+	return read, nil
+}
+
+// ReadAll reads at most limit events of every stream after position in ascending position order
+func (s *MemoryStore) ReadAll(_ context.Context, after int64, limit int) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	read := []Event{}
+	if after < 0 {
+		after = 0
+	}
+	for p := after; p < int64(len(s.events)) && len(read) < limit; p++ {
+		read = append(read, s.events[p])
+	}
+
+	return read, nil
+}
+
+// LastPosition returns position of the last event, 0 if there is none
+func (s *MemoryStore) LastPosition(context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return int64(len(s.events)), nil
+}
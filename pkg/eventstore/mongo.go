@@ -0,0 +1,174 @@
+package eventstore
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const eventsCollection = "events"
+
+// Indexes keep versions of streams and positions of events unique and serve reading streams, every event in order
+// and the pending events of Sequencer
+var Indexes = []migration.Index{
+	{Collection: eventsCollection, Keys: bson.D{{Key: "stream", Value: 1}, {Key: "version", Value: 1}}, Unique: true},
+	{Collection: eventsCollection, Keys: bson.D{{Key: "position", Value: 1}}, Unique: true, Sparse: true},
+	{
+		Collection:    eventsCollection,
+		Keys:          bson.D{{Key: "recorded_at", Value: 1}, {Key: "stream", Value: 1}, {Key: "version", Value: 1}},
+		PartialFilter: bson.M{"pending": true},
+	},
+}
+
+// CounterIndexes are the indexes of stores whose appends allocated positions from a counter, every event had a
+// position then. The migration that created them is applied, so they are kept as they were; their position index
+// is replaced by the one of Indexes
+var CounterIndexes = []migration.Index{
+	{Collection: eventsCollection, Keys: bson.D{{Key: "stream", Value: 1}, {Key: "version", Value: 1}}, Unique: true},
+	{Collection: eventsCollection, Keys: bson.D{{Key: "position", Value: 1}}, Unique: true},
+}
+
+// MongoStore is a Store that keeps events in events collection. Appended events are pending until Sequencer
+// stamps their positions after their appends commit, so appends to different streams in mongo transactions don't
+// conflict on a shared counter. Pending events are read by their streams but not by ReadAll
+type MongoStore struct {
+	events *mongo.Collection
+}
+
+// pendingEvent is the document of an appended event that has no position yet
+type pendingEvent struct {
+	Event   `bson:",inline"`
+	Pending bool `bson:"pending"`
+}
+
+// NewMongoStore creates new instance of MongoStore
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{events: db.Collection(eventsCollection)}
+}
+
+// Append appends events to stream if it is at expectedVersion and returns them with their versions and record
+// times, positions are stamped later by Sequencer. Events without id get one. ErrVersionConflict is returned if
+// stream is at another version or a concurrent append wins, including one of an event with the same id
+func (s *MongoStore) Append(ctx context.Context, stream string, expectedVersion int64, events ...Event) ([]Event, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	version, err := s.version(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != AnyVersion && version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+
+	appended := stamp(stream, version, 0, events)
+	documents := make([]interface{}, len(appended))
+	for i, e := range appended {
+		documents[i] = pendingEvent{Event: e, Pending: true}
+	}
+	if _, err = s.events.InsertMany(ctx, documents); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrVersionConflict
+		}
+		return nil, err
+	}
+
+	return appended, nil
+}
+
+// version returns version of the last event of stream, 0 if it has none
+func (s *MongoStore) version(ctx context.Context, stream string) (int64, error) {
+	last := new(Event)
+	err := s.events.FindOne(ctx, bson.M{"stream": stream},
+		options.FindOne().SetSort(bson.M{"version": -1}).SetProjection(bson.M{"version": 1})).Decode(last)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return last.Version, nil
+}
+
+// ReadStream reads at most limit events of stream starting from version in direction, from 0 starts at the
+// first event forward and at the last one backward
+func (s *MongoStore) ReadStream(ctx context.Context, stream string, from int64, direction Direction, limit int) ([]Event, error) {
+	filter, order := bson.M{"stream": stream}, 1
+	if direction == Backward {
+		order = -1
+		if from > 0 {
+			filter["version"] = bson.M{"$lte": from}
+		}
+	} else if from > 0 {
+		filter["version"] = bson.M{"$gte": from}
+	}
+
+	return s.find(ctx, filter, options.Find().SetSort(bson.M{"version": order}).SetLimit(int64(limit)))
+}
+
+// ReadAll reads at most limit events of every stream after position in ascending position order, pending events
+// aren't read until they are stamped
+func (s *MongoStore) ReadAll(ctx context.Context, after int64, limit int) ([]Event, error) {
+	if after < 0 {
+		after = 0
+	}
+
+	return s.find(ctx, bson.M{"position": bson.M{"$gt": after}},
+		options.Find().SetSort(bson.M{"position": 1}).SetLimit(int64(limit)))
+}
+
+// LastPosition returns the last stamped position, 0 if none is stamped
+func (s *MongoStore) LastPosition(ctx context.Context) (int64, error) {
+	last := new(Event)
+	err := s.events.FindOne(ctx, bson.M{"position": bson.M{"$gt": 0}},
+		options.FindOne().SetSort(bson.M{"position": -1}).SetProjection(bson.M{"position": 1})).Decode(last)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return last.Position, nil
+}
+
+func (s *MongoStore) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]Event, error) {
+	cursor, err := s.events.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []Event{}
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// stamp returns copies of events appended to stream at version with positions starting from position, 0 leaves
+// them without positions
+func stamp(stream string, version, position int64, events []Event) []Event {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	stamped := make([]Event, len(events))
+	for i, e := range events {
+		if e.ID == "" {
+			e.ID = uuid.NewString()
+		}
+		e.Stream = stream
+		e.Version = version + int64(i) + 1
+		if position > 0 {
+			e.Position = position + int64(i)
+		}
+		e.RecordedAt = now
+		stamped[i] = e
+	}
+
+	return stamped
+}
@@ -0,0 +1,120 @@
+package eventstore
+
+import (
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"reflect"
+)
+
+type (
+	// Upcaster converts payload of an event type from a schema version to the next one
+	Upcaster func(data bson.M) (bson.M, error)
+
+	// Registry maps payload types to event types and their schema versions. Payloads are encoded at the
+	// registered version and decoded from older versions by upcasting them one version at a time
+	Registry struct {
+		types map[string]*eventType
+		names map[reflect.Type]string
+	}
+
+	eventType struct {
+		version int
+		payload reflect.Type
+		// upcasters are upcasters by the version they convert from
+		upcasters map[int]Upcaster
+	}
+)
+
+// NewRegistry creates new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{types: map[string]*eventType{}, names: map[reflect.Type]string{}}
+}
+
+// Register registers type of payload as event type name at schema version, it panics if name or type of
+// payload is already registered
+func (r *Registry) Register(name string, version int, payload any) {
+	t := reflect.TypeOf(payload)
+	if _, ok := r.types[name]; ok {
+		panic(fmt.Sprintf("event type %s is already registered", name))
+	}
+	if _, ok := r.names[t]; ok {
+		panic(fmt.Sprintf("payload %s is already registered", t))
+	}
+	if version < 1 {
+		panic(fmt.Sprintf("schema version of event type %s must be positive", name))
+	}
+
+	r.types[name] = &eventType{version: version, payload: t, upcasters: map[int]Upcaster{}}
+	r.names[t] = name
+}
+
+// Upcast registers upcaster of event type name from schema version from to the next one, it panics if name
+// isn't registered or from isn't older than its version
+func (r *Registry) Upcast(name string, from int, upcaster Upcaster) {
+	t, ok := r.types[name]
+	if !ok {
+		panic(fmt.Sprintf("event type %s is not registered", name))
+	}
+	if from < 1 || from >= t.version {
+		panic(fmt.Sprintf("event type %s can't be upcast from version %d", name, from))
+	}
+
+	t.upcasters[from] = upcaster
+}
+
+// Encode returns event of payload with its type and the registered schema version, id and metadata of the
+// event are left to callers
+func (r *Registry) Encode(payload any) (Event, error) {
+	name, ok := r.names[reflect.TypeOf(payload)]
+	if !ok {
+		return Event{}, fmt.Errorf("payload %T is not registered", payload)
+	}
+
+	data, err := bson.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("payload of event type %s can't be encoded: %w", name, err)
+	}
+
+	return Event{Type: name, SchemaVersion: r.types[name].version, Data: data}, nil
+}
+
+// Decode returns payload of e in the registered schema version of its type, payloads of older versions are
+// upcast and payloads of newer versions are rejected
+func (r *Registry) Decode(e Event) (any, error) {
+	t, ok := r.types[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("event type %s of event %s is not registered", e.Type, e.ID)
+	}
+	if e.SchemaVersion > t.version {
+		return nil, fmt.Errorf("event %s is at version %d of %s which is newer than %d", e.ID, e.SchemaVersion, e.Type, t.version)
+	}
+
+	data := e.Data
+	if e.SchemaVersion < t.version {
+		var doc bson.M
+		if err := bson.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("event %s can't be decoded: %w", e.ID, err)
+		}
+		for v := e.SchemaVersion; v < t.version; v++ {
+			upcaster, ok := t.upcasters[v]
+			if !ok {
+				return nil, fmt.Errorf("event type %s has no upcaster from version %d", e.Type, v)
+			}
+			var err error
+			if doc, err = upcaster(doc); err != nil {
+				return nil, fmt.Errorf("event %s can't be upcast from version %d: %w", e.ID, v, err)
+			}
+		}
+		var err error
+		if data, err = bson.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("event %s can't be decoded: %w", e.ID, err)
+		}
+	}
+
+	payload := reflect.New(t.payload)
+	if err := bson.Unmarshal(data, payload.Interface()); err != nil {
+		return nil, fmt.Errorf("event %s can't be decoded: %w", e.ID, err)
+	}
+
+	return payload.Elem().Interface(), nil
+}
@@ -0,0 +1,216 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const (
+	sequencerCollection = "event_sequencer"
+
+	// sequencerLeaseID is the id of the document that holds the lease of the running sequencer
+	sequencerLeaseID = "sequencer"
+)
+
+// Sequencer stamps positions of events appended to a MongoStore once their appends are committed, in the order
+// it finds them. Positions are stamped one at a time by a single sequencer, which holds a lease in event_sequencer
+// collection, so positions become readable in ascending order whatever order the appends commit in. Events of a
+// stream are stamped in version order
+type Sequencer struct {
+	events    *mongo.Collection
+	leases    *mongo.Collection
+	id        string
+	lease     time.Duration
+	batchSize int
+	log       *slog.Logger
+}
+
+// NewSequencer creates new instance of Sequencer that holds the lease for lease and stamps at most batchSize events
+// per step
+func NewSequencer(db *mongo.Database, lease time.Duration, batchSize int, log *slog.Logger) *Sequencer {
+	return &Sequencer{
+		events:    db.Collection(eventsCollection),
+		leases:    db.Collection(sequencerCollection),
+		id:        uuid.NewString(),
+		lease:     lease,
+		batchSize: batchSize,
+		log:       log,
+	}
+}
+
+// Run steps sequencer until ctx is done, it waits interval after stamping every pending event or failing
+func (q *Sequencer) Run(ctx context.Context, interval time.Duration) {
+	for {
+		stamped, err := q.Step(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			q.log.Error("events could not be sequenced", "error", err)
+		}
+		if err == nil && stamped >= q.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Step stamps positions of at most batchSize pending events after the last stamped position and returns how many
+// it stamped, none are stamped while another sequencer holds the lease. The lease is renewed while stamping once
+// half of it is over, the step stops if it is taken over meanwhile
+func (q *Sequencer) Step(ctx context.Context) (int, error) {
+	claimed := time.Now()
+	if ok, err := q.claim(ctx); err != nil || !ok {
+		return 0, err
+	}
+
+	cursor, err := q.events.Find(ctx, bson.M{"pending": true}, options.Find().
+		SetSort(bson.D{{Key: "recorded_at", Value: 1}, {Key: "stream", Value: 1}, {Key: "version", Value: 1}}).
+		SetLimit(int64(q.batchSize)))
+	if err != nil {
+		return 0, err
+	}
+	var pending []Event
+	if err = cursor.All(ctx, &pending); err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	earlier, err := q.earlier(ctx, pending)
+	if err != nil {
+		return 0, err
+	}
+	position, err := (&MongoStore{events: q.events}).LastPosition(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	stamped := 0
+	for _, e := range order(append(earlier, pending...)) {
+		if time.Since(claimed) > q.lease/2 {
+			claimed = time.Now()
+			ok, err := q.claim(ctx)
+			if err != nil {
+				return stamped, err
+			}
+			if !ok {
+				return stamped, fmt.Errorf("lease is taken over by another sequencer after %d events", stamped)
+			}
+		}
+		position++
+		res, err := q.events.UpdateOne(ctx, bson.M{"_id": e.ID, "pending": true},
+			bson.M{"$set": bson.M{"position": position}, "$unset": bson.M{"pending": ""}})
+		if err != nil {
+			return stamped, err
+		}
+		if res.ModifiedCount == 0 {
+			return stamped, fmt.Errorf("event %s is stamped by another sequencer", e.ID)
+		}
+		stamped++
+	}
+
+	return stamped, nil
+}
+
+// claim takes or renews the lease, false is returned if another sequencer holds it
+func (q *Sequencer) claim(ctx context.Context) (bool, error) {
+	now := time.Now()
+	filter := bson.M{"_id": sequencerLeaseID, "$or": bson.A{
+		bson.M{"owner": q.id},
+		bson.M{"lease_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{"$set": bson.M{"owner": q.id, "lease_until": now.Add(q.lease)}}
+	if _, err := q.leases.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// earlier returns pending events of streams of pending with versions before theirs, they are found later than
+// events of their streams if clocks of appending instances disagree
+func (q *Sequencer) earlier(ctx context.Context, pending []Event) ([]Event, error) {
+	first := map[string]int64{}
+	for _, e := range pending {
+		if v, ok := first[e.Stream]; !ok || e.Version < v {
+			first[e.Stream] = e.Version
+		}
+	}
+
+	before := bson.A{}
+	for stream, version := range first {
+		if version > 1 {
+			before = append(before, bson.M{"stream": stream, "version": bson.M{"$lt": version}})
+		}
+	}
+	if len(before) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := q.events.Find(ctx, bson.M{"pending": true, "$or": before})
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// order returns pending events in the order their positions are stamped in, which is the order they are given in
+// except that events of a stream are put in version order. Events after a version missing from pending are left
+// for a later step
+func order(pending []Event) []Event {
+	slots := map[string][]int{}
+	for i, e := range pending {
+		slots[e.Stream] = append(slots[e.Stream], i)
+	}
+
+	ordered := make([]Event, len(pending))
+	for _, indexes := range slots {
+		events := make([]Event, len(indexes))
+		for i, index := range indexes {
+			events[i] = pending[index]
+		}
+		sort.Slice(events, func(i, j int) bool { return events[i].Version < events[j].Version })
+		for i, index := range indexes {
+			ordered[index] = events[i]
+		}
+	}
+
+	blocked := map[string]bool{}
+	last := map[string]int64{}
+	stamped := make([]Event, 0, len(ordered))
+	for _, e := range ordered {
+		if blocked[e.Stream] {
+			continue
+		}
+		if v, ok := last[e.Stream]; ok && e.Version != v+1 {
+			blocked[e.Stream] = true
+			continue
+		}
+		last[e.Stream] = e.Version
+		stamped = append(stamped, e)
+	}
+
+	return stamped
+}
@@ -0,0 +1,138 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/ybalcin/wallet-service/pkg/migration"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOrder(t *testing.T) {
+	event := func(stream string, version int64) Event {
+		return Event{ID: fmt.Sprintf("%s%d", stream, version), Stream: stream, Version: version}
+	}
+
+	t.Run("should keep versions of a stream in order", func(t *testing.T) {
+		ordered := order([]Event{event("a", 2), event("b", 1), event("a", 1), event("a", 3)})
+		assert.Equal(t, []string{"a1", "b1", "a2", "a3"}, ids(ordered))
+	})
+
+	t.Run("should leave events after a missing version for a later step", func(t *testing.T) {
+		ordered := order([]Event{event("a", 1), event("b", 4), event("a", 3), event("b", 5)})
+		assert.Equal(t, []string{"a1", "b4", "b5"}, ids(ordered))
+	})
+}
+
+// TestMongoStore_ConcurrentAppends appends to two streams in parallel mongo transactions, it needs a replica set
+// at MONGO_TEST_URI and is skipped without one
+func TestMongoStore_ConcurrentAppends(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	assert.Nil(t, err)
+	defer client.Disconnect(ctx)
+	db := client.Database("eventstore_test_" + uuid.NewString()[:8])
+	defer db.Drop(ctx)
+	assert.Nil(t, migration.CreateIndexes(db, Indexes...)(ctx))
+
+	store := NewMongoStore(db)
+	const appends = 50
+	errs := make(chan error, 2*appends)
+	var wg sync.WaitGroup
+	for _, stream := range []string{"wallet-a", "wallet-b"} {
+		wg.Add(1)
+		go func(stream string) {
+			defer wg.Done()
+			for version := int64(0); version < appends; version++ {
+				// transactions aren't retried, a conflict of the two streams fails the test
+				errs <- appendInTransaction(ctx, client, store, stream, version)
+			}
+		}(stream)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+
+	events, err := store.ReadAll(ctx, 0, 1000)
+	assert.Nil(t, err)
+	assert.Empty(t, events, "pending events must not be read before they are stamped")
+
+	sequencer := NewSequencer(db, time.Minute, 30, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for {
+		stamped, err := sequencer.Step(ctx)
+		assert.Nil(t, err)
+		if stamped == 0 {
+			break
+		}
+	}
+
+	events, err = store.ReadAll(ctx, 0, 1000)
+	assert.Nil(t, err)
+	assert.Len(t, events, 2*appends)
+	versions := map[string]int64{}
+	for i, e := range events {
+		assert.Equal(t, int64(i+1), e.Position)
+		assert.Equal(t, versions[e.Stream]+1, e.Version, "events of a stream must be stamped in version order")
+		versions[e.Stream] = e.Version
+	}
+
+	t.Run("should not stamp while another sequencer holds the lease", func(t *testing.T) {
+		assert.Nil(t, appendInTransaction(ctx, client, store, "wallet-a", appends))
+
+		other := NewSequencer(db, time.Minute, 30, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		stamped, err := other.Step(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, stamped)
+
+		stamped, err = sequencer.Step(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, stamped)
+	})
+
+	t.Run("should renew the lease while stamping", func(t *testing.T) {
+		for version := int64(appends + 1); version < appends+4; version++ {
+			assert.Nil(t, appendInTransaction(ctx, client, store, "wallet-a", version))
+		}
+
+		// every event is stamped after half of the lease is over
+		sequencer.lease = time.Nanosecond
+		defer func() { sequencer.lease = time.Minute }()
+		stamped, err := sequencer.Step(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, stamped)
+	})
+}
+
+func appendInTransaction(ctx context.Context, client *mongo.Client, store *MongoStore, stream string, version int64) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	if err = session.StartTransaction(); err != nil {
+		return err
+	}
+	sc := mongo.NewSessionContext(ctx, session)
+	if _, err = store.Append(sc, stream, version, Event{Type: "t"}); err != nil {
+		_ = session.AbortTransaction(ctx)
+		return err
+	}
+
+	return session.CommitTransaction(sc)
+}
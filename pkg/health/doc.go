@@ -0,0 +1,2 @@
+// Package health provides a registry of health checks and liveness and readiness handlers to use with fiber framework
+package health
@@ -0,0 +1,26 @@
+package health
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// LivenessHandler returns fiber.Handler that reports the process is alive, it doesn't run checks so that
+// unhealthy dependencies don't get the process restarted
+func LivenessHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(Report{Status: StatusUp})
+	}
+}
+
+// ReadinessHandler returns fiber.Handler that runs checks of registry and responds 503 with the report if
+// any of them fails
+func ReadinessHandler(r *Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := r.Check(c.UserContext())
+		if report.Status != StatusUp {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+
+		return c.JSON(report)
+	}
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	// ShutdownCheck is the name of the built-in check that fails once shutdown begins
+	ShutdownCheck = "shutdown"
+
+	defaultTimeout = 2 * time.Second
+)
+
+var errShuttingDown = errors.New("shutting down")
+
+type (
+	// CheckFunc checks a dependency, nil is healthy
+	CheckFunc func(ctx context.Context) error
+
+	// Registry is a registry of named health checks that decide readiness
+	Registry struct {
+		mu     sync.RWMutex
+		names  []string
+		checks map[string]CheckFunc
+
+		timeout      time.Duration
+		shuttingDown atomic.Bool
+	}
+
+	// Report is the result of running every check of Registry
+	Report struct {
+		Status string                 `json:"status"`
+		Checks map[string]CheckResult `json:"checks,omitempty"`
+	}
+
+	// CheckResult is the result of a check
+	CheckResult struct {
+		Status   string  `json:"status"`
+		Duration float64 `json:"duration_ms"`
+		Error    string  `json:"error,omitempty"`
+	}
+)
+
+// NewRegistry creates new instance of Registry with the built-in shutdown check, every check is cancelled
+// after timeout, zero timeout is 2 seconds
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	r := &Registry{checks: map[string]CheckFunc{}, timeout: timeout}
+	r.Register(ShutdownCheck, func(context.Context) error {
+		if r.shuttingDown.Load() {
+			return errShuttingDown
+		}
+		return nil
+	})
+
+	return r
+}
+
+// Register registers check by name, check registered with the same name is replaced
+func (r *Registry) Register(name string, check CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.checks[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.checks[name] = check
+}
+
+// Shutdown makes the registry not ready, it is called as soon as graceful shutdown begins so that no new
+// traffic is routed to the instance
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check runs every check concurrently and reports up if all of them pass
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	names := append([]string{}, r.names...)
+	checks := make([]CheckFunc, len(names))
+	for i, name := range names {
+		checks[i] = r.checks[name]
+	}
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(names))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = r.run(ctx, checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(names))}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, check CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:   StatusUp,
+		Duration: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
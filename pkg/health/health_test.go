@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry(10 * time.Millisecond)
+	r.Register("mongo", func(context.Context) error { return nil })
+
+	report := r.Check(ctx)
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, StatusUp, report.Checks["mongo"].Status)
+	assert.Equal(t, StatusUp, report.Checks[ShutdownCheck].Status)
+
+	t.Run("should be down when a check fails", func(t *testing.T) {
+		r.Register("mongo", func(context.Context) error { return errors.New("no reachable servers") })
+
+		report := r.Check(ctx)
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Equal(t, "no reachable servers", report.Checks["mongo"].Error)
+		assert.Len(t, report.Checks, 2)
+	})
+
+	t.Run("should cancel checks after timeout", func(t *testing.T) {
+		r.Register("mongo", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		report := r.Check(ctx)
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["mongo"].Error)
+	})
+
+	t.Run("should be down after shutdown", func(t *testing.T) {
+		r := NewRegistry(0)
+		r.Shutdown()
+
+		report := r.Check(ctx)
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Equal(t, StatusDown, report.Checks[ShutdownCheck].Status)
+	})
+}
+
+func TestReadinessHandler(t *testing.T) {
+	r := NewRegistry(0)
+	r.Register("mongo", func(context.Context) error { return errors.New("down") })
+
+	app := fiber.New()
+	app.Get("/readyz", ReadinessHandler(r))
+
+	res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/readyz", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, res.StatusCode)
+
+	report := new(Report)
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(report))
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusDown, report.Checks["mongo"].Status)
+	assert.Equal(t, StatusUp, report.Checks[ShutdownCheck].Status)
+}
@@ -0,0 +1,3 @@
+// Package logger provides structured json logger with context attributes, sampling and redaction,
+// and request id middleware to use with fiber framework
+package logger
@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	RequestIDKey = "request_id"
+	TraceIDKey   = "trace_id"
+	SpanIDKey    = "span_id"
+
+	redacted = "[REDACTED]"
+)
+
+type (
+	Config struct {
+		// Level is one of debug, info, warn or error
+		Level string
+		// SampleRatio is the ratio of records below warn level to log, records of warn and error are always logged
+		SampleRatio float64
+		// RedactKeys are attribute keys whose values are replaced when Redact is true
+		RedactKeys []string
+		Redact     bool
+		// Secrets are values that are masked wherever they appear in records, e.g. in error messages
+		Secrets []string
+		// Levels overrides Level and SampleRatio when it is set, it is used to change them at runtime
+		Levels *Levels
+	}
+
+	// Levels are level and sample ratio of loggers, changes take effect on loggers created with them immediately
+	Levels struct {
+		level       slog.LevelVar
+		sampleRatio atomic.Uint64
+	}
+
+	// contextHandler is a slog.Handler that adds attributes stored in context, trace ids of span in context
+	// and drops sampled out records
+	contextHandler struct {
+		slog.Handler
+		levels *Levels
+	}
+
+	attrsKey struct{}
+)
+
+// New creates json logger writing to w by cfg
+func New(w io.Writer, cfg Config) *slog.Logger {
+	keys := map[string]bool{}
+	if cfg.Redact {
+		for _, k := range cfg.RedactKeys {
+			keys[strings.ToLower(strings.TrimSpace(k))] = true
+		}
+	}
+	var secrets []string
+	for _, s := range cfg.Secrets {
+		if s != "" {
+			secrets = append(secrets, s)
+		}
+	}
+
+	levels := cfg.Levels
+	if levels == nil {
+		levels = NewLevels(cfg.Level, cfg.SampleRatio)
+	}
+
+	opts := &slog.HandlerOptions{Level: &levels.level}
+	if len(keys) > 0 || len(secrets) > 0 {
+		opts.ReplaceAttr = func(_ []string, a slog.Attr) slog.Attr {
+			if keys[strings.ToLower(a.Key)] {
+				return slog.String(a.Key, redacted)
+			}
+			return maskSecrets(a, secrets)
+		}
+	}
+
+	return slog.New(&contextHandler{
+		Handler: slog.NewJSONHandler(w, opts),
+		levels:  levels,
+	})
+}
+
+// NewLevels creates new instance of Levels
+func NewLevels(level string, sampleRatio float64) *Levels {
+	l := new(Levels)
+	l.Set(level, sampleRatio)
+
+	return l
+}
+
+// Set sets level and sample ratio
+func (l *Levels) Set(level string, sampleRatio float64) {
+	l.level.Set(ParseLevel(level))
+	l.sampleRatio.Store(math.Float64bits(sampleRatio))
+}
+
+// Level returns the level
+func (l *Levels) Level() slog.Level {
+	return l.level.Level()
+}
+
+// SampleRatio returns the sample ratio
+func (l *Levels) SampleRatio() float64 {
+	return math.Float64frombits(l.sampleRatio.Load())
+}
+
+// maskSecrets replaces secrets in string values of a, other values are checked by their text
+func maskSecrets(a slog.Attr, secrets []string) slog.Attr {
+	kind := a.Value.Kind()
+	if len(secrets) == 0 || (kind != slog.KindString && kind != slog.KindAny) {
+		return a
+	}
+
+	text := a.Value.String()
+	masked := text
+	for _, s := range secrets {
+		masked = strings.ReplaceAll(masked, s, redacted)
+	}
+	if masked == text {
+		return a
+	}
+
+	return slog.String(a.Key, masked)
+}
+
+// ParseLevel parses level name, info level is returned for unknown names
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+
+	return l
+}
+
+// With returns copy of ctx that carries attrs, attrs are added to every record logged with the context
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append([]any{}, attrsFrom(ctx)...)
+	return context.WithValue(ctx, attrsKey{}, append(attrs, args...))
+}
+
+// RequestID returns request id attached to ctx by Middleware or interceptors, empty if there is none
+func RequestID(ctx context.Context) string {
+	attrs := attrsFrom(ctx)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if key, ok := attrs[i].(string); ok && key == RequestIDKey {
+			id, _ := attrs[i+1].(string)
+			return id
+		}
+	}
+
+	return ""
+}
+
+func attrsFrom(ctx context.Context) []any {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]any)
+
+	return attrs
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ratio := h.levels.SampleRatio(); r.Level < slog.LevelWarn && ratio < 1 && rand.Float64() >= ratio {
+		return nil
+	}
+
+	if attrs := attrsFrom(ctx); len(attrs) > 0 {
+		r.Add(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String(TraceIDKey, sc.TraceID().String()), slog.String(SpanIDKey, sc.SpanID().String()))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), levels: h.levels}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), levels: h.levels}
+}
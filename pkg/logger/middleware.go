@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"log/slog"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware returns fiber.Handler that propagates X-Request-ID header or generates one, adds it to context
+// of handlers and to response, and logs every request
+func Middleware(log *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := c.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Set(RequestIDHeader, requestID)
+
+		ctx := With(c.UserContext(), RequestIDKey, requestID)
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if e, ok := err.(*fiber.Error); ok {
+			status = e.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		log.LogAttrs(ctx, level, "http request",
+			slog.String("method", c.Method()),
+			slog.String("route", c.Route().Path),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+		)
+
+		return err
+	}
+}
+
+// UnaryServerInterceptor returns grpc.UnaryServerInterceptor that propagates x-request-id metadata or
+// generates one, adds it to context of handlers and logs every call
+func UnaryServerInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = withIncomingRequestID(ctx)
+
+		res, err := handler(ctx, req)
+		logCall(ctx, log, info.FullMethod, start, err)
+
+		return res, err
+	}
+}
+
+// StreamServerInterceptor is the streaming equivalent of UnaryServerInterceptor
+func StreamServerInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withIncomingRequestID(ss.Context())
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, log, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func withIncomingRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+
+	return With(ctx, RequestIDKey, requestID)
+}
+
+func logCall(ctx context.Context, log *slog.Logger, method string, start time.Time, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	log.LogAttrs(ctx, slog.LevelInfo, "grpc call", attrs...)
+}
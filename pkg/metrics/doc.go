@@ -0,0 +1,3 @@
+// Package metrics provides prometheus registry, http metrics middleware and metrics handler to use with fiber framework
+// and grpc server interceptors
+package metrics
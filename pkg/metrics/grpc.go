@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// GrpcMetrics records count and latency of grpc calls per method and status code
+type GrpcMetrics struct {
+	calls    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewGrpcMetrics creates new instance of GrpcMetrics and registers its collectors to reg
+func NewGrpcMetrics(reg prometheus.Registerer) *GrpcMetrics {
+	m := &GrpcMetrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_calls_total",
+			Help: "Total number of grpc calls by method and status code.",
+		}, []string{"method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_call_duration_seconds",
+			Help:    "Latency of grpc calls by method and status code, streams last until they end.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+	reg.MustRegister(m.calls, m.duration)
+
+	return m
+}
+
+// UnaryServerInterceptor returns grpc.UnaryServerInterceptor that records every call
+func (m *GrpcMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		m.observe(info.FullMethod, start, err)
+
+		return res, err
+	}
+}
+
+// StreamServerInterceptor is the streaming equivalent of UnaryServerInterceptor
+func (m *GrpcMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observe(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func (m *GrpcMetrics) observe(method string, start time.Time, err error) {
+	labels := prometheus.Labels{
+		"method": method,
+		"code":   status.Code(err).String(),
+	}
+	m.calls.With(labels).Inc()
+	m.duration.With(labels).Observe(time.Since(start).Seconds())
+}
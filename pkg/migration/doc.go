@@ -0,0 +1,2 @@
+// Package migration provides versioned schema migrations tracked in mongo and declarative mongo indexes
+package migration
@@ -0,0 +1,201 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotCurrent is returned by Verify if schema is not at the latest version
+var ErrNotCurrent = errors.New("schema is not current")
+
+type (
+	// Migration is a versioned schema change, Down reverts what Up does and is optional
+	Migration struct {
+		Version     int
+		Description string
+		Up          func(ctx context.Context) error
+		Down        func(ctx context.Context) error
+	}
+
+	// Record is a migration that is applied
+	Record struct {
+		Version     int       `bson:"_id" json:"version"`
+		Description string    `bson:"description" json:"description"`
+		AppliedAt   time.Time `bson:"applied_at" json:"applied_at"`
+	}
+
+	// Store stores records of applied migrations
+	Store interface {
+		// Applied returns records of applied migrations in ascending version order
+		Applied(ctx context.Context) ([]Record, error)
+		// Insert inserts record of migration after it is applied
+		Insert(ctx context.Context, r Record) error
+		// Delete deletes record of migration after it is reverted
+		Delete(ctx context.Context, version int) error
+	}
+
+	// Status is a known migration with the time it is applied at, Applied is false for pending migrations
+	Status struct {
+		Version     int       `json:"version"`
+		Description string    `json:"description"`
+		Applied     bool      `json:"applied"`
+		AppliedAt   time.Time `json:"applied_at,omitempty"`
+	}
+
+	// Migrator applies and reverts migrations in version order
+	Migrator struct {
+		store      Store
+		migrations []Migration
+	}
+)
+
+// New creates new instance of Migrator, migrations must be given in ascending version order
+func New(store Store, migrations ...Migration) (*Migrator, error) {
+	for i, m := range migrations {
+		if m.Version <= 0 || m.Up == nil {
+			return nil, fmt.Errorf("migration %d must have a positive version and up", m.Version)
+		}
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("migration %d must come after %d", m.Version, migrations[i-1].Version)
+		}
+	}
+
+	return &Migrator{store: store, migrations: migrations}, nil
+}
+
+// Latest returns version of the last migration
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies pending migrations up to target version in ascending order, every pending migration is applied
+// if target is 0. Applied migrations are returned even if a later one fails
+func (m *Migrator) Up(ctx context.Context, target int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err = migration.Up(ctx); err != nil {
+			return done, fmt.Errorf("migration %d up failed: %w", migration.Version, err)
+		}
+		if err = m.store.Insert(ctx, Record{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts applied migrations above target version in descending order, reverted migrations are returned
+// even if a later one fails
+func (m *Migrator) Down(ctx context.Context, target int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return done, fmt.Errorf("migration %d can't be reverted", migration.Version)
+		}
+
+		if err = migration.Down(ctx); err != nil {
+			return done, fmt.Errorf("migration %d down failed: %w", migration.Version, err)
+		}
+		if err = m.store.Delete(ctx, migration.Version); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Status returns status of every known migration
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		r, ok := applied[migration.Version]
+		statuses[i] = Status{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Applied:     ok,
+			AppliedAt:   r.AppliedAt,
+		}
+	}
+
+	return statuses, nil
+}
+
+// Verify returns error wrapping ErrNotCurrent if a migration is pending or an applied migration is unknown,
+// e.g. the database is migrated by a newer version of the service
+func (m *Migrator) Verify(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []int
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration.Version)
+		}
+		delete(applied, migration.Version)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w, pending migrations %v", ErrNotCurrent, pending)
+	}
+	for version := range applied {
+		return fmt.Errorf("%w, applied migration %d is unknown", ErrNotCurrent, version)
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]Record, error) {
+	records, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
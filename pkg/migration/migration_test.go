@@ -0,0 +1,130 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"sort"
+	"testing"
+	"time"
+)
+
+type memoryStore map[int]Record
+
+func (s memoryStore) Applied(context.Context) ([]Record, error) {
+	var records []Record
+	for _, r := range s {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Version < records[j].Version })
+
+	return records, nil
+}
+
+func (s memoryStore) Insert(_ context.Context, r Record) error {
+	s[r.Version] = r
+	return nil
+}
+
+func (s memoryStore) Delete(_ context.Context, version int) error {
+	delete(s, version)
+	return nil
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	store := memoryStore{}
+
+	var log []string
+	step := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			log = append(log, name)
+			return nil
+		}
+	}
+	m, err := New(store,
+		Migration{Version: 1, Description: "first", Up: step("up 1"), Down: step("down 1")},
+		Migration{Version: 2, Description: "second", Up: step("up 2"), Down: step("down 2")},
+		Migration{Version: 3, Description: "third", Up: step("up 3")},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, m.Latest())
+	assert.ErrorIs(t, m.Verify(ctx), ErrNotCurrent)
+
+	done, err := m.Up(ctx, 2)
+	assert.Nil(t, err)
+	assert.Len(t, done, 2)
+	assert.Equal(t, []string{"up 1", "up 2"}, log)
+	assert.ErrorContains(t, m.Verify(ctx), "pending migrations [3]")
+
+	done, err = m.Up(ctx, 0)
+	assert.Nil(t, err)
+	assert.Len(t, done, 1)
+	assert.Nil(t, m.Verify(ctx))
+
+	t.Run("should not apply migrations twice", func(t *testing.T) {
+		done, err := m.Up(ctx, 0)
+		assert.Nil(t, err)
+		assert.Empty(t, done)
+	})
+
+	t.Run("should report status of every migration", func(t *testing.T) {
+		statuses, err := m.Status(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, statuses, 3)
+		assert.True(t, statuses[2].Applied)
+		assert.WithinDuration(t, time.Now(), statuses[2].AppliedAt, time.Second)
+	})
+
+	t.Run("should not revert migrations without down", func(t *testing.T) {
+		_, err := m.Down(ctx, 1)
+		assert.EqualError(t, err, "migration 3 can't be reverted")
+	})
+
+	t.Run("should revert migrations above target in descending order", func(t *testing.T) {
+		delete(store, 3)
+		log = nil
+
+		done, err := m.Down(ctx, 0)
+		assert.Nil(t, err)
+		assert.Len(t, done, 2)
+		assert.Equal(t, []string{"down 2", "down 1"}, log)
+		assert.Empty(t, store)
+	})
+
+	t.Run("should stop at failed migration", func(t *testing.T) {
+		failing, err := New(store,
+			Migration{Version: 1, Up: step("up 1")},
+			Migration{Version: 2, Up: func(context.Context) error { return errors.New("boom") }},
+		)
+		assert.Nil(t, err)
+
+		done, err := failing.Up(ctx, 0)
+		assert.EqualError(t, err, "migration 2 up failed: boom")
+		assert.Len(t, done, 1)
+		assert.Len(t, store, 1)
+	})
+
+	t.Run("should report unknown applied migrations", func(t *testing.T) {
+		store[9] = Record{Version: 9}
+		old, err := New(store, Migration{Version: 1, Up: step("up 1")})
+		assert.Nil(t, err)
+		assert.ErrorContains(t, old.Verify(ctx), "applied migration 9 is unknown")
+	})
+}
+
+func TestNew(t *testing.T) {
+	up := func(context.Context) error { return nil }
+
+	_, err := New(memoryStore{}, Migration{Version: 2, Up: up}, Migration{Version: 1, Up: up})
+	assert.EqualError(t, err, "migration 1 must come after 2")
+
+	_, err = New(memoryStore{}, Migration{Version: 1})
+	assert.NotNil(t, err)
+}
+
+func TestIndex_Name(t *testing.T) {
+	i := Index{Keys: bson.D{{Key: "wallet_ids", Value: 1}, {Key: "sequence", Value: -1}}}
+	assert.Equal(t, "wallet_ids_1_sequence_-1", i.Name())
+}
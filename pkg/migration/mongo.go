@@ -0,0 +1,144 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strings"
+	"time"
+)
+
+const (
+	migrationsCollection = "schema_migrations"
+
+	// namespaceNotFoundCode and indexNotFoundCode are mongo error codes of dropping an index of a collection
+	// or an index that doesn't exist
+	namespaceNotFoundCode = 26
+	indexNotFoundCode     = 27
+)
+
+type (
+	// Index is a declarative index of a collection
+	Index struct {
+		Collection string
+		Keys       bson.D
+		Unique     bool
+		// Sparse skips documents without the indexed fields
+		Sparse bool
+		// PartialFilter indexes only documents that match it, nil indexes every document
+		PartialFilter bson.M
+		// ExpireAfter deletes documents the duration after the date of the indexed field, 0 never deletes
+		ExpireAfter time.Duration
+	}
+
+	// MongoStore is a Store that keeps records in schema_migrations collection
+	MongoStore struct {
+		records *mongo.Collection
+	}
+)
+
+// NewMongoStore creates new instance of MongoStore
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{records: db.Collection(migrationsCollection)}
+}
+
+// Applied returns records of applied migrations in ascending version order
+func (s *MongoStore) Applied(ctx context.Context) ([]Record, error) {
+	cursor, err := s.records.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Insert inserts record of migration after it is applied
+func (s *MongoStore) Insert(ctx context.Context, r Record) error {
+	_, err := s.records.InsertOne(ctx, r)
+	return err
+}
+
+// Delete deletes record of migration after it is reverted
+func (s *MongoStore) Delete(ctx context.Context, version int) error {
+	_, err := s.records.DeleteOne(ctx, bson.M{"_id": version})
+	return err
+}
+
+// Name returns the name mongo gives the index by default, e.g. wallet_id_1_created_at_1
+func (i Index) Name() string {
+	parts := make([]string, 0, len(i.Keys)*2)
+	for _, k := range i.Keys {
+		parts = append(parts, k.Key, fmt.Sprint(k.Value))
+	}
+
+	return strings.Join(parts, "_")
+}
+
+func (i Index) model() mongo.IndexModel {
+	opts := options.Index().SetName(i.Name())
+	if i.Unique {
+		opts.SetUnique(true)
+	}
+	if i.Sparse {
+		opts.SetSparse(true)
+	}
+	if i.PartialFilter != nil {
+		opts.SetPartialFilterExpression(i.PartialFilter)
+	}
+	if i.ExpireAfter > 0 {
+		opts.SetExpireAfterSeconds(int32(i.ExpireAfter.Seconds()))
+	}
+
+	return mongo.IndexModel{Keys: i.Keys, Options: opts}
+}
+
+// CreateIndexes returns migration step that creates indexes, existing indexes with the same definition are kept
+func CreateIndexes(db *mongo.Database, indexes ...Index) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, i := range indexes {
+			if _, err := db.Collection(i.Collection).Indexes().CreateOne(ctx, i.model()); err != nil {
+				return fmt.Errorf("index %s of %s can't be created: %w", i.Name(), i.Collection, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// DropIndexes returns migration step that drops indexes, indexes that don't exist are ignored
+func DropIndexes(db *mongo.Database, indexes ...Index) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, i := range indexes {
+			_, err := db.Collection(i.Collection).Indexes().DropOne(ctx, i.Name())
+			var commandErr mongo.CommandError
+			if errors.As(err, &commandErr) && (commandErr.Code == namespaceNotFoundCode || commandErr.Code == indexNotFoundCode) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("index %s of %s can't be dropped: %w", i.Name(), i.Collection, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// ReplaceIndexes returns migration step that drops indexes and creates their replacements, e.g. to change options
+// of an index whose name is kept
+func ReplaceIndexes(db *mongo.Database, dropped []Index, created []Index) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if err := DropIndexes(db, dropped...)(ctx); err != nil {
+			return err
+		}
+
+		return CreateIndexes(db, created...)(ctx)
+	}
+}
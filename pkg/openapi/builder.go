@@ -0,0 +1,153 @@
+package openapi
+
+import (
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const jsonContentType = "application/json"
+
+type (
+	// Route describes an endpoint to document
+	Route struct {
+		// Method is http method of route
+		Method string
+		// Path is fiber style path of route, e.g. /wallets/:id
+		Path    string
+		Summary string
+		Tags    []string
+		// Query is query parameters of route
+		Query []Parameter
+		// Request is a value of request body type, nil if route has no body
+		Request interface{}
+		// Response is a value of success response body type
+		Response interface{}
+		// Status is http status code of success responses, 200 if it is 0
+		Status int
+		// Errors is http status codes of errr.Error responses of route
+		Errors []int
+	}
+
+	// Builder builds Document from routes
+	Builder struct {
+		doc   *Document
+		types map[string]reflect.Type
+	}
+)
+
+// New creates new instance of Builder
+func New(title, version string) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI:    Version,
+			Info:       Info{Title: title, Version: version},
+			Paths:      map[string]*PathItem{},
+			Components: Components{Schemas: map[string]*Schema{}},
+		},
+		types: map[string]reflect.Type{},
+	}
+}
+
+// AddRoutes adds routes under prefix to the document
+func (b *Builder) AddRoutes(prefix string, routes ...Route) *Builder {
+	for _, r := range routes {
+		path := Path(prefix + r.Path)
+
+		item, ok := b.doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			b.doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(r.Method)] = b.operation(path, r)
+	}
+
+	return b
+}
+
+// Document returns built document
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+func (b *Builder) operation(path string, r Route) *Operation {
+	op := &Operation{
+		OperationID: operationID(r.Method, path),
+		Summary:     r.Summary,
+		Tags:        r.Tags,
+		Responses:   map[string]*Response{},
+	}
+
+	for _, segment := range strings.Split(r.Path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?"),
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	for _, q := range r.Query {
+		q.In = "query"
+		if q.Schema == nil {
+			q.Schema = &Schema{Type: "string"}
+		}
+		op.Parameters = append(op.Parameters, q)
+	}
+
+	if r.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{jsonContentType: {Schema: b.schemaOf(reflect.TypeOf(r.Request))}},
+		}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ok := &Response{Description: http.StatusText(status)}
+	if r.Response != nil {
+		ok.Content = map[string]MediaType{jsonContentType: {Schema: b.schemaOf(reflect.TypeOf(r.Response))}}
+	}
+	op.Responses[strconv.Itoa(status)] = ok
+
+	for _, code := range r.Errors {
+		op.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]MediaType{jsonContentType: {Schema: b.schemaOf(reflect.TypeOf(errr.Error{}))}},
+		}
+	}
+
+	return op
+}
+
+// Path converts fiber style path to OpenAPI path, e.g. /wallets/:id/ to /wallets/{id}
+func Path(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			segments[i] = "{" + strings.TrimSuffix(strings.TrimPrefix(s, ":"), "?") + "}"
+		}
+	}
+
+	path = strings.Join(segments, "/")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	return path
+}
+
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, s := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '{' || r == '}' || r == '-' || r == '_' || r == '.' }) {
+		sb.WriteString(strings.ToUpper(s[:1]) + s[1:])
+	}
+
+	return sb.String()
+}
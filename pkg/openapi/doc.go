@@ -0,0 +1,2 @@
+// Package openapi provides OpenAPI 3 document generation from route descriptions and Go types to use with fiber framework
+package openapi
@@ -0,0 +1,75 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+	swaggerfiles "github.com/swaggo/files/v2"
+	"net/http"
+)
+
+const uiTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8"/>
+  <title>%[1]s</title>
+  <link rel="stylesheet" href="%[3]s/swagger-ui.css"/>
+  <link rel="icon" type="image/png" href="%[3]s/favicon-32x32.png" sizes="32x32"/>
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="%[3]s/swagger-ui-bundle.js"></script>
+<script>
+  window.onload = () => {
+    window.ui = SwaggerUIBundle({url: %[2]q, dom_id: '#swagger-ui'});
+  };
+</script>
+</body>
+</html>`
+
+// uiAssets are the embedded Swagger UI assets that the page loads
+var uiAssets = map[string]bool{
+	"swagger-ui.css":       true,
+	"swagger-ui-bundle.js": true,
+	"favicon-32x32.png":    true,
+}
+
+// Handler returns fiber.Handler that serves the document as json
+func (b *Builder) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		body, err := json.Marshal(b.doc)
+		if err != nil {
+			return err
+		}
+
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(body)
+	}
+}
+
+// UIHandler returns fiber.Handler that serves a Swagger UI page for the document at specURL, the page loads its
+// assets from assetsURL where UIAssetHandler serves them
+func (b *Builder) UIHandler(specURL, assetsURL string) fiber.Handler {
+	page := fmt.Sprintf(uiTemplate, b.doc.Info.Title, specURL, assetsURL)
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(page)
+	}
+}
+
+// UIAssetHandler returns fiber.Handler that serves the Swagger UI asset named by the asset param. Assets are
+// embedded, so the page works without access to a CDN
+func UIAssetHandler() fiber.Handler {
+	assets := http.FS(swaggerfiles.FS)
+
+	return func(c *fiber.Ctx) error {
+		name := c.Params("asset")
+		if !uiAssets[name] {
+			return fiber.ErrNotFound
+		}
+
+		return filesystem.SendFile(c, assets, name)
+	}
+}
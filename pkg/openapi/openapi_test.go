@@ -0,0 +1,77 @@
+package openapi
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type (
+	money struct {
+		Amount float32 `json:"amount"`
+	}
+
+	account struct {
+		sync.Mutex `json:"-"`
+		ID         string    `json:"id"`
+		Balance    money     `json:"balance"`
+		Tags       []string  `json:"tags,omitempty"`
+		CreatedAt  time.Time `json:"created_at"`
+		Secret     string    `json:"-"`
+		internal   string
+	}
+)
+
+func TestPath(t *testing.T) {
+	cases := map[string]string{
+		"/api/wallets/":            "/api/wallets",
+		"/api/wallets/:id":         "/api/wallets/{id}",
+		"/api/wallets/:id/deposit": "/api/wallets/{id}/deposit",
+		"/":                        "/",
+	}
+
+	for in, expected := range cases {
+		assert.Equal(t, expected, Path(in))
+	}
+}
+
+func TestBuilder_AddRoutes(t *testing.T) {
+	doc := New("test", "1").AddRoutes("/api", Route{
+		Method:   "PUT",
+		Path:     "/accounts/:id",
+		Request:  money{},
+		Response: account{},
+		Errors:   []int{404},
+	}).Document()
+
+	op := (*doc.Paths["/api/accounts/{id}"])["put"]
+	assert.NotNil(t, op)
+	assert.Equal(t, "putApiAccountsId", op.OperationID)
+	assert.Equal(t, "id", op.Parameters[0].Name)
+	assert.Equal(t, "path", op.Parameters[0].In)
+	assert.Equal(t, componentsRefPrefix+"money", op.RequestBody.Content[jsonContentType].Schema.Ref)
+	assert.Equal(t, componentsRefPrefix+"account", op.Responses["200"].Content[jsonContentType].Schema.Ref)
+	assert.Equal(t, componentsRefPrefix+"Error", op.Responses["404"].Content[jsonContentType].Schema.Ref)
+
+	schema := doc.Components.Schemas["account"]
+	assert.Len(t, schema.Properties, 4)
+	assert.Equal(t, "string", schema.Properties["created_at"].Type)
+	assert.Equal(t, "date-time", schema.Properties["created_at"].Format)
+	assert.Equal(t, "array", schema.Properties["tags"].Type)
+	assert.Equal(t, componentsRefPrefix+"money", schema.Properties["balance"].Ref)
+	assert.Equal(t, "number", doc.Components.Schemas["money"].Properties["amount"].Type)
+}
+
+func TestBuilder_AddRoutesWithStatus(t *testing.T) {
+	doc := New("test", "1").AddRoutes("/api", Route{
+		Method:   "POST",
+		Path:     "/accounts/",
+		Response: account{},
+		Status:   202,
+	}).Document()
+
+	op := (*doc.Paths["/api/accounts"])["post"]
+	assert.NotNil(t, op.Responses["202"])
+	assert.Nil(t, op.Responses["200"])
+}
@@ -0,0 +1,115 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const componentsRefPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns schema of t, named struct types are added to components and referenced
+func (b *Builder) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := b.componentName(t)
+		if _, ok := b.doc.Components.Schemas[name]; !ok {
+			// reserve the name first to stop recursion on self referencing types
+			b.doc.Components.Schemas[name] = &Schema{Type: "object"}
+			b.doc.Components.Schemas[name] = b.structSchema(t)
+		}
+		return &Schema{Ref: componentsRefPrefix + name}
+	}
+
+	return &Schema{}
+}
+
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addFields(s, t)
+
+	return s
+}
+
+func (b *Builder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, skip := jsonName(f)
+		if skip {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(s, ft)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = b.schemaOf(f.Type)
+	}
+}
+
+// componentName returns unique component name of t, type name is prefixed by package name on conflict
+func (b *Builder) componentName(t reflect.Type) string {
+	name := t.Name()
+	if existing, ok := b.types[name]; ok && existing != t {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + name
+	}
+	b.types[name] = t
+
+	return name
+}
+
+// jsonName returns json name of field from tag and whether field is skipped by encoding/json
+func jsonName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	return name, false
+}
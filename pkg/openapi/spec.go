@@ -0,0 +1,65 @@
+package openapi
+
+const Version = "3.0.3"
+
+type (
+	Document struct {
+		OpenAPI    string               `json:"openapi"`
+		Info       Info                 `json:"info"`
+		Paths      map[string]*PathItem `json:"paths"`
+		Components Components           `json:"components"`
+	}
+
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	// PathItem holds operations of a path by lower case http method
+	PathItem map[string]*Operation
+
+	Operation struct {
+		OperationID string               `json:"operationId,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		Tags        []string             `json:"tags,omitempty"`
+		Parameters  []Parameter          `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+	}
+
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Description string  `json:"description,omitempty"`
+		Required    bool    `json:"required"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	RequestBody struct {
+		Required bool                 `json:"required"`
+		Content  map[string]MediaType `json:"content"`
+	}
+
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	}
+
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	}
+)
@@ -0,0 +1,3 @@
+// Package projection provides a runner that keeps read models up to date from committed events with checkpoints
+// tracked in mongo, rebuilds them from scratch and reports their lag
+package projection
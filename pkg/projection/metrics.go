@@ -0,0 +1,50 @@
+package projection
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// Metrics are prometheus metrics of projections
+type Metrics struct {
+	projected *prometheus.CounterVec
+	failures  *prometheus.CounterVec
+	lag       *prometheus.GaugeVec
+	steps     *prometheus.GaugeVec
+}
+
+// NewMetrics creates metrics of projections and registers them to reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		projected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "projection_events_total",
+			Help: "Total number of events projected by projection.",
+		}, []string{"projection"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "projection_failures_total",
+			Help: "Total number of failed steps by projection.",
+		}, []string{"projection"}),
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "projection_lag_seconds",
+			Help: "How far the last projected event is behind now by projection, 0 if it is caught up.",
+		}, []string{"projection"}),
+		steps: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "projection_last_step_timestamp_seconds",
+			Help: "Unix time of the last successful step by projection.",
+		}, []string{"projection"}),
+	}
+	reg.MustRegister(m.projected, m.failures, m.lag, m.steps)
+
+	return m
+}
+
+func (m *Metrics) observe(projection string, progress Progress, err error) {
+	if err != nil {
+		m.failures.WithLabelValues(projection).Inc()
+		return
+	}
+
+	m.projected.WithLabelValues(projection).Add(float64(progress.Projected))
+	m.lag.WithLabelValues(projection).Set(progress.Lag.Seconds())
+	m.steps.WithLabelValues(projection).Set(float64(time.Now().Unix()))
+}
@@ -0,0 +1,46 @@
+package projection
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const checkpointsCollection = "projection_checkpoints"
+
+// MongoCheckpointStore is a CheckpointStore that keeps checkpoints in projection_checkpoints collection
+type MongoCheckpointStore struct {
+	checkpoints *mongo.Collection
+}
+
+// NewMongoCheckpointStore creates new instance of MongoCheckpointStore
+func NewMongoCheckpointStore(db *mongo.Database) *MongoCheckpointStore {
+	return &MongoCheckpointStore{checkpoints: db.Collection(checkpointsCollection)}
+}
+
+// Load returns checkpoint of projection, an empty one is returned if projection has none
+func (s *MongoCheckpointStore) Load(ctx context.Context, projection string) (*Checkpoint, error) {
+	c := new(Checkpoint)
+	err := s.checkpoints.FindOne(ctx, bson.M{"_id": projection}).Decode(c)
+	if err == mongo.ErrNoDocuments {
+		return &Checkpoint{Projection: projection}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Save saves checkpoint
+func (s *MongoCheckpointStore) Save(ctx context.Context, c *Checkpoint) error {
+	_, err := s.checkpoints.ReplaceOne(ctx, bson.M{"_id": c.Projection}, c, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete deletes checkpoint of projection
+func (s *MongoCheckpointStore) Delete(ctx context.Context, projection string) error {
+	_, err := s.checkpoints.DeleteOne(ctx, bson.M{"_id": projection})
+	return err
+}
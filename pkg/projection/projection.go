@@ -0,0 +1,137 @@
+package projection
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type (
+	// Checkpoint is the position of the last event a projection has projected, 0 starts from scratch
+	Checkpoint struct {
+		Projection string    `bson:"_id" json:"projection"`
+		Position   int64     `bson:"position" json:"position"`
+		UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+	}
+
+	// CheckpointStore stores checkpoints of projections
+	CheckpointStore interface {
+		// Load returns checkpoint of projection, an empty one is returned if projection has none
+		Load(ctx context.Context, projection string) (*Checkpoint, error)
+		// Save saves checkpoint
+		Save(ctx context.Context, c *Checkpoint) error
+		// Delete deletes checkpoint of projection
+		Delete(ctx context.Context, projection string) error
+	}
+
+	// Progress is what a step of a projection has done
+	Progress struct {
+		// Projected is the number of events projected
+		Projected int
+		// CaughtUp is false if there are more events than the step projected
+		CaughtUp bool
+		// Lag is how far the last projected event is behind now if the projection isn't caught up
+		Lag time.Duration
+	}
+
+	// Projection maintains a read model from streams of committed events
+	Projection interface {
+		// Name is the unique name of projection, its checkpoint is stored by it
+		Name() string
+		// Step projects at most limit events after position of checkpoint and advances it.
+		// Events may be projected again if checkpoint isn't saved after a step so steps must be idempotent
+		Step(ctx context.Context, checkpoint *Checkpoint, limit int) (Progress, error)
+		// Reset deletes the read model so that it is projected from scratch
+		Reset(ctx context.Context) error
+	}
+
+	// Runner steps a projection, saves its checkpoint after every step and records its metrics
+	Runner struct {
+		projection  Projection
+		checkpoints CheckpointStore
+		batchSize   int
+		metrics     *Metrics
+		log         *slog.Logger
+	}
+)
+
+// NewRunner creates new instance of Runner that projects at most batchSize events per step, metrics are optional
+func NewRunner(p Projection, checkpoints CheckpointStore, batchSize int, metrics *Metrics, log *slog.Logger) *Runner {
+	return &Runner{projection: p, checkpoints: checkpoints, batchSize: batchSize, metrics: metrics, log: log}
+}
+
+// Run steps projection until ctx is done, it waits interval after catching up or failing
+func (r *Runner) Run(ctx context.Context, interval time.Duration) {
+	r.log.Info("projection is running", "projection", r.projection.Name())
+	for {
+		progress, err := r.Step(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.log.Error("projection step failed", "projection", r.projection.Name(), "error", err)
+		}
+		if err == nil && !progress.CaughtUp {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Step runs a step of projection from its checkpoint and saves the checkpoint
+func (r *Runner) Step(ctx context.Context) (Progress, error) {
+	progress, err := r.step(ctx)
+	if r.metrics != nil {
+		r.metrics.observe(r.projection.Name(), progress, err)
+	}
+
+	return progress, err
+}
+
+func (r *Runner) step(ctx context.Context) (Progress, error) {
+	checkpoint, err := r.checkpoints.Load(ctx, r.projection.Name())
+	if err != nil {
+		return Progress{}, err
+	}
+
+	progress, err := r.projection.Step(ctx, checkpoint, r.batchSize)
+	if err != nil {
+		return Progress{}, err
+	}
+	checkpoint.UpdatedAt = time.Now()
+	if err = r.checkpoints.Save(ctx, checkpoint); err != nil {
+		return Progress{}, err
+	}
+
+	return progress, nil
+}
+
+// CatchUp steps projection until it is caught up and returns the number of events projected
+func (r *Runner) CatchUp(ctx context.Context) (int, error) {
+	projected := 0
+	for {
+		progress, err := r.Step(ctx)
+		projected += progress.Projected
+		if err != nil || progress.CaughtUp {
+			return projected, err
+		}
+	}
+}
+
+// Rebuild resets projection, deletes its checkpoint and catches up from scratch. The read model is incomplete
+// until it is caught up
+func (r *Runner) Rebuild(ctx context.Context) (int, error) {
+	if err := r.checkpoints.Delete(ctx, r.projection.Name()); err != nil {
+		return 0, err
+	}
+	if err := r.projection.Reset(ctx); err != nil {
+		return 0, err
+	}
+
+	return r.CatchUp(ctx)
+}
@@ -0,0 +1,125 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type memoryStore map[string]*Checkpoint
+
+func (s memoryStore) Load(_ context.Context, projection string) (*Checkpoint, error) {
+	if c, ok := s[projection]; ok {
+		copied := *c
+		return &copied, nil
+	}
+
+	return &Checkpoint{Projection: projection}, nil
+}
+
+func (s memoryStore) Save(_ context.Context, c *Checkpoint) error {
+	s[c.Projection] = c
+	return nil
+}
+
+func (s memoryStore) Delete(_ context.Context, projection string) error {
+	delete(s, projection)
+	return nil
+}
+
+// counter projects events numbered from 1 to events into projected
+type counter struct {
+	events    int
+	projected []int
+	fail      bool
+}
+
+func (c *counter) Name() string {
+	return "counter"
+}
+
+func (c *counter) Step(_ context.Context, checkpoint *Checkpoint, limit int) (Progress, error) {
+	if c.fail {
+		return Progress{}, errors.New("projection failed")
+	}
+
+	last, n := int(checkpoint.Position), 0
+	for ; n < limit && last+n < c.events; n++ {
+		c.projected = append(c.projected, last+n+1)
+	}
+	checkpoint.Position = int64(last + n)
+
+	return Progress{Projected: n, CaughtUp: n < limit, Lag: time.Duration(c.events-last-n) * time.Second}, nil
+}
+
+func (c *counter) Reset(context.Context) error {
+	c.projected = nil
+	return nil
+}
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("should catch up from checkpoint and rebuild from scratch", func(t *testing.T) {
+		store, p := memoryStore{}, &counter{events: 5}
+		metrics := NewMetrics(prometheus.NewRegistry())
+		r := NewRunner(p, store, 2, metrics, log)
+
+		projected, err := r.CatchUp(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 5, projected)
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, p.projected)
+		assert.Equal(t, int64(5), store["counter"].Position)
+		assert.False(t, store["counter"].UpdatedAt.IsZero())
+
+		p.events = 6
+		projected, err = r.CatchUp(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, projected)
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, p.projected, "events are projected once")
+
+		projected, err = r.Rebuild(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 6, projected)
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, p.projected)
+
+		assert.Equal(t, float64(12), testutil.ToFloat64(metrics.projected))
+	})
+
+	t.Run("should not save checkpoint of failed step", func(t *testing.T) {
+		store, p := memoryStore{}, &counter{events: 5, fail: true}
+		metrics := NewMetrics(prometheus.NewRegistry())
+		r := NewRunner(p, store, 2, metrics, log)
+
+		_, err := r.Step(ctx)
+		assert.NotNil(t, err)
+		assert.Empty(t, store)
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.failures))
+	})
+
+	t.Run("should record lag of step", func(t *testing.T) {
+		metrics := NewMetrics(prometheus.NewRegistry())
+		r := NewRunner(&counter{events: 5}, memoryStore{}, 2, metrics, log)
+
+		progress, err := r.Step(ctx)
+		assert.Nil(t, err)
+		assert.False(t, progress.CaughtUp)
+		assert.Equal(t, float64(3), testutil.ToFloat64(metrics.lag))
+	})
+
+	t.Run("should run until context is done", func(t *testing.T) {
+		p := &counter{events: 3}
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		NewRunner(p, memoryStore{}, 2, nil, log).Run(ctx, time.Millisecond)
+		assert.Equal(t, []int{1, 2, 3}, p.projected)
+	})
+}
@@ -0,0 +1,2 @@
+// Package ratelimit provides a per key rate limiter whose limit can be changed at runtime and its middleware to use with fiber framework
+package ratelimit
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/ybalcin/wallet-service/pkg/errr"
+	"github.com/ybalcin/wallet-service/pkg/response"
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+const (
+	ErrRateLimitExceeded = "rate limit exceeded, retry later"
+
+	// idleTimeout is the duration after buckets of keys that made no request are dropped
+	idleTimeout   = 10 * time.Minute
+	sweepInterval = time.Minute
+)
+
+type (
+	// Limiter limits rate of requests per key with token buckets
+	Limiter struct {
+		mu        sync.Mutex
+		limit     rate.Limit
+		burst     int
+		buckets   map[string]*bucket
+		lastSweep time.Time
+	}
+
+	bucket struct {
+		limiter  *rate.Limiter
+		lastSeen time.Time
+	}
+)
+
+// New creates new instance of Limiter that allows rps requests per second with bursts of burst requests per key,
+// rps of zero or less is unlimited
+func New(rps float64, burst int) *Limiter {
+	l := &Limiter{buckets: map[string]*bucket{}, lastSweep: time.Now()}
+	l.limit, l.burst = limitOf(rps, burst)
+
+	return l
+}
+
+// SetLimit changes limit of every key, it takes effect on the next request
+func (l *Limiter) SetLimit(rps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit, l.burst = limitOf(rps, burst)
+	now := time.Now()
+	for _, b := range l.buckets {
+		b.limiter.SetLimitAt(now, l.limit)
+		b.limiter.SetBurstAt(now, l.burst)
+	}
+}
+
+// Allow reports whether a request of key is allowed now
+func (l *Limiter) Allow(key string) bool {
+	now := time.Now()
+
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	l.mu.Unlock()
+
+	return b.limiter.AllowN(now, 1)
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func limitOf(rps float64, burst int) (rate.Limit, int) {
+	if rps <= 0 {
+		return rate.Inf, 0
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return rate.Limit(rps), burst
+}
+
+// Middleware returns fiber.Handler that responds 429 to requests exceeding the limit of their key
+func Middleware(l *Limiter, key func(c *fiber.Ctx) string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !l.Allow(key(c)) {
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return response.New(c).Error(errr.ThrowTooManyRequestsError(errors.New(ErrRateLimitExceeded))).JSON()
+		}
+
+		return c.Next()
+	}
+}
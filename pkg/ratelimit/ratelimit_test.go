@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(1, 2)
+
+	assert.True(t, l.Allow("a"))
+	assert.True(t, l.Allow("a"))
+	assert.False(t, l.Allow("a"))
+	assert.True(t, l.Allow("b"), "keys must have their own buckets")
+
+	t.Run("should change limit of existing and new keys", func(t *testing.T) {
+		l.SetLimit(0, 0)
+		for i := 0; i < 10; i++ {
+			assert.True(t, l.Allow("a"))
+		}
+
+		l.SetLimit(1, 1)
+		assert.True(t, l.Allow("c"))
+		assert.False(t, l.Allow("c"))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware(New(1, 1), func(c *fiber.Ctx) string { return c.Get("X-Key") }))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	req := func() int {
+		r := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		r.Header.Set("X-Key", "a")
+		res, err := app.Test(r)
+		assert.Nil(t, err)
+		return res.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, req())
+	assert.Equal(t, fiber.StatusTooManyRequests, req())
+}
@@ -0,0 +1,3 @@
+// Package tracing provides OpenTelemetry tracer provider setup, tracing middleware to use with fiber framework and
+// grpc server interceptors
+package tracing
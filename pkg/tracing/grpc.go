@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// metadataCarrier adapts incoming grpc metadata to propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) Get(key string) string {
+	if values := metadata.MD(m).Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (m metadataCarrier) Set(key, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// UnaryServerInterceptor returns grpc.UnaryServerInterceptor that starts a server span for every call, continuing
+// the trace of incoming W3C trace context metadata, and passes span context to handlers by their context
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, span := startCall(ctx, tracer, info.FullMethod)
+		defer span.End()
+
+		res, err := handler(ctx, req)
+		endCall(span, err)
+
+		return res, err
+	}
+}
+
+// StreamServerInterceptor is the streaming equivalent of UnaryServerInterceptor, the span lasts as long as the stream
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	tracer := otel.Tracer(instrumentationName)
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startCall(ss.Context(), tracer, info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		endCall(span, err)
+
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// startCall starts the span of a call of full method, which is named by it like /wallet.v1.WalletService/GetWallet
+func startCall(ctx context.Context, tracer trace.Tracer, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md.Copy()))
+
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return tracer.Start(ctx, service+"/"+method, trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCService(service), semconv.RPCMethod(method)),
+	)
+}
+
+// endCall records status code of err on span, codes that are server faults mark the span as failed
+func endCall(span trace.Span, err error) {
+	s := status.Convert(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(s.Code())))
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	if serverFault(s.Code()) {
+		span.SetStatus(codes.Error, s.Message())
+	}
+}
+
+// serverFault reports whether code is an error of the server rather than of the call
+func serverFault(code grpccodes.Code) bool {
+	switch code {
+	case grpccodes.Unknown, grpccodes.DeadlineExceeded, grpccodes.Unimplemented, grpccodes.Internal,
+		grpccodes.Unavailable, grpccodes.DataLoss:
+		return true
+	}
+
+	return false
+}
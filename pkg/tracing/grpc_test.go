@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var handlerSpan trace.SpanContext
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/wallet.v1.WalletService/GetWallet"}
+	_, err := UnaryServerInterceptor()(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return nil, status.Error(grpccodes.Internal, "mongo is down")
+	})
+	assert.NotNil(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "wallet.v1.WalletService/GetWallet", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, span.SpanContext().SpanID(), handlerSpan.SpanID())
+	assert.Equal(t, codes.Error, span.Status().Code)
+
+	attrs := map[string]interface{}{}
+	for _, attr := range span.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.AsInterface()
+	}
+	assert.Equal(t, "wallet.v1.WalletService", attrs["rpc.service"])
+	assert.Equal(t, "GetWallet", attrs["rpc.method"])
+	assert.Equal(t, int64(grpccodes.Internal), attrs["rpc.grpc.status_code"])
+}
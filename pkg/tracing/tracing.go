@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"io"
+	"os"
+)
+
+const (
+	// NoneExporter disables exporting of spans
+	NoneExporter = "none"
+	// OTLPExporter exports spans to an OTLP collector over grpc
+	OTLPExporter = "otlp"
+	// StdoutExporter writes spans as json to stdout or to a file
+	StdoutExporter = "stdout"
+)
+
+type Config struct {
+	ServiceName string
+	// Exporter is one of NoneExporter, OTLPExporter or StdoutExporter
+	Exporter string
+	// OTLPEndpoint is host:port of OTLP collector, OTEL_EXPORTER_OTLP_ENDPOINT env is used if empty
+	OTLPEndpoint string
+	// OTLPInsecure disables tls of OTLP exporter
+	OTLPInsecure bool
+	// File is the path StdoutExporter appends to, stdout is used if empty
+	File string
+	// SampleRatio is the ratio of root spans to sample, child spans follow their parent
+	SampleRatio float64
+}
+
+// Setup sets global tracer provider and W3C trace context propagator by cfg, returned func flushes and stops exporting
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+		err      error
+	)
+	switch cfg.Exporter {
+	case NoneExporter, "":
+		return func(context.Context) error { return nil }, nil
+	case OTLPExporter:
+		var opts []otlptracegrpc.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case StdoutExporter:
+		var w io.Writer = os.Stdout
+		if cfg.File != "" {
+			f, e := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+			if e != nil {
+				return nil, e
+			}
+			w, closer = f, f
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if e := closer.Close(); err == nil {
+				err = e
+			}
+		}
+		return err
+	}, nil
+}